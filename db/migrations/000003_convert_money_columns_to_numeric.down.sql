@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE "smartduka_sale"
+  DROP COLUMN IF EXISTS "currency",
+  ALTER COLUMN "price" TYPE float USING "price"::float,
+  ALTER COLUMN "quantity" TYPE float USING "quantity"::float;
+
+ALTER TABLE "smartduka_product"
+  DROP COLUMN IF EXISTS "currency",
+  ALTER COLUMN "vat" TYPE float USING "vat"::float,
+  ALTER COLUMN "price" TYPE float USING "price"::float,
+  ALTER COLUMN "quantity" TYPE float USING "quantity"::float;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE "smartduka_product"
+  ALTER COLUMN "quantity" TYPE numeric(14,4) USING round("quantity"::numeric, 4),
+  ALTER COLUMN "price" TYPE numeric(14,2) USING round("price"::numeric, 2),
+  ALTER COLUMN "vat" TYPE numeric(7,4) USING round("vat"::numeric, 4),
+  ADD COLUMN IF NOT EXISTS "currency" varchar(3) NOT NULL DEFAULT 'KES';
+
+ALTER TABLE "smartduka_sale"
+  ALTER COLUMN "quantity" TYPE numeric(14,4) USING round("quantity"::numeric, 4),
+  ALTER COLUMN "price" TYPE numeric(14,2) USING round("price"::numeric, 2),
+  ADD COLUMN IF NOT EXISTS "currency" varchar(3) NOT NULL DEFAULT 'KES';
+
+COMMIT;
\ No newline at end of file
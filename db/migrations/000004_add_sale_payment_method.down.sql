@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "smartduka_sale_created_at_idx";
+
+ALTER TABLE "smartduka_sale" DROP COLUMN IF EXISTS "payment_method";
+
+COMMIT;
\ No newline at end of file
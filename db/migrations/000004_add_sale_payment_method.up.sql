@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "smartduka_sale" ADD COLUMN IF NOT EXISTS "payment_method" varchar(20) NOT NULL DEFAULT 'CASH';
+
+CREATE INDEX IF NOT EXISTS "smartduka_sale_created_at_idx" ON "smartduka_sale" ("created_at");
+
+COMMIT;
\ No newline at end of file
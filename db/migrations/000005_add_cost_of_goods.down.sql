@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "smartduka_stock_receipt";
+
+ALTER TABLE "smartduka_sale" DROP COLUMN IF EXISTS "cost_of_goods";
+
+ALTER TABLE "smartduka_product" DROP COLUMN IF EXISTS "cost_price";
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,27 @@
+BEGIN;
+
+ALTER TABLE "smartduka_product" ADD COLUMN IF NOT EXISTS "cost_price" numeric(14,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE "smartduka_sale" ADD COLUMN IF NOT EXISTS "cost_of_goods" numeric(14,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "smartduka_stock_receipt" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" uuid NOT NULL,
+  "updated_at" timestamp,
+  "updated_by" uuid,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "product_id" uuid NOT NULL,
+  "quantity" numeric(14,4) NOT NULL,
+  "remaining_quantity" numeric(14,4) NOT NULL,
+  "unit_cost" numeric(14,2) NOT NULL,
+  "currency" varchar(3) NOT NULL DEFAULT 'KES',
+  "supplier" varchar(100)
+);
+
+ALTER TABLE "smartduka_stock_receipt" ADD FOREIGN KEY ("product_id") REFERENCES "smartduka_product" ("id");
+
+CREATE INDEX IF NOT EXISTS "smartduka_stock_receipt_product_id_idx" ON "smartduka_stock_receipt" ("product_id", "created_at");
+
+COMMIT;
\ No newline at end of file
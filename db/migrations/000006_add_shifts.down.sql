@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "smartduka_sale_shift_id_idx";
+
+ALTER TABLE "smartduka_sale" DROP COLUMN IF EXISTS "shift_id";
+
+ALTER TABLE "smartduka_sale" DROP COLUMN IF EXISTS "discount";
+
+DROP TABLE IF EXISTS "smartduka_cash_movement";
+
+DROP TABLE IF EXISTS "smartduka_shift";
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,49 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "smartduka_shift" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" uuid NOT NULL,
+  "updated_at" timestamp,
+  "updated_by" uuid,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "cashier_id" uuid NOT NULL,
+  "status" varchar(10) NOT NULL DEFAULT 'OPEN',
+  "opening_float" numeric(14,2) NOT NULL DEFAULT 0,
+  "expected_cash" numeric(14,2) NOT NULL DEFAULT 0,
+  "counted_cash" numeric(14,2) NOT NULL DEFAULT 0,
+  "over_short" numeric(14,2) NOT NULL DEFAULT 0,
+  "currency" varchar(3) NOT NULL DEFAULT 'KES',
+  "opened_at" timestamp NOT NULL,
+  "closed_at" timestamp
+);
+
+CREATE TABLE IF NOT EXISTS "smartduka_cash_movement" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" uuid NOT NULL,
+  "updated_at" timestamp,
+  "updated_by" uuid,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "shift_id" uuid NOT NULL,
+  "type" varchar(20) NOT NULL,
+  "amount" numeric(14,2) NOT NULL,
+  "currency" varchar(3) NOT NULL DEFAULT 'KES',
+  "reason" varchar(255)
+);
+
+ALTER TABLE "smartduka_shift" ADD FOREIGN KEY ("cashier_id") REFERENCES "smartduka_user" ("id");
+
+ALTER TABLE "smartduka_cash_movement" ADD FOREIGN KEY ("shift_id") REFERENCES "smartduka_shift" ("id");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_shift_open_cashier_idx" ON "smartduka_shift" ("cashier_id") WHERE "status" = 'OPEN';
+
+ALTER TABLE "smartduka_sale" ADD COLUMN IF NOT EXISTS "discount" numeric(14,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE "smartduka_sale" ADD COLUMN IF NOT EXISTS "shift_id" uuid REFERENCES "smartduka_shift" ("id");
+
+CREATE INDEX IF NOT EXISTS "smartduka_sale_shift_id_idx" ON "smartduka_sale" ("shift_id");
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "smartduka_product_lower_name_idx";
+
+DROP INDEX IF EXISTS "smartduka_product_sku_idx";
+
+ALTER TABLE "smartduka_product" DROP COLUMN IF EXISTS "sku";
+
+COMMIT;
\ No newline at end of file
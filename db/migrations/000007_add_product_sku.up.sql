@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE "smartduka_product" ADD COLUMN IF NOT EXISTS "sku" varchar(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_product_sku_idx" ON "smartduka_product" ("sku") WHERE "sku" IS NOT NULL AND "active" = true;
+
+CREATE INDEX IF NOT EXISTS "smartduka_product_lower_name_idx" ON "smartduka_product" (LOWER("name"));
+
+COMMIT;
\ No newline at end of file
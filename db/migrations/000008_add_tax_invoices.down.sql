@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "smartduka_tax_invoice";
+
+COMMIT;
\ No newline at end of file
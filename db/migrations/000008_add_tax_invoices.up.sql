@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "smartduka_tax_invoice" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" uuid,
+  "updated_at" timestamp,
+  "updated_by" uuid,
+  "deleted_at" timestamp,
+  "sale_id" uuid UNIQUE NOT NULL,
+  "status" varchar(10) NOT NULL DEFAULT 'PENDING',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamp NOT NULL,
+  "last_error" text,
+  "invoice_number" varchar(64),
+  "receipt_signature" varchar(64),
+  "qr_data" text,
+  "submitted_at" timestamp
+);
+
+ALTER TABLE "smartduka_tax_invoice" ADD FOREIGN KEY ("sale_id") REFERENCES "smartduka_sale" ("id");
+
+CREATE INDEX IF NOT EXISTS "smartduka_tax_invoice_pending_idx" ON "smartduka_tax_invoice" ("next_attempt_at") WHERE "status" = 'PENDING';
+
+COMMIT;
\ No newline at end of file
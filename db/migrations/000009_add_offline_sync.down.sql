@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "smartduka_product_changes_idx";
+
+DROP TABLE IF EXISTS "smartduka_sync_operation";
+
+DROP TABLE IF EXISTS "smartduka_sync_device";
+
+COMMIT;
\ No newline at end of file
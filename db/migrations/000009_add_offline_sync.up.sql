@@ -0,0 +1,40 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "smartduka_sync_device" (
+  "id" varchar(64) UNIQUE PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" uuid,
+  "updated_at" timestamp,
+  "updated_by" uuid,
+  "deleted_at" timestamp,
+  "user_id" uuid NOT NULL,
+  "cursor" text,
+  "last_pushed_at" timestamp,
+  "last_pulled_at" timestamp
+);
+
+CREATE TABLE IF NOT EXISTS "smartduka_sync_operation" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" uuid,
+  "updated_at" timestamp,
+  "updated_by" uuid,
+  "deleted_at" timestamp,
+  "device_id" varchar(64) NOT NULL,
+  "type" varchar(20) NOT NULL,
+  "status" varchar(10) NOT NULL,
+  "conflict" varchar(20),
+  "message" text,
+  "record_id" uuid,
+  "occurred_at" timestamp NOT NULL
+);
+
+ALTER TABLE "smartduka_sync_device" ADD FOREIGN KEY ("user_id") REFERENCES "smartduka_user" ("id");
+
+ALTER TABLE "smartduka_sync_operation" ADD FOREIGN KEY ("device_id") REFERENCES "smartduka_sync_device" ("id");
+
+CREATE INDEX IF NOT EXISTS "smartduka_sync_operation_flagged_idx" ON "smartduka_sync_operation" ("device_id") WHERE "status" = 'FLAGGED';
+
+CREATE INDEX IF NOT EXISTS "smartduka_product_changes_idx" ON "smartduka_product" ("updated_at", (id::text));
+
+COMMIT;
\ No newline at end of file
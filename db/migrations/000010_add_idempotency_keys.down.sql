@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "smartduka_idempotency_key";
+
+COMMIT;
\ No newline at end of file
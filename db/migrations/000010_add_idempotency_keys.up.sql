@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "smartduka_idempotency_key" (
+  "scope" varchar(64) NOT NULL,
+  "key" varchar(255) NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" uuid,
+  "updated_at" timestamp,
+  "updated_by" uuid,
+  "deleted_at" timestamp,
+  "request_hash" varchar(64) NOT NULL,
+  "locked_until" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "completed_at" timestamp,
+  "response_status" integer,
+  "response_content_type" text,
+  "response_body" bytea,
+  PRIMARY KEY ("scope", "key")
+);
+
+CREATE INDEX IF NOT EXISTS "smartduka_idempotency_key_expires_at_idx" ON "smartduka_idempotency_key" ("expires_at");
+
+COMMIT;
\ No newline at end of file
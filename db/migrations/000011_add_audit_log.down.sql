@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "smartduka_audit_log";
+
+DROP FUNCTION IF EXISTS "smartduka_audit_log_append_only"();
+
+COMMIT;
\ No newline at end of file
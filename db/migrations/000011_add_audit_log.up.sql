@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "smartduka_audit_log" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "entity" varchar(64) NOT NULL,
+  "entity_id" varchar(255) NOT NULL,
+  "action" varchar(10) NOT NULL,
+  "changes" jsonb NOT NULL,
+  "actor_id" uuid,
+  "ip_address" varchar(45),
+  "device" text
+);
+
+CREATE INDEX IF NOT EXISTS "smartduka_audit_log_entity_idx" ON "smartduka_audit_log" ("entity", "entity_id", "created_at");
+
+CREATE INDEX IF NOT EXISTS "smartduka_audit_log_actor_idx" ON "smartduka_audit_log" ("actor_id", "created_at");
+
+CREATE OR REPLACE FUNCTION "smartduka_audit_log_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'the audit log cannot be changed';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "smartduka_audit_log_append_only"
+  BEFORE UPDATE OR DELETE ON "smartduka_audit_log"
+  FOR EACH ROW EXECUTE FUNCTION "smartduka_audit_log_append_only"();
+
+COMMIT;
\ No newline at end of file
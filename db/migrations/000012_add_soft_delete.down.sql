@@ -0,0 +1,15 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "smartduka_sale_deleted_at_idx";
+
+DROP INDEX IF EXISTS "smartduka_product_deleted_at_idx";
+
+DROP INDEX IF EXISTS "smartduka_contact_deleted_at_idx";
+
+DROP INDEX IF EXISTS "smartduka_user_deleted_at_idx";
+
+DROP INDEX IF EXISTS "smartduka_product_sku_idx";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_product_sku_idx" ON "smartduka_product" ("sku") WHERE "sku" IS NOT NULL AND "active" = true;
+
+COMMIT;
\ No newline at end of file
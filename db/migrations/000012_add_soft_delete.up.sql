@@ -0,0 +1,15 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "smartduka_product_sku_idx";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_product_sku_idx" ON "smartduka_product" ("sku") WHERE "sku" IS NOT NULL AND "active" = true AND "deleted_at" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "smartduka_user_deleted_at_idx" ON "smartduka_user" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS "smartduka_contact_deleted_at_idx" ON "smartduka_contact" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS "smartduka_product_deleted_at_idx" ON "smartduka_product" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS "smartduka_sale_deleted_at_idx" ON "smartduka_sale" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
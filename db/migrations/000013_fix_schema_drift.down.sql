@@ -0,0 +1,31 @@
+BEGIN;
+
+ALTER TABLE "smartduka_audit_log" DROP CONSTRAINT IF EXISTS "smartduka_audit_log_action_check";
+
+ALTER TABLE "smartduka_sync_operation" DROP CONSTRAINT IF EXISTS "smartduka_sync_operation_conflict_check";
+
+ALTER TABLE "smartduka_sync_operation" DROP CONSTRAINT IF EXISTS "smartduka_sync_operation_status_check";
+
+ALTER TABLE "smartduka_sync_operation" DROP CONSTRAINT IF EXISTS "smartduka_sync_operation_type_check";
+
+ALTER TABLE "smartduka_cash_movement" DROP CONSTRAINT IF EXISTS "smartduka_cash_movement_type_check";
+
+ALTER TABLE "smartduka_shift" DROP CONSTRAINT IF EXISTS "smartduka_shift_status_check";
+
+ALTER TABLE "smartduka_tax_invoice" DROP CONSTRAINT IF EXISTS "smartduka_tax_invoice_status_check";
+
+ALTER TABLE "smartduka_sale" DROP CONSTRAINT IF EXISTS "smartduka_sale_payment_method_check";
+
+ALTER TABLE "smartduka_sale" DROP CONSTRAINT IF EXISTS "smartduka_sale_unit_check";
+
+ALTER TABLE "smartduka_product" DROP CONSTRAINT IF EXISTS "smartduka_product_unit_check";
+
+ALTER TABLE "smartduka_product" DROP CONSTRAINT IF EXISTS "smartduka_product_category_check";
+
+ALTER TABLE "smartduka_contact" DROP CONSTRAINT IF EXISTS "smartduka_contact_flavour_check";
+
+ALTER TABLE "smartduka_user_otp" DROP CONSTRAINT IF EXISTS "smartduka_user_otp_flavour_check";
+
+ALTER TABLE "smartduka_user_pin" DROP COLUMN IF EXISTS "flavour";
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,88 @@
+BEGIN;
+
+ALTER TABLE "smartduka_user_pin" ADD COLUMN IF NOT EXISTS "flavour" varchar(10);
+
+UPDATE "smartduka_user_pin" SET "flavour" = "smartduka_contact"."flavour"
+  FROM "smartduka_contact" WHERE "smartduka_contact"."user_id" = "smartduka_user_pin"."user_id";
+
+UPDATE "smartduka_user_pin" SET "flavour" = 'CONSUMER' WHERE "flavour" IS NULL;
+
+ALTER TABLE "smartduka_user_pin" ALTER COLUMN "flavour" SET NOT NULL;
+
+UPDATE "smartduka_user_pin" SET "flavour" = UPPER(TRIM("flavour"));
+
+UPDATE "smartduka_user_otp" SET "flavour" = UPPER(TRIM("flavour"));
+
+UPDATE "smartduka_contact" SET "flavour" = UPPER(TRIM("flavour"));
+
+ALTER TABLE "smartduka_user_pin" ADD CONSTRAINT "smartduka_user_pin_flavour_check" CHECK ("flavour" IN ('PRO', 'CONSUMER'));
+
+ALTER TABLE "smartduka_user_otp" ADD CONSTRAINT "smartduka_user_otp_flavour_check" CHECK ("flavour" IN ('PRO', 'CONSUMER'));
+
+ALTER TABLE "smartduka_contact" ADD CONSTRAINT "smartduka_contact_flavour_check" CHECK ("flavour" IN ('PRO', 'CONSUMER'));
+
+-- Rows written before the enums were enforced used free text such as
+-- 'PHARMACEUTICALS' or 'Box'. Map them onto the enum values first so the
+-- constraints below can be added; anything unrecognised falls back to
+-- FOOD_STUFF, ONE and CASH. The down migration cannot restore them.
+UPDATE "smartduka_product" SET "category" = CASE UPPER(REPLACE(TRIM("category"), ' ', '_'))
+  WHEN 'CEREALS' THEN 'CEREALS' WHEN 'CEREAL' THEN 'CEREALS'
+  WHEN 'MEDICINE' THEN 'MEDICINE' WHEN 'MEDICINES' THEN 'MEDICINE' WHEN 'PHARMACEUTICALS' THEN 'MEDICINE' WHEN 'PHARMACEUTICAL' THEN 'MEDICINE'
+  WHEN 'FOOD_STUFF' THEN 'FOOD_STUFF' WHEN 'FOODSTUFF' THEN 'FOOD_STUFF' WHEN 'FOODSTUFFS' THEN 'FOOD_STUFF' WHEN 'FOOD' THEN 'FOOD_STUFF'
+  ELSE 'FOOD_STUFF'
+END;
+
+UPDATE "smartduka_product" SET "unit" = CASE UPPER(REPLACE(TRIM("unit"), ' ', '_'))
+  WHEN 'ONE' THEN 'ONE' WHEN 'PIECE' THEN 'ONE' WHEN 'PIECES' THEN 'ONE' WHEN 'PCS' THEN 'ONE' WHEN 'EACH' THEN 'ONE' WHEN 'SINGLE' THEN 'ONE'
+  WHEN 'HALF_DOZEN' THEN 'HALF_DOZEN'
+  WHEN 'DOZEN' THEN 'DOZEN' WHEN 'DOZ' THEN 'DOZEN'
+  WHEN 'OUTER' THEN 'OUTER'
+  WHEN 'CARTON' THEN 'CARTON' WHEN 'CARTONS' THEN 'CARTON' WHEN 'CTN' THEN 'CARTON'
+  WHEN 'BALE' THEN 'BALE' WHEN 'BALES' THEN 'BALE'
+  WHEN 'BAG' THEN 'BAG' WHEN 'BAGS' THEN 'BAG' WHEN 'SACK' THEN 'BAG'
+  WHEN 'PACKET' THEN 'PACKET' WHEN 'PACKETS' THEN 'PACKET' WHEN 'PACK' THEN 'PACKET' WHEN 'PKT' THEN 'PACKET' WHEN 'BOX' THEN 'PACKET'
+  ELSE 'ONE'
+END;
+
+UPDATE "smartduka_sale" SET "unit" = CASE UPPER(REPLACE(TRIM("unit"), ' ', '_'))
+  WHEN 'ONE' THEN 'ONE' WHEN 'PIECE' THEN 'ONE' WHEN 'PIECES' THEN 'ONE' WHEN 'PCS' THEN 'ONE' WHEN 'EACH' THEN 'ONE' WHEN 'SINGLE' THEN 'ONE'
+  WHEN 'HALF_DOZEN' THEN 'HALF_DOZEN'
+  WHEN 'DOZEN' THEN 'DOZEN' WHEN 'DOZ' THEN 'DOZEN'
+  WHEN 'OUTER' THEN 'OUTER'
+  WHEN 'CARTON' THEN 'CARTON' WHEN 'CARTONS' THEN 'CARTON' WHEN 'CTN' THEN 'CARTON'
+  WHEN 'BALE' THEN 'BALE' WHEN 'BALES' THEN 'BALE'
+  WHEN 'BAG' THEN 'BAG' WHEN 'BAGS' THEN 'BAG' WHEN 'SACK' THEN 'BAG'
+  WHEN 'PACKET' THEN 'PACKET' WHEN 'PACKETS' THEN 'PACKET' WHEN 'PACK' THEN 'PACKET' WHEN 'PKT' THEN 'PACKET' WHEN 'BOX' THEN 'PACKET'
+  ELSE 'ONE'
+END;
+
+UPDATE "smartduka_sale" SET "payment_method" = UPPER(REPLACE(TRIM("payment_method"), ' ', '_'));
+
+UPDATE "smartduka_sale" SET "payment_method" = 'MPESA' WHERE "payment_method" IN ('M-PESA', 'M_PESA');
+
+UPDATE "smartduka_sale" SET "payment_method" = 'CASH'
+  WHERE "payment_method" NOT IN ('CASH', 'MPESA', 'CARD', 'BANK_TRANSFER', 'CREDIT');
+
+ALTER TABLE "smartduka_product" ADD CONSTRAINT "smartduka_product_category_check" CHECK ("category" IN ('CEREALS', 'MEDICINE', 'FOOD_STUFF'));
+
+ALTER TABLE "smartduka_product" ADD CONSTRAINT "smartduka_product_unit_check" CHECK ("unit" IN ('ONE', 'HALF_DOZEN', 'DOZEN', 'OUTER', 'CARTON', 'BALE', 'BAG', 'PACKET'));
+
+ALTER TABLE "smartduka_sale" ADD CONSTRAINT "smartduka_sale_unit_check" CHECK ("unit" IN ('ONE', 'HALF_DOZEN', 'DOZEN', 'OUTER', 'CARTON', 'BALE', 'BAG', 'PACKET'));
+
+ALTER TABLE "smartduka_sale" ADD CONSTRAINT "smartduka_sale_payment_method_check" CHECK ("payment_method" IN ('CASH', 'MPESA', 'CARD', 'BANK_TRANSFER', 'CREDIT'));
+
+ALTER TABLE "smartduka_tax_invoice" ADD CONSTRAINT "smartduka_tax_invoice_status_check" CHECK ("status" IN ('PENDING', 'SUBMITTED', 'REJECTED'));
+
+ALTER TABLE "smartduka_shift" ADD CONSTRAINT "smartduka_shift_status_check" CHECK ("status" IN ('OPEN', 'CLOSED'));
+
+ALTER TABLE "smartduka_cash_movement" ADD CONSTRAINT "smartduka_cash_movement_type_check" CHECK ("type" IN ('CASH_IN', 'CASH_OUT', 'DROP'));
+
+ALTER TABLE "smartduka_sync_operation" ADD CONSTRAINT "smartduka_sync_operation_type_check" CHECK ("type" IN ('SALE', 'RETURN', 'STOCK_RECEIPT'));
+
+ALTER TABLE "smartduka_sync_operation" ADD CONSTRAINT "smartduka_sync_operation_status_check" CHECK ("status" IN ('APPLIED', 'FLAGGED', 'REJECTED'));
+
+ALTER TABLE "smartduka_sync_operation" ADD CONSTRAINT "smartduka_sync_operation_conflict_check" CHECK ("conflict" IN ('NEGATIVE_STOCK', 'PRICE_MISMATCH'));
+
+ALTER TABLE "smartduka_audit_log" ADD CONSTRAINT "smartduka_audit_log_action_check" CHECK ("action" IN ('CREATE', 'UPDATE', 'DELETE'));
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "smartduka_product_name_trgm_idx";
+
+DROP INDEX IF EXISTS "smartduka_product_search_vector_idx";
+
+ALTER TABLE "smartduka_product" DROP COLUMN IF EXISTS "search_vector";
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE "smartduka_product" ADD COLUMN IF NOT EXISTS "search_vector" tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('simple', coalesce("name", '')), 'A') ||
+  setweight(to_tsvector('simple', coalesce("manufacturer", '')), 'B') ||
+  setweight(to_tsvector('simple', coalesce("description", '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS "smartduka_product_search_vector_idx" ON "smartduka_product" USING GIN ("search_vector");
+
+CREATE INDEX IF NOT EXISTS "smartduka_product_name_trgm_idx" ON "smartduka_product" USING GIN ("name" gin_trgm_ops);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE "smartduka_user" DROP COLUMN IF EXISTS "middle_name";
+
+COMMIT;
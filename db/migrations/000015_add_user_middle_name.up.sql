@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE "smartduka_user" ADD COLUMN IF NOT EXISTS "middle_name" varchar(25);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "smartduka_user" DROP COLUMN IF EXISTS "tokens_revoked_at";
+ALTER TABLE "smartduka_user" DROP COLUMN IF EXISTS "frozen_at";
+ALTER TABLE "smartduka_user" DROP COLUMN IF EXISTS "frozen_reason";
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "smartduka_user" ADD COLUMN IF NOT EXISTS "frozen_reason" varchar(200);
+ALTER TABLE "smartduka_user" ADD COLUMN IF NOT EXISTS "frozen_at" timestamp;
+ALTER TABLE "smartduka_user" ADD COLUMN IF NOT EXISTS "tokens_revoked_at" timestamp;
+
+COMMIT;
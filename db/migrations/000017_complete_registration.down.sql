@@ -0,0 +1,15 @@
+BEGIN;
+
+DELETE FROM "smartduka_user_otp" WHERE "user_id" IS NULL;
+
+ALTER TABLE "smartduka_user_otp" ALTER COLUMN "user_id" SET NOT NULL;
+
+DROP INDEX IF EXISTS "smartduka_contact_phone_flavour_idx";
+
+DROP INDEX IF EXISTS "smartduka_user_username_idx";
+
+DROP TABLE IF EXISTS "smartduka_identifier";
+
+ALTER TABLE "smartduka_user" DROP COLUMN IF EXISTS "residence";
+
+COMMIT;
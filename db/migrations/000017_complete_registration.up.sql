@@ -0,0 +1,64 @@
+BEGIN;
+
+ALTER TABLE "smartduka_user" ADD COLUMN IF NOT EXISTS "residence" varchar(100);
+
+CREATE TABLE IF NOT EXISTS "smartduka_identifier" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" uuid,
+  "updated_at" timestamp,
+  "updated_by" uuid,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "identifier_type" varchar(20) NOT NULL,
+  "identifier_value" varchar(20) NOT NULL,
+  "user_id" uuid NOT NULL REFERENCES "smartduka_user" ("id"),
+  CONSTRAINT "smartduka_identifier_identifier_type_check" CHECK ("identifier_type" IN ('NATIONAL_ID', 'PASSPORT'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_identifier_value_idx" ON "smartduka_identifier" ("identifier_type", "identifier_value") WHERE "deleted_at" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "smartduka_identifier_deleted_at_idx" ON "smartduka_identifier" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+-- Usernames and phone numbers were never unique before. Keep the oldest
+-- user holding a username and clear it on the rest so they can pick another.
+UPDATE "smartduka_user" SET "username" = NULL WHERE "id" IN (
+  SELECT "id" FROM (
+    SELECT "id", ROW_NUMBER() OVER (PARTITION BY "username" ORDER BY "created_at", "id") AS "rank"
+    FROM "smartduka_user"
+    WHERE "username" IS NOT NULL AND "username" <> '' AND "deleted_at" IS NULL
+  ) AS "duplicates" WHERE "rank" > 1
+);
+
+-- Phone numbers are stored the way NormalizeMSISDN formats them (+2547...),
+-- but older rows may be local (07...) or missing the plus sign.
+UPDATE "smartduka_contact" SET "contact_value" = regexp_replace("contact_value", '[^0-9+]', '', 'g')
+  WHERE "contact_type" = 'PHONE';
+
+UPDATE "smartduka_contact" SET "contact_value" = '+254' || substring("contact_value" FROM 2)
+  WHERE "contact_type" = 'PHONE' AND "contact_value" ~ '^0[17][0-9]{8}$';
+
+UPDATE "smartduka_contact" SET "contact_value" = '+254' || "contact_value"
+  WHERE "contact_type" = 'PHONE' AND "contact_value" ~ '^[17][0-9]{8}$';
+
+UPDATE "smartduka_contact" SET "contact_value" = '+' || "contact_value"
+  WHERE "contact_type" = 'PHONE' AND "contact_value" ~ '^254[17][0-9]{8}$';
+
+-- The oldest contact keeps a number shared within a flavour; the rest are
+-- soft deleted.
+UPDATE "smartduka_contact" SET "deleted_at" = now() WHERE "id" IN (
+  SELECT "id" FROM (
+    SELECT "id", ROW_NUMBER() OVER (PARTITION BY "contact_value", "flavour" ORDER BY "created_at", "id") AS "rank"
+    FROM "smartduka_contact"
+    WHERE "contact_type" = 'PHONE' AND "deleted_at" IS NULL
+  ) AS "duplicates" WHERE "rank" > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_user_username_idx" ON "smartduka_user" ("username") WHERE "username" IS NOT NULL AND "username" <> '' AND "deleted_at" IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_contact_phone_flavour_idx" ON "smartduka_contact" ("contact_value", "flavour") WHERE "contact_type" = 'PHONE' AND "deleted_at" IS NULL;
+
+-- a phone number is verified before the user it belongs to is registered
+ALTER TABLE "smartduka_user_otp" ALTER COLUMN "user_id" DROP NOT NULL;
+
+COMMIT;
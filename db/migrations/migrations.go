@@ -0,0 +1,10 @@
+// Package migrations holds the versioned SQL migrations of the database schema.
+// They are embedded in the server binary so that it can bring the schema up to date itself
+package migrations
+
+import "embed"
+
+// FS holds the migration files named `<version>_<name>.<up|down>.sql`
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,10 @@
+// Package sqlite holds the schema of the SQLite database used by shops that run on a single machine
+package sqlite
+
+import _ "embed"
+
+// Schema creates the tables of the SQLite database. It mirrors the Postgres migrations and
+// only creates what does not exist, so it is run every time the database is opened
+//
+//go:embed schema.sql
+var Schema string
@@ -0,0 +1,298 @@
+CREATE TABLE IF NOT EXISTS "smartduka_user" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "username" varchar(20),
+  "first_name" varchar(25),
+  "middle_name" varchar(25),
+  "last_name" varchar(25),
+  "email" varchar(100),
+  "user_type" varchar(20),
+  "push_token" varchar(200),
+  "frozen_reason" varchar(200),
+  "frozen_at" timestamp,
+  "tokens_revoked_at" timestamp,
+  "residence" varchar(100)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_user_username_idx" ON "smartduka_user" ("username") WHERE "username" IS NOT NULL AND "username" <> '' AND "deleted_at" IS NULL;
+
+CREATE TABLE IF NOT EXISTS "smartduka_contact" (
+  "id" text PRIMARY KEY NOT NULL,
+  "active" boolean NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "contact_type" varchar(16) NOT NULL,
+  "contact_value" text NOT NULL,
+  "flavour" text NOT NULL,
+  "user_id" text NOT NULL REFERENCES "smartduka_user" ("id"),
+  CONSTRAINT "smartduka_contact_flavour_check" CHECK ("flavour" IN ('PRO', 'CONSUMER'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_contact_phone_flavour_idx" ON "smartduka_contact" ("contact_value", "flavour") WHERE "contact_type" = 'PHONE' AND "deleted_at" IS NULL;
+
+CREATE TABLE IF NOT EXISTS "smartduka_identifier" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "identifier_type" varchar(20) NOT NULL,
+  "identifier_value" varchar(20) NOT NULL,
+  "user_id" text NOT NULL REFERENCES "smartduka_user" ("id"),
+  CONSTRAINT "smartduka_identifier_identifier_type_check" CHECK ("identifier_type" IN ('NATIONAL_ID', 'PASSPORT'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_identifier_value_idx" ON "smartduka_identifier" ("identifier_type", "identifier_value") WHERE "deleted_at" IS NULL;
+
+CREATE TABLE IF NOT EXISTS "smartduka_user_pin" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "flavour" varchar(10) NOT NULL,
+  "valid_from" timestamp NOT NULL,
+  "valid_to" timestamp NOT NULL,
+  "hashed_pin" text NOT NULL,
+  "salt" text NOT NULL,
+  "user_id" text NOT NULL REFERENCES "smartduka_user" ("id"),
+  CONSTRAINT "smartduka_user_pin_flavour_check" CHECK ("flavour" IN ('PRO', 'CONSUMER'))
+);
+
+CREATE TABLE IF NOT EXISTS "smartduka_user_otp" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "is_valid" boolean NOT NULL,
+  "valid_until" timestamp NOT NULL,
+  "phone_number" varchar(20) NOT NULL,
+  "otp" varchar(10) NOT NULL,
+  "flavour" varchar(10) NOT NULL,
+  "user_id" text REFERENCES "smartduka_user" ("id"),
+  CONSTRAINT "smartduka_user_otp_flavour_check" CHECK ("flavour" IN ('PRO', 'CONSUMER'))
+);
+
+CREATE TABLE IF NOT EXISTS "smartduka_product" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text NOT NULL REFERENCES "smartduka_user" ("id"),
+  "updated_at" timestamp,
+  "updated_by" text REFERENCES "smartduka_user" ("id"),
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "sku" varchar(64),
+  "name" varchar(50) NOT NULL,
+  "category" varchar(25) NOT NULL,
+  "quantity" numeric(14,4) NOT NULL,
+  "unit" varchar(15) NOT NULL,
+  "price" numeric(14,2) NOT NULL,
+  "cost_price" numeric(14,2) NOT NULL DEFAULT 0,
+  "currency" varchar(3) NOT NULL DEFAULT 'KES',
+  "vat" numeric(7,4) NOT NULL,
+  "description" text,
+  "manufacturer" varchar(50),
+  "in_stock" boolean NOT NULL,
+  CONSTRAINT "smartduka_product_category_check" CHECK ("category" IN ('CEREALS', 'MEDICINE', 'FOOD_STUFF')),
+  CONSTRAINT "smartduka_product_unit_check" CHECK ("unit" IN ('ONE', 'HALF_DOZEN', 'DOZEN', 'OUTER', 'CARTON', 'BALE', 'BAG', 'PACKET'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_product_sku_idx" ON "smartduka_product" ("sku") WHERE "sku" IS NOT NULL AND "active" = true AND "deleted_at" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "smartduka_product_changes_idx" ON "smartduka_product" ("updated_at", "id");
+
+CREATE TABLE IF NOT EXISTS "smartduka_shift" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text NOT NULL,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "cashier_id" text NOT NULL REFERENCES "smartduka_user" ("id"),
+  "status" varchar(10) NOT NULL DEFAULT 'OPEN',
+  "opening_float" numeric(14,2) NOT NULL DEFAULT 0,
+  "expected_cash" numeric(14,2) NOT NULL DEFAULT 0,
+  "counted_cash" numeric(14,2) NOT NULL DEFAULT 0,
+  "over_short" numeric(14,2) NOT NULL DEFAULT 0,
+  "currency" varchar(3) NOT NULL DEFAULT 'KES',
+  "opened_at" timestamp NOT NULL,
+  "closed_at" timestamp,
+  CONSTRAINT "smartduka_shift_status_check" CHECK ("status" IN ('OPEN', 'CLOSED'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "smartduka_shift_open_cashier_idx" ON "smartduka_shift" ("cashier_id") WHERE "status" = 'OPEN';
+
+CREATE TABLE IF NOT EXISTS "smartduka_cash_movement" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text NOT NULL,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "shift_id" text NOT NULL REFERENCES "smartduka_shift" ("id"),
+  "type" varchar(20) NOT NULL,
+  "amount" numeric(14,2) NOT NULL,
+  "currency" varchar(3) NOT NULL DEFAULT 'KES',
+  "reason" varchar(255),
+  CONSTRAINT "smartduka_cash_movement_type_check" CHECK ("type" IN ('CASH_IN', 'CASH_OUT', 'DROP'))
+);
+
+CREATE TABLE IF NOT EXISTS "smartduka_sale" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text NOT NULL REFERENCES "smartduka_user" ("id"),
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "product_id" text NOT NULL REFERENCES "smartduka_product" ("id"),
+  "quantity" numeric(14,4) NOT NULL,
+  "unit" varchar(50) NOT NULL,
+  "price" numeric(14,2) NOT NULL,
+  "discount" numeric(14,2) NOT NULL DEFAULT 0,
+  "cost_of_goods" numeric(14,2) NOT NULL DEFAULT 0,
+  "currency" varchar(3) NOT NULL DEFAULT 'KES',
+  "payment_method" varchar(20) NOT NULL DEFAULT 'CASH',
+  "shift_id" text REFERENCES "smartduka_shift" ("id"),
+  CONSTRAINT "smartduka_sale_unit_check" CHECK ("unit" IN ('ONE', 'HALF_DOZEN', 'DOZEN', 'OUTER', 'CARTON', 'BALE', 'BAG', 'PACKET')),
+  CONSTRAINT "smartduka_sale_payment_method_check" CHECK ("payment_method" IN ('CASH', 'MPESA', 'CARD', 'BANK_TRANSFER', 'CREDIT'))
+);
+
+CREATE INDEX IF NOT EXISTS "smartduka_sale_created_at_idx" ON "smartduka_sale" ("created_at");
+
+CREATE INDEX IF NOT EXISTS "smartduka_sale_shift_id_idx" ON "smartduka_sale" ("shift_id");
+
+CREATE TABLE IF NOT EXISTS "smartduka_stock_receipt" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text NOT NULL,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "active" boolean NOT NULL,
+  "product_id" text NOT NULL REFERENCES "smartduka_product" ("id"),
+  "quantity" numeric(14,4) NOT NULL,
+  "remaining_quantity" numeric(14,4) NOT NULL,
+  "unit_cost" numeric(14,2) NOT NULL,
+  "currency" varchar(3) NOT NULL DEFAULT 'KES',
+  "supplier" varchar(100)
+);
+
+CREATE INDEX IF NOT EXISTS "smartduka_stock_receipt_product_id_idx" ON "smartduka_stock_receipt" ("product_id", "created_at");
+
+CREATE TABLE IF NOT EXISTS "smartduka_tax_invoice" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "sale_id" text UNIQUE NOT NULL REFERENCES "smartduka_sale" ("id"),
+  "status" varchar(10) NOT NULL DEFAULT 'PENDING',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamp NOT NULL,
+  "last_error" text,
+  "invoice_number" varchar(64),
+  "receipt_signature" varchar(64),
+  "qr_data" text,
+  "submitted_at" timestamp,
+  CONSTRAINT "smartduka_tax_invoice_status_check" CHECK ("status" IN ('PENDING', 'SUBMITTED', 'REJECTED'))
+);
+
+CREATE INDEX IF NOT EXISTS "smartduka_tax_invoice_pending_idx" ON "smartduka_tax_invoice" ("next_attempt_at") WHERE "status" = 'PENDING';
+
+CREATE TABLE IF NOT EXISTS "smartduka_sync_device" (
+  "id" varchar(64) PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "user_id" text NOT NULL REFERENCES "smartduka_user" ("id"),
+  "cursor" text,
+  "last_pushed_at" timestamp,
+  "last_pulled_at" timestamp
+);
+
+CREATE TABLE IF NOT EXISTS "smartduka_sync_operation" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "device_id" varchar(64) NOT NULL REFERENCES "smartduka_sync_device" ("id"),
+  "type" varchar(20) NOT NULL,
+  "status" varchar(10) NOT NULL,
+  "conflict" varchar(20),
+  "message" text,
+  "record_id" text,
+  "occurred_at" timestamp NOT NULL,
+  CONSTRAINT "smartduka_sync_operation_type_check" CHECK ("type" IN ('SALE', 'RETURN', 'STOCK_RECEIPT')),
+  CONSTRAINT "smartduka_sync_operation_status_check" CHECK ("status" IN ('APPLIED', 'FLAGGED', 'REJECTED')),
+  CONSTRAINT "smartduka_sync_operation_conflict_check" CHECK ("conflict" IN ('NEGATIVE_STOCK', 'PRICE_MISMATCH'))
+);
+
+CREATE INDEX IF NOT EXISTS "smartduka_sync_operation_flagged_idx" ON "smartduka_sync_operation" ("device_id") WHERE "status" = 'FLAGGED';
+
+CREATE TABLE IF NOT EXISTS "smartduka_idempotency_key" (
+  "scope" varchar(64) NOT NULL,
+  "key" varchar(255) NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "created_by" text,
+  "updated_at" timestamp,
+  "updated_by" text,
+  "deleted_at" timestamp,
+  "request_hash" varchar(64) NOT NULL,
+  "locked_until" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "completed_at" timestamp,
+  "response_status" integer,
+  "response_content_type" text,
+  "response_body" blob,
+  PRIMARY KEY ("scope", "key")
+);
+
+CREATE INDEX IF NOT EXISTS "smartduka_idempotency_key_expires_at_idx" ON "smartduka_idempotency_key" ("expires_at");
+
+CREATE TABLE IF NOT EXISTS "smartduka_audit_log" (
+  "id" text PRIMARY KEY NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "entity" varchar(64) NOT NULL,
+  "entity_id" varchar(255) NOT NULL,
+  "action" varchar(10) NOT NULL,
+  "changes" text NOT NULL,
+  "actor_id" text,
+  "ip_address" varchar(45),
+  "device" text,
+  CONSTRAINT "smartduka_audit_log_action_check" CHECK ("action" IN ('CREATE', 'UPDATE', 'DELETE'))
+);
+
+CREATE INDEX IF NOT EXISTS "smartduka_audit_log_entity_idx" ON "smartduka_audit_log" ("entity", "entity_id", "created_at");
+
+CREATE INDEX IF NOT EXISTS "smartduka_audit_log_actor_idx" ON "smartduka_audit_log" ("actor_id", "created_at");
+
+CREATE TRIGGER IF NOT EXISTS "smartduka_audit_log_no_update" BEFORE UPDATE ON "smartduka_audit_log"
+BEGIN
+  SELECT RAISE(ABORT, 'the audit log cannot be changed');
+END;
+
+CREATE TRIGGER IF NOT EXISTS "smartduka_audit_log_no_delete" BEFORE DELETE ON "smartduka_audit_log"
+BEGIN
+  SELECT RAISE(ABORT, 'the audit log cannot be changed');
+END;
\ No newline at end of file
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/oryx-systems/smartduka/db/migrations"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/migrate"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up [N]         apply all pending migrations, or the next N
+  down [N]       roll back the last migration, or the last N
+  status         list the migrations and whether they have been applied
+  force VERSION  record the schema as being at VERSION without running anything
+  drift          compare the schema with the gorm models and list where they disagree`
+
+// runMigrate runs the `migrate` subcommand against the configured database
+func runMigrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	count := func(fallback int) (int, error) {
+		if len(args) < 2 {
+			return fallback, nil
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid number of migrations: %s", args[1])
+		}
+		return n, nil
+	}
+
+	pg, err := gorm.NewDatabaseInstance()
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "drift" {
+		problems, err := pg.CheckSchemaDrift(ctx)
+		if err != nil {
+			return err
+		}
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("the schema and the models disagree in %d places", len(problems))
+		}
+		fmt.Println("the schema matches the models")
+		return nil
+	}
+
+	if pg.IsSQLite() {
+		return errors.New("the migrations are written for Postgres, a SQLite schema is created when the server starts")
+	}
+	sqlDB, err := pg.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get a connection to the database: %v", err)
+	}
+	defer sqlDB.Close()
+
+	migrator, err := migrate.NewMigrator(sqlDB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		steps, err := count(0)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(ctx, steps)
+		fmt.Printf("applied %d migrations\n", applied)
+		return err
+
+	case "down":
+		steps, err := count(1)
+		if err != nil {
+			return err
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		fmt.Printf("rolled back %d migrations\n", rolledBack)
+		return err
+
+	case "status":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("schema version %d of %d", version, migrator.Latest())
+		if dirty {
+			fmt.Print(" (dirty)")
+		}
+		fmt.Println()
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%06d_%s\t%s\n", status.Migration.Version, status.Migration.Name, state)
+		}
+		return nil
+
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		return migrator.Force(ctx, uint(version))
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+}
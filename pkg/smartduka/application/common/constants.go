@@ -24,4 +24,132 @@ const (
 
 	// AuthTokenContextKey is the key used to store the auth token on the context.Context
 	AuthTokenContextKey = ContextKey("UID")
+
+	// ClientIPContextKey is the key used to store the IP address a request was made from on the context.Context
+	ClientIPContextKey = ContextKey("ClientIP")
+
+	// DeviceContextKey is the key used to store the device a request was made from on the context.Context
+	DeviceContextKey = ContextKey("Device")
+
+	// DeviceIDHeader identifies the till or phone a request was made from
+	DeviceIDHeader = "X-Device-ID"
+
+	// FlavourContextKey is the key used to store the app a request was made from on the context.Context
+	FlavourContextKey = ContextKey("Flavour")
+
+	// FlavourHeader names the app a request was made from i.e PRO or CONSUMER
+	FlavourHeader = "X-Flavour"
+
+	// LanguageContextKey is the key used to store the language a user reads on the context.Context.
+	// It is picked from the request's Accept-Language header
+	LanguageContextKey = ContextKey("Language")
+
+	// AdminUserType is the user type of the shop owner
+	AdminUserType = "ADMIN"
+
+	// ShopTimezoneEnvVarName is the name of the environment variable that defines the
+	// timezone the shop operates in e.g Africa/Nairobi
+	ShopTimezoneEnvVarName = "SHOP_TIMEZONE"
+
+	// DefaultShopTimezone is used when the shop timezone has not been configured
+	DefaultShopTimezone = "Africa/Nairobi"
+
+	// SoftDeleteRetentionDaysEnvVarName is the name of the environment variable that defines how many days
+	// deleted records are kept before they are purged
+	SoftDeleteRetentionDaysEnvVarName = "SOFT_DELETE_RETENTION_DAYS"
+
+	// DefaultSoftDeleteRetentionDays is used when the retention period has not been configured
+	DefaultSoftDeleteRetentionDays = 90
+
+	// CostingMethodEnvVarName is the name of the environment variable that defines how
+	// the shop values the cost of goods sold i.e WEIGHTED_AVERAGE or FIFO
+	CostingMethodEnvVarName = "COSTING_METHOD"
+
+	// ShopNameEnvVarName is the name of the environment variable that defines the shop name printed on receipts
+	ShopNameEnvVarName = "SHOP_NAME"
+
+	// ShopAddressEnvVarName is the name of the environment variable that defines the shop address printed on receipts
+	ShopAddressEnvVarName = "SHOP_ADDRESS"
+
+	// ShopPhoneEnvVarName is the name of the environment variable that defines the shop phone number printed on receipts
+	ShopPhoneEnvVarName = "SHOP_PHONE"
+
+	// ShopKRAPINEnvVarName is the name of the environment variable that defines the shop's KRA PIN
+	ShopKRAPINEnvVarName = "SHOP_KRA_PIN"
+
+	// ReceiptVerificationURLEnvVarName is the name of the environment variable that defines the base URL
+	// encoded in a receipt's QR code. The receipt number is appended to it
+	ReceiptVerificationURLEnvVarName = "RECEIPT_VERIFICATION_URL"
+
+	// ETIMSBaseURLEnvVarName is the name of the environment variable that defines the KRA eTIMS API URL.
+	// Sales are not submitted to eTIMS when it is not set
+	ETIMSBaseURLEnvVarName = "ETIMS_BASE_URL"
+
+	// ETIMSBranchIDEnvVarName is the name of the environment variable that defines the shop's eTIMS branch ID
+	ETIMSBranchIDEnvVarName = "ETIMS_BRANCH_ID"
+
+	// DefaultETIMSBranchID is the branch ID KRA assigns to a taxpayer's head office
+	DefaultETIMSBranchID = "00"
+
+	// ETIMSDeviceSerialEnvVarName is the name of the environment variable that defines the serial number
+	// of the device registered with eTIMS
+	ETIMSDeviceSerialEnvVarName = "ETIMS_DEVICE_SERIAL"
+
+	// ETIMSCommunicationKeyEnvVarName is the name of the environment variable that defines the key
+	// issued by eTIMS when the device was initialised. Requests are signed with it
+	ETIMSCommunicationKeyEnvVarName = "ETIMS_COMMUNICATION_KEY"
+
+	// LowStockThresholdEnvVarName is the name of the environment variable that defines the quantity
+	// at or below which a product is running out of stock
+	LowStockThresholdEnvVarName = "LOW_STOCK_THRESHOLD"
+
+	// DefaultLowStockThreshold is the low stock threshold used when none has been configured
+	DefaultLowStockThreshold = 10
+
+	// GraphQLComplexityLimitEnvVarName is the name of the environment variable that defines the most
+	// fields a GraphQL operation may resolve. Fields on a page of a list count once for each record on the page
+	GraphQLComplexityLimitEnvVarName = "GRAPHQL_COMPLEXITY_LIMIT"
+
+	// DefaultGraphQLComplexityLimit is the complexity limit used when none has been configured
+	DefaultGraphQLComplexityLimit = 2500
+
+	// GraphQLDepthLimitEnvVarName is the name of the environment variable that defines how deeply
+	// the fields of a GraphQL operation may be nested
+	GraphQLDepthLimitEnvVarName = "GRAPHQL_DEPTH_LIMIT"
+
+	// DefaultGraphQLDepthLimit is the depth limit used when none has been configured
+	DefaultGraphQLDepthLimit = 10
+
+	// ImportMaxSizeEnvVarName is the name of the environment variable that defines the largest file,
+	// in megabytes, that products can be imported from
+	ImportMaxSizeEnvVarName = "IMPORT_MAX_SIZE_MB"
+
+	// DefaultImportMaxSize is the largest import file in megabytes when none has been configured
+	DefaultImportMaxSize = 5
+
+	// GraphQLAllowListEnvVarName is the name of the environment variable that defines the path to the file
+	// listing the operations each app may send. Any operation is accepted when it is not set
+	GraphQLAllowListEnvVarName = "GRAPHQL_ALLOW_LIST"
+
+	// AITAPIKeyEnvVarName is the name of the environment variable that defines the Africa's Talking API key.
+	// SMS are not sent when it is not set
+	AITAPIKeyEnvVarName = "AIT_API_KEY"
+
+	// AITUsernameEnvVarName is the name of the environment variable that defines the Africa's Talking app username
+	AITUsernameEnvVarName = "AIT_USERNAME"
+
+	// AITSenderIDEnvVarName is the name of the environment variable that defines the sender ID SMS are sent from
+	AITSenderIDEnvVarName = "AIT_SENDER_ID"
+
+	// AITEnvironmentEnvVarName is the name of the environment variable that defines whether SMS are sent
+	// through the Africa's Talking `sandbox` or to real phones
+	AITEnvironmentEnvVarName = "AIT_ENVIRONMENT"
+
+	// RegistrationOTPRequiredEnvVarName is the name of the environment variable that defines whether users
+	// have to verify their phone number with an OTP before they can register
+	RegistrationOTPRequiredEnvVarName = "REGISTRATION_OTP_REQUIRED"
+
+	// ETIMSReceiptQRBaseURL is the KRA page a receipt's QR code links to. The shop's PIN, branch ID
+	// and the receipt signature are appended to it
+	ETIMSReceiptQRBaseURL = "https://etims.kra.go.ke/common/link/etims/receipt/indexEtimsReceiptData?Data="
 )
@@ -12,7 +12,11 @@ import (
 	"cloud.google.com/go/profiler"
 	"contrib.go.opencensus.io/exporter/stackdriver"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats/view"
 	"go.opencensus.io/trace"
 )
 
@@ -108,6 +112,7 @@ func StackDriver(ctx context.Context) *errorreporting.Client {
 		return errorClient // the error client is already initialized, return it
 	}
 	trace.RegisterExporter(exporter)
+	view.RegisterExporter(exporter)
 
 	// profiler
 	err = profiler.Start(profiler.Config{
@@ -172,3 +177,113 @@ func GetPinExpiryDate() (*time.Time, error) {
 
 	return &expiryDate, nil
 }
+
+// GetShopLocation returns the timezone the shop operates in.
+// Reports use it to determine where a calendar day, week or month starts and ends
+func GetShopLocation() (*time.Location, error) {
+	timezone := os.Getenv(common.ShopTimezoneEnvVarName)
+	if timezone == "" {
+		timezone = common.DefaultShopTimezone
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shop timezone %s: %v", timezone, err)
+	}
+
+	return location, nil
+}
+
+// GetSoftDeleteRetention returns how long deleted records are kept before they are purged
+func GetSoftDeleteRetention() (time.Duration, error) {
+	days := common.DefaultSoftDeleteRetentionDays
+	if value := os.Getenv(common.SoftDeleteRetentionDaysEnvVarName); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 {
+			return 0, fmt.Errorf("invalid soft delete retention: %s", value)
+		}
+	}
+
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// GetCostingMethod returns the method the shop uses to value the cost of goods sold.
+// Weighted average costing is used when none has been configured
+func GetCostingMethod() (enums.CostingMethod, error) {
+	method := enums.CostingMethod(os.Getenv(common.CostingMethodEnvVarName))
+	if method == "" {
+		return enums.CostingMethodWeightedAverage, nil
+	}
+
+	if !method.IsValid() {
+		return "", fmt.Errorf("invalid costing method: %s", method)
+	}
+
+	return method, nil
+}
+
+// GetLowStockThreshold returns the quantity at or below which a product is running out of stock
+func GetLowStockThreshold() (money.Decimal, error) {
+	value := os.Getenv(common.LowStockThresholdEnvVarName)
+	if value == "" {
+		return money.DecimalFromInt(common.DefaultLowStockThreshold), nil
+	}
+
+	threshold, err := money.ParseDecimal(value)
+	if err != nil || threshold.IsNegative() {
+		return money.Decimal{}, fmt.Errorf("invalid low stock threshold: %s", value)
+	}
+
+	return threshold, nil
+}
+
+// GetGraphQLComplexityLimit returns the most fields a GraphQL operation may resolve
+func GetGraphQLComplexityLimit() (int, error) {
+	return getLimit(common.GraphQLComplexityLimitEnvVarName, common.DefaultGraphQLComplexityLimit, "GraphQL complexity limit")
+}
+
+// GetGraphQLDepthLimit returns how deeply the fields of a GraphQL operation may be nested
+func GetGraphQLDepthLimit() (int, error) {
+	return getLimit(common.GraphQLDepthLimitEnvVarName, common.DefaultGraphQLDepthLimit, "GraphQL depth limit")
+}
+
+// GetImportMaxSize returns the largest file in bytes that products can be imported from
+func GetImportMaxSize() (int64, error) {
+	megabytes, err := getLimit(common.ImportMaxSizeEnvVarName, common.DefaultImportMaxSize, "import size limit")
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(megabytes) << 20, nil
+}
+
+// getLimit reads a positive limit from an environment variable, falling back to a default when it is not set
+func getLimit(envVarName string, defaultLimit int, name string) (int, error) {
+	value := os.Getenv(envVarName)
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("invalid %s: %s", name, value)
+	}
+
+	return limit, nil
+}
+
+// GetShopDetails returns the shop details printed on receipts
+func GetShopDetails() (*domain.ShopDetails, error) {
+	name := os.Getenv(common.ShopNameEnvVarName)
+	if name == "" {
+		return nil, fmt.Errorf("the %s environment variable is not set", common.ShopNameEnvVarName)
+	}
+
+	return &domain.ShopDetails{
+		Name:    name,
+		Address: os.Getenv(common.ShopAddressEnvVarName),
+		Phone:   os.Getenv(common.ShopPhoneEnvVarName),
+		KRAPIN:  os.Getenv(common.ShopKRAPINEnvVarName),
+	}, nil
+}
@@ -0,0 +1,101 @@
+// Package dataloader batches the lookups made while resolving a GraphQL request.
+// GraphQL resolves a field on every item of a list at the same time, so a loader collects the keys asked for
+// within a short wait and fetches them all in one query instead of one query per item.
+// Values are not cached between batches: a loader lives as long as a websocket does, and a subscription
+// must not be sent records that changed since they were first loaded
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// Wait is how long a loader collects keys before fetching them
+	Wait = 2 * time.Millisecond
+
+	// MaxBatch is the most keys fetched at once. A full batch is fetched without waiting
+	MaxBatch = 100
+)
+
+// BatchFunc fetches the values of keys. Keys without a value are left out of the map
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader fetches values in batches
+type Loader[K comparable, V any] struct {
+	ctx   context.Context
+	fetch BatchFunc[K, V]
+
+	mu    sync.Mutex
+	batch *batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	keys   []K
+	seen   map[K]bool
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+// New creates a loader fetching values with fetch. ctx is the context of the request the loader serves
+func New[K comparable, V any](ctx context.Context, fetch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{ctx: ctx, fetch: fetch}
+}
+
+// Load returns the value of a key, fetched together with the other keys asked for within the wait.
+// It returns false when the key has no value
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, bool, error) {
+	l.mu.Lock()
+	b := l.batch
+	if b == nil {
+		b = &batch[K, V]{seen: map[K]bool{}, done: make(chan struct{})}
+		l.batch = b
+		time.AfterFunc(Wait, func() { l.dispatch(b) })
+	}
+	if !b.seen[key] {
+		b.seen[key] = true
+		b.keys = append(b.keys, key)
+	}
+	full := len(b.keys) >= MaxBatch
+	if full {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	if full {
+		go l.run(b)
+	}
+
+	var zero V
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return zero, false, ctx.Err()
+	}
+
+	if b.err != nil {
+		return zero, false, b.err
+	}
+	value, ok := b.values[key]
+	return value, ok, nil
+}
+
+// dispatch closes a batch to new keys and fetches it, unless it was fetched when it filled up
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if l.batch != b {
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+
+	l.run(b)
+}
+
+func (l *Loader[K, V]) run(b *batch[K, V]) {
+	b.values, b.err = l.fetch(l.ctx, b.keys)
+	close(b.done)
+}
@@ -0,0 +1,64 @@
+package dataloader_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dataloader"
+)
+
+func TestLoader_Load(t *testing.T) {
+	many := []int{}
+	for key := 0; key < dataloader.MaxBatch*2; key++ {
+		many = append(many, key)
+	}
+
+	tests := []struct {
+		name           string
+		keys           []int
+		wantMinBatches int
+		wantMaxBatches int
+	}{
+		{name: "Happy case: keys asked for together are fetched at once", keys: []int{1, 2, 3, 2, 1}, wantMinBatches: 1, wantMaxBatches: 1},
+		{name: "Happy case: batches are split when full", keys: many, wantMinBatches: 2, wantMaxBatches: len(many)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			batches := 0
+			loader := dataloader.New(context.Background(), func(ctx context.Context, keys []int) (map[int]string, error) {
+				mu.Lock()
+				batches++
+				if len(keys) > dataloader.MaxBatch {
+					t.Errorf("Loader.Load() fetched %d keys at once, want at most %d", len(keys), dataloader.MaxBatch)
+				}
+				mu.Unlock()
+
+				values := map[int]string{}
+				for _, key := range keys {
+					values[key] = fmt.Sprint(key)
+				}
+				return values, nil
+			})
+
+			var wg sync.WaitGroup
+			for _, key := range tt.keys {
+				wg.Add(1)
+				go func(key int) {
+					defer wg.Done()
+					value, ok, err := loader.Load(context.Background(), key)
+					if err != nil || !ok || value != fmt.Sprint(key) {
+						t.Errorf("Loader.Load(%d) = %q, %v, %v", key, value, ok, err)
+					}
+				}(key)
+			}
+			wg.Wait()
+
+			if batches < tt.wantMinBatches || batches > tt.wantMaxBatches {
+				t.Errorf("Loader.Load() fetched %d batches, want %d to %d", batches, tt.wantMinBatches, tt.wantMaxBatches)
+			}
+		})
+	}
+}
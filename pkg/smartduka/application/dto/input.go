@@ -1,33 +1,154 @@
 package dto
 
-import "github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+import (
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+)
 
 // LoginInput represents the login input
 type LoginInput struct {
-	PhoneNumber string        `json:"phone_number"`
-	PIN         string        `json:"pin"`
-	Flavour     enums.Flavour `json:"flavour"`
+	PhoneNumber string        `json:"phone_number" validate:"required,msisdn"`
+	PIN         string        `json:"pin" validate:"required"`
+	Flavour     enums.Flavour `json:"flavour" validate:"required,enum"`
 }
 
-// RegisterUserInput represents the register user input
+// RegisterUserInput represents the register user input. The names fit the 25 characters of their columns.
+// The identification document is optional but its type and number are given together.
+// OTP is the code sent to the phone number, needed when users verify their phone number before registering
 type RegisterUserInput struct {
-	IdentificationDocumentNumber string        `json:"identification_number"`
-	FirstName                    string        `json:"first_name"`
-	MiddleName                   string        `json:"middle_name"`
-	LastName                     string        `json:"last_name"`
-	PhoneNumber                  string        `json:"phone_number"`
-	Flavour                      enums.Flavour `json:"flavour"`
-	UserName                     string        `json:"username"`
-	DeviceToken                  string        `json:"device_token"`
-	Residence                    string        `json:"residence"`
-	PIN                          string        `json:"pin"`
-	ConfirmPIN                   string        `json:"confirm_pin"`
+	IdentificationDocumentType   enums.IdentifierType `json:"identification_type" validate:"required_with=IdentificationDocumentNumber,omitempty,enum"`
+	IdentificationDocumentNumber string               `json:"identification_number" validate:"required_with=IdentificationDocumentType,omitempty,alphanum,max=20"`
+	FirstName                    string               `json:"first_name" validate:"required,max=25"`
+	MiddleName                   string               `json:"middle_name" validate:"max=25"`
+	LastName                     string               `json:"last_name" validate:"required,max=25"`
+	PhoneNumber                  string               `json:"phone_number" validate:"required,msisdn"`
+	Flavour                      enums.Flavour        `json:"flavour" validate:"required,enum"`
+	UserName                     string               `json:"username" validate:"required,username"`
+	DeviceToken                  string               `json:"device_token" validate:"max=200"`
+	Residence                    string               `json:"residence" validate:"max=100"`
+	PIN                          string               `json:"pin" validate:"required,numeric,len=4"`
+	ConfirmPIN                   string               `json:"confirm_pin" validate:"required,eqfield=PIN"`
+	OTP                          string               `json:"otp" validate:"omitempty,numeric"`
+}
+
+// RegistrationOTPInput is the phone number a user registering wants to verify
+type RegistrationOTPInput struct {
+	PhoneNumber string        `json:"phone_number" validate:"required,msisdn"`
+	Flavour     enums.Flavour `json:"flavour" validate:"required,enum"`
 }
 
 // UserPINInput represents the user pin input
 type UserPINInput struct {
-	UserID     string        `json:"user_id"`
-	PIN        string        `json:"pin"`
-	ConfirmPIN string        `json:"confirm_pin"`
-	Flavour    enums.Flavour `json:"flavour"`
+	UserID     string        `json:"user_id" validate:"required"`
+	PIN        string        `json:"pin" validate:"required,numeric,len=4"`
+	ConfirmPIN string        `json:"confirm_pin" validate:"required,eqfield=PIN"`
+	Flavour    enums.Flavour `json:"flavour" validate:"required,enum"`
+}
+
+// ProductInput represents the input used to add a product
+type ProductInput struct {
+	Name         string         `json:"name"`
+	Category     enums.Category `json:"category"`
+	Quantity     money.Decimal  `json:"quantity"`
+	Unit         enums.Unit     `json:"unit"`
+	Price        money.Money    `json:"price"`
+	CostPrice    money.Money    `json:"cost_price"`
+	VAT          money.Decimal  `json:"vat"`
+	Description  string         `json:"description"`
+	Manufacturer string         `json:"manufacturer"`
+}
+
+// SaleInput represents the input used to record a sale
+type SaleInput struct {
+	// ID and SoldAt are set by offline clients replaying a sale made while disconnected
+	ID            string              `json:"id"`
+	SoldAt        time.Time           `json:"sold_at"`
+	ProductID     string              `json:"product_id" validate:"required"`
+	Quantity      money.Decimal       `json:"quantity"`
+	Unit          enums.Unit          `json:"unit" validate:"omitempty,enum"`
+	Price         money.Money         `json:"price"`
+	Discount      money.Money         `json:"discount"`
+	PaymentMethod enums.PaymentMethod `json:"payment_method"`
+}
+
+// StockReceiptInput represents the input used to record a delivery of stock
+type StockReceiptInput struct {
+	// ID and ReceivedAt are set by offline clients replaying a delivery received while disconnected
+	ID         string        `json:"id"`
+	ReceivedAt time.Time     `json:"received_at"`
+	ProductID  string        `json:"product_id" validate:"required"`
+	Quantity   money.Decimal `json:"quantity"`
+	UnitCost   money.Money   `json:"unit_cost"`
+	Supplier   string        `json:"supplier" validate:"max=100"`
+}
+
+// CashMovementInput represents the input used to put cash into or take cash out of a drawer
+type CashMovementInput struct {
+	Type   enums.CashMovementType `json:"type" validate:"required,enum"`
+	Amount money.Money            `json:"amount"`
+	Reason string                 `json:"reason" validate:"max=255"`
+}
+
+// SyncPushInput is a batch of changes made by an offline client
+type SyncPushInput struct {
+	DeviceID   string                `json:"device_id"`
+	Operations []*SyncOperationInput `json:"operations"`
+}
+
+// SyncOperationInput is a change made by an offline client. Its ID is a UUID generated by the client
+// and becomes the ID of the sale or stock receipt it creates
+type SyncOperationInput struct {
+	ID           string                  `json:"id"`
+	Type         enums.SyncOperationType `json:"type"`
+	OccurredAt   time.Time               `json:"occurred_at"`
+	Sale         *SaleInput              `json:"sale"`
+	StockReceipt *StockReceiptInput      `json:"stock_receipt"`
+}
+
+// AuditLogFilter narrows down the audit log. Entity is a table name e.g smartduka_product
+type AuditLogFilter struct {
+	Entity   *string    `json:"entity"`
+	EntityID *string    `json:"entityID"`
+	ActorID  *string    `json:"actorID"`
+	From     *time.Time `json:"from"`
+	To       *time.Time `json:"to"`
+	Limit    *int       `json:"limit"`
+}
+
+// ProductFilter narrows down a list of products. SearchTerm is matched against the name ignoring case and
+// the price range includes both ends
+type ProductFilter struct {
+	SearchTerm *string         `json:"searchTerm"`
+	Active     *bool           `json:"active"`
+	Category   *enums.Category `json:"category"`
+	MinPrice   *money.Money    `json:"minPrice"`
+	MaxPrice   *money.Money    `json:"maxPrice"`
+}
+
+// ProductSort orders a list of products. Products with the same value are ordered by ID
+type ProductSort struct {
+	Field     enums.ProductSortField `json:"field"`
+	Direction enums.SortDirection    `json:"direction"`
+}
+
+// UserFilter narrows down a list of users. SearchTerm is matched against the names, username and phone number
+type UserFilter struct {
+	SearchTerm *string `json:"searchTerm"`
+	Active     *bool   `json:"active"`
+}
+
+// UserSort orders a list of users. Users with the same value are ordered by ID
+type UserSort struct {
+	Field     enums.UserSortField `json:"field"`
+	Direction enums.SortDirection `json:"direction"`
+}
+
+// SaleFilter narrows down a list of sales to those made in the period [from, to)
+type SaleFilter struct {
+	From          *time.Time           `json:"from"`
+	To            *time.Time           `json:"to"`
+	ProductID     *string              `json:"productID"`
+	PaymentMethod *enums.PaymentMethod `json:"paymentMethod"`
 }
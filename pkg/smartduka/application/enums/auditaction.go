@@ -0,0 +1,56 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// AuditAction is the kind of change recorded in the audit log
+type AuditAction string
+
+const (
+	// AuditActionCreate is a record being added
+	AuditActionCreate AuditAction = "CREATE"
+
+	// AuditActionUpdate is a record being changed
+	AuditActionUpdate AuditAction = "UPDATE"
+
+	// AuditActionDelete is a record being deleted or purged
+	AuditActionDelete AuditAction = "DELETE"
+)
+
+// AllAuditAction lists every valid AuditAction
+var AllAuditAction = []AuditAction{AuditActionCreate, AuditActionUpdate, AuditActionDelete}
+
+// IsValid returns true if a AuditAction type is valid
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case AuditActionCreate, AuditActionUpdate, AuditActionDelete:
+		return true
+	}
+	return false
+}
+
+func (a AuditAction) String() string {
+	return string(a)
+}
+
+// UnmarshalGQL converts the supplied value to a AuditAction type.
+func (a *AuditAction) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*a = AuditAction(str)
+	if !a.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditAction", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the AuditAction type to the supplied writer
+func (a AuditAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(a.String()))
+}
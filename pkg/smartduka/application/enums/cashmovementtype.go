@@ -0,0 +1,56 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// CashMovementType is the reason cash was added to or taken out of a cash drawer
+type CashMovementType string
+
+const (
+	// CashMovementTypeCashIn represents cash paid into the drawer e.g change from the bank
+	CashMovementTypeCashIn CashMovementType = "CASH_IN"
+
+	// CashMovementTypeCashOut represents petty cash paid out of the drawer
+	CashMovementTypeCashOut CashMovementType = "CASH_OUT"
+
+	// CashMovementTypeDrop represents cash removed from the drawer for safekeeping
+	CashMovementTypeDrop CashMovementType = "DROP"
+)
+
+// AllCashMovementType lists every valid CashMovementType
+var AllCashMovementType = []CashMovementType{CashMovementTypeCashIn, CashMovementTypeCashOut, CashMovementTypeDrop}
+
+// IsValid returns true if a CashMovementType type is valid
+func (c CashMovementType) IsValid() bool {
+	switch c {
+	case CashMovementTypeCashIn, CashMovementTypeCashOut, CashMovementTypeDrop:
+		return true
+	}
+	return false
+}
+
+func (c CashMovementType) String() string {
+	return string(c)
+}
+
+// UnmarshalGQL converts the supplied value to a CashMovementType type.
+func (c *CashMovementType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*c = CashMovementType(str)
+	if !c.IsValid() {
+		return fmt.Errorf("%s is not a valid CashMovementType", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the CashMovementType type to the supplied writer
+func (c CashMovementType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(c.String()))
+}
@@ -0,0 +1,50 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// CostingMethod is the method used to value the cost of goods sold
+type CostingMethod string
+
+const (
+	// CostingMethodWeightedAverage values stock at the weighted average cost of the units received
+	CostingMethodWeightedAverage CostingMethod = "WEIGHTED_AVERAGE"
+
+	// CostingMethodFIFO values stock at the cost of the oldest units received first
+	CostingMethodFIFO CostingMethod = "FIFO"
+)
+
+// IsValid returns true if a CostingMethod type is valid
+func (c CostingMethod) IsValid() bool {
+	switch c {
+	case CostingMethodWeightedAverage, CostingMethodFIFO:
+		return true
+	}
+	return false
+}
+
+func (c CostingMethod) String() string {
+	return string(c)
+}
+
+// UnmarshalGQL converts the supplied value to a CostingMethod type.
+func (c *CostingMethod) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*c = CostingMethod(str)
+	if !c.IsValid() {
+		return fmt.Errorf("%s is not a valid CostingMethod", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the CostingMethod type to the supplied writer
+func (c CostingMethod) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(c.String()))
+}
@@ -0,0 +1,50 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// FileFormat is the format of a file that records are imported from or exported to
+type FileFormat string
+
+const (
+	// FileFormatCSV represents comma separated values
+	FileFormatCSV FileFormat = "CSV"
+
+	// FileFormatXLSX represents an Excel workbook
+	FileFormatXLSX FileFormat = "XLSX"
+)
+
+// IsValid returns true if a FileFormat type is valid
+func (f FileFormat) IsValid() bool {
+	switch f {
+	case FileFormatCSV, FileFormatXLSX:
+		return true
+	}
+	return false
+}
+
+func (f FileFormat) String() string {
+	return string(f)
+}
+
+// UnmarshalGQL converts the supplied value to a FileFormat type.
+func (f *FileFormat) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*f = FileFormat(str)
+	if !f.IsValid() {
+		return fmt.Errorf("%s is not a valid FileFormat", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the FileFormat type to the supplied writer
+func (f FileFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(f.String()))
+}
@@ -16,6 +16,9 @@ const (
 	FlavourConsumer Flavour = "CONSUMER"
 )
 
+// AllFlavour lists every valid Flavour
+var AllFlavour = []Flavour{FlavourPro, FlavourConsumer}
+
 // IsValid returns true if a flavour type is valid
 func (f Flavour) IsValid() bool {
 	switch f {
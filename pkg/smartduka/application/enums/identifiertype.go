@@ -0,0 +1,53 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// IdentifierType is the kind of document that identifies a user
+type IdentifierType string
+
+const (
+	// IdentifierTypeNationalID is a Kenyan national identity card
+	IdentifierTypeNationalID IdentifierType = "NATIONAL_ID"
+
+	// IdentifierTypePassport is a passport, used by users without a national ID
+	IdentifierTypePassport IdentifierType = "PASSPORT"
+)
+
+// AllIdentifierType lists every valid IdentifierType
+var AllIdentifierType = []IdentifierType{IdentifierTypeNationalID, IdentifierTypePassport}
+
+// IsValid returns true if an IdentifierType is valid
+func (i IdentifierType) IsValid() bool {
+	switch i {
+	case IdentifierTypeNationalID, IdentifierTypePassport:
+		return true
+	}
+	return false
+}
+
+func (i IdentifierType) String() string {
+	return string(i)
+}
+
+// UnmarshalGQL converts the supplied value to an IdentifierType.
+func (i *IdentifierType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*i = IdentifierType(str)
+	if !i.IsValid() {
+		return fmt.Errorf("%s is not a valid IdentifierType", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the IdentifierType to the supplied writer
+func (i IdentifierType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(i.String()))
+}
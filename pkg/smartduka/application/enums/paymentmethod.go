@@ -0,0 +1,62 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// PaymentMethod is the means by which a customer paid for a sale
+type PaymentMethod string
+
+const (
+	// PaymentMethodCash represents payment in cash
+	PaymentMethodCash PaymentMethod = "CASH"
+
+	// PaymentMethodMpesa represents payment through M-PESA
+	PaymentMethodMpesa PaymentMethod = "MPESA"
+
+	// PaymentMethodCard represents payment by debit or credit card
+	PaymentMethodCard PaymentMethod = "CARD"
+
+	// PaymentMethodBankTransfer represents payment by bank transfer
+	PaymentMethodBankTransfer PaymentMethod = "BANK_TRANSFER"
+
+	// PaymentMethodCredit represents a sale made on credit
+	PaymentMethodCredit PaymentMethod = "CREDIT"
+)
+
+// AllPaymentMethod lists every valid PaymentMethod
+var AllPaymentMethod = []PaymentMethod{PaymentMethodCash, PaymentMethodMpesa, PaymentMethodCard, PaymentMethodBankTransfer, PaymentMethodCredit}
+
+// IsValid returns true if a PaymentMethod type is valid
+func (p PaymentMethod) IsValid() bool {
+	switch p {
+	case PaymentMethodCash, PaymentMethodMpesa, PaymentMethodCard, PaymentMethodBankTransfer, PaymentMethodCredit:
+		return true
+	}
+	return false
+}
+
+func (p PaymentMethod) String() string {
+	return string(p)
+}
+
+// UnmarshalGQL converts the supplied value to a PaymentMethod type.
+func (p *PaymentMethod) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*p = PaymentMethod(str)
+	if !p.IsValid() {
+		return fmt.Errorf("%s is not a valid PaymentMethod", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the PaymentMethod type to the supplied writer
+func (p PaymentMethod) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(p.String()))
+}
@@ -20,6 +20,9 @@ const (
 	CategoryFoodStuff Category = "FOOD_STUFF"
 )
 
+// AllCategory lists every valid Category
+var AllCategory = []Category{CategoryCereals, CategoryMedicine, CategoryFoodStuff}
+
 // IsValid returns true if a Category type is valid
 func (u Category) IsValid() bool {
 	switch u {
@@ -0,0 +1,50 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ProductRanking determines how products are ranked in a top products report
+type ProductRanking string
+
+const (
+	// ProductRankingRevenue ranks products by the revenue they brought in
+	ProductRankingRevenue ProductRanking = "REVENUE"
+
+	// ProductRankingQuantity ranks products by the quantity sold
+	ProductRankingQuantity ProductRanking = "QUANTITY"
+)
+
+// IsValid returns true if a ProductRanking type is valid
+func (p ProductRanking) IsValid() bool {
+	switch p {
+	case ProductRankingRevenue, ProductRankingQuantity:
+		return true
+	}
+	return false
+}
+
+func (p ProductRanking) String() string {
+	return string(p)
+}
+
+// UnmarshalGQL converts the supplied value to a ProductRanking type.
+func (p *ProductRanking) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*p = ProductRanking(str)
+	if !p.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductRanking", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the ProductRanking type to the supplied writer
+func (p ProductRanking) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(p.String()))
+}
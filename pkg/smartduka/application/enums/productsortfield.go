@@ -0,0 +1,56 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ProductSortField is a field a list of products can be sorted by
+type ProductSortField string
+
+const (
+	// ProductSortFieldName sorts products by name
+	ProductSortFieldName ProductSortField = "NAME"
+
+	// ProductSortFieldPrice sorts products by selling price
+	ProductSortFieldPrice ProductSortField = "PRICE"
+
+	// ProductSortFieldCreatedAt sorts products by when they were added
+	ProductSortFieldCreatedAt ProductSortField = "CREATED_AT"
+)
+
+// AllProductSortField lists every valid ProductSortField
+var AllProductSortField = []ProductSortField{ProductSortFieldName, ProductSortFieldPrice, ProductSortFieldCreatedAt}
+
+// IsValid returns true if a ProductSortField type is valid
+func (p ProductSortField) IsValid() bool {
+	switch p {
+	case ProductSortFieldName, ProductSortFieldPrice, ProductSortFieldCreatedAt:
+		return true
+	}
+	return false
+}
+
+func (p ProductSortField) String() string {
+	return string(p)
+}
+
+// UnmarshalGQL converts the supplied value to a ProductSortField type.
+func (p *ProductSortField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*p = ProductSortField(str)
+	if !p.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductSortField", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the ProductSortField type to the supplied writer
+func (p ProductSortField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(p.String()))
+}
@@ -35,6 +35,9 @@ const (
 	UnitPacket Unit = "PACKET"
 )
 
+// AllUnit lists every valid Unit
+var AllUnit = []Unit{UnitSingle, UnitHalfDozen, UnitDozen, UnitOuter, UnitCarton, UnitBale, UnitBag, UnitPacket}
+
 // IsValid returns true if a Unit type is valid
 func (u Unit) IsValid() bool {
 	switch u {
@@ -0,0 +1,59 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ProfitGrouping determines how gross profit is broken down in a report
+type ProfitGrouping string
+
+const (
+	// ProfitGroupingProduct breaks gross profit down by product
+	ProfitGroupingProduct ProfitGrouping = "PRODUCT"
+
+	// ProfitGroupingCategory breaks gross profit down by product category
+	ProfitGroupingCategory ProfitGrouping = "CATEGORY"
+
+	// ProfitGroupingDay breaks gross profit down by calendar day
+	ProfitGroupingDay ProfitGrouping = "DAY"
+
+	// ProfitGroupingWeek breaks gross profit down by calendar week starting on Monday
+	ProfitGroupingWeek ProfitGrouping = "WEEK"
+
+	// ProfitGroupingMonth breaks gross profit down by calendar month
+	ProfitGroupingMonth ProfitGrouping = "MONTH"
+)
+
+// IsValid returns true if a ProfitGrouping type is valid
+func (p ProfitGrouping) IsValid() bool {
+	switch p {
+	case ProfitGroupingProduct, ProfitGroupingCategory, ProfitGroupingDay, ProfitGroupingWeek, ProfitGroupingMonth:
+		return true
+	}
+	return false
+}
+
+func (p ProfitGrouping) String() string {
+	return string(p)
+}
+
+// UnmarshalGQL converts the supplied value to a ProfitGrouping type.
+func (p *ProfitGrouping) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*p = ProfitGrouping(str)
+	if !p.IsValid() {
+		return fmt.Errorf("%s is not a valid ProfitGrouping", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the ProfitGrouping type to the supplied writer
+func (p ProfitGrouping) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(p.String()))
+}
@@ -0,0 +1,56 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ReceiptFormat is the form a receipt is produced in
+type ReceiptFormat string
+
+const (
+	// ReceiptFormatESCPOS58 represents ESC/POS commands for a 58mm thermal printer
+	ReceiptFormatESCPOS58 ReceiptFormat = "ESC_POS_58MM"
+
+	// ReceiptFormatESCPOS80 represents ESC/POS commands for an 80mm thermal printer
+	ReceiptFormatESCPOS80 ReceiptFormat = "ESC_POS_80MM"
+
+	// ReceiptFormatPDF represents a PDF document
+	ReceiptFormatPDF ReceiptFormat = "PDF"
+
+	// ReceiptFormatText represents compact plain text for SMS or WhatsApp
+	ReceiptFormatText ReceiptFormat = "TEXT"
+)
+
+// IsValid returns true if a ReceiptFormat type is valid
+func (r ReceiptFormat) IsValid() bool {
+	switch r {
+	case ReceiptFormatESCPOS58, ReceiptFormatESCPOS80, ReceiptFormatPDF, ReceiptFormatText:
+		return true
+	}
+	return false
+}
+
+func (r ReceiptFormat) String() string {
+	return string(r)
+}
+
+// UnmarshalGQL converts the supplied value to a ReceiptFormat type.
+func (r *ReceiptFormat) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*r = ReceiptFormat(str)
+	if !r.IsValid() {
+		return fmt.Errorf("%s is not a valid ReceiptFormat", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the ReceiptFormat type to the supplied writer
+func (r ReceiptFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(r.String()))
+}
@@ -0,0 +1,53 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ReportInterval is the calendar period sales reports are grouped by
+type ReportInterval string
+
+const (
+	// ReportIntervalDay groups report figures by calendar day
+	ReportIntervalDay ReportInterval = "DAY"
+
+	// ReportIntervalWeek groups report figures by calendar week starting on Monday
+	ReportIntervalWeek ReportInterval = "WEEK"
+
+	// ReportIntervalMonth groups report figures by calendar month
+	ReportIntervalMonth ReportInterval = "MONTH"
+)
+
+// IsValid returns true if a ReportInterval type is valid
+func (r ReportInterval) IsValid() bool {
+	switch r {
+	case ReportIntervalDay, ReportIntervalWeek, ReportIntervalMonth:
+		return true
+	}
+	return false
+}
+
+func (r ReportInterval) String() string {
+	return string(r)
+}
+
+// UnmarshalGQL converts the supplied value to a ReportInterval type.
+func (r *ReportInterval) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*r = ReportInterval(str)
+	if !r.IsValid() {
+		return fmt.Errorf("%s is not a valid ReportInterval", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the ReportInterval type to the supplied writer
+func (r ReportInterval) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(r.String()))
+}
@@ -0,0 +1,53 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// SalesGrouping determines how sales are broken down in a report
+type SalesGrouping string
+
+const (
+	// SalesGroupingCashier breaks sales down by the user who made them
+	SalesGroupingCashier SalesGrouping = "CASHIER"
+
+	// SalesGroupingCategory breaks sales down by product category
+	SalesGroupingCategory SalesGrouping = "CATEGORY"
+
+	// SalesGroupingPaymentMethod breaks sales down by payment method
+	SalesGroupingPaymentMethod SalesGrouping = "PAYMENT_METHOD"
+)
+
+// IsValid returns true if a SalesGrouping type is valid
+func (s SalesGrouping) IsValid() bool {
+	switch s {
+	case SalesGroupingCashier, SalesGroupingCategory, SalesGroupingPaymentMethod:
+		return true
+	}
+	return false
+}
+
+func (s SalesGrouping) String() string {
+	return string(s)
+}
+
+// UnmarshalGQL converts the supplied value to a SalesGrouping type.
+func (s *SalesGrouping) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*s = SalesGrouping(str)
+	if !s.IsValid() {
+		return fmt.Errorf("%s is not a valid SalesGrouping", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the SalesGrouping type to the supplied writer
+func (s SalesGrouping) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(s.String()))
+}
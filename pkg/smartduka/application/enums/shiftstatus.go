@@ -0,0 +1,53 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ShiftStatus is the state of a cashier's shift
+type ShiftStatus string
+
+const (
+	// ShiftStatusOpen represents a shift that is still taking sales
+	ShiftStatusOpen ShiftStatus = "OPEN"
+
+	// ShiftStatusClosed represents a shift that has been closed and locked
+	ShiftStatusClosed ShiftStatus = "CLOSED"
+)
+
+// AllShiftStatus lists every valid ShiftStatus
+var AllShiftStatus = []ShiftStatus{ShiftStatusOpen, ShiftStatusClosed}
+
+// IsValid returns true if a ShiftStatus type is valid
+func (s ShiftStatus) IsValid() bool {
+	switch s {
+	case ShiftStatusOpen, ShiftStatusClosed:
+		return true
+	}
+	return false
+}
+
+func (s ShiftStatus) String() string {
+	return string(s)
+}
+
+// UnmarshalGQL converts the supplied value to a ShiftStatus type.
+func (s *ShiftStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*s = ShiftStatus(str)
+	if !s.IsValid() {
+		return fmt.Errorf("%s is not a valid ShiftStatus", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the ShiftStatus type to the supplied writer
+func (s ShiftStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(s.String()))
+}
@@ -0,0 +1,53 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// SortDirection is the order a list is sorted in
+type SortDirection string
+
+const (
+	// SortDirectionAsc sorts from the smallest value to the largest
+	SortDirectionAsc SortDirection = "ASC"
+
+	// SortDirectionDesc sorts from the largest value to the smallest
+	SortDirectionDesc SortDirection = "DESC"
+)
+
+// AllSortDirection lists every valid SortDirection
+var AllSortDirection = []SortDirection{SortDirectionAsc, SortDirectionDesc}
+
+// IsValid returns true if a SortDirection type is valid
+func (s SortDirection) IsValid() bool {
+	switch s {
+	case SortDirectionAsc, SortDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (s SortDirection) String() string {
+	return string(s)
+}
+
+// UnmarshalGQL converts the supplied value to a SortDirection type.
+func (s *SortDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*s = SortDirection(str)
+	if !s.IsValid() {
+		return fmt.Errorf("%s is not a valid SortDirection", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the SortDirection type to the supplied writer
+func (s SortDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(s.String()))
+}
@@ -0,0 +1,53 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// SyncConflict is the reason a pushed operation was flagged
+type SyncConflict string
+
+const (
+	// SyncConflictNegativeStock is a sale that took a product's stock below zero
+	SyncConflictNegativeStock SyncConflict = "NEGATIVE_STOCK"
+
+	// SyncConflictPriceMismatch is a sale made at a price other than the product's current price
+	SyncConflictPriceMismatch SyncConflict = "PRICE_MISMATCH"
+)
+
+// AllSyncConflict lists every valid SyncConflict
+var AllSyncConflict = []SyncConflict{SyncConflictNegativeStock, SyncConflictPriceMismatch}
+
+// IsValid returns true if a SyncConflict type is valid
+func (c SyncConflict) IsValid() bool {
+	switch c {
+	case SyncConflictNegativeStock, SyncConflictPriceMismatch:
+		return true
+	}
+	return false
+}
+
+func (c SyncConflict) String() string {
+	return string(c)
+}
+
+// UnmarshalGQL converts the supplied value to a SyncConflict type.
+func (c *SyncConflict) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*c = SyncConflict(str)
+	if !c.IsValid() {
+		return fmt.Errorf("%s is not a valid SyncConflict", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the SyncConflict type to the supplied writer
+func (c SyncConflict) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(c.String()))
+}
@@ -0,0 +1,56 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// SyncOperationStatus is the outcome of applying a pushed operation
+type SyncOperationStatus string
+
+const (
+	// SyncOperationStatusApplied is an operation applied without conflicts
+	SyncOperationStatusApplied SyncOperationStatus = "APPLIED"
+
+	// SyncOperationStatusFlagged is an operation applied with a conflict a supervisor should review
+	SyncOperationStatusFlagged SyncOperationStatus = "FLAGGED"
+
+	// SyncOperationStatusRejected is an operation that could not be applied. It had no effect and can be pushed again
+	SyncOperationStatusRejected SyncOperationStatus = "REJECTED"
+)
+
+// AllSyncOperationStatus lists every valid SyncOperationStatus
+var AllSyncOperationStatus = []SyncOperationStatus{SyncOperationStatusApplied, SyncOperationStatusFlagged, SyncOperationStatusRejected}
+
+// IsValid returns true if a SyncOperationStatus type is valid
+func (s SyncOperationStatus) IsValid() bool {
+	switch s {
+	case SyncOperationStatusApplied, SyncOperationStatusFlagged, SyncOperationStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (s SyncOperationStatus) String() string {
+	return string(s)
+}
+
+// UnmarshalGQL converts the supplied value to a SyncOperationStatus type.
+func (s *SyncOperationStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*s = SyncOperationStatus(str)
+	if !s.IsValid() {
+		return fmt.Errorf("%s is not a valid SyncOperationStatus", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the SyncOperationStatus type to the supplied writer
+func (s SyncOperationStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(s.String()))
+}
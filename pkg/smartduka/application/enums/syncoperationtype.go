@@ -0,0 +1,56 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// SyncOperationType is the kind of change an offline client pushes to the server
+type SyncOperationType string
+
+const (
+	// SyncOperationTypeSale is a sale made at the till
+	SyncOperationTypeSale SyncOperationType = "SALE"
+
+	// SyncOperationTypeReturn is goods returned by a customer
+	SyncOperationTypeReturn SyncOperationType = "RETURN"
+
+	// SyncOperationTypeStockReceipt is a delivery of stock
+	SyncOperationTypeStockReceipt SyncOperationType = "STOCK_RECEIPT"
+)
+
+// AllSyncOperationType lists every valid SyncOperationType
+var AllSyncOperationType = []SyncOperationType{SyncOperationTypeSale, SyncOperationTypeReturn, SyncOperationTypeStockReceipt}
+
+// IsValid returns true if a SyncOperationType type is valid
+func (o SyncOperationType) IsValid() bool {
+	switch o {
+	case SyncOperationTypeSale, SyncOperationTypeReturn, SyncOperationTypeStockReceipt:
+		return true
+	}
+	return false
+}
+
+func (o SyncOperationType) String() string {
+	return string(o)
+}
+
+// UnmarshalGQL converts the supplied value to a SyncOperationType type.
+func (o *SyncOperationType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*o = SyncOperationType(str)
+	if !o.IsValid() {
+		return fmt.Errorf("%s is not a valid SyncOperationType", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the SyncOperationType type to the supplied writer
+func (o SyncOperationType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(o.String()))
+}
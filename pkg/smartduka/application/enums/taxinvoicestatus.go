@@ -0,0 +1,56 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// TaxInvoiceStatus is the state of a sale's electronic tax invoice submission to KRA
+type TaxInvoiceStatus string
+
+const (
+	// TaxInvoiceStatusPending is an invoice waiting to be submitted
+	TaxInvoiceStatusPending TaxInvoiceStatus = "PENDING"
+
+	// TaxInvoiceStatusSubmitted is an invoice accepted by KRA
+	TaxInvoiceStatusSubmitted TaxInvoiceStatus = "SUBMITTED"
+
+	// TaxInvoiceStatusRejected is an invoice KRA refused. It is not retried
+	TaxInvoiceStatusRejected TaxInvoiceStatus = "REJECTED"
+)
+
+// AllTaxInvoiceStatus lists every valid TaxInvoiceStatus
+var AllTaxInvoiceStatus = []TaxInvoiceStatus{TaxInvoiceStatusPending, TaxInvoiceStatusSubmitted, TaxInvoiceStatusRejected}
+
+// IsValid returns true if a TaxInvoiceStatus type is valid
+func (s TaxInvoiceStatus) IsValid() bool {
+	switch s {
+	case TaxInvoiceStatusPending, TaxInvoiceStatusSubmitted, TaxInvoiceStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (s TaxInvoiceStatus) String() string {
+	return string(s)
+}
+
+// UnmarshalGQL converts the supplied value to a TaxInvoiceStatus type.
+func (s *TaxInvoiceStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*s = TaxInvoiceStatus(str)
+	if !s.IsValid() {
+		return fmt.Errorf("%s is not a valid TaxInvoiceStatus", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the TaxInvoiceStatus type to the supplied writer
+func (s TaxInvoiceStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(s.String()))
+}
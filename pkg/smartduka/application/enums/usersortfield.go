@@ -0,0 +1,53 @@
+package enums
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// UserSortField is a field a list of users can be sorted by
+type UserSortField string
+
+const (
+	// UserSortFieldUsername sorts users by username
+	UserSortFieldUsername UserSortField = "USERNAME"
+
+	// UserSortFieldCreatedAt sorts users by when they registered
+	UserSortFieldCreatedAt UserSortField = "CREATED_AT"
+)
+
+// AllUserSortField lists every valid UserSortField
+var AllUserSortField = []UserSortField{UserSortFieldUsername, UserSortFieldCreatedAt}
+
+// IsValid returns true if a UserSortField type is valid
+func (u UserSortField) IsValid() bool {
+	switch u {
+	case UserSortFieldUsername, UserSortFieldCreatedAt:
+		return true
+	}
+	return false
+}
+
+func (u UserSortField) String() string {
+	return string(u)
+}
+
+// UnmarshalGQL converts the supplied value to a UserSortField type.
+func (u *UserSortField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*u = UserSortField(str)
+	if !u.IsValid() {
+		return fmt.Errorf("%s is not a valid UserSortField", str)
+	}
+	return nil
+}
+
+// MarshalGQL writes the UserSortField type to the supplied writer
+func (u UserSortField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(u.String()))
+}
@@ -4,16 +4,27 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
 
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// The Africa's Talking endpoints SMS are sent through
+const (
+	aitSMSURL        = "https://api.africastalking.com/version1/messaging/bulk"
+	aitSandboxSMSURL = "https://api.sandbox.africastalking.com/version1/messaging/bulk"
 )
 
 // Extension holds the methods that are used by the extension
 type Extension interface {
 	MakeRequest(ctx context.Context, method string, path string, body interface{}) (*http.Response, error)
 	GetLoggedInUserUID(ctx context.Context) (string, error)
+	SendSMS(ctx context.Context, phoneNumber string, message string) error
 }
 
 // ExtImpl implements the Extension interface
@@ -27,7 +38,7 @@ func NewExtension() Extension {
 
 // MakeRequest performs a http request and returns a response
 func (e ExtImpl) MakeRequest(ctx context.Context, method string, path string, body interface{}) (*http.Response, error) {
-	apiKey := helpers.MustGetEnvVar("AIT_API_KEY")
+	apiKey := helpers.MustGetEnvVar(common.AITAPIKeyEnvVarName)
 
 	client := &http.Client{}
 
@@ -75,3 +86,36 @@ func (e ExtImpl) GetLoggedInUserUID(ctx context.Context) (string, error) {
 
 	return uid, nil
 }
+
+// SendSMS sends a text message to a phone number through Africa's Talking.
+// The message is dropped when Africa's Talking has not been configured. It is not logged since it may hold a verification code
+func (e ExtImpl) SendSMS(ctx context.Context, phoneNumber string, message string) error {
+	if os.Getenv(common.AITAPIKeyEnvVarName) == "" {
+		logrus.Printf("SMS to %s not sent: Africa's Talking is not configured", phoneNumber)
+		return nil
+	}
+
+	url := aitSMSURL
+	if os.Getenv(common.AITEnvironmentEnvVarName) == "sandbox" {
+		url = aitSandboxSMSURL
+	}
+
+	payload := map[string]interface{}{
+		"username":     helpers.MustGetEnvVar(common.AITUsernameEnvVarName),
+		"message":      message,
+		"senderId":     os.Getenv(common.AITSenderIDEnvVarName),
+		"phoneNumbers": []string{phoneNumber},
+	}
+
+	resp, err := e.MakeRequest(ctx, http.MethodPost, url, payload)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to send SMS: Africa's Talking responded with %s", resp.Status)
+	}
+
+	return nil
+}
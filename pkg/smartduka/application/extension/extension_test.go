@@ -0,0 +1,33 @@
+package extension_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/sirupsen/logrus"
+)
+
+func TestExtImpl_SendSMS_NotConfigured(t *testing.T) {
+	t.Setenv(common.AITAPIKeyEnvVarName, "")
+
+	var logs bytes.Buffer
+	output := logrus.StandardLogger().Out
+	logrus.SetOutput(&logs)
+	defer logrus.SetOutput(output)
+
+	err := extension.NewExtension().SendSMS(context.Background(), "+254722000000", "Your Smartduka verification code is 482913")
+	if err != nil {
+		t.Fatalf("ExtImpl.SendSMS() error = %v", err)
+	}
+
+	if strings.Contains(logs.String(), "482913") {
+		t.Errorf("ExtImpl.SendSMS() logged the message: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "not sent") {
+		t.Errorf("ExtImpl.SendSMS() logs = %q, want the SMS reported as not sent", logs.String())
+	}
+}
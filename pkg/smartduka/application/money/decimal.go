@@ -0,0 +1,284 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DecimalScale is the number of decimal places held by a Decimal
+const DecimalScale = 4
+
+// Decimal is a fixed point number with DecimalScale decimal places.
+// It is used for product quantities and percentage rates such as VAT
+type Decimal struct {
+	units int64
+}
+
+// NewDecimal creates a decimal from a count of 1/10^DecimalScale units
+func NewDecimal(units int64) Decimal {
+	return Decimal{units: units}
+}
+
+// DecimalFromInt creates a decimal holding a whole number
+func DecimalFromInt(value int64) Decimal {
+	return Decimal{units: value * pow10(DecimalScale).Int64()}
+}
+
+// ParseDecimal converts a decimal string e.g `12.5` into a Decimal
+func ParseDecimal(value string) (Decimal, error) {
+	units, err := parseFixed(value, DecimalScale)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: %w", value, err)
+	}
+	return Decimal{units: units}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if the value cannot be parsed
+func MustParseDecimal(value string) Decimal {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Units returns the raw count of 1/10^DecimalScale units
+func (d Decimal) Units() int64 {
+	return d.units
+}
+
+// Add returns the sum of two decimals
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{units: d.units + other.units}
+}
+
+// Sub returns the difference between two decimals
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{units: d.units - other.units}
+}
+
+// Mul multiplies two decimals, rounding half away from zero
+func (d Decimal) Mul(other Decimal) Decimal {
+	product := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(other.units))
+	return Decimal{units: divRound(product, pow10(DecimalScale))}
+}
+
+// Cmp compares two decimals and returns -1, 0 or +1
+func (d Decimal) Cmp(other Decimal) int {
+	switch {
+	case d.units < other.units:
+		return -1
+	case d.units > other.units:
+		return 1
+	}
+	return 0
+}
+
+// IsZero returns true if the decimal is zero
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// IsNegative returns true if the decimal is below zero
+func (d Decimal) IsNegative() bool {
+	return d.units < 0
+}
+
+// String formats the decimal without trailing zeros e.g `12.5`
+func (d Decimal) String() string {
+	formatted := formatFixed(d.units, DecimalScale)
+	formatted = strings.TrimRight(formatted, "0")
+	return strings.TrimSuffix(formatted, ".")
+}
+
+// Scan implements the sql.Scanner interface
+func (d *Decimal) Scan(value interface{}) error {
+	units, err := scanFixed(value, DecimalScale)
+	if err != nil {
+		return fmt.Errorf("failed to scan decimal: %w", err)
+	}
+	d.units = units
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// GormDataType is the column type used when gorm creates the schema
+func (Decimal) GormDataType() string {
+	return "numeric(14,4)"
+}
+
+// MarshalJSON writes the decimal as a string so that clients do not lose precision
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a decimal from either a string or a JSON number
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalFixedJSON(data)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseDecimal(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// UnmarshalGQL converts the supplied value to a decimal
+func (d *Decimal) UnmarshalGQL(v interface{}) error {
+	value, err := gqlFixedString(v)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseDecimal(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalGQL writes the decimal to the supplied writer
+func (d Decimal) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(d.String()))
+}
+
+// parseFixed reads a plain decimal string into an integer scaled by 10^scale
+func parseFixed(value string, scale int) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty value")
+	}
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("no digits")
+	}
+	if len(fraction) > scale {
+		// trailing zeros do not lose precision
+		if strings.Trim(fraction[scale:], "0") != "" {
+			return 0, fmt.Errorf("more than %d decimal places", scale)
+		}
+		fraction = fraction[:scale]
+	}
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	digits := whole + fraction + strings.Repeat("0", scale-len(fraction))
+	parsed, ok := new(big.Int).SetString(digits, 10)
+	if !ok || !parsed.IsInt64() {
+		return 0, fmt.Errorf("value out of range")
+	}
+
+	result := parsed.Int64()
+	if negative {
+		result = -result
+	}
+	return result, nil
+}
+
+// formatFixed writes an integer scaled by 10^scale as a decimal string
+func formatFixed(units int64, scale int) string {
+	digits := new(big.Int).Abs(big.NewInt(units)).String()
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	formatted := digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	if units < 0 {
+		formatted = "-" + formatted
+	}
+	return formatted
+}
+
+// scanFixed reads a database value into an integer scaled by 10^scale
+func scanFixed(value interface{}, scale int) (int64, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case []byte:
+		return parseFixed(string(v), scale)
+	case string:
+		return parseFixed(v, scale)
+	case int64:
+		return parseFixed(strconv.FormatInt(v, 10), scale)
+	case float64:
+		// legacy float columns are rounded to the scale we hold
+		return parseFixed(strconv.FormatFloat(v, 'f', scale, 64), scale)
+	}
+	return 0, fmt.Errorf("unsupported type %T", value)
+}
+
+// unmarshalFixedJSON accepts a decimal encoded either as a JSON string or number
+func unmarshalFixedJSON(data []byte) (string, error) {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		return value, nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return "", fmt.Errorf("expected a decimal string or number: %w", err)
+	}
+	return number.String(), nil
+}
+
+// gqlFixedString converts a GraphQL input value into a decimal string
+func gqlFixedString(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case int:
+		return strconv.Itoa(value), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("%T is not a valid decimal value", v)
+}
+
+// divRound divides two integers rounding half away from zero
+func divRound(numerator *big.Int, denominator *big.Int) int64 {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+
+	doubled := new(big.Int).Abs(remainder)
+	doubled.Mul(doubled, big.NewInt(2))
+	if doubled.Cmp(new(big.Int).Abs(denominator)) >= 0 {
+		if numerator.Sign()*denominator.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient.Int64()
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
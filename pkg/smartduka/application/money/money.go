@@ -0,0 +1,272 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	// CurrencyKES represents the Kenyan shilling
+	CurrencyKES Currency = "KES"
+
+	// DefaultCurrency is the currency used when none has been specified
+	DefaultCurrency = CurrencyKES
+
+	// MinorUnitScale is the number of decimal places held by a money amount.
+	// All the currencies we deal with are subdivided into 100 minor units
+	MinorUnitScale = 2
+)
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies
+var ErrCurrencyMismatch = fmt.Errorf("currency mismatch")
+
+// IsValid returns true if a currency code is valid
+func (c Currency) IsValid() bool {
+	if len(c) != 3 {
+		return false
+	}
+	for _, r := range c {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Currency) String() string {
+	return string(c)
+}
+
+// Money is a monetary amount held as an integer count of minor units (e.g cents).
+// It is used in place of float64 so that totals, VAT and change are computed exactly.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+// New creates a money amount from a count of minor units
+func New(amount int64, currency Currency) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(currency Currency) Money {
+	return New(0, currency)
+}
+
+// Parse converts a decimal string e.g `760.50` into a money amount.
+// It refuses values with more decimal places than a currency can hold
+func Parse(value string, currency Currency) (Money, error) {
+	amount, err := parseFixed(value, MinorUnitScale)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid money amount %q: %w", value, err)
+	}
+	return New(amount, currency), nil
+}
+
+// MustParse is like Parse but panics if the value cannot be parsed.
+// It is intended for constants in code and tests
+func MustParse(value string, currency Currency) Money {
+	m, err := Parse(value, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Sum adds up the supplied amounts. All of them must be in the same currency
+func Sum(currency Currency, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, amount := range amounts {
+		var err error
+		total, err = total.Add(amount)
+		if err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Add returns the sum of two amounts
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return New(m.Amount+other.Amount, m.currency()), nil
+}
+
+// Sub returns the difference between two amounts
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return New(m.Amount-other.Amount, m.currency()), nil
+}
+
+// Mul multiplies an amount by a decimal quantity, rounding half away from zero to the nearest minor unit
+func (m Money) Mul(quantity Decimal) Money {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(quantity.units))
+	return New(divRound(product, pow10(DecimalScale)), m.currency())
+}
+
+// Div divides an amount by a decimal quantity e.g to get a unit cost from a total cost.
+// The result is rounded half away from zero to the nearest minor unit
+func (m Money) Div(quantity Decimal) (Money, error) {
+	if quantity.IsZero() {
+		return Money{}, fmt.Errorf("cannot divide %s by zero", m)
+	}
+	numerator := new(big.Int).Mul(big.NewInt(m.Amount), pow10(DecimalScale))
+	return New(divRound(numerator, big.NewInt(quantity.units)), m.currency()), nil
+}
+
+// Percentage expresses an amount as a percentage of another e.g a gross profit as a margin of revenue.
+// It returns zero when the whole is zero
+func Percentage(part Money, whole Money) (Decimal, error) {
+	if err := part.checkCurrency(whole); err != nil {
+		return Decimal{}, err
+	}
+	if whole.IsZero() {
+		return Decimal{}, nil
+	}
+	numerator := new(big.Int).Mul(big.NewInt(part.Amount), new(big.Int).Mul(big.NewInt(100), pow10(DecimalScale)))
+	return Decimal{units: divRound(numerator, big.NewInt(whole.Amount))}, nil
+}
+
+// VATExclusive calculates the VAT chargeable on a net amount at the given percentage rate e.g 16
+func (m Money) VATExclusive(rate Decimal) Money {
+	numerator := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(rate.units))
+	denominator := new(big.Int).Mul(big.NewInt(100), pow10(DecimalScale))
+	return New(divRound(numerator, denominator), m.currency())
+}
+
+// VATInclusive splits a VAT inclusive (gross) amount into its net and VAT parts at the given percentage rate.
+// The VAT part is rounded and the net part is derived from it so that net + vat always equals the gross amount
+func (m Money) VATInclusive(rate Decimal) (net Money, vat Money) {
+	numerator := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(rate.units))
+	denominator := new(big.Int).Add(new(big.Int).Mul(big.NewInt(100), pow10(DecimalScale)), big.NewInt(rate.units))
+	vat = New(divRound(numerator, denominator), m.currency())
+	net = New(m.Amount-vat.Amount, m.currency())
+	return net, vat
+}
+
+// Neg returns the amount with its sign inverted
+func (m Money) Neg() Money {
+	return New(-m.Amount, m.currency())
+}
+
+// Cmp compares two amounts and returns -1, 0 or +1
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// IsZero returns true if the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative returns true if the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// String formats the amount in major units e.g `760.50`
+func (m Money) String() string {
+	return formatFixed(m.Amount, MinorUnitScale)
+}
+
+// Display formats the amount together with its currency e.g `KES 760.50`
+func (m Money) Display() string {
+	return fmt.Sprintf("%s %s", m.currency(), m.String())
+}
+
+func (m Money) currency() Currency {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+func (m Money) checkCurrency(other Money) error {
+	if m.currency() != other.currency() {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency(), other.currency())
+	}
+	return nil
+}
+
+// Scan implements the sql.Scanner interface. It reads a `numeric` column.
+// The currency is not stored in the column and defaults to DefaultCurrency
+func (m *Money) Scan(value interface{}) error {
+	amount, err := scanFixed(value, MinorUnitScale)
+	if err != nil {
+		return fmt.Errorf("failed to scan money amount: %w", err)
+	}
+	*m = New(amount, m.Currency)
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// GormDataType is the column type used when gorm creates the schema
+func (Money) GormDataType() string {
+	return "numeric(14,2)"
+}
+
+// MarshalJSON writes the amount as a decimal string so that clients do not lose precision
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON reads an amount from either a decimal string or a JSON number
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalFixedJSON(data)
+	if err != nil {
+		return err
+	}
+	parsed, err := Parse(value, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// UnmarshalGQL converts the supplied value to a money amount
+func (m *Money) UnmarshalGQL(v interface{}) error {
+	value, err := gqlFixedString(v)
+	if err != nil {
+		return err
+	}
+	parsed, err := Parse(value, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalGQL writes the money amount to the supplied writer
+func (m Money) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(m.String()))
+}
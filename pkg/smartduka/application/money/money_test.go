@@ -0,0 +1,277 @@
+package money_test
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/quick"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+)
+
+// bounded keeps generated amounts within a realistic till range (below 10 million shillings)
+func bounded(amount int64) int64 {
+	return amount % 1_000_000_000
+}
+
+func TestMoney_TotalsAreExact(t *testing.T) {
+	property := func(amounts []int64) bool {
+		var expected int64
+		lines := []money.Money{}
+		for _, amount := range amounts {
+			amount = bounded(amount)
+			expected += amount
+			lines = append(lines, money.New(amount, money.CurrencyKES))
+		}
+
+		total, err := money.Sum(money.CurrencyKES, lines...)
+		if err != nil {
+			return false
+		}
+		return total.Amount == expected
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Errorf("totals are not exact: %v", err)
+	}
+}
+
+func TestMoney_VATInclusiveSplitsExactly(t *testing.T) {
+	property := func(amount int64, rate uint32) bool {
+		gross := money.New(bounded(amount), money.CurrencyKES)
+		// rates between 0% and 100%
+		vatRate := money.NewDecimal(int64(rate % 1_000_001))
+
+		net, vat := gross.VATInclusive(vatRate)
+		sum, err := net.Add(vat)
+		if err != nil {
+			return false
+		}
+		return sum.Amount == gross.Amount
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Errorf("net + vat does not add up to the gross amount: %v", err)
+	}
+}
+
+func TestMoney_ChangeIsExact(t *testing.T) {
+	property := func(total int64, tendered int64) bool {
+		due := money.New(bounded(total), money.CurrencyKES)
+		paid := money.New(bounded(tendered), money.CurrencyKES)
+
+		change, err := paid.Sub(due)
+		if err != nil {
+			return false
+		}
+		back, err := due.Add(change)
+		if err != nil {
+			return false
+		}
+		return back.Amount == paid.Amount
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Errorf("total + change does not equal the amount tendered: %v", err)
+	}
+}
+
+func TestMoney_StringRoundTrips(t *testing.T) {
+	property := func(amount int64) bool {
+		m := money.New(amount, money.CurrencyKES)
+		parsed, err := money.Parse(m.String(), money.CurrencyKES)
+		if err != nil {
+			return false
+		}
+		return parsed == m
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Errorf("formatting and parsing does not round trip: %v", err)
+	}
+}
+
+func TestDecimal_StringRoundTrips(t *testing.T) {
+	property := func(units int64) bool {
+		d := money.NewDecimal(units)
+		parsed, err := money.ParseDecimal(d.String())
+		if err != nil {
+			return false
+		}
+		return parsed == d
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Errorf("formatting and parsing does not round trip: %v", err)
+	}
+}
+
+func TestMoney_Mul(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    string
+		quantity string
+		want     string
+	}{
+		{
+			name:     "Happy case: whole quantity",
+			price:    "760.00",
+			quantity: "3",
+			want:     "2280.00",
+		},
+		{
+			name:     "Happy case: float64 would drift",
+			price:    "0.10",
+			quantity: "3",
+			want:     "0.30",
+		},
+		{
+			name:     "Happy case: fractional quantity rounds half away from zero",
+			price:    "0.15",
+			quantity: "0.5",
+			want:     "0.08",
+		},
+		{
+			name:     "Happy case: negative amount rounds half away from zero",
+			price:    "-0.15",
+			quantity: "0.5",
+			want:     "-0.08",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := money.MustParse(tt.price, money.CurrencyKES).Mul(money.MustParseDecimal(tt.quantity))
+			if got.String() != tt.want {
+				t.Errorf("Money.Mul() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_VAT(t *testing.T) {
+	rate := money.MustParseDecimal("16")
+
+	vat := money.MustParse("100.00", money.CurrencyKES).VATExclusive(rate)
+	if vat.String() != "16.00" {
+		t.Errorf("Money.VATExclusive() = %v, want 16.00", vat)
+	}
+
+	net, vat := money.MustParse("116.00", money.CurrencyKES).VATInclusive(rate)
+	if net.String() != "100.00" || vat.String() != "16.00" {
+		t.Errorf("Money.VATInclusive() = %v, %v, want 100.00, 16.00", net, vat)
+	}
+}
+
+func TestMoney_DivUndoesMul(t *testing.T) {
+	property := func(amount int64, quantity uint16) bool {
+		price := money.New(bounded(amount), money.CurrencyKES)
+		count := money.DecimalFromInt(int64(quantity) + 1)
+
+		unit, err := price.Mul(count).Div(count)
+		if err != nil {
+			return false
+		}
+		return unit == price
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Errorf("dividing a total by its quantity does not give the unit price: %v", err)
+	}
+}
+
+func TestPercentage(t *testing.T) {
+	margin, err := money.Percentage(money.MustParse("25.00", money.CurrencyKES), money.MustParse("200.00", money.CurrencyKES))
+	if err != nil {
+		t.Errorf("Percentage() error = %v", err)
+		return
+	}
+	if margin.String() != "12.5" {
+		t.Errorf("Percentage() = %v, want 12.5", margin)
+	}
+
+	margin, err = money.Percentage(money.MustParse("25.00", money.CurrencyKES), money.Zero(money.CurrencyKES))
+	if err != nil || !margin.IsZero() {
+		t.Errorf("Percentage() = %v, %v, want 0", margin, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{
+			name:  "Happy case: two decimal places",
+			value: "900.89",
+			want:  90089,
+		},
+		{
+			name:  "Happy case: no decimal places",
+			value: "12",
+			want:  1200,
+		},
+		{
+			name:  "Happy case: trailing zeros",
+			value: "1.500",
+			want:  150,
+		},
+		{
+			name:  "Happy case: negative",
+			value: "-0.05",
+			want:  -5,
+		},
+		{
+			name:    "Sad case: too many decimal places",
+			value:   "1.005",
+			wantErr: true,
+		},
+		{
+			name:    "Sad case: not a number",
+			value:   "12a",
+			wantErr: true,
+		},
+		{
+			name:    "Sad case: empty",
+			value:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := money.Parse(tt.value, money.CurrencyKES)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.Amount != tt.want {
+				t.Errorf("Parse() = %v, want %v", got.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Add_CurrencyMismatch(t *testing.T) {
+	_, err := money.New(100, money.CurrencyKES).Add(money.New(100, "USD"))
+	if err == nil {
+		t.Errorf("Money.Add() expected a currency mismatch error")
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var product struct {
+		Price    money.Money   `json:"price"`
+		Quantity money.Decimal `json:"quantity"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"price": 900.89, "quantity": "1.25"}`), &product); err != nil {
+		t.Errorf("json.Unmarshal() error = %v", err)
+		return
+	}
+	if product.Price.Amount != 90089 || product.Price.Currency != money.DefaultCurrency {
+		t.Errorf("unexpected price %#v", product.Price)
+	}
+
+	encoded, err := json.Marshal(product)
+	if err != nil {
+		t.Errorf("json.Marshal() error = %v", err)
+		return
+	}
+	if string(encoded) != `{"price":"900.89","quantity":"1.25"}` {
+		t.Errorf("json.Marshal() = %s", encoded)
+	}
+}
@@ -0,0 +1,86 @@
+// Package pagination pages through long lists of records with opaque cursors.
+// A cursor holds the value the list is sorted by and the ID of the last record on a page, so the next page
+// starts right after that record even when records are added or removed in the meantime
+package pagination
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+const (
+	// DefaultLimit is the number of records on a page when the client does not ask for a number
+	DefaultLimit = 20
+
+	// MaxLimit is the most records on a page
+	MaxLimit = 100
+)
+
+// Cursor marks the last record on a page. Value is the record's value of the field the list is sorted by
+type Cursor struct {
+	Value string
+	ID    string
+}
+
+// Encode writes a cursor as an opaque string for clients to pass back
+func Encode(cursor Cursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor.Value + "|" + cursor.ID))
+}
+
+// Decode reads a cursor returned with an earlier page. An empty cursor starts from the first page
+func Decode(cursor string) (*Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domain.Errorf(domain.Validation, "invalid cursor")
+	}
+
+	// the value may contain the separator but IDs never do
+	separator := strings.LastIndex(string(decoded), "|")
+	if separator < 0 {
+		return nil, domain.Errorf(domain.Validation, "invalid cursor")
+	}
+
+	return &Cursor{Value: string(decoded[:separator]), ID: string(decoded[separator+1:])}, nil
+}
+
+// Limit works out the number of records on a page from the number a client asked for
+func Limit(first *int) (int, error) {
+	if first == nil || *first == 0 {
+		return DefaultLimit, nil
+	}
+
+	if *first < 0 {
+		return 0, domain.Errorf(domain.Validation, "the number of records asked for cannot be negative")
+	}
+
+	if *first > MaxLimit {
+		return MaxLimit, nil
+	}
+
+	return *first, nil
+}
+
+// Args reads the number of records a client asked for and the cursor of the page before the one it wants
+func Args(first *int, after *string) (int, *Cursor, error) {
+	limit, err := Limit(first)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if after == nil {
+		return limit, nil, nil
+	}
+
+	cursor, err := Decode(*after)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return limit, cursor, nil
+}
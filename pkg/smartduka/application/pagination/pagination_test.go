@@ -0,0 +1,94 @@
+package pagination_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursor  string
+		want    *pagination.Cursor
+		wantErr bool
+	}{
+		{
+			name:   "Happy case: decode an encoded cursor",
+			cursor: pagination.Encode(pagination.Cursor{Value: "Sugar | 2kg", ID: "6ecbbc80-24c8-421a-9f1a-e14e12678ee0"}),
+			want:   &pagination.Cursor{Value: "Sugar | 2kg", ID: "6ecbbc80-24c8-421a-9f1a-e14e12678ee0"},
+		},
+		{
+			name:   "Happy case: an empty cursor starts from the first page",
+			cursor: "",
+			want:   nil,
+		},
+		{
+			name:    "Sad case: not base64",
+			cursor:  "not a cursor!",
+			wantErr: true,
+		},
+		{
+			name:    "Sad case: no ID",
+			cursor:  "c3VnYXI",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pagination.Decode(tt.cursor)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	number := func(n int) *int { return &n }
+
+	tests := []struct {
+		name    string
+		first   *int
+		want    int
+		wantErr bool
+	}{
+		{
+			name:  "Happy case: default limit",
+			first: nil,
+			want:  pagination.DefaultLimit,
+		},
+		{
+			name:  "Happy case: limit asked for",
+			first: number(5),
+			want:  5,
+		},
+		{
+			name:  "Happy case: limit is capped",
+			first: number(1000),
+			want:  pagination.MaxLimit,
+		},
+		{
+			name:    "Sad case: negative limit",
+			first:   number(-1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pagination.Limit(tt.first)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Limit() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Limit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// PaperWidth is the number of characters that fit on a line of a thermal printer using its standard font
+type PaperWidth int
+
+const (
+	// Paper58mm is the width of a 58mm roll
+	Paper58mm PaperWidth = 32
+
+	// Paper80mm is the width of an 80mm roll
+	Paper80mm PaperWidth = 48
+)
+
+// ESC/POS control codes
+const (
+	esc = 0x1b
+	gs  = 0x1d
+)
+
+// ESCPOS produces the command stream that prints a receipt on an ESC/POS thermal printer.
+// The printer draws the QR code itself so that it stays sharp at any resolution
+func ESCPOS(receipt *domain.Receipt, width PaperWidth) []byte {
+	p := &escposPrinter{width: int(width)}
+
+	p.command(esc, '@')
+
+	p.align(1)
+	p.command(gs, '!', 0x11)
+	p.line(receipt.Shop.Name)
+	p.command(gs, '!', 0x00)
+	p.line(receipt.Shop.Address)
+	if receipt.Shop.Phone != "" {
+		p.line("Tel: " + receipt.Shop.Phone)
+	}
+	if receipt.Shop.KRAPIN != "" {
+		p.line("PIN: " + receipt.Shop.KRAPIN)
+	}
+
+	p.align(0)
+	p.separator()
+	p.columns("Receipt", shortNumber(receipt.Number))
+	p.columns("Date", formatTime(receipt.IssuedAt))
+	if receipt.Cashier != "" {
+		p.columns("Cashier", receipt.Cashier)
+	}
+	p.separator()
+
+	for _, line := range receipt.Lines {
+		p.line(line.Description)
+		p.columns("  "+formatQuantity(line), line.UnitPrice.Mul(line.Quantity).String())
+		if !line.Discount.IsZero() {
+			p.columns("  Discount", "-"+line.Discount.String())
+		}
+	}
+	p.separator()
+
+	p.columns("SUBTOTAL", receipt.Subtotal.String())
+	if !receipt.Discount.IsZero() {
+		p.columns("DISCOUNT", "-"+receipt.Discount.String())
+	}
+	p.bold(true)
+	p.columns("TOTAL "+receipt.Total.Currency.String(), receipt.Total.String())
+	p.bold(false)
+	p.columns("Paid by", paymentLabel(receipt.PaymentMethod))
+	p.separator()
+
+	p.vatTable(receipt.VAT)
+	p.separator()
+
+	if invoiceNumber := controlUnitInvoiceNumber(receipt); invoiceNumber != "" {
+		p.columns("CU Invoice No", invoiceNumber)
+		p.separator()
+	}
+
+	p.align(1)
+	if data := verificationData(receipt); data != "" {
+		p.qrCode(data, int(width))
+	}
+	p.line("Thank you for shopping with us")
+
+	p.command(esc, 'd', 4)
+	p.command(gs, 'V', 'B', 0)
+
+	return p.buf.Bytes()
+}
+
+type escposPrinter struct {
+	buf   bytes.Buffer
+	width int
+}
+
+func (p *escposPrinter) command(codes ...byte) {
+	p.buf.Write(codes)
+}
+
+func (p *escposPrinter) align(justification byte) {
+	p.command(esc, 'a', justification)
+}
+
+func (p *escposPrinter) bold(on bool) {
+	if on {
+		p.command(esc, 'E', 1)
+		return
+	}
+	p.command(esc, 'E', 0)
+}
+
+// line prints text, truncating it to the width of the paper
+func (p *escposPrinter) line(text string) {
+	text = printable(text)
+	if len(text) > p.width {
+		text = text[:p.width]
+	}
+	p.buf.WriteString(text)
+	p.buf.WriteByte('\n')
+}
+
+// columns prints a label on the left and a value on the right of the same line
+func (p *escposPrinter) columns(label, value string) {
+	label, value = printable(label), printable(value)
+	space := p.width - len(value) - 1
+	if space < 0 {
+		space = 0
+	}
+	if len(label) > space {
+		label = label[:space]
+	}
+	p.line(label + strings.Repeat(" ", p.width-len(label)-len(value)) + value)
+}
+
+func (p *escposPrinter) separator() {
+	p.line(strings.Repeat("-", p.width))
+}
+
+func (p *escposPrinter) vatTable(lines []*domain.VATLine) {
+	column := p.width / 4
+	row := func(cells ...string) {
+		text := fmt.Sprintf("%-*s", p.width-3*column, cells[0])
+		for _, cell := range cells[1:] {
+			text += fmt.Sprintf("%*s", column, cell)
+		}
+		p.line(text)
+	}
+
+	row("VAT", "NET", "VAT", "GROSS")
+	for _, line := range lines {
+		row(line.Rate.String()+"%", line.Net.String(), line.VAT.String(), line.Gross.String())
+	}
+}
+
+// qrCode stores the data in the printer's QR symbol buffer and prints it
+func (p *escposPrinter) qrCode(data string, width int) {
+	moduleSize := byte(4)
+	if width >= int(Paper80mm) {
+		moduleSize = 6
+	}
+
+	// model 2
+	p.command(gs, '(', 'k', 4, 0, '1', 'A', '2', 0)
+	// size of a module in dots
+	p.command(gs, '(', 'k', 3, 0, '1', 'C', moduleSize)
+	// error correction level M
+	p.command(gs, '(', 'k', 3, 0, '1', 'E', '1')
+
+	length := len(data) + 3
+	p.command(gs, '(', 'k', byte(length%256), byte(length/256), '1', 'P', '0')
+	p.buf.WriteString(data)
+
+	p.command(gs, '(', 'k', 3, 0, '1', 'Q', '0')
+	p.buf.WriteByte('\n')
+}
+
+// printable replaces characters thermal printers cannot print in their default code page
+func printable(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,148 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/boombuler/barcode/qr"
+	"github.com/go-pdf/fpdf"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+const (
+	// pdfWidth matches an 80mm till roll so that the PDF looks like the printed receipt
+	pdfWidth = 80.0
+
+	pdfMargin     = 5.0
+	pdfLineHeight = 4.5
+	pdfQRSize     = 30.0
+)
+
+// PDF produces a receipt as a PDF document the width of a till roll.
+// The document is dated with the time of the sale so that rendering the same receipt always gives the same bytes
+func PDF(receipt *domain.Receipt) ([]byte, error) {
+	rows := 20 + 2*len(receipt.Lines) + len(receipt.VAT)
+	height := 2*pdfMargin + float64(rows)*pdfLineHeight + pdfQRSize + 10
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: pdfWidth, Ht: height},
+	})
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.SetCreationDate(receipt.IssuedAt)
+	pdf.SetModificationDate(receipt.IssuedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle(fmt.Sprintf("Receipt %s", shortNumber(receipt.Number)), true)
+	pdf.AddPage()
+
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	width := pdfWidth - 2*pdfMargin
+	text := func(style string, size float64, align string, value string) {
+		pdf.SetFont("Helvetica", style, size)
+		pdf.CellFormat(width, pdfLineHeight, translate(value), "", 1, align, false, 0, "")
+	}
+	columns := func(style string, label string, value string) {
+		pdf.SetFont("Helvetica", style, 8)
+		pdf.CellFormat(width/2, pdfLineHeight, translate(label), "", 0, "L", false, 0, "")
+		pdf.CellFormat(width/2, pdfLineHeight, translate(value), "", 1, "R", false, 0, "")
+	}
+	separator := func() {
+		y := pdf.GetY() + pdfLineHeight/2
+		pdf.Line(pdfMargin, y, pdfWidth-pdfMargin, y)
+		pdf.Ln(pdfLineHeight)
+	}
+
+	text("B", 12, "C", receipt.Shop.Name)
+	text("", 8, "C", receipt.Shop.Address)
+	if receipt.Shop.Phone != "" {
+		text("", 8, "C", "Tel: "+receipt.Shop.Phone)
+	}
+	if receipt.Shop.KRAPIN != "" {
+		text("", 8, "C", "PIN: "+receipt.Shop.KRAPIN)
+	}
+	separator()
+
+	columns("", "Receipt", shortNumber(receipt.Number))
+	columns("", "Date", formatTime(receipt.IssuedAt))
+	if receipt.Cashier != "" {
+		columns("", "Cashier", receipt.Cashier)
+	}
+	separator()
+
+	for _, line := range receipt.Lines {
+		text("", 8, "L", line.Description)
+		columns("", "  "+formatQuantity(line), line.UnitPrice.Mul(line.Quantity).String())
+		if !line.Discount.IsZero() {
+			columns("", "  Discount", "-"+line.Discount.String())
+		}
+	}
+	separator()
+
+	columns("", "Subtotal", receipt.Subtotal.String())
+	if !receipt.Discount.IsZero() {
+		columns("", "Discount", "-"+receipt.Discount.String())
+	}
+	columns("B", "TOTAL "+receipt.Total.Currency.String(), receipt.Total.String())
+	columns("", "Paid by", paymentLabel(receipt.PaymentMethod))
+	separator()
+
+	pdf.SetFont("Helvetica", "B", 7)
+	for i, heading := range []string{"VAT", "Net", "VAT", "Gross"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(width/4, pdfLineHeight, heading, "", 0, align, false, 0, "")
+	}
+	pdf.Ln(pdfLineHeight)
+	pdf.SetFont("Helvetica", "", 7)
+	for _, line := range receipt.VAT {
+		pdf.CellFormat(width/4, pdfLineHeight, line.Rate.String()+"%", "", 0, "L", false, 0, "")
+		pdf.CellFormat(width/4, pdfLineHeight, line.Net.String(), "", 0, "R", false, 0, "")
+		pdf.CellFormat(width/4, pdfLineHeight, line.VAT.String(), "", 0, "R", false, 0, "")
+		pdf.CellFormat(width/4, pdfLineHeight, line.Gross.String(), "", 1, "R", false, 0, "")
+	}
+	separator()
+
+	if invoiceNumber := controlUnitInvoiceNumber(receipt); invoiceNumber != "" {
+		columns("", "CU Invoice No", invoiceNumber)
+		separator()
+	}
+
+	if data := verificationData(receipt); data != "" {
+		if err := drawQRCode(pdf, data, (pdfWidth-pdfQRSize)/2, pdf.GetY()); err != nil {
+			return nil, err
+		}
+		pdf.Ln(pdfQRSize + 2)
+	}
+	text("", 8, "C", "Thank you for shopping with us")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render receipt PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawQRCode draws each dark module of a QR code as a filled square
+func drawQRCode(pdf *fpdf.Fpdf, data string, x, y float64) error {
+	code, err := qr.Encode(data, qr.M, qr.Auto)
+	if err != nil {
+		return fmt.Errorf("failed to encode receipt QR code: %v", err)
+	}
+
+	modules := code.Bounds().Dx()
+	size := pdfQRSize / float64(modules)
+
+	pdf.SetFillColor(0, 0, 0)
+	for row := 0; row < modules; row++ {
+		for column := 0; column < modules; column++ {
+			if r, _, _, _ := code.At(column, row).RGBA(); r == 0 {
+				pdf.Rect(x+float64(column)*size, y+float64(row)*size, size, size, "F")
+			}
+		}
+	}
+	return nil
+}
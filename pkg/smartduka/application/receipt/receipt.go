@@ -0,0 +1,136 @@
+package receipt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// dateLayout is how the time of a sale is printed on a receipt
+const dateLayout = "02/01/2006 15:04"
+
+// Build works out the line totals, receipt totals and VAT breakdown of a receipt from its lines
+func Build(receipt *domain.Receipt) (*domain.Receipt, error) {
+	if len(receipt.Lines) == 0 {
+		return nil, fmt.Errorf("a receipt must have at least one line")
+	}
+
+	currency := receipt.Lines[0].UnitPrice.Currency
+	subtotal, discount, total := money.Zero(currency), money.Zero(currency), money.Zero(currency)
+	rates := map[money.Decimal]*domain.VATLine{}
+
+	for _, line := range receipt.Lines {
+		gross := line.UnitPrice.Mul(line.Quantity)
+		var err error
+		if line.Total, err = gross.Sub(line.Discount); err != nil {
+			return nil, err
+		}
+		if subtotal, err = subtotal.Add(gross); err != nil {
+			return nil, err
+		}
+		if discount, err = discount.Add(line.Discount); err != nil {
+			return nil, err
+		}
+		if total, err = total.Add(line.Total); err != nil {
+			return nil, err
+		}
+
+		vatLine, ok := rates[line.VATRate]
+		if !ok {
+			vatLine = &domain.VATLine{Rate: line.VATRate, Gross: money.Zero(currency)}
+			rates[line.VATRate] = vatLine
+		}
+		if vatLine.Gross, err = vatLine.Gross.Add(line.Total); err != nil {
+			return nil, err
+		}
+	}
+
+	// VAT is worked out on the total at each rate so that the breakdown adds up to the amount paid
+	receipt.VAT = []*domain.VATLine{}
+	receipt.VATTotal = money.Zero(currency)
+	for _, vatLine := range rates {
+		vatLine.Net, vatLine.VAT = vatLine.Gross.VATInclusive(vatLine.Rate)
+		receipt.VAT = append(receipt.VAT, vatLine)
+
+		var err error
+		if receipt.VATTotal, err = receipt.VATTotal.Add(vatLine.VAT); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(receipt.VAT, func(i, j int) bool {
+		return receipt.VAT[i].Rate.Cmp(receipt.VAT[j].Rate) > 0
+	})
+
+	receipt.Subtotal = subtotal
+	receipt.Discount = discount
+	receipt.Total = total
+
+	return receipt, nil
+}
+
+// Render produces a receipt in the requested format. It returns the content type of the output
+func Render(receipt *domain.Receipt, format enums.ReceiptFormat) ([]byte, string, error) {
+	switch format {
+	case enums.ReceiptFormatESCPOS58:
+		return ESCPOS(receipt, Paper58mm), "application/octet-stream", nil
+	case enums.ReceiptFormatESCPOS80:
+		return ESCPOS(receipt, Paper80mm), "application/octet-stream", nil
+	case enums.ReceiptFormatPDF:
+		document, err := PDF(receipt)
+		return document, "application/pdf", err
+	case enums.ReceiptFormatText:
+		return []byte(Text(receipt)), "text/plain; charset=utf-8", nil
+	}
+	return nil, "", fmt.Errorf("invalid receipt format: %s", format)
+}
+
+// shortNumber is the part of the receipt number printed for customers to quote
+func shortNumber(number string) string {
+	number = strings.ToUpper(strings.ReplaceAll(number, "-", ""))
+	if len(number) > 12 {
+		return number[:12]
+	}
+	return number
+}
+
+// formatTime prints the time of a sale in its own timezone
+func formatTime(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+// formatQuantity prints a line's quantity and price e.g `2 x 760.00`
+func formatQuantity(line *domain.ReceiptLine) string {
+	return fmt.Sprintf("%s x %s", line.Quantity, line.UnitPrice)
+}
+
+// paymentLabel prints a payment method the way customers know it
+func paymentLabel(method enums.PaymentMethod) string {
+	switch method {
+	case enums.PaymentMethodMpesa:
+		return "M-PESA"
+	case enums.PaymentMethodBankTransfer:
+		return "BANK TRANSFER"
+	}
+	return method.String()
+}
+
+// controlUnitInvoiceNumber is the eTIMS invoice number printed once KRA has accepted the sale
+func controlUnitInvoiceNumber(receipt *domain.Receipt) string {
+	if receipt.TaxInvoice == nil || receipt.TaxInvoice.Status != enums.TaxInvoiceStatusSubmitted {
+		return ""
+	}
+	return receipt.TaxInvoice.InvoiceNumber
+}
+
+// verificationData is encoded in a receipt's QR code. KRA's link is preferred once eTIMS has accepted the sale
+func verificationData(receipt *domain.Receipt) string {
+	if controlUnitInvoiceNumber(receipt) != "" && receipt.TaxInvoice.QRData != "" {
+		return receipt.TaxInvoice.QRData
+	}
+	return receipt.VerificationURL
+}
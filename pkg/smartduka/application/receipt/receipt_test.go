@@ -0,0 +1,135 @@
+package receipt_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/receipt"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func sampleReceipt(t *testing.T) *domain.Receipt {
+	nairobi := time.FixedZone("EAT", 3*60*60)
+	built, err := receipt.Build(&domain.Receipt{
+		Number:   "6ecbbc80-24c8-421a-9f1a-e14e12678ee0",
+		IssuedAt: time.Date(2023, 7, 14, 18, 45, 0, 0, nairobi),
+		Shop: domain.ShopDetails{
+			Name:    "Smartduka Chemist",
+			Address: "Moi Avenue, Nairobi",
+			Phone:   "+254722000000",
+			KRAPIN:  "P051234567X",
+		},
+		Cashier: "Jane Wanjiru",
+		Lines: []*domain.ReceiptLine{
+			{
+				Description: "Panadol Extra 24 tablets",
+				Quantity:    money.DecimalFromInt(2),
+				Unit:        "PIECE",
+				UnitPrice:   money.MustParse("760.00", money.CurrencyKES),
+				Discount:    money.MustParse("20.00", money.CurrencyKES),
+				VATRate:     money.DecimalFromInt(16),
+			},
+			{
+				Description: "Maize flour 2kg",
+				Quantity:    money.MustParseDecimal("1.5"),
+				Unit:        "PACKET",
+				UnitPrice:   money.MustParse("210.00", money.CurrencyKES),
+				Discount:    money.Zero(money.CurrencyKES),
+				VATRate:     money.DecimalFromInt(0),
+			},
+		},
+		PaymentMethod:   enums.PaymentMethodMpesa,
+		VerificationURL: "https://receipts.smartduka.co.ke/6ecbbc80-24c8-421a-9f1a-e14e12678ee0",
+		TaxInvoice: &domain.TaxInvoice{
+			Status:        enums.TaxInvoiceStatusSubmitted,
+			InvoiceNumber: "KRACU0100000001/1",
+			QRData:        "https://etims.kra.go.ke/common/link/etims/receipt/indexEtimsReceiptData?Data=P051234567X00SIGN000000000001",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	return built
+}
+
+func TestBuild(t *testing.T) {
+	got := sampleReceipt(t)
+
+	tests := []struct {
+		name string
+		got  money.Money
+		want string
+	}{
+		{name: "first line total", got: got.Lines[0].Total, want: "1500.00"},
+		{name: "second line total", got: got.Lines[1].Total, want: "315.00"},
+		{name: "subtotal", got: got.Subtotal, want: "1835.00"},
+		{name: "discount", got: got.Discount, want: "20.00"},
+		{name: "total", got: got.Total, want: "1815.00"},
+		{name: "VAT at 16%", got: got.VAT[0].VAT, want: "206.90"},
+		{name: "net at 16%", got: got.VAT[0].Net, want: "1293.10"},
+		{name: "VAT at 0%", got: got.VAT[1].VAT, want: "0.00"},
+		{name: "VAT total", got: got.VATTotal, want: "206.90"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.String() != tt.want {
+				t.Errorf("Build() %s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+
+	if _, err := receipt.Build(&domain.Receipt{}); err == nil {
+		t.Errorf("Build() expected an error for a receipt without lines")
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		format      enums.ReceiptFormat
+		golden      string
+		contentType string
+	}{
+		{format: enums.ReceiptFormatESCPOS58, golden: "receipt_58mm.golden", contentType: "application/octet-stream"},
+		{format: enums.ReceiptFormatESCPOS80, golden: "receipt_80mm.golden", contentType: "application/octet-stream"},
+		{format: enums.ReceiptFormatText, golden: "receipt_text.golden", contentType: "text/plain; charset=utf-8"},
+		{format: enums.ReceiptFormatPDF, golden: "receipt_pdf.golden", contentType: "application/pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			got, contentType, err := receipt.Render(sampleReceipt(t), tt.format)
+			if err != nil {
+				t.Errorf("Render() error = %v", err)
+				return
+			}
+			if contentType != tt.contentType {
+				t.Errorf("Render() content type = %v, want %v", contentType, tt.contentType)
+			}
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Render() output does not match %s, run the tests with -update if the change is intended", golden)
+			}
+		})
+	}
+
+	if _, _, err := receipt.Render(sampleReceipt(t), enums.ReceiptFormat("INVALID")); err == nil {
+		t.Errorf("Render() expected an error for an invalid format")
+	}
+}
@@ -0,0 +1,33 @@
+package receipt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// Text produces a compact receipt short enough to send by SMS or WhatsApp.
+// The verification link takes the place of the QR code
+func Text(receipt *domain.Receipt) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n", receipt.Shop.Name)
+	fmt.Fprintf(&b, "Receipt %s %s\n", shortNumber(receipt.Number), formatTime(receipt.IssuedAt))
+	for _, line := range receipt.Lines {
+		fmt.Fprintf(&b, "%s %s = %s\n", line.Description, formatQuantity(line), line.UnitPrice.Mul(line.Quantity))
+		if !line.Discount.IsZero() {
+			fmt.Fprintf(&b, "Discount -%s\n", line.Discount)
+		}
+	}
+	fmt.Fprintf(&b, "Total %s (incl. VAT %s)\n", receipt.Total.Display(), receipt.VATTotal)
+	fmt.Fprintf(&b, "Paid by %s\n", paymentLabel(receipt.PaymentMethod))
+	if invoiceNumber := controlUnitInvoiceNumber(receipt); invoiceNumber != "" {
+		fmt.Fprintf(&b, "CU Invoice No %s\n", invoiceNumber)
+	}
+	if data := verificationData(receipt); data != "" {
+		fmt.Fprintf(&b, "Verify: %s\n", data)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,247 @@
+// Package search matches products against what a cashier types at the till.
+// Every word typed matches the start of a word in a product's name, manufacturer or description, or of one of
+// its synonyms, so that results show up while the cashier is still typing. Names that are spelt close enough to
+// the term match too, so that typos such as "panadl" still find Panadol.
+// Postgres does the matching in the database; the Go functions here do the same for the SQLite and in-memory datastores
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Threshold is the least word similarity a product name must have to a term to match it despite typos.
+// It is the default of Postgres' pg_trgm.word_similarity_threshold, used by the <% operator
+const Threshold = 0.6
+
+// Weights of a term found in a product's name, manufacturer and description, the defaults of Postgres' ts_rank
+const (
+	nameWeight         = 1.0
+	manufacturerWeight = 0.4
+	descriptionWeight  = 0.2
+)
+
+// synonyms are groups of words shoppers use for the same thing, in English and Swahili or as brand and generic names
+var synonyms = [][]string{
+	{"sugar", "sukari"},
+	{"salt", "chumvi"},
+	{"rice", "mchele"},
+	{"flour", "unga"},
+	{"maize", "mahindi"},
+	{"beans", "maharagwe"},
+	{"milk", "maziwa"},
+	{"bread", "mkate"},
+	{"egg", "eggs", "yai", "mayai"},
+	{"oil", "mafuta"},
+	{"soap", "sabuni"},
+	{"tea", "chai"},
+	{"water", "maji"},
+	{"matches", "kiberiti"},
+	{"candle", "mshumaa"},
+	{"paracetamol", "panadol"},
+	{"medicine", "dawa"},
+}
+
+// synonymsOf maps every word in synonyms to the others in its group
+var synonymsOf = func() map[string][]string {
+	words := map[string][]string{}
+	for _, group := range synonyms {
+		for _, word := range group {
+			for _, other := range group {
+				if other != word {
+					words[word] = append(words[word], other)
+				}
+			}
+		}
+	}
+	return words
+}()
+
+// Query is a search term split into words. Each word is listed with its synonyms
+type Query struct {
+	Term  string
+	Words [][]string
+}
+
+// Parse splits a search term into lower case words, ignoring punctuation
+func Parse(term string) Query {
+	query := Query{Term: strings.TrimSpace(term)}
+	for _, word := range words(term) {
+		query.Words = append(query.Words, append([]string{word}, synonymsOf[word]...))
+	}
+	return query
+}
+
+// IsEmpty is true when the term has no words to search for
+func (q Query) IsEmpty() bool {
+	return len(q.Words) == 0
+}
+
+// TSQuery writes the query as a Postgres tsquery for the simple configuration. Every word of the term, or one of
+// its synonyms, must be the start of a word in the product
+func (q Query) TSQuery() string {
+	clauses := []string{}
+	for _, alternatives := range q.Words {
+		prefixes := []string{}
+		for _, word := range alternatives {
+			prefixes = append(prefixes, word+":*")
+		}
+		clauses = append(clauses, "("+strings.Join(prefixes, " | ")+")")
+	}
+	return strings.Join(clauses, " & ")
+}
+
+// Fragments lists the runs of three letters in the words of the term, or the whole word when it is shorter.
+// A name spelt close enough to the term to match it despite typos contains at least one of them
+func (q Query) Fragments() []string {
+	fragments := []string{}
+	for _, word := range words(q.Term) {
+		letters := []rune(word)
+		if len(letters) < 3 {
+			fragments = append(fragments, word)
+			continue
+		}
+		for i := 0; i+3 <= len(letters); i++ {
+			fragments = append(fragments, string(letters[i:i+3]))
+		}
+	}
+	return fragments
+}
+
+// Score ranks how well a product matches the query. Products matching every word of the term rank above those
+// that only have a name spelt like it. It returns false when the product does not match
+func (q Query) Score(name, manufacturer, description string) (float64, bool) {
+	if q.IsEmpty() {
+		return 0, false
+	}
+
+	fields := []struct {
+		words  []string
+		weight float64
+	}{
+		{words(name), nameWeight},
+		{words(manufacturer), manufacturerWeight},
+		{words(description), descriptionWeight},
+	}
+
+	rank, matched := 0.0, true
+	for _, alternatives := range q.Words {
+		best := 0.0
+		for _, field := range fields {
+			if field.weight > best && hasPrefix(field.words, alternatives) {
+				best = field.weight
+			}
+		}
+		if best == 0 {
+			matched = false
+			break
+		}
+		rank += best / float64(len(q.Words))
+	}
+	if !matched {
+		rank = 0
+	}
+
+	similarity := WordSimilarity(q.Term, name)
+	if !matched && similarity < Threshold {
+		return 0, false
+	}
+
+	return rank + similarity, true
+}
+
+// WordSimilarity measures how close the term is to the closest run of words in text, from 0 to 1, the way
+// Postgres' pg_trgm word_similarity does: the share of trigrams the term and the run have in common
+func WordSimilarity(term, text string) float64 {
+	termTrigrams := map[string]bool{}
+	for _, trigram := range trigrams(term) {
+		termTrigrams[trigram] = true
+	}
+	if len(termTrigrams) == 0 {
+		return 0
+	}
+
+	textTrigrams := trigrams(text)
+	best := 0.0
+	for start := range textTrigrams {
+		extent := map[string]bool{}
+		common := 0
+		for _, trigram := range textTrigrams[start:] {
+			if !extent[trigram] {
+				extent[trigram] = true
+				if termTrigrams[trigram] {
+					common++
+				}
+			}
+
+			similarity := float64(common) / float64(len(termTrigrams)+len(extent)-common)
+			if similarity > best {
+				best = similarity
+			}
+		}
+	}
+
+	return best
+}
+
+// Rank sorts the records matching the query from the best match to the worst and keeps the first limit of them
+func Rank[T any](records []T, query Query, limit int, fields func(T) (name, manufacturer, description string)) []T {
+	type scored struct {
+		record T
+		name   string
+		score  float64
+	}
+
+	matches := []scored{}
+	for _, record := range records {
+		name, manufacturer, description := fields(record)
+		if score, ok := query.Score(name, manufacturer, description); ok {
+			matches = append(matches, scored{record: record, name: name, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	ranked := []T{}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		ranked = append(ranked, matches[i].record)
+	}
+	return ranked
+}
+
+// words splits text into lower case words of letters and digits
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// trigrams lists the trigrams of every word in text in order, each word padded with two spaces in front and one behind
+func trigrams(text string) []string {
+	list := []string{}
+	for _, word := range words(text) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			list = append(list, string(padded[i:i+3]))
+		}
+	}
+	return list
+}
+
+// hasPrefix is true when one of the alternatives is the start of one of the words
+func hasPrefix(words []string, alternatives []string) bool {
+	for _, word := range words {
+		for _, alternative := range alternatives {
+			if strings.HasPrefix(word, alternative) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package search_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/search"
+)
+
+func TestQuery_TSQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		term string
+		want string
+	}{
+		{
+			name: "Happy case: every word is a prefix",
+			term: "Pana 500",
+			want: "(pana:*) & (500:*)",
+		},
+		{
+			name: "Happy case: synonyms are alternatives",
+			term: "sukari",
+			want: "(sukari:* | sugar:*)",
+		},
+		{
+			name: "Happy case: punctuation is ignored",
+			term: "coca-cola!",
+			want: "(coca:*) & (cola:*)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := search.Parse(tt.term).TSQuery(); got != tt.want {
+				t.Errorf("Query.TSQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery_Fragments(t *testing.T) {
+	tests := []struct {
+		name string
+		term string
+		want []string
+	}{
+		{
+			name: "Happy case: runs of three letters",
+			term: "Panadl",
+			want: []string{"pan", "ana", "nad", "adl"},
+		},
+		{
+			name: "Happy case: every word of the term",
+			term: "Omo 1kg",
+			want: []string{"omo", "1kg"},
+		},
+		{
+			name: "Happy case: words shorter than three letters",
+			term: "tv",
+			want: []string{"tv"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := search.Parse(tt.term).Fragments(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query.Fragments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	type product struct {
+		name         string
+		manufacturer string
+		description  string
+	}
+	products := []product{
+		{name: "Mumias Sugar 2kg"},
+		{name: "Panadol Extra", manufacturer: "GSK"},
+		{name: "Kabras Sugar 1kg"},
+		{name: "Cough syrup", description: "Soothes a sore throat, contains paracetamol"},
+		{name: "Royco Cubes"},
+	}
+	fields := func(p product) (string, string, string) {
+		return p.name, p.manufacturer, p.description
+	}
+
+	tests := []struct {
+		name string
+		term string
+		want []string
+	}{
+		{
+			name: "Happy case: prefix of a word in the name",
+			term: "sug",
+			want: []string{"Kabras Sugar 1kg", "Mumias Sugar 2kg"},
+		},
+		{
+			name: "Happy case: Swahili synonym",
+			term: "sukari 2",
+			want: []string{"Mumias Sugar 2kg"},
+		},
+		{
+			name: "Happy case: typo",
+			term: "panadl",
+			want: []string{"Panadol Extra"},
+		},
+		{
+			name: "Happy case: matches in the name rank above matches in the description",
+			term: "paracetamol",
+			want: []string{"Panadol Extra", "Cough syrup"},
+		},
+		{
+			name: "Happy case: manufacturer",
+			term: "gsk",
+			want: []string{"Panadol Extra"},
+		},
+		{
+			name: "Sad case: nothing to search for",
+			term: " - ",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, p := range search.Rank(products, search.Parse(tt.term), 10, fields) {
+				got = append(got, p.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rank() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		term string
+		text string
+		want float64
+	}{
+		{
+			name: "Happy case: the closest word counts",
+			term: "word",
+			text: "two words",
+			want: 0.8,
+		},
+		{
+			name: "Happy case: same word",
+			term: "Sugar",
+			text: "sugar",
+			want: 1,
+		},
+		{
+			name: "Sad case: nothing in common",
+			term: "salt",
+			text: "bread",
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := search.WordSimilarity(tt.term, tt.text); got != tt.want {
+				t.Errorf("WordSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
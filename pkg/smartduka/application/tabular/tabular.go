@@ -0,0 +1,191 @@
+package tabular
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/xuri/excelize/v2"
+)
+
+// maxUnzippedSize is the most a workbook may take up once it is unzipped, so that a small upload
+// cannot expand into more than the server can hold
+const maxUnzippedSize = 100 << 20
+
+// Reader reads the rows of a CSV file or the first sheet of an XLSX workbook.
+// Read returns io.EOF once all the rows have been read.
+// Line is the number of the row last read as it would be shown in a spreadsheet
+type Reader interface {
+	Read() ([]string, error)
+	Line() int
+}
+
+// Writer writes rows to a CSV file or a sheet of an XLSX workbook.
+// Close must be called once all the rows have been written
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// FormatFromFilename works out the format of a file from its extension
+func FormatFromFilename(filename string) (enums.FileFormat, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return enums.FileFormatCSV, nil
+	case ".xlsx":
+		return enums.FileFormatXLSX, nil
+	}
+	return "", domain.Errorf(domain.Validation, "unsupported file type %q, expected a .csv or .xlsx file", filepath.Ext(filename))
+}
+
+// ContentType returns the MIME type of a file format
+func ContentType(format enums.FileFormat) string {
+	if format == enums.FileFormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv"
+}
+
+// Filename returns the name of an export file with the extension of its format
+func Filename(name string, format enums.FileFormat) string {
+	return fmt.Sprintf("%s.%s", name, strings.ToLower(format.String()))
+}
+
+// NewReader creates a reader for a file in the given format
+func NewReader(format enums.FileFormat, r io.Reader) (Reader, error) {
+	switch format {
+	case enums.FileFormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return &csvReader{reader: reader}, nil
+
+	case enums.FileFormatXLSX:
+		workbook, err := excelize.OpenReader(r, excelize.Options{UnzipSizeLimit: maxUnzippedSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open workbook: %v", err)
+		}
+
+		sheets := workbook.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("workbook has no sheets")
+		}
+
+		rows, err := workbook.Rows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %s: %v", sheets[0], err)
+		}
+		return &xlsxReader{workbook: workbook, rows: rows}, nil
+	}
+	return nil, fmt.Errorf("unsupported file format: %s", format)
+}
+
+// NewWriter creates a writer for a file in the given format.
+// XLSX rows are streamed to a temporary file once they no longer fit in memory and are copied to w on Close
+func NewWriter(format enums.FileFormat, w io.Writer, sheet string) (Writer, error) {
+	switch format {
+	case enums.FileFormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+
+	case enums.FileFormatXLSX:
+		workbook := excelize.NewFile()
+		if err := workbook.SetSheetName(workbook.GetSheetName(0), sheet); err != nil {
+			return nil, fmt.Errorf("failed to name sheet %s: %v", sheet, err)
+		}
+
+		stream, err := workbook.NewStreamWriter(sheet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sheet writer: %v", err)
+		}
+		return &xlsxWriter{workbook: workbook, stream: stream, out: w}, nil
+	}
+	return nil, fmt.Errorf("unsupported file format: %s", format)
+}
+
+type csvReader struct {
+	reader *csv.Reader
+}
+
+func (c *csvReader) Read() ([]string, error) {
+	return c.reader.Read()
+}
+
+// Line uses the position of the row's first field because blank lines are skipped by the reader
+func (c *csvReader) Line() int {
+	line, _ := c.reader.FieldPos(0)
+	return line
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) Write(row []string) error {
+	return c.writer.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type xlsxReader struct {
+	workbook *excelize.File
+	rows     *excelize.Rows
+	line     int
+}
+
+// Line counts the rows read because empty rows in the sheet are returned with no cells
+func (x *xlsxReader) Line() int {
+	return x.line
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	if !x.rows.Next() {
+		if err := x.rows.Error(); err != nil {
+			return nil, err
+		}
+		_ = x.rows.Close()
+		_ = x.workbook.Close()
+		return nil, io.EOF
+	}
+	x.line++
+	return x.rows.Columns()
+}
+
+type xlsxWriter struct {
+	workbook *excelize.File
+	stream   *excelize.StreamWriter
+	out      io.Writer
+	rows     int
+}
+
+func (x *xlsxWriter) Write(row []string) error {
+	values := make([]interface{}, len(row))
+	for i, value := range row {
+		values[i] = value
+	}
+
+	x.rows++
+	cell, err := excelize.CoordinatesToCellName(1, x.rows)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, values)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.workbook.Close()
+
+	if err := x.stream.Flush(); err != nil {
+		return fmt.Errorf("failed to write sheet: %v", err)
+	}
+	if _, err := x.workbook.WriteTo(x.out); err != nil {
+		return fmt.Errorf("failed to write workbook: %v", err)
+	}
+	return nil
+}
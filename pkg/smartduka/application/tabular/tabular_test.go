@@ -0,0 +1,100 @@
+package tabular_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/tabular"
+)
+
+func TestRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"name", "category", "price"},
+		{"Panadol, 24 tablets", "MEDICINE", "760.50"},
+		{"Unga", "FOOD_STUFF", "210.00"},
+	}
+
+	for _, format := range []enums.FileFormat{enums.FileFormatCSV, enums.FileFormatXLSX} {
+		t.Run(format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := tabular.NewWriter(format, &buf, "Products")
+			if err != nil {
+				t.Errorf("NewWriter() error = %v", err)
+				return
+			}
+			for _, row := range rows {
+				if err := writer.Write(row); err != nil {
+					t.Errorf("Writer.Write() error = %v", err)
+					return
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Errorf("Writer.Close() error = %v", err)
+				return
+			}
+
+			reader, err := tabular.NewReader(format, &buf)
+			if err != nil {
+				t.Errorf("NewReader() error = %v", err)
+				return
+			}
+			got := [][]string{}
+			for {
+				row, err := reader.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Errorf("Reader.Read() error = %v", err)
+					return
+				}
+				got = append(got, row)
+			}
+
+			if !reflect.DeepEqual(got, rows) {
+				t.Errorf("read %v, want %v", got, rows)
+			}
+		})
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     enums.FileFormat
+		wantErr  bool
+	}{
+		{
+			name:     "Happy case: csv",
+			filename: "products.csv",
+			want:     enums.FileFormatCSV,
+		},
+		{
+			name:     "Happy case: upper case xlsx",
+			filename: "PRODUCTS.XLSX",
+			want:     enums.FileFormatXLSX,
+		},
+		{
+			name:     "Sad case: legacy excel file",
+			filename: "products.xls",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tabular.FormatFromFilename(tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FormatFromFilename() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FormatFromFilename() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,7 +12,9 @@ import (
 	"time"
 
 	"github.com/labstack/gommon/log"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 	"github.com/pkg/errors"
 	"github.com/pquerna/otp/totp"
 )
@@ -47,13 +50,16 @@ func WriteJSONResponse(w http.ResponseWriter, source interface{}, status int) {
 	}
 }
 
-// DecodeJSONToTargetStruct maps JSON from a HTTP request to a struct.
-func DecodeJSONToTargetStruct(w http.ResponseWriter, r *http.Request, targetStruct interface{}) {
+// DecodeJSONToTargetStruct maps JSON from a HTTP request to a struct. A body that cannot be read is reported
+// to the client and returned so that the handler stops
+func DecodeJSONToTargetStruct(w http.ResponseWriter, r *http.Request, targetStruct interface{}) error {
 	err := json.NewDecoder(r.Body).Decode(targetStruct)
 	if err != nil {
-		WriteJSONResponse(w, ErrorMap(err), http.StatusBadRequest)
-		return
+		err = domain.WrapError(domain.Validation, err, "the request body is not valid JSON")
+		ReportErr(r.Context(), w, err)
+		return err
 	}
+	return nil
 }
 
 // BoolEnv gets and parses a boolean environment variable
@@ -97,12 +103,32 @@ func RequestDebugMiddleware() func(http.Handler) http.Handler {
 	}
 }
 
-// ReportErr writes the indicated error to supplied response writer and also logs it
-func ReportErr(w http.ResponseWriter, err error, status int) {
-	if IsDebug() {
+// ReportErr writes an error to the supplied response writer with the status its code maps to, e.g a 404 when
+// a record is not found. Users are only shown messages safe for them to see, in their language,
+// while the details of unexpected errors are logged
+func ReportErr(ctx context.Context, w http.ResponseWriter, err error) {
+	code := domain.ErrorCodeOf(err)
+	if code == domain.Internal || IsDebug() {
 		log.Printf("%s", err)
 	}
-	WriteJSONResponse(w, ErrorMap(err), status)
+
+	language := GetLanguage(ctx)
+	body := map[string]interface{}{
+		"error": domain.UserMessage(err, language),
+		"code":  string(code),
+	}
+	if fields := domain.UserFields(err, language); len(fields) > 0 {
+		body["fields"] = fields
+	}
+	WriteJSONResponse(w, body, code.HTTPStatus())
+}
+
+// GetLanguage returns the language the user reads. It is English unless the request asked for another
+func GetLanguage(ctx context.Context) string {
+	if language, ok := ctx.Value(common.LanguageContextKey).(string); ok && language != "" {
+		return language
+	}
+	return domain.English
 }
 
 // ErrorMap turns the supplied error into a map with "error" as the key
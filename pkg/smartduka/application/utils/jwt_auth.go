@@ -8,6 +8,7 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 )
 
 // Create the JWT key used to create the signature
@@ -105,23 +106,32 @@ func ValidateJWTToken(tokenString string) (*TokenResponse, error) {
 
 }
 
-// GetLoggedInUser retrieves the logged in user from the context
-func GetLoggedInUser(ctx context.Context) (string, error) {
-	UID := ctx.Value(common.AuthTokenContextKey).(string)
-
-	tkn, err := jwt.ParseWithClaims(UID, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+// ParseJWTToken returns the claims of a valid JWT token
+func ParseJWTToken(tokenString string) (*Claims, error) {
+	tkn, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return jwtKey, nil
 	})
 	if err != nil {
-		return "", err
+		return nil, domain.WrapError(domain.Unauthenticated, err, "the request is not authenticated")
 	}
 
 	claims, ok := tkn.Claims.(*Claims)
+	if !ok || !tkn.Valid {
+		return nil, domain.Errorf(domain.Unauthenticated, "the request is not authenticated")
+	}
+
+	return claims, nil
+}
+
+// GetLoggedInUser retrieves the logged in user from the context
+func GetLoggedInUser(ctx context.Context) (string, error) {
+	UID, ok := ctx.Value(common.AuthTokenContextKey).(string)
 	if !ok {
-		return "", err
+		return "", domain.Errorf(domain.Unauthenticated, "the request is not authenticated")
 	}
 
-	if !tkn.Valid {
+	claims, err := ParseJWTToken(UID)
+	if err != nil {
 		return "", err
 	}
 
@@ -5,11 +5,11 @@ import (
 	"crypto/rand"
 	"crypto/sha512"
 	"encoding/hex"
-	"fmt"
 	"hash"
 	"math/big"
 	"strconv"
 
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 	"github.com/xdg-go/pbkdf2"
 )
 
@@ -117,7 +117,7 @@ func ValidatePIN(pin string) error {
 func ValidatePINLength(pin string) error {
 	// make sure pin length is [4]
 	if len(pin) < minPINLength || len(pin) > maxPINLength {
-		return fmt.Errorf("PIN should be of 4 digits")
+		return domain.Errorf(domain.Validation, "PIN should be of 4 digits")
 	}
 	return nil
 }
@@ -127,7 +127,7 @@ func ValidatePINDigits(pin string) error {
 	// ensure pin is only digits
 	_, err := strconv.ParseUint(pin, 10, 64)
 	if err != nil {
-		return fmt.Errorf("PIN should only contain digits")
+		return domain.Errorf(domain.Validation, "PIN should only contain digits")
 	}
 	return nil
 }
@@ -0,0 +1,124 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// InvalidInputMessage is shown to users whose input breaks a rule. The fields of the error say which rules
+const InvalidInputMessage = "the input is invalid"
+
+// usernamePattern allows the usernames that fit the 20 characters of the username column
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.]{3,20}$`)
+
+// enum is implemented by the enums an input can hold
+type enum interface {
+	IsValid() bool
+}
+
+var validate = newValidator()
+
+// newValidator creates a validator that names fields the way clients send them and knows the rules
+// specific to smartduka:
+//   - `msisdn` checks a phone number
+//   - `enum` checks the value of an enum
+//   - `username` checks the format of a username
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	rules := map[string]validator.Func{
+		"msisdn": func(fl validator.FieldLevel) bool {
+			return helpers.IsMSISDNValid(fl.Field().String())
+		},
+		"enum": func(fl validator.FieldLevel) bool {
+			value, ok := fl.Field().Interface().(enum)
+			return ok && value.IsValid()
+		},
+		"username": func(fl validator.FieldLevel) bool {
+			return usernamePattern.MatchString(fl.Field().String())
+		},
+	}
+	for tag, rule := range rules {
+		if err := v.RegisterValidation(tag, rule); err != nil {
+			panic(fmt.Sprintf("failed to register the %s validation: %v", tag, err))
+		}
+	}
+
+	return v
+}
+
+// Validate checks an input against the rules in its `validate` tags. An input that breaks them
+// gets a validation error listing what is wrong with each field
+func Validate(input interface{}) error {
+	err := validate.Struct(input)
+	if err == nil {
+		return nil
+	}
+
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return domain.WrapError(domain.Internal, err, domain.InternalErrorMessage)
+	}
+
+	fields := make([]domain.FieldError, 0, len(invalid))
+	for _, field := range invalid {
+		fields = append(fields, domain.FieldError{
+			Field:   fieldName(field),
+			Message: message(field),
+		})
+	}
+
+	return &domain.Error{
+		Code:    domain.Validation,
+		Message: InvalidInputMessage,
+		Fields:  fields,
+	}
+}
+
+// fieldName is the path to a field from the input e.g `operations[0].sale.product_id`
+func fieldName(field validator.FieldError) string {
+	namespace := field.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// message tells a user which rule a field broke
+func message(field validator.FieldError) string {
+	switch field.Tag() {
+	case "required", "required_with":
+		return "is required"
+	case "msisdn":
+		return "is not a valid phone number"
+	case "enum":
+		return "is not a valid option"
+	case "username":
+		return "must be 3 to 20 letters, digits, dots or underscores"
+	case "numeric":
+		return "must only contain digits"
+	case "alphanum":
+		return "must only contain letters and digits"
+	case "eqfield":
+		return "does not match"
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", field.Param())
+	case "len":
+		return fmt.Sprintf("must be %s characters long", field.Param())
+	}
+	return "is not valid"
+}
@@ -0,0 +1,105 @@
+package validation_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/validation"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+func TestValidate(t *testing.T) {
+	validInput := func() *dto.RegisterUserInput {
+		return &dto.RegisterUserInput{
+			FirstName:   "Wanjiku",
+			LastName:    "Kamau",
+			PhoneNumber: "+254722000000",
+			Flavour:     enums.FlavourPro,
+			UserName:    "wanjiku_k",
+			PIN:         "1234",
+			ConfirmPIN:  "1234",
+		}
+	}
+
+	tests := []struct {
+		name       string
+		input      func() interface{}
+		wantFields []domain.FieldError
+	}{
+		{
+			name:  "Happy case: a valid registration",
+			input: func() interface{} { return validInput() },
+		},
+		{
+			name: "Sad case: missing names and a bad phone number",
+			input: func() interface{} {
+				input := validInput()
+				input.FirstName = ""
+				input.LastName = ""
+				input.PhoneNumber = "not a phone"
+				return input
+			},
+			wantFields: []domain.FieldError{
+				{Field: "first_name", Message: "is required"},
+				{Field: "last_name", Message: "is required"},
+				{Field: "phone_number", Message: "is not a valid phone number"},
+			},
+		},
+		{
+			name: "Sad case: a name longer than its column, a bad flavour and username",
+			input: func() interface{} {
+				input := validInput()
+				input.MiddleName = "Wambui Njeri Wanjiru Kamau"
+				input.Flavour = "ADMIN"
+				input.UserName = "wa"
+				return input
+			},
+			wantFields: []domain.FieldError{
+				{Field: "middle_name", Message: "must be at most 25 characters long"},
+				{Field: "flavour", Message: "is not a valid option"},
+				{Field: "username", Message: "must be 3 to 20 letters, digits, dots or underscores"},
+			},
+		},
+		{
+			name: "Sad case: PINs that are not digits and do not match",
+			input: func() interface{} {
+				return &dto.UserPINInput{UserID: "123", PIN: "12a4", ConfirmPIN: "1234", Flavour: enums.FlavourConsumer}
+			},
+			wantFields: []domain.FieldError{
+				{Field: "pin", Message: "must only contain digits"},
+				{Field: "confirm_pin", Message: "does not match"},
+			},
+		},
+		{
+			name: "Sad case: an invalid cash movement",
+			input: func() interface{} {
+				return &dto.CashMovementInput{Type: "REFUND"}
+			},
+			wantFields: []domain.FieldError{
+				{Field: "type", Message: "is not a valid option"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.Validate(tt.input())
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var coded *domain.Error
+			if !errors.As(err, &coded) || coded.Code != domain.Validation {
+				t.Fatalf("Validate() error = %v, want a validation error", err)
+			}
+			if !reflect.DeepEqual(coded.Fields, tt.wantFields) {
+				t.Errorf("Validate() fields = %v, want %v", coded.Fields, tt.wantFields)
+			}
+		})
+	}
+}
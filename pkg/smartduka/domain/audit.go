@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+)
+
+// AuditLog is a change made to a record, who made it and from where
+type AuditLog struct {
+	ID        string            `json:"id"`
+	Entity    string            `json:"entity"`
+	EntityID  string            `json:"entityID"`
+	Action    enums.AuditAction `json:"action"`
+	Changes   []*AuditChange    `json:"changes"`
+	ActorID   string            `json:"actorID"`
+	IPAddress string            `json:"ipAddress"`
+	Device    string            `json:"device"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// AuditChange is the value of a field before and after a change, encoded as JSON.
+// From is empty for a record being created
+type AuditChange struct {
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
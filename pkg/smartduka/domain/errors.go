@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// InternalErrorMessage is shown to users in place of errors whose details are kept from them
+const InternalErrorMessage = "something went wrong, please try again"
+
+// ErrorCode tells clients what went wrong so that they can act on an error without reading its message
+type ErrorCode string
+
+// The kinds of errors clients are told about
+const (
+	// NotFound is returned when a record asked for does not exist
+	NotFound ErrorCode = "NOT_FOUND"
+
+	// Unauthenticated is returned when the request does not carry a valid token
+	Unauthenticated ErrorCode = "UNAUTHENTICATED"
+
+	// Forbidden is returned when the logged in user is not allowed to do what they asked
+	Forbidden ErrorCode = "FORBIDDEN"
+
+	// Validation is returned when the input is invalid
+	Validation ErrorCode = "VALIDATION"
+
+	// Conflict is returned when a request clashes with the current state of a record
+	Conflict ErrorCode = "CONFLICT"
+
+	// Internal is returned for any other error. Its details are kept from clients
+	Internal ErrorCode = "INTERNAL"
+)
+
+// HTTPStatus is the status a REST response reporting an error with the code is sent with
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case NotFound:
+		return http.StatusNotFound
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	case Forbidden:
+		return http.StatusForbidden
+	case Validation:
+		return http.StatusBadRequest
+	case Conflict:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Error is an error that clients are told about. Message is safe to show to users while
+// Err holds the details of what caused the error for the logs.
+// Fields lists what is wrong with each field of an invalid input
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError tells a user what is wrong with a field of their input
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errorf creates an error with a message safe to show to users
+func Errorf(code ErrorCode, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// WrapError gives an error a code and a message safe to show to users, keeping the error as the cause
+func WrapError(code ErrorCode, err error, message string) error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if len(e.Fields) > 0 {
+		fields := make([]string, 0, len(e.Fields))
+		for _, field := range e.Fields {
+			fields = append(fields, field.Field+" "+field.Message)
+		}
+		message += ": " + strings.Join(fields, ", ")
+	}
+
+	if e.Err == nil {
+		return message
+	}
+	return fmt.Sprintf("%s: %v", message, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCodeOf returns the code of an error. Errors without one are internal
+func ErrorCodeOf(err error) ErrorCode {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return Internal
+}
+
+// UserMessage is the message to show users for an error in their language.
+// Errors without a code may hold details users should not see so they get a generic message
+func UserMessage(err error, language string) string {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return Localize(coded.Message, language)
+	}
+	return Localize(InternalErrorMessage, language)
+}
+
+// UserFields lists what is wrong with the fields of an invalid input, in the user's language
+func UserFields(err error, language string) []FieldError {
+	var coded *Error
+	if !errors.As(err, &coded) || len(coded.Fields) == 0 {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(coded.Fields))
+	for _, field := range coded.Fields {
+		fields = append(fields, FieldError{Field: field.Field, Message: Localize(field.Message, language)})
+	}
+	return fields
+}
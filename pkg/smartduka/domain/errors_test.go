@@ -0,0 +1,63 @@
+package domain_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+func TestUserMessage(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		acceptLanguage string
+		wantMessage    string
+		wantStatus     int
+	}{
+		{
+			name:        "Happy case: a record that does not exist",
+			err:         fmt.Errorf("failed to render receipt: %w", domain.Errorf(domain.NotFound, "the sale does not exist")),
+			wantMessage: "the sale does not exist",
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			name:           "Happy case: a message in the user's language",
+			err:            domain.Errorf(domain.Unauthenticated, "invalid pin"),
+			acceptLanguage: "sw-KE,sw;q=0.9,en;q=0.8",
+			wantMessage:    "PIN si sahihi",
+			wantStatus:     http.StatusUnauthorized,
+		},
+		{
+			name:           "Happy case: a message without a translation is left in English",
+			err:            domain.Errorf(domain.Validation, "invalid sort field: PRICE"),
+			acceptLanguage: "sw",
+			wantMessage:    "invalid sort field: PRICE",
+			wantStatus:     http.StatusBadRequest,
+		},
+		{
+			name:           "Happy case: an unsupported language falls back to English",
+			err:            domain.Errorf(domain.Conflict, "the user is already frozen"),
+			acceptLanguage: "fr-FR",
+			wantMessage:    "the user is already frozen",
+			wantStatus:     http.StatusConflict,
+		},
+		{
+			name:        "Sad case: the details of an unexpected error are kept from users",
+			err:         fmt.Errorf("failed to get sale: connection refused"),
+			wantMessage: domain.InternalErrorMessage,
+			wantStatus:  http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := domain.UserMessage(tt.err, domain.Language(tt.acceptLanguage)); got != tt.wantMessage {
+				t.Errorf("UserMessage() = %q, want %q", got, tt.wantMessage)
+			}
+			if got := domain.ErrorCodeOf(tt.err).HTTPStatus(); got != tt.wantStatus {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// IdempotencyKey is a key sent by a client to make retrying a request safe, along with the response to replay
+type IdempotencyKey struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	LockedUntil time.Time
+	ExpiresAt   time.Time
+	CompletedAt *time.Time
+
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseBody        []byte
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+
+// StockLevel is how much of a product is in stock after a sale, return or delivery
+type StockLevel struct {
+	ProductID string        `json:"productID"`
+	Name      string        `json:"name"`
+	Quantity  money.Decimal `json:"quantity"`
+	Unit      string        `json:"unit"`
+	InStock   bool          `json:"inStock"`
+}
+
+// LowStockAlert warns that a sale has taken a product's stock down to the shop's low stock threshold
+type LowStockAlert struct {
+	ProductID string        `json:"productID"`
+	Name      string        `json:"name"`
+	Quantity  money.Decimal `json:"quantity"`
+	Threshold money.Decimal `json:"threshold"`
+}
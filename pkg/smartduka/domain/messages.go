@@ -0,0 +1,82 @@
+package domain
+
+import "strings"
+
+// The languages messages to users are written in
+const (
+	// English is the language messages are written in and the one used when a user's language is not supported
+	English = "en"
+
+	// Swahili messages are shown to users who ask for them
+	Swahili = "sw"
+)
+
+// translations hold the messages users see most often in the languages other than English, keyed by the English message.
+// Messages without a translation are shown in English
+var translations = map[string]map[string]string{
+	Swahili: {
+		InternalErrorMessage:                                          "hitilafu imetokea, tafadhali jaribu tena",
+		"the request is not authenticated":                            "ombi hili halijathibitishwa",
+		"this session has ended, please log in again":                 "kipindi chako kimeisha, tafadhali ingia tena",
+		"this account cannot be used, please contact the shop owner":  "akaunti hii haiwezi kutumika, tafadhali wasiliana na mwenye duka",
+		"your account has been frozen, please contact the shop owner": "akaunti yako imesimamishwa, tafadhali wasiliana na mwenye duka",
+		"invalid pin":                                                "PIN si sahihi",
+		"pin expired. Please change your pin":                        "muda wa PIN yako umeisha. Tafadhali badilisha PIN yako",
+		"PIN should be of 4 digits":                                  "PIN inapaswa kuwa na tarakimu 4",
+		"PIN should only contain digits":                             "PIN inapaswa kuwa na tarakimu pekee",
+		"the PINs do not match":                                      "PIN hazilingani",
+		"invalid phone number":                                       "nambari ya simu si sahihi",
+		"no user has this phone number":                              "hakuna mtumiaji mwenye nambari hii ya simu",
+		"no user has this ID":                                        "hakuna mtumiaji mwenye kitambulisho hiki",
+		"the user does not exist":                                    "mtumiaji huyu hayupo",
+		"the user has not set a PIN":                                 "mtumiaji huyu hajaweka PIN",
+		"the product does not exist":                                 "bidhaa hii haipo",
+		"no product has this ID":                                     "hakuna bidhaa yenye kitambulisho hiki",
+		"the sale does not exist":                                    "mauzo haya hayapo",
+		"the shift does not exist":                                   "zamu hii haipo",
+		"open a shift before recording sales":                        "fungua zamu kabla ya kurekodi mauzo",
+		"open a shift before moving cash":                            "fungua zamu kabla ya kuhamisha pesa",
+		"there is no open shift to close":                            "hakuna zamu iliyo wazi ya kufunga",
+		"close your open shift before opening another":               "funga zamu yako iliyo wazi kabla ya kufungua nyingine",
+		"the sale has been reported to KRA, record a return instead": "mauzo haya yameripotiwa KRA, rekodi bidhaa zilizorudishwa badala yake",
+		"the sale's shift has been closed, record a return instead":  "zamu ya mauzo haya imefungwa, rekodi bidhaa zilizorudishwa badala yake",
+		"quantity must be positive":                                  "idadi lazima iwe zaidi ya sifuri",
+		"sale must have a quantity greater than zero":                "mauzo lazima yawe na idadi zaidi ya sifuri",
+		"discount cannot be negative":                                "punguzo haliwezi kuwa hasi",
+		"you cannot delete your own account":                         "huwezi kufuta akaunti yako mwenyewe",
+		"you cannot freeze your own account":                         "huwezi kusimamisha akaunti yako mwenyewe",
+		"the input is invalid":                                       "maelezo uliyotuma si sahihi",
+		"is required":                                                "inahitajika",
+		"is not a valid phone number":                                "si nambari sahihi ya simu",
+		"is not a valid option":                                      "si chaguo sahihi",
+		"must only contain digits":                                   "inapaswa kuwa na tarakimu pekee",
+		"must only contain letters and digits":                       "inapaswa kuwa na herufi na tarakimu pekee",
+		"has already been registered":                                "tayari imesajiliwa",
+		"this user is already registered":                            "mtumiaji huyu tayari amesajiliwa",
+		"the verification code is wrong or has expired":              "nambari ya uthibitisho si sahihi au muda wake umeisha",
+		"does not match":                                             "hailingani",
+		"is not valid":                                               "si sahihi",
+	},
+}
+
+// Localize translates a message into a language. Languages are named by their ISO 639-1 code
+// and messages are left in English when the language or the message has no translation
+func Localize(message string, language string) string {
+	if translated, ok := translations[strings.ToLower(language)][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Language picks the language to show a user from an Accept-Language header, e.g `sw-KE,sw;q=0.9,en;q=0.8`.
+// Languages are tried in the order they are listed and English is used when none of them is supported
+func Language(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		name := strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+		name = strings.ToLower(strings.SplitN(name, "-", 2)[0])
+		if _, ok := translations[name]; ok || name == English {
+			return name
+		}
+	}
+	return English
+}
@@ -0,0 +1,43 @@
+package domain
+
+// PageInfo tells a client where a page of records ends and whether there are more after it
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+// ProductEdge is a product on a page together with the cursor marking its place in the list
+type ProductEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Product `json:"node"`
+}
+
+// ProductConnection is a page of products
+type ProductConnection struct {
+	Edges    []*ProductEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+// UserEdge is a user on a page together with the cursor marking their place in the list
+type UserEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *User  `json:"node"`
+}
+
+// UserConnection is a page of users
+type UserConnection struct {
+	Edges    []*UserEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
+
+// SaleEdge is a sale on a page together with the cursor marking its place in the list
+type SaleEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Sale  `json:"node"`
+}
+
+// SaleConnection is a page of sales
+type SaleConnection struct {
+	Edges    []*SaleEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
@@ -1,25 +1,83 @@
 package domain
 
+import (
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+)
+
 // Product is used to display product info
 type Product struct {
-	ID           string  `json:"id"`
-	Active       bool    `json:"active"`
-	Name         string  `json:"name"`
-	Category     string  `json:"category"`
-	Quantity     float64 `json:"quantity"`
-	Unit         string  `json:"unit"`
-	Price        float64 `json:"price"`
-	Description  string  `json:"description"`
-	Manufacturer string  `json:"manufacturer"`
-	InStock      bool    `json:"inStock"`
+	ID           string        `json:"id"`
+	Active       bool          `json:"active"`
+	SKU          string        `json:"sku"`
+	Name         string        `json:"name"`
+	Category     string        `json:"category"`
+	Quantity     money.Decimal `json:"quantity"`
+	Unit         string        `json:"unit"`
+	Price        money.Money   `json:"price"`
+	CostPrice    money.Money   `json:"costPrice"`
+	VAT          money.Decimal `json:"vat"`
+	Description  string        `json:"description"`
+	Manufacturer string        `json:"manufacturer"`
+	InStock      bool          `json:"inStock"`
+	CreatedBy    string        `json:"createdBy"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+	// Deleted is set on a product pulled by offline tills after it was deleted
+	Deleted bool `json:"deleted"`
 }
 
 // Sale is used to show sales data
 type Sale struct {
-	ID        string  `json:"id"`
-	ProductID string  `json:"productName"`
-	Quantity  float64 `json:"quantity"`
-	Unit      string  `json:"unit"`
-	Price     float64 `json:"price"`
-	SoldBy    string  `json:"soldBy"`
+	ID            string              `json:"id"`
+	ProductID     string              `json:"productName"`
+	Quantity      money.Decimal       `json:"quantity"`
+	Unit          string              `json:"unit"`
+	Price         money.Money         `json:"price"`
+	Discount      money.Money         `json:"discount"`
+	CostOfGoods   money.Money         `json:"costOfGoods"`
+	PaymentMethod enums.PaymentMethod `json:"paymentMethod"`
+	ShiftID       string              `json:"shiftID"`
+	SoldBy        string              `json:"soldBy"`
+	SoldAt        time.Time           `json:"soldAt"`
+	TaxInvoice    *TaxInvoice         `json:"taxInvoice"`
+}
+
+// StockReceipt is a delivery of stock and what it cost
+type StockReceipt struct {
+	ID                string        `json:"id"`
+	ProductID         string        `json:"productID"`
+	Quantity          money.Decimal `json:"quantity"`
+	RemainingQuantity money.Decimal `json:"remainingQuantity"`
+	UnitCost          money.Money   `json:"unitCost"`
+	Supplier          string        `json:"supplier"`
+	ReceivedBy        string        `json:"receivedBy"`
+	ReceivedAt        time.Time     `json:"receivedAt"`
+}
+
+// SaleExport is a sale together with the product sold and the cashier who sold it
+type SaleExport struct {
+	Sale
+	SoldAt          time.Time `json:"soldAt"`
+	ProductName     string    `json:"productName"`
+	ProductCategory string    `json:"productCategory"`
+	CashierName     string    `json:"cashierName"`
+}
+
+// ProductImport is the outcome of importing a file of products.
+// Nothing is saved when any row has an error or when the import is a dry run
+type ProductImport struct {
+	DryRun  bool              `json:"dryRun"`
+	Rows    int               `json:"rows"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Errors  []*ImportRowError `json:"errors"`
+}
+
+// ImportRowError is a problem found with a row of an imported file
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
 }
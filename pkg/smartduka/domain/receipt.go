@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+)
+
+// ShopDetails are printed at the top of every receipt
+type ShopDetails struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+	KRAPIN  string `json:"kraPIN"`
+}
+
+// Receipt is the record of a completed sale given to the customer
+type Receipt struct {
+	Number          string              `json:"number"`
+	IssuedAt        time.Time           `json:"issuedAt"`
+	Shop            ShopDetails         `json:"shop"`
+	Cashier         string              `json:"cashier"`
+	Lines           []*ReceiptLine      `json:"lines"`
+	VAT             []*VATLine          `json:"vat"`
+	Subtotal        money.Money         `json:"subtotal"`
+	Discount        money.Money         `json:"discount"`
+	Total           money.Money         `json:"total"`
+	VATTotal        money.Money         `json:"vatTotal"`
+	PaymentMethod   enums.PaymentMethod `json:"paymentMethod"`
+	VerificationURL string              `json:"verificationURL"`
+	TaxInvoice      *TaxInvoice         `json:"taxInvoice"`
+}
+
+// ReceiptLine is an item on a receipt. Prices include VAT
+type ReceiptLine struct {
+	Description string        `json:"description"`
+	Quantity    money.Decimal `json:"quantity"`
+	Unit        string        `json:"unit"`
+	UnitPrice   money.Money   `json:"unitPrice"`
+	Discount    money.Money   `json:"discount"`
+	Total       money.Money   `json:"total"`
+	VATRate     money.Decimal `json:"vatRate"`
+}
+
+// VATLine is the VAT charged at one rate on a receipt
+type VATLine struct {
+	Rate  money.Decimal `json:"rate"`
+	Net   money.Money   `json:"net"`
+	VAT   money.Money   `json:"vat"`
+	Gross money.Money   `json:"gross"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+)
+
+// SalesSummary is the total of the sales made within a calendar day, week or month
+type SalesSummary struct {
+	Period       time.Time     `json:"period"`
+	Revenue      money.Money   `json:"revenue"`
+	Quantity     money.Decimal `json:"quantity"`
+	Transactions int           `json:"transactions"`
+}
+
+// SalesBreakdown is the total of the sales sharing a cashier, product category or payment method
+type SalesBreakdown struct {
+	Key          string        `json:"key"`
+	Label        string        `json:"label"`
+	Revenue      money.Money   `json:"revenue"`
+	Quantity     money.Decimal `json:"quantity"`
+	Transactions int           `json:"transactions"`
+}
+
+// ProductSales is the total of the sales of a single product
+type ProductSales struct {
+	ProductID    string        `json:"productID"`
+	Name         string        `json:"name"`
+	Category     string        `json:"category"`
+	Revenue      money.Money   `json:"revenue"`
+	Quantity     money.Decimal `json:"quantity"`
+	Transactions int           `json:"transactions"`
+}
+
+// HourlySales is a cell of the sales heatmap i.e the sales made within an hour of a given day of the week.
+// Days of the week follow ISO 8601 i.e Monday is 1 and Sunday is 7
+type HourlySales struct {
+	DayOfWeek    int         `json:"dayOfWeek"`
+	Hour         int         `json:"hour"`
+	Revenue      money.Money `json:"revenue"`
+	Transactions int         `json:"transactions"`
+}
+
+// ProfitSummary is the gross profit made on a product, category or calendar period.
+// The margin is the gross profit as a percentage of revenue
+type ProfitSummary struct {
+	Key                   string        `json:"key"`
+	Label                 string        `json:"label"`
+	Revenue               money.Money   `json:"revenue"`
+	CostOfGoods           money.Money   `json:"costOfGoods"`
+	GrossProfit           money.Money   `json:"grossProfit"`
+	Margin                money.Decimal `json:"margin"`
+	Quantity              money.Decimal `json:"quantity"`
+	Transactions          int           `json:"transactions"`
+	BelowCostTransactions int           `json:"belowCostTransactions"`
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+)
+
+// Shift is a cashier's session at the till
+type Shift struct {
+	ID           string            `json:"id"`
+	CashierID    string            `json:"cashierID"`
+	Status       enums.ShiftStatus `json:"status"`
+	OpeningFloat money.Money       `json:"openingFloat"`
+	ExpectedCash money.Money       `json:"expectedCash"`
+	CountedCash  money.Money       `json:"countedCash"`
+	OverShort    money.Money       `json:"overShort"`
+	OpenedAt     time.Time         `json:"openedAt"`
+	ClosedAt     *time.Time        `json:"closedAt"`
+}
+
+// CashMovement is cash put into or taken out of the drawer other than through a sale e.g petty cash
+type CashMovement struct {
+	ID         string                 `json:"id"`
+	ShiftID    string                 `json:"shiftID"`
+	Type       enums.CashMovementType `json:"type"`
+	Amount     money.Money            `json:"amount"`
+	Reason     string                 `json:"reason"`
+	RecordedBy string                 `json:"recordedBy"`
+}
+
+// TenderTotal is the amount taken through a payment method
+type TenderTotal struct {
+	PaymentMethod enums.PaymentMethod `json:"paymentMethod"`
+	Amount        money.Money         `json:"amount"`
+	Transactions  int                 `json:"transactions"`
+}
+
+// CashMovementTotal is the amount moved into or out of the drawer for a reason
+type CashMovementTotal struct {
+	Type   enums.CashMovementType `json:"type"`
+	Amount money.Money            `json:"amount"`
+}
+
+// ShiftTotals are the sales, returns, discounts and VAT of a shift
+type ShiftTotals struct {
+	GrossSales   money.Money `json:"grossSales"`
+	Returns      money.Money `json:"returns"`
+	Discounts    money.Money `json:"discounts"`
+	VAT          money.Money `json:"vat"`
+	Transactions int         `json:"transactions"`
+}
+
+// ZReport is the end of shift report. Net sales are the gross sales less returns and discounts
+type ZReport struct {
+	Shift         *Shift               `json:"shift"`
+	Tenders       []*TenderTotal       `json:"tenders"`
+	CashMovements []*CashMovementTotal `json:"cashMovements"`
+	GrossSales    money.Money          `json:"grossSales"`
+	Returns       money.Money          `json:"returns"`
+	Discounts     money.Money          `json:"discounts"`
+	NetSales      money.Money          `json:"netSales"`
+	VAT           money.Money          `json:"vat"`
+	Transactions  int                  `json:"transactions"`
+}
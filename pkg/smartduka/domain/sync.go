@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+)
+
+// SyncOperation is the outcome of applying a change pushed by an offline client
+type SyncOperation struct {
+	ID         string                    `json:"id"`
+	DeviceID   string                    `json:"deviceID"`
+	Type       enums.SyncOperationType   `json:"type"`
+	Status     enums.SyncOperationStatus `json:"status"`
+	Conflict   enums.SyncConflict        `json:"conflict,omitempty"`
+	Message    string                    `json:"message,omitempty"`
+	RecordID   string                    `json:"recordID,omitempty"`
+	OccurredAt time.Time                 `json:"occurredAt"`
+	// Replayed is set when the operation had already been pushed and its recorded outcome is returned
+	Replayed bool `json:"replayed"`
+}
+
+// SyncDevice is the sync state of a till that works offline
+type SyncDevice struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"userID"`
+	Cursor       string     `json:"cursor"`
+	LastPushedAt *time.Time `json:"lastPushedAt"`
+	LastPulledAt *time.Time `json:"lastPulledAt"`
+}
+
+// SyncPush is the outcome of each operation in a pushed batch, in the order they were pushed
+type SyncPush struct {
+	Operations []*SyncOperation `json:"operations"`
+}
+
+// SyncPull is a page of the changes made on the server since a client last pulled.
+// The cursor is passed back on the next pull to continue from where this page ended
+type SyncPull struct {
+	Products []*Product `json:"products"`
+	Cursor   string     `json:"cursor"`
+	HasMore  bool       `json:"hasMore"`
+}
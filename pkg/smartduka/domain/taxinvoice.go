@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+)
+
+// TaxInvoice is a sale's electronic tax invoice as registered with KRA eTIMS
+type TaxInvoice struct {
+	ID               string                 `json:"id"`
+	SaleID           string                 `json:"saleID"`
+	Status           enums.TaxInvoiceStatus `json:"status"`
+	Attempts         int                    `json:"attempts"`
+	NextAttemptAt    time.Time              `json:"nextAttemptAt"`
+	LastError        string                 `json:"lastError"`
+	InvoiceNumber    string                 `json:"invoiceNumber"`
+	ReceiptSignature string                 `json:"receiptSignature"`
+	QRData           string                 `json:"qrData"`
+	SubmittedAt      *time.Time             `json:"submittedAt"`
+}
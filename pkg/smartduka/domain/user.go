@@ -9,6 +9,7 @@ import (
 type User struct {
 	ID              string          `json:"id"`
 	FirstName       string          `json:"firstName"`
+	MiddleName      string          `json:"middleName"`
 	LastName        string          `json:"lastName"`
 	Active          bool            `json:"active"`
 	UserName        string          `json:"username"`
@@ -16,7 +17,11 @@ type User struct {
 	UserContact     Contact         `json:"userContact"`
 	DeviceToken     string          `json:"deviceToken"`
 	Email           string          `json:"email"`
+	Residence       string          `json:"residence"`
 	AuthCredentials AuthCredentials `json:"authCredentials"`
+	FrozenReason    string          `json:"frozenReason"`
+	FrozenAt        *time.Time      `json:"frozenAt"`
+	TokensRevokedAt *time.Time      `json:"-"`
 }
 
 type AuthCredentials struct {
@@ -34,6 +39,15 @@ type Contact struct {
 	UserID       string        `json:"user_id"`
 }
 
+// Identifier is a document that identifies a user e.g their national ID
+type Identifier struct {
+	ID              string               `json:"id"`
+	Active          bool                 `json:"active"`
+	IdentifierType  enums.IdentifierType `json:"identifier_type"`
+	IdentifierValue string               `json:"identifier_value"`
+	UserID          string               `json:"user_id"`
+}
+
 type UserPIN struct {
 	ID        string        `json:"id"`
 	Active    bool          `json:"active"`
@@ -0,0 +1,961 @@
+// Package contract checks that a datastore behaves the way the usecases expect.
+// The same suite runs against the Postgres datastore and the in-memory one so that tests using the in-memory
+// datastore can be trusted to behave like production. Every check creates the records it needs with unique
+// values so that it can run against a database that already holds data
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+)
+
+// Run runs the contract suite against a datastore
+func Run(t *testing.T, repository gorm.Repository) {
+	t.Run("users", func(t *testing.T) { testUsers(t, repository) })
+	t.Run("registration", func(t *testing.T) { testRegistration(t, repository) })
+	t.Run("credentials", func(t *testing.T) { testCredentials(t, repository) })
+	t.Run("products", func(t *testing.T) { testProducts(t, repository) })
+	t.Run("stock and sales", func(t *testing.T) { testStockAndSales(t, repository) })
+	t.Run("pagination", func(t *testing.T) { testPagination(t, repository) })
+	t.Run("batch lookups", func(t *testing.T) { testBatchLookups(t, repository) })
+	t.Run("missing records", func(t *testing.T) { testMissingRecords(t, repository) })
+	t.Run("shifts", func(t *testing.T) { testShifts(t, repository) })
+	t.Run("idempotency keys", func(t *testing.T) { testIdempotencyKeys(t, repository) })
+	t.Run("sync", func(t *testing.T) { testSync(t, repository) })
+	t.Run("reports", func(t *testing.T) { testReports(t, repository) })
+}
+
+// unique is a short random suffix that keeps the records of a run apart
+func unique() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")[:10]
+}
+
+// phoneNumber is a random Kenyan mobile number
+func phoneNumber() string {
+	return fmt.Sprintf("+2547%08d", uuid.New().ID()%100000000)
+}
+
+func registerUser(t *testing.T, repository gorm.Repository, phone string) *gorm.User {
+	t.Helper()
+
+	suffix := unique()
+	user, err := repository.RegisterUser(context.Background(), &gorm.User{
+		FirstName: "Contract",
+		LastName:  "User" + suffix,
+		Active:    true,
+		UserName:  "user" + suffix,
+		UserType:  "STAFF",
+		Email:     "user" + suffix + "@example.com",
+	}, &gorm.Contact{
+		Active:       true,
+		ContactType:  "PHONE",
+		ContactValue: phone,
+		Flavour:      enums.FlavourPro,
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+
+	return user
+}
+
+func addProduct(t *testing.T, repository gorm.Repository, userID string, quantity int64, costPrice int64) *gorm.Product {
+	t.Helper()
+
+	product, err := repository.AddProduct(context.Background(), &gorm.Product{
+		Base:      gorm.Base{CreatedBy: &userID},
+		Active:    true,
+		Name:      "Contract product " + unique(),
+		Category:  string(enums.CategoryFoodStuff),
+		Quantity:  money.DecimalFromInt(quantity),
+		Unit:      string(enums.UnitSingle),
+		Price:     money.New(10000, money.DefaultCurrency),
+		CostPrice: money.New(costPrice, money.DefaultCurrency),
+		VAT:       money.DecimalFromInt(16),
+		InStock:   quantity > 0,
+	})
+	if err != nil {
+		t.Fatalf("AddProduct() error = %v", err)
+	}
+
+	return product
+}
+
+func addSale(t *testing.T, repository gorm.Repository, sale *gorm.Sale) *gorm.Sale {
+	t.Helper()
+
+	if sale.Unit == "" {
+		sale.Unit = string(enums.UnitSingle)
+	}
+	sale.Active = true
+	saved, err := repository.AddSaleRecord(context.Background(), sale, enums.CostingMethodFIFO)
+	if err != nil {
+		t.Fatalf("AddSaleRecord() error = %v", err)
+	}
+
+	return saved
+}
+
+func testUsers(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	phone := phoneNumber()
+	user := registerUser(t, repository, phone)
+
+	got, err := repository.GetUserProfileByUserID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserProfileByUserID() error = %v", err)
+	}
+	if got.UserName != user.UserName || got.Contacts.ContactValue != phone {
+		t.Errorf("GetUserProfileByUserID() = %s with contact %s, want %s with contact %s", got.UserName, got.Contacts.ContactValue, user.UserName, phone)
+	}
+
+	if _, err := repository.GetUserProfileByPhoneNumber(ctx, phone, enums.FlavourPro); err != nil {
+		t.Errorf("GetUserProfileByPhoneNumber() error = %v", err)
+	}
+	if _, err := repository.GetUserProfileByPhoneNumber(ctx, phone, enums.FlavourConsumer); err == nil {
+		t.Errorf("GetUserProfileByPhoneNumber() found a user with another flavour")
+	}
+
+	users, err := repository.SearchUser(ctx, strings.ToUpper(user.UserName))
+	if err != nil {
+		t.Fatalf("SearchUser() error = %v", err)
+	}
+	if len(users) != 1 || *users[0].ID != *user.ID {
+		t.Errorf("SearchUser() found %d users, want the registered user", len(users))
+	}
+
+	suffix := unique()
+	_, err = repository.RegisterUser(ctx, &gorm.User{Active: true, UserName: "user" + suffix}, &gorm.Contact{ContactValue: phoneNumber(), Flavour: "invalid"}, nil, nil)
+	if err == nil {
+		t.Errorf("RegisterUser() registered a user with an invalid flavour")
+	}
+	if users, _ := repository.SearchUser(ctx, "user"+suffix); len(users) != 0 {
+		t.Errorf("RegisterUser() kept a user whose contact failed")
+	}
+
+	if err := repository.UpdateUser(ctx, user, map[string]interface{}{"push_token": "token"}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	got, err = repository.GetUserProfileByUserID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserProfileByUserID() error = %v", err)
+	}
+	if got.PushToken != "token" {
+		t.Errorf("UpdateUser() push token = %s, want token", got.PushToken)
+	}
+
+	if err := repository.DeleteUser(ctx, *user.ID); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if _, err := repository.GetUserProfileByUserID(ctx, user.ID); err == nil {
+		t.Errorf("GetUserProfileByUserID() found a deleted user")
+	}
+	if err := repository.DeleteUser(ctx, *user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("DeleteUser() of a deleted user error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+
+	restored, err := repository.RestoreUser(ctx, *user.ID)
+	if err != nil {
+		t.Fatalf("RestoreUser() error = %v", err)
+	}
+	if restored.DeletedAt.Valid {
+		t.Errorf("RestoreUser() left the user deleted")
+	}
+	if _, err := repository.GetUserProfileByPhoneNumber(ctx, phone, enums.FlavourPro); err != nil {
+		t.Errorf("RestoreUser() did not restore the user's contact: %v", err)
+	}
+
+	frozenAt := time.Now().UTC().Truncate(time.Second)
+	if err := repository.UpdateUser(ctx, user, map[string]interface{}{"active": false, "frozen_reason": "stock missing", "frozen_at": frozenAt}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if users, _ := repository.SearchUser(ctx, user.UserName); len(users) != 0 {
+		t.Errorf("SearchUser() found an inactive user")
+	}
+	if _, err := repository.GetUserProfileByUserID(ctx, user.ID); err == nil {
+		t.Errorf("GetUserProfileByUserID() found an inactive user")
+	}
+	users, err = repository.GetUsersByIDs(ctx, []string{*user.ID})
+	if err != nil || len(users) != 1 {
+		t.Fatalf("GetUsersByIDs() = %d users, error = %v, want the inactive user", len(users), err)
+	}
+	if users[0].FrozenReason != "stock missing" || users[0].FrozenAt == nil || !users[0].FrozenAt.Equal(frozenAt) {
+		t.Errorf("UpdateUser() frozen = %q at %v, want stock missing at %v", users[0].FrozenReason, users[0].FrozenAt, frozenAt)
+	}
+
+	if err := repository.UpdateUser(ctx, user, map[string]interface{}{"active": true, "frozen_reason": "", "frozen_at": nil}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	got, err = repository.GetUserProfileByUserID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserProfileByUserID() error = %v", err)
+	}
+	if got.FrozenAt != nil || got.FrozenReason != "" {
+		t.Errorf("UpdateUser() left the user frozen at %v", got.FrozenAt)
+	}
+}
+
+func testRegistration(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	phone := phoneNumber()
+	nationalID := unique()
+
+	register := func(username string, phone string, flavour enums.Flavour, identifier *gorm.Identifier) (*gorm.User, error) {
+		return repository.RegisterUser(ctx, &gorm.User{Active: true, FirstName: "Contract", UserName: username},
+			&gorm.Contact{Active: true, ContactType: "PHONE", ContactValue: phone, Flavour: flavour}, identifier,
+			&gorm.UserPIN{Active: true, Flavour: flavour, ValidFrom: time.Now(), ValidTo: time.Now().AddDate(1, 0, 0), HashedPIN: "hash", Salt: "salt"})
+	}
+	nationalIDCard := func(value string) *gorm.Identifier {
+		return &gorm.Identifier{Active: true, IdentifierType: enums.IdentifierTypeNationalID, IdentifierValue: value}
+	}
+
+	username := "user" + unique()
+	user, err := register(username, phone, enums.FlavourPro, nationalIDCard(nationalID))
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	if _, err := repository.GetUserPINByUserID(ctx, *user.ID, enums.FlavourPro); err != nil {
+		t.Errorf("RegisterUser() did not save the user's PIN: %v", err)
+	}
+	if got, err := repository.GetUserByIdentifier(ctx, enums.IdentifierTypeNationalID, nationalID); err != nil || *got.ID != *user.ID {
+		t.Errorf("GetUserByIdentifier() error = %v, want the registered user", err)
+	}
+	if _, err := repository.GetUserByIdentifier(ctx, enums.IdentifierTypePassport, nationalID); err == nil {
+		t.Errorf("GetUserByIdentifier() found a user with another kind of document")
+	}
+	if got, err := repository.GetUserByUsername(ctx, username); err != nil || *got.ID != *user.ID {
+		t.Errorf("GetUserByUsername() error = %v, want the registered user", err)
+	}
+
+	if _, err := register(username, phoneNumber(), enums.FlavourPro, nil); err == nil {
+		t.Errorf("RegisterUser() registered a username twice")
+	}
+	if _, err := register("user"+unique(), phone, enums.FlavourPro, nil); err == nil {
+		t.Errorf("RegisterUser() registered a phone number twice in one flavour")
+	}
+	if _, err := register("user"+unique(), phone, enums.FlavourConsumer, nil); err != nil {
+		t.Errorf("RegisterUser() error = %v, want a phone number to be used in each flavour", err)
+	}
+
+	duplicate := "user" + unique()
+	if _, err := register(duplicate, phoneNumber(), enums.FlavourPro, nationalIDCard(nationalID)); err == nil {
+		t.Errorf("RegisterUser() registered an identification document twice")
+	}
+	if _, err := repository.GetUserByUsername(ctx, duplicate); err == nil {
+		t.Errorf("RegisterUser() kept a user whose identification document failed")
+	}
+
+	// a deleted user's username, phone number and document can be registered again
+	if err := repository.DeleteUser(ctx, *user.ID); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if _, err := repository.GetUserByIdentifier(ctx, enums.IdentifierTypeNationalID, nationalID); err == nil {
+		t.Errorf("GetUserByIdentifier() found a deleted user")
+	}
+	if _, err := register(username, phone, enums.FlavourPro, nationalIDCard(nationalID)); err != nil {
+		t.Errorf("RegisterUser() error = %v, want a deleted user's details to be reused", err)
+	}
+	if _, err := repository.RestoreUser(ctx, *user.ID); err == nil {
+		t.Errorf("RestoreUser() restored a user whose details have been taken")
+	}
+
+	// a phone number is verified before its user registers
+	otpPhone := phoneNumber()
+	otp, err := repository.SaveOTP(ctx, &gorm.OTP{Flavour: enums.FlavourPro, IsValid: true, ValidUntil: time.Now().Add(time.Hour), PhoneNumber: otpPhone, OTP: "123456"})
+	if err != nil {
+		t.Fatalf("SaveOTP() error = %v", err)
+	}
+	if _, err := repository.SaveOTP(ctx, &gorm.OTP{Flavour: enums.FlavourPro, IsValid: true, ValidUntil: time.Now().Add(-time.Minute), PhoneNumber: otpPhone, OTP: "654321"}); err != nil {
+		t.Fatalf("SaveOTP() error = %v", err)
+	}
+	if _, err := repository.GetValidOTP(ctx, otpPhone, enums.FlavourPro, "654321"); err == nil {
+		t.Errorf("GetValidOTP() found an expired OTP")
+	}
+	if _, err := repository.GetValidOTP(ctx, otpPhone, enums.FlavourConsumer, "123456"); err == nil {
+		t.Errorf("GetValidOTP() found an OTP sent for another flavour")
+	}
+	got, err := repository.GetValidOTP(ctx, otpPhone, enums.FlavourPro, "123456")
+	if err != nil || got.ID != otp.ID {
+		t.Fatalf("GetValidOTP() error = %v, want the OTP", err)
+	}
+	if err := repository.InvalidateOTP(ctx, otp.ID); err != nil {
+		t.Fatalf("InvalidateOTP() error = %v", err)
+	}
+	if err := repository.InvalidateOTP(ctx, otp.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("InvalidateOTP() error = %v, want %v for an OTP used twice", err, gorm.ErrRecordNotFound)
+	}
+	if _, err := repository.GetValidOTP(ctx, otpPhone, enums.FlavourPro, "123456"); err == nil {
+		t.Errorf("GetValidOTP() found an OTP that has been used")
+	}
+}
+
+func testCredentials(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	phone := phoneNumber()
+	user := registerUser(t, repository, phone)
+
+	unknown := uuid.New().String()
+	if _, err := repository.SavePIN(ctx, &gorm.UserPIN{UserID: unknown, Flavour: enums.FlavourPro, Active: true}); err == nil {
+		t.Errorf("SavePIN() saved a pin for a user that does not exist")
+	}
+	if _, err := repository.SaveOTP(ctx, &gorm.OTP{UserID: &unknown, Flavour: enums.FlavourPro, IsValid: true}); err == nil {
+		t.Errorf("SaveOTP() saved an OTP for a user that does not exist")
+	}
+
+	_, err := repository.SaveOTP(ctx, &gorm.OTP{
+		UserID:      user.ID,
+		Flavour:     enums.FlavourPro,
+		IsValid:     true,
+		ValidUntil:  time.Now().Add(time.Hour),
+		PhoneNumber: phone,
+		OTP:         "1234",
+	})
+	if err != nil {
+		t.Errorf("SaveOTP() error = %v", err)
+	}
+
+	pin, err := repository.SavePIN(ctx, &gorm.UserPIN{
+		UserID:    *user.ID,
+		Flavour:   enums.FlavourPro,
+		Active:    true,
+		ValidFrom: time.Now(),
+		ValidTo:   time.Now().AddDate(1, 0, 0),
+		HashedPIN: "hash",
+		Salt:      "salt",
+	})
+	if err != nil {
+		t.Fatalf("SavePIN() error = %v", err)
+	}
+
+	got, err := repository.GetUserPINByUserID(ctx, *user.ID, enums.FlavourPro)
+	if err != nil {
+		t.Fatalf("GetUserPINByUserID() error = %v", err)
+	}
+	if got.ID != pin.ID {
+		t.Errorf("GetUserPINByUserID() = %s, want %s", got.ID, pin.ID)
+	}
+	if _, err := repository.GetUserPINByUserID(ctx, *user.ID, enums.FlavourConsumer); err == nil {
+		t.Errorf("GetUserPINByUserID() found a pin for another flavour")
+	}
+
+	if err := repository.InvalidatePIN(ctx, *user.ID, enums.FlavourPro); err != nil {
+		t.Fatalf("InvalidatePIN() error = %v", err)
+	}
+	if _, err := repository.GetUserPINByUserID(ctx, *user.ID, enums.FlavourPro); err == nil {
+		t.Errorf("GetUserPINByUserID() found an invalidated pin")
+	}
+}
+
+func testProducts(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	user := registerUser(t, repository, phoneNumber())
+
+	if _, err := repository.AddProduct(ctx, &gorm.Product{Active: true, Name: "Contract product " + unique(), Category: string(enums.CategoryCereals), Unit: string(enums.UnitSingle)}); err == nil {
+		t.Errorf("AddProduct() added a product without a creator")
+	}
+
+	sku := "SKU" + unique()
+	product := &gorm.Product{
+		Base:     gorm.Base{CreatedBy: user.ID},
+		Active:   true,
+		SKU:      &sku,
+		Name:     "Contract product " + unique(),
+		Category: string(enums.CategoryCereals),
+		Unit:     string(enums.UnitSingle),
+		Price:    money.New(10000, money.DefaultCurrency),
+	}
+	product, err := repository.AddProduct(ctx, product)
+	if err != nil {
+		t.Fatalf("AddProduct() error = %v", err)
+	}
+
+	duplicate := &gorm.Product{Base: gorm.Base{CreatedBy: user.ID}, Active: true, SKU: &sku, Name: "Contract product " + unique(), Category: string(enums.CategoryCereals), Unit: string(enums.UnitSingle)}
+	if _, err := repository.AddProduct(ctx, duplicate); err == nil {
+		t.Errorf("AddProduct() added two active products with the same SKU")
+	}
+	inactive := &gorm.Product{Base: gorm.Base{CreatedBy: user.ID}, Active: false, SKU: &sku, Name: "Contract product " + unique(), Category: string(enums.CategoryCereals), Unit: string(enums.UnitSingle)}
+	if _, err := repository.AddProduct(ctx, inactive); err != nil {
+		t.Errorf("AddProduct() error = %v, an inactive product does not hold its SKU", err)
+	}
+	invalid := &gorm.Product{Base: gorm.Base{CreatedBy: user.ID}, Active: true, Name: "Contract product " + unique(), Category: "invalid", Unit: string(enums.UnitSingle)}
+	if _, err := repository.AddProduct(ctx, invalid); err == nil {
+		t.Errorf("AddProduct() added a product with an invalid category")
+	}
+
+	products, err := repository.SearchProduct(ctx, strings.ToUpper(product.Name), 10)
+	if err != nil {
+		t.Fatalf("SearchProduct() error = %v", err)
+	}
+	if len(products) != 1 || products[0].ID != product.ID {
+		t.Errorf("SearchProduct() found %d products, want the active product", len(products))
+	}
+
+	// cashiers see matches while they are still typing the product's name
+	typed := product.Name[:len(product.Name)-4]
+	products, err = repository.SearchProduct(ctx, typed, 10)
+	if err != nil {
+		t.Fatalf("SearchProduct() error = %v", err)
+	}
+	if len(products) != 1 || products[0].ID != product.ID {
+		t.Errorf("SearchProduct(%q) found %d products, want the active product", typed, len(products))
+	}
+
+	if err := repository.UpdateProduct(ctx, product, map[string]interface{}{"description": "updated"}); err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+	got, err := repository.GetProductByID(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("GetProductByID() error = %v", err)
+	}
+	if got.Description != "updated" || got.Price != product.Price {
+		t.Errorf("UpdateProduct() = %s at %v, want updated at %v", got.Description, got.Price, product.Price)
+	}
+
+	if err := repository.DeleteProduct(ctx, product.ID); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+	if _, err := repository.GetProductByID(ctx, product.ID); err == nil {
+		t.Errorf("GetProductByID() found a deleted product")
+	}
+	if _, err := repository.RestoreProduct(ctx, product.ID); err != nil {
+		t.Fatalf("RestoreProduct() error = %v", err)
+	}
+	if _, err := repository.GetProductByID(ctx, product.ID); err != nil {
+		t.Errorf("GetProductByID() error = %v after restoring the product", err)
+	}
+	if _, err := repository.RestoreProduct(ctx, product.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("RestoreProduct() of a product that is not deleted error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+}
+
+func testStockAndSales(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	user := registerUser(t, repository, phoneNumber())
+	product := addProduct(t, repository, *user.ID, 10, 5000)
+
+	_, err := repository.AddStockReceipt(ctx, &gorm.StockReceipt{
+		Base:      gorm.Base{CreatedBy: user.ID},
+		Active:    true,
+		ProductID: product.ID,
+		Quantity:  money.DecimalFromInt(10),
+		UnitCost:  money.New(7000, money.DefaultCurrency),
+		Supplier:  "Contract supplier",
+	})
+	if err != nil {
+		t.Fatalf("AddStockReceipt() error = %v", err)
+	}
+	got, err := repository.GetProductByID(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("GetProductByID() error = %v", err)
+	}
+	if got.Quantity.Cmp(money.DecimalFromInt(20)) != 0 || got.CostPrice.Amount != 6000 {
+		t.Errorf("AddStockReceipt() left %s in stock at %d, want 20 at 6000", got.Quantity, got.CostPrice.Amount)
+	}
+
+	// the receipt is used up first and the rest is valued at the average cost price
+	sale := addSale(t, repository, &gorm.Sale{
+		Base:      gorm.Base{CreatedBy: user.ID},
+		ProductID: product.ID,
+		Quantity:  money.DecimalFromInt(15),
+		Price:     money.New(10000, money.DefaultCurrency),
+	})
+	if sale.CostOfGoods.Amount != 100000 {
+		t.Errorf("AddSaleRecord() cost of goods = %d, want 100000", sale.CostOfGoods.Amount)
+	}
+
+	unknown := &gorm.Sale{Base: gorm.Base{CreatedBy: user.ID}, Active: true, ProductID: uuid.New().String(), Quantity: money.DecimalFromInt(1), Unit: string(enums.UnitSingle)}
+	if _, err := repository.AddSaleRecord(ctx, unknown, enums.CostingMethodFIFO); err == nil {
+		t.Errorf("AddSaleRecord() sold a product that does not exist")
+	}
+
+	invoiced := addSale(t, repository, &gorm.Sale{
+		Base:       gorm.Base{CreatedBy: user.ID},
+		ProductID:  product.ID,
+		Quantity:   money.DecimalFromInt(1),
+		Price:      money.New(10000, money.DefaultCurrency),
+		TaxInvoice: &gorm.TaxInvoice{},
+	})
+	saved, err := repository.GetSaleByID(ctx, invoiced.ID)
+	if err != nil {
+		t.Fatalf("GetSaleByID() error = %v", err)
+	}
+	if saved.TaxInvoice == nil || saved.TaxInvoice.Status != enums.TaxInvoiceStatusPending {
+		t.Errorf("GetSaleByID() did not return a pending tax invoice")
+	}
+	if err := repository.DeleteSale(ctx, invoiced.ID); !errors.Is(err, gorm.ErrSaleReported) {
+		t.Errorf("DeleteSale() of a sale with a tax invoice error = %v, want %v", err, gorm.ErrSaleReported)
+	}
+
+	if err := repository.DeleteSale(ctx, sale.ID); err != nil {
+		t.Fatalf("DeleteSale() error = %v", err)
+	}
+	if _, err := repository.GetSaleByID(ctx, sale.ID); err == nil {
+		t.Errorf("GetSaleByID() found a deleted sale")
+	}
+	got, err = repository.GetProductByID(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("GetProductByID() error = %v", err)
+	}
+	if got.Quantity.Cmp(money.DecimalFromInt(19)) != 0 {
+		t.Errorf("DeleteSale() left %s in stock, want 19", got.Quantity)
+	}
+
+	if _, err := repository.RestoreSale(ctx, sale.ID); err != nil {
+		t.Fatalf("RestoreSale() error = %v", err)
+	}
+	got, err = repository.GetProductByID(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("GetProductByID() error = %v", err)
+	}
+	if got.Quantity.Cmp(money.DecimalFromInt(4)) != 0 {
+		t.Errorf("RestoreSale() left %s in stock, want 4", got.Quantity)
+	}
+}
+
+func testPagination(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	user := registerUser(t, repository, phoneNumber())
+
+	// products with the same price must keep their order from page to page
+	name := "Paged product " + unique()
+	prices := []int64{300, 100, 200, 200, 200}
+	var want []string
+	for _, price := range prices {
+		product, err := repository.AddProduct(ctx, &gorm.Product{
+			Base:     gorm.Base{CreatedBy: user.ID},
+			Active:   true,
+			Name:     name,
+			Category: string(enums.CategoryCereals),
+			Unit:     string(enums.UnitSingle),
+			Price:    money.New(price, money.DefaultCurrency),
+		})
+		if err != nil {
+			t.Fatalf("AddProduct() error = %v", err)
+		}
+		want = append(want, product.ID)
+	}
+
+	filter := &dto.ProductFilter{SearchTerm: &name}
+	sortBy := dto.ProductSort{Field: enums.ProductSortFieldPrice, Direction: enums.SortDirectionDesc}
+	var got []*gorm.Product
+	var after *pagination.Cursor
+	for page := 0; page < len(prices); page++ {
+		products, err := repository.ListProducts(ctx, filter, sortBy, after, 2)
+		if err != nil {
+			t.Fatalf("ListProducts() error = %v", err)
+		}
+		if len(products) == 0 {
+			break
+		}
+		got = append(got, products...)
+		cursor := gorm.ProductCursor(products[len(products)-1], sortBy.Field)
+		after = &cursor
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("ListProducts() paged through %d products, want %d", len(got), len(want))
+	}
+	seen := map[string]bool{}
+	for i, product := range got {
+		if seen[product.ID] {
+			t.Errorf("ListProducts() returned product %s twice", product.ID)
+		}
+		seen[product.ID] = true
+		if i > 0 && product.Price.Amount > got[i-1].Price.Amount {
+			t.Errorf("ListProducts() returned %v after %v, want the highest price first", product.Price, got[i-1].Price)
+		}
+	}
+
+	invalid := &pagination.Cursor{Value: "not a price", ID: want[0]}
+	if _, err := repository.ListProducts(ctx, filter, sortBy, invalid, 2); domain.ErrorCodeOf(err) != domain.Validation {
+		t.Errorf("ListProducts() with an invalid cursor error = %v, want a validation error", err)
+	}
+
+	minPrice := money.New(200, money.DefaultCurrency)
+	filter.MinPrice = &minPrice
+	products, err := repository.ListProducts(ctx, filter, sortBy, nil, 10)
+	if err != nil {
+		t.Fatalf("ListProducts() error = %v", err)
+	}
+	if len(products) != 4 {
+		t.Errorf("ListProducts() found %d products priced from 200, want 4", len(products))
+	}
+
+	product := addProduct(t, repository, *user.ID, 10, 5000)
+	for i := 0; i < 3; i++ {
+		addSale(t, repository, &gorm.Sale{Base: gorm.Base{CreatedBy: user.ID}, ProductID: product.ID, Quantity: money.DecimalFromInt(1), Price: product.Price, PaymentMethod: enums.PaymentMethodCash})
+	}
+
+	saleFilter := &dto.SaleFilter{ProductID: &product.ID}
+	first, err := repository.ListSales(ctx, saleFilter, enums.SortDirectionDesc, nil, 2)
+	if err != nil {
+		t.Fatalf("ListSales() error = %v", err)
+	}
+	cursor := gorm.SaleCursor(first[len(first)-1])
+	rest, err := repository.ListSales(ctx, saleFilter, enums.SortDirectionDesc, &cursor, 2)
+	if err != nil {
+		t.Fatalf("ListSales() error = %v", err)
+	}
+	if len(first) != 2 || len(rest) != 1 || rest[0].ID == first[0].ID || rest[0].ID == first[1].ID {
+		t.Errorf("ListSales() paged through %d and %d sales, want 2 and then the third", len(first), len(rest))
+	}
+}
+
+func testBatchLookups(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	cashier := registerUser(t, repository, phoneNumber())
+	other := registerUser(t, repository, phoneNumber())
+	ids := []string{*cashier.ID, *other.ID, uuid.New().String()}
+
+	users, err := repository.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		t.Fatalf("GetUsersByIDs() error = %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("GetUsersByIDs() found %d users, want 2", len(users))
+	}
+	contacts, err := repository.GetContactsByUserIDs(ctx, ids)
+	if err != nil {
+		t.Fatalf("GetContactsByUserIDs() error = %v", err)
+	}
+	if len(contacts) != 2 {
+		t.Errorf("GetContactsByUserIDs() found %d contacts, want 2", len(contacts))
+	}
+
+	// past sales still name the products that have since been deleted
+	product := addProduct(t, repository, *cashier.ID, 10, 5000)
+	deleted := addProduct(t, repository, *cashier.ID, 10, 5000)
+	if err := repository.DeleteProduct(ctx, deleted.ID); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+	products, err := repository.GetProductsByIDs(ctx, []string{product.ID, deleted.ID})
+	if err != nil {
+		t.Fatalf("GetProductsByIDs() error = %v", err)
+	}
+	if len(products) != 2 {
+		t.Errorf("GetProductsByIDs() found %d products, want 2 including the deleted one", len(products))
+	}
+
+	shift, err := repository.OpenShift(ctx, &gorm.Shift{Base: gorm.Base{CreatedBy: cashier.ID}, Active: true, CashierID: *cashier.ID})
+	if err != nil {
+		t.Fatalf("OpenShift() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		addSale(t, repository, &gorm.Sale{Base: gorm.Base{CreatedBy: cashier.ID}, ProductID: product.ID, Quantity: money.DecimalFromInt(1), Price: product.Price, PaymentMethod: enums.PaymentMethodCash, ShiftID: &shift.ID})
+	}
+	sales, err := repository.GetSalesByShiftIDs(ctx, []string{shift.ID})
+	if err != nil {
+		t.Fatalf("GetSalesByShiftIDs() error = %v", err)
+	}
+	if len(sales) != 2 || *sales[0].ShiftID != shift.ID {
+		t.Errorf("GetSalesByShiftIDs() found %d sales, want the 2 sales of the shift", len(sales))
+	}
+}
+
+// testMissingRecords checks that lookups matching nothing return gorm's not found error, which clients are told about
+func testMissingRecords(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	id := uuid.New().String()
+
+	lookups := map[string]func() error{
+		"user": func() error {
+			_, err := repository.GetUserProfileByUserID(ctx, &id)
+			return err
+		},
+		"product": func() error {
+			_, err := repository.GetProductByID(ctx, id)
+			return err
+		},
+		"sale": func() error {
+			_, err := repository.GetSaleByID(ctx, id)
+			return err
+		},
+		"shift": func() error {
+			_, err := repository.GetShiftByID(ctx, id)
+			return err
+		},
+	}
+	for name, lookup := range lookups {
+		if err := lookup(); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("looking up a missing %s returned %v, want %v", name, err, gorm.ErrRecordNotFound)
+		}
+	}
+}
+
+func testShifts(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	user := registerUser(t, repository, phoneNumber())
+	product := addProduct(t, repository, *user.ID, 10, 5000)
+
+	shift, err := repository.OpenShift(ctx, &gorm.Shift{Base: gorm.Base{CreatedBy: user.ID}, Active: true, CashierID: *user.ID, OpeningFloat: money.New(100000, money.DefaultCurrency)})
+	if err != nil {
+		t.Fatalf("OpenShift() error = %v", err)
+	}
+	if _, err := repository.OpenShift(ctx, &gorm.Shift{Base: gorm.Base{CreatedBy: user.ID}, Active: true, CashierID: *user.ID}); err == nil {
+		t.Errorf("OpenShift() opened a second shift for a cashier")
+	}
+	open, err := repository.GetOpenShift(ctx, *user.ID)
+	if err != nil {
+		t.Fatalf("GetOpenShift() error = %v", err)
+	}
+	if open.ID != shift.ID {
+		t.Errorf("GetOpenShift() = %s, want %s", open.ID, shift.ID)
+	}
+
+	_, err = repository.AddCashMovement(ctx, &gorm.CashMovement{Base: gorm.Base{CreatedBy: user.ID}, Active: true, ShiftID: shift.ID, Type: enums.CashMovementTypeCashIn, Amount: money.New(50000, money.DefaultCurrency), Reason: "change"})
+	if err != nil {
+		t.Fatalf("AddCashMovement() error = %v", err)
+	}
+	addSale(t, repository, &gorm.Sale{
+		Base:          gorm.Base{CreatedBy: user.ID},
+		ProductID:     product.ID,
+		Quantity:      money.DecimalFromInt(1),
+		Price:         money.New(10000, money.DefaultCurrency),
+		PaymentMethod: enums.PaymentMethodCash,
+		ShiftID:       &shift.ID,
+	})
+
+	expected, err := repository.GetShiftExpectedCash(ctx, shift.ID)
+	if err != nil {
+		t.Fatalf("GetShiftExpectedCash() error = %v", err)
+	}
+	if expected.Amount != 160000 {
+		t.Errorf("GetShiftExpectedCash() = %d, want 160000", expected.Amount)
+	}
+	tenders, err := repository.GetShiftTenders(ctx, shift.ID)
+	if err != nil {
+		t.Fatalf("GetShiftTenders() error = %v", err)
+	}
+	if len(tenders) != 1 || tenders[0].PaymentMethod != enums.PaymentMethodCash || tenders[0].Amount.Amount != 10000 {
+		t.Errorf("GetShiftTenders() = %d tenders, want 10000 in cash", len(tenders))
+	}
+
+	closed, err := repository.CloseShift(ctx, shift.ID, money.New(155000, money.DefaultCurrency))
+	if err != nil {
+		t.Fatalf("CloseShift() error = %v", err)
+	}
+	if closed.Status != enums.ShiftStatusClosed || closed.OverShort.Amount != -5000 {
+		t.Errorf("CloseShift() = %s with %d over, want CLOSED with -5000 over", closed.Status, closed.OverShort.Amount)
+	}
+
+	late := &gorm.Sale{Base: gorm.Base{CreatedBy: user.ID}, Active: true, ProductID: product.ID, Quantity: money.DecimalFromInt(1), Unit: string(enums.UnitSingle), ShiftID: &shift.ID}
+	if _, err := repository.AddSaleRecord(ctx, late, enums.CostingMethodFIFO); err == nil {
+		t.Errorf("AddSaleRecord() made a sale in a closed shift")
+	}
+	if _, err := repository.AddCashMovement(ctx, &gorm.CashMovement{Base: gorm.Base{CreatedBy: user.ID}, Active: true, ShiftID: shift.ID, Type: enums.CashMovementTypeDrop}); err == nil {
+		t.Errorf("AddCashMovement() moved cash in a closed shift")
+	}
+	if _, err := repository.CloseShift(ctx, shift.ID, money.New(0, money.DefaultCurrency)); err == nil {
+		t.Errorf("CloseShift() closed a shift twice")
+	}
+}
+
+func testIdempotencyKeys(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	key := &gorm.IdempotencyKey{
+		Scope:       "contract",
+		Key:         unique(),
+		RequestHash: "hash",
+		LockedUntil: now.Add(time.Minute),
+		ExpiresAt:   now.Add(time.Hour),
+	}
+
+	if _, reserved, err := repository.ReserveIdempotencyKey(ctx, key); err != nil || !reserved {
+		t.Fatalf("ReserveIdempotencyKey() reserved = %v, error = %v", reserved, err)
+	}
+	if _, reserved, err := repository.ReserveIdempotencyKey(ctx, key); err != nil || reserved {
+		t.Errorf("ReserveIdempotencyKey() reserved = %v, error = %v for a key in use", reserved, err)
+	}
+
+	if err := repository.ReleaseIdempotencyKey(ctx, key.Scope, key.Key); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey() error = %v", err)
+	}
+	if _, reserved, err := repository.ReserveIdempotencyKey(ctx, key); err != nil || !reserved {
+		t.Fatalf("ReserveIdempotencyKey() reserved = %v, error = %v for a released key", reserved, err)
+	}
+
+	status := 200
+	contentType := "application/json"
+	completedAt := time.Now().UTC()
+	key.CompletedAt = &completedAt
+	key.ResponseStatus = &status
+	key.ResponseContentType = &contentType
+	key.ResponseBody = []byte(`{"ok":true}`)
+	if err := repository.CompleteIdempotencyKey(ctx, key); err != nil {
+		t.Fatalf("CompleteIdempotencyKey() error = %v", err)
+	}
+
+	stored, reserved, err := repository.ReserveIdempotencyKey(ctx, key)
+	if err != nil || reserved {
+		t.Fatalf("ReserveIdempotencyKey() reserved = %v, error = %v for a completed key", reserved, err)
+	}
+	if stored.ResponseStatus == nil || *stored.ResponseStatus != status || string(stored.ResponseBody) != `{"ok":true}` {
+		t.Errorf("ReserveIdempotencyKey() did not return the stored response")
+	}
+}
+
+func testSync(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	user := registerUser(t, repository, phoneNumber())
+
+	device := &gorm.SyncDevice{ID: "device-" + unique(), UserID: *user.ID}
+	if err := repository.SaveSyncDevice(ctx, device); err != nil {
+		t.Fatalf("SaveSyncDevice() error = %v", err)
+	}
+	cursor := "cursor"
+	if err := repository.SaveSyncDevice(ctx, &gorm.SyncDevice{ID: device.ID, UserID: *user.ID, Cursor: &cursor}); err != nil {
+		t.Fatalf("SaveSyncDevice() error = %v", err)
+	}
+	saved, err := repository.GetSyncDevice(ctx, device.ID)
+	if err != nil {
+		t.Fatalf("GetSyncDevice() error = %v", err)
+	}
+	if saved.Cursor == nil || *saved.Cursor != cursor {
+		t.Errorf("SaveSyncDevice() did not update the device's cursor")
+	}
+
+	operation := &gorm.SyncOperation{
+		ID:         "operation-" + unique(),
+		DeviceID:   device.ID,
+		Type:       enums.SyncOperationTypeSale,
+		Status:     enums.SyncOperationStatusApplied,
+		OccurredAt: time.Now().UTC(),
+	}
+	if _, err := repository.SaveSyncOperation(ctx, operation); err != nil {
+		t.Fatalf("SaveSyncOperation() error = %v", err)
+	}
+	replayed, err := repository.SaveSyncOperation(ctx, &gorm.SyncOperation{ID: operation.ID, DeviceID: device.ID, Type: enums.SyncOperationTypeSale, Status: enums.SyncOperationStatusRejected})
+	if err != nil {
+		t.Fatalf("SaveSyncOperation() error = %v", err)
+	}
+	if replayed.Status != enums.SyncOperationStatusApplied {
+		t.Errorf("SaveSyncOperation() = %s, want the first outcome %s", replayed.Status, enums.SyncOperationStatusApplied)
+	}
+	if _, err := repository.GetSyncOperation(ctx, operation.ID); err != nil {
+		t.Errorf("GetSyncOperation() error = %v", err)
+	}
+
+	// a till that has pulled a product learns that it was deleted and then restored
+	product := addProduct(t, repository, *user.ID, 10, 5000)
+	pulled := pullProduct(t, repository, time.Time{}, "", product.ID)
+	if pulled == nil || pulled.DeletedAt.Valid {
+		t.Fatalf("GetProductChanges() did not return the new product")
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := repository.DeleteProduct(ctx, product.ID); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+	deleted := pullProduct(t, repository, pulled.UpdatedAt, pulled.ID, product.ID)
+	if deleted == nil || !deleted.DeletedAt.Valid {
+		t.Fatalf("GetProductChanges() did not return the deleted product after the till's cursor")
+	}
+
+	time.Sleep(time.Millisecond)
+	if _, err := repository.RestoreProduct(ctx, product.ID); err != nil {
+		t.Fatalf("RestoreProduct() error = %v", err)
+	}
+	restored := pullProduct(t, repository, deleted.UpdatedAt, deleted.ID, product.ID)
+	if restored == nil || restored.DeletedAt.Valid {
+		t.Errorf("GetProductChanges() did not return the restored product after the till's cursor")
+	}
+}
+
+// pullProduct returns a product if it is among the changes after a cursor
+func pullProduct(t *testing.T, repository gorm.Repository, since time.Time, afterID string, productID string) *gorm.Product {
+	t.Helper()
+
+	changes, err := repository.GetProductChanges(context.Background(), since, afterID, 100000)
+	if err != nil {
+		t.Fatalf("GetProductChanges() error = %v", err)
+	}
+	for _, change := range changes {
+		if change.ID == productID {
+			return change
+		}
+	}
+
+	return nil
+}
+
+func testReports(t *testing.T, repository gorm.Repository) {
+	ctx := context.Background()
+	location, err := time.LoadLocation("Africa/Nairobi")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	user := registerUser(t, repository, phoneNumber())
+	product := addProduct(t, repository, *user.ID, 10, 5000)
+
+	// the sales are made on Monday 5th February 2001, far from the sales any other test makes
+	from := time.Date(2001, 2, 5, 0, 0, 0, 0, location)
+	to := from.AddDate(0, 0, 1)
+	for _, hour := range []int{9, 9, 14} {
+		addSale(t, repository, &gorm.Sale{
+			Base:          gorm.Base{CreatedBy: user.ID, CreatedAt: from.Add(time.Duration(hour) * time.Hour)},
+			ProductID:     product.ID,
+			Quantity:      money.DecimalFromInt(2),
+			Price:         money.New(10000, money.DefaultCurrency),
+			Discount:      money.New(1000, money.DefaultCurrency),
+			PaymentMethod: enums.PaymentMethodMpesa,
+		})
+	}
+
+	summaries, err := repository.GetSalesSummary(ctx, from, to, enums.ReportIntervalDay, location)
+	if err != nil {
+		t.Fatalf("GetSalesSummary() error = %v", err)
+	}
+	if len(summaries) != 1 || !summaries[0].Period.Equal(from) || summaries[0].Revenue.Amount != 57000 || summaries[0].Transactions != 3 {
+		t.Errorf("GetSalesSummary() = %d periods, want one day with 57000 from 3 sales", len(summaries))
+	}
+
+	breakdown, err := repository.GetSalesBreakdown(ctx, from, to, enums.SalesGroupingPaymentMethod)
+	if err != nil {
+		t.Fatalf("GetSalesBreakdown() error = %v", err)
+	}
+	if len(breakdown) != 1 || breakdown[0].Key != string(enums.PaymentMethodMpesa) || breakdown[0].Quantity.Cmp(money.DecimalFromInt(6)) != 0 {
+		t.Errorf("GetSalesBreakdown() = %d groups, want 6 paid by MPESA", len(breakdown))
+	}
+
+	top, err := repository.GetTopProducts(ctx, from, to, enums.ProductRankingRevenue, 5)
+	if err != nil {
+		t.Fatalf("GetTopProducts() error = %v", err)
+	}
+	if len(top) != 1 || top[0].ProductID != product.ID || top[0].Name != product.Name || top[0].Revenue.Amount != 57000 {
+		t.Errorf("GetTopProducts() = %d products, want the product sold", len(top))
+	}
+
+	hourly, err := repository.GetHourlySales(ctx, from, to, location)
+	if err != nil {
+		t.Fatalf("GetHourlySales() error = %v", err)
+	}
+	if len(hourly) != 2 || hourly[0].DayOfWeek != 1 || hourly[0].Hour != 9 || hourly[0].Transactions != 2 || hourly[1].Hour != 14 {
+		t.Errorf("GetHourlySales() = %d hours, want 2 sales at 9 and 1 at 14 on Monday", len(hourly))
+	}
+
+	profit, err := repository.GetProfitReport(ctx, from, to, enums.ProfitGroupingProduct, location)
+	if err != nil {
+		t.Fatalf("GetProfitReport() error = %v", err)
+	}
+	if len(profit) != 1 || profit[0].Revenue.Amount != 57000 || profit[0].CostOfGoods.Amount != 30000 {
+		t.Errorf("GetProfitReport() = %d products, want 57000 revenue at a cost of 30000", len(profit))
+	}
+
+	if _, err := repository.GetSalesSummary(ctx, from, to, "invalid", location); err == nil {
+		t.Errorf("GetSalesSummary() accepted an invalid interval")
+	}
+}
@@ -14,10 +14,11 @@ type DbServiceImpl struct {
 	create gorm.Create
 	query  gorm.Query
 	update gorm.Update
+	delete gorm.Delete
 }
 
 // NewDBService creates a new database service
-func NewDBService(c gorm.Create, q gorm.Query, u gorm.Update) *DbServiceImpl {
+func NewDBService(c gorm.Create, q gorm.Query, u gorm.Update, d gorm.Delete) *DbServiceImpl {
 	environment := helpers.MustGetEnvVar("REPOSITORY")
 
 	switch environment {
@@ -29,6 +30,7 @@ func NewDBService(c gorm.Create, q gorm.Query, u gorm.Update) *DbServiceImpl {
 			create: c,
 			query:  q,
 			update: u,
+			delete: d,
 		}
 
 	default:
@@ -0,0 +1,328 @@
+package gorm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// auditBeforeKey stores the rows an update or delete is about to change on the statement
+const auditBeforeKey = "smartduka:audit_before"
+
+// redactedValue replaces secrets in the audit log. The log shows that they changed but not what to
+const redactedValue = "[REDACTED]"
+
+// auditedTables are the tables whose changes are recorded in the audit log
+var auditedTables = map[string]bool{
+	"smartduka_user":          true,
+	"smartduka_contact":       true,
+	"smartduka_identifier":    true,
+	"smartduka_user_pin":      true,
+	"smartduka_product":       true,
+	"smartduka_sale":          true,
+	"smartduka_stock_receipt": true,
+	"smartduka_shift":         true,
+	"smartduka_cash_movement": true,
+}
+
+// redactedColumns hold secrets that are never copied into the audit log
+var redactedColumns = map[string]bool{
+	"hashed_pin": true,
+	"salt":       true,
+}
+
+// unauditedColumns are kept by every table and are recorded on the audit log entry itself
+var unauditedColumns = map[string]bool{
+	"created_at": true,
+	"created_by": true,
+	"updated_at": true,
+	"updated_by": true,
+}
+
+// auditChange is the value of a column before and after a change
+type auditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// registerCallbacks records who creates and changes each record and keeps the audit log
+func registerCallbacks(db *gorm.DB, ext extension.Extension) error {
+	actor := func(ctx context.Context) *string {
+		userID, err := ext.GetLoggedInUserUID(ctx)
+		if err != nil || userID == "" {
+			return nil
+		}
+		return &userID
+	}
+
+	if err := db.Callback().Create().Before("gorm:create").Register("smartduka:created_by", setCreatedBy(actor)); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("gorm:create").Register("smartduka:audit_create", auditCreate(actor)); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("smartduka:updated_by", setUpdatedBy(actor)); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("smartduka:audit_before_update", auditBefore); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("smartduka:audit_update", auditChanges(actor, enums.AuditActionUpdate)); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("smartduka:audit_before_delete", auditBefore); err != nil {
+		return err
+	}
+
+	return db.Callback().Delete().After("gorm:delete").Register("smartduka:audit_delete", auditChanges(actor, enums.AuditActionDelete))
+}
+
+// setCreatedBy records the logged in user as the creator of new records that do not name one
+func setCreatedBy(actor func(ctx context.Context) *string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		if db.Error != nil || stmt.Schema == nil {
+			return
+		}
+
+		userID := actor(stmt.Context)
+		for _, name := range []string{"CreatedBy", "UpdatedBy"} {
+			field := stmt.Schema.LookUpField(name)
+			if field == nil {
+				continue
+			}
+
+			eachRow(stmt, func(row reflect.Value) {
+				value, isZero := field.ValueOf(stmt.Context, row)
+				if current, ok := value.(*string); isZero || (ok && *current == "") {
+					db.AddError(field.Set(stmt.Context, row, userID))
+				}
+			})
+		}
+	}
+}
+
+// setUpdatedBy records the logged in user as the last to change the records being updated
+func setUpdatedBy(actor func(ctx context.Context) *string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		if db.Error != nil || stmt.Schema == nil || stmt.Schema.LookUpField("UpdatedBy") == nil {
+			return
+		}
+
+		if userID := actor(stmt.Context); userID != nil {
+			stmt.SetColumn("updated_by", userID, true)
+		}
+	}
+}
+
+// auditCreate records the values of new records
+func auditCreate(actor func(ctx context.Context) *string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		if db.Error != nil || db.RowsAffected == 0 || !isAudited(stmt) {
+			return
+		}
+
+		logs := []*AuditLog{}
+		eachRow(stmt, func(row reflect.Value) {
+			changes := map[string]*auditChange{}
+			for _, field := range auditedFields(stmt.Schema) {
+				value, isZero := field.ValueOf(stmt.Context, row)
+				if isZero {
+					continue
+				}
+				changes[field.DBName] = &auditChange{To: auditValue(field, value)}
+			}
+
+			logs = append(logs, newAuditLog(stmt, row, enums.AuditActionCreate, changes, actor(stmt.Context)))
+		})
+
+		saveAuditLogs(db, logs)
+	}
+}
+
+// auditBefore keeps a copy of the rows an update or delete is about to change.
+// The rows are locked so that the copy is still accurate when the change runs
+func auditBefore(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || !isAudited(stmt) {
+		return
+	}
+
+	query := db.Session(&gorm.Session{NewDB: true}).Clauses(clause.Locking{Strength: "UPDATE"})
+	if stmt.Unscoped {
+		query = query.Unscoped()
+	}
+
+	conditions := 0
+	if where, ok := stmt.Clauses["WHERE"]; ok && where.Expression != nil {
+		query = query.Clauses(where.Expression)
+		conditions++
+	}
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		for _, field := range stmt.Schema.PrimaryFields {
+			if value, isZero := field.ValueOf(stmt.Context, stmt.ReflectValue); !isZero {
+				query = query.Where(clause.Eq{Column: clause.Column{Table: stmt.Table, Name: field.DBName}, Value: value})
+				conditions++
+			}
+		}
+	}
+	if conditions == 0 {
+		// gorm refuses updates and deletes without conditions
+		return
+	}
+
+	rows := reflect.New(reflect.SliceOf(reflect.PointerTo(stmt.Schema.ModelType)))
+	if err := query.Table(stmt.Table).Find(rows.Interface()).Error; err != nil {
+		db.AddError(fmt.Errorf("failed to read records for the audit log: %v", err))
+		return
+	}
+
+	db.InstanceSet(auditBeforeKey, rows.Elem())
+}
+
+// auditChanges records the columns an update or delete changed with their old and new values.
+// A soft delete shows up as a change to deleted_at while a purged record has no new values
+func auditChanges(actor func(ctx context.Context) *string, action enums.AuditAction) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		value, ok := db.InstanceGet(auditBeforeKey)
+		if !ok || db.Error != nil || db.RowsAffected == 0 {
+			return
+		}
+		before := value.(reflect.Value)
+
+		fields := auditedFields(stmt.Schema)
+		logs := []*AuditLog{}
+		for i := 0; i < before.Len(); i++ {
+			old := before.Index(i).Elem()
+
+			// the row is found again by its primary key since the update may have changed the columns it was matched on
+			current := reflect.New(stmt.Schema.ModelType)
+			query := db.Session(&gorm.Session{NewDB: true}).Unscoped().Table(stmt.Table)
+			for _, field := range stmt.Schema.PrimaryFields {
+				key, _ := field.ValueOf(stmt.Context, old)
+				query = query.Where(clause.Eq{Column: clause.Column{Table: stmt.Table, Name: field.DBName}, Value: key})
+			}
+			err := query.First(current.Interface()).Error
+			purged := errors.Is(err, gorm.ErrRecordNotFound) && action == enums.AuditActionDelete
+			if err != nil && !purged {
+				db.AddError(fmt.Errorf("failed to read records for the audit log: %v", err))
+				return
+			}
+
+			changes := map[string]*auditChange{}
+			for _, field := range fields {
+				from, _ := field.ValueOf(stmt.Context, old)
+				if purged {
+					changes[field.DBName] = &auditChange{From: auditValue(field, from)}
+					continue
+				}
+
+				to, _ := field.ValueOf(stmt.Context, current.Elem())
+				if sameValue(from, to) {
+					continue
+				}
+				changes[field.DBName] = &auditChange{From: auditValue(field, from), To: auditValue(field, to)}
+			}
+			if len(changes) == 0 {
+				continue
+			}
+
+			logs = append(logs, newAuditLog(stmt, old, action, changes, actor(stmt.Context)))
+		}
+
+		saveAuditLogs(db, logs)
+	}
+}
+
+// saveAuditLogs writes the audit log in the transaction of the change so that one is never kept without the other
+func saveAuditLogs(db *gorm.DB, logs []*AuditLog) {
+	if len(logs) == 0 {
+		return
+	}
+
+	if err := db.Session(&gorm.Session{NewDB: true}).Create(&logs).Error; err != nil {
+		db.AddError(fmt.Errorf("failed to record audit log: %v", err))
+	}
+}
+
+func newAuditLog(stmt *gorm.Statement, row reflect.Value, action enums.AuditAction, changes map[string]*auditChange, actorID *string) *AuditLog {
+	keys := []string{}
+	for _, field := range stmt.Schema.PrimaryFields {
+		value, _ := field.ValueOf(stmt.Context, row)
+		keys = append(keys, fmt.Sprint(reflect.Indirect(reflect.ValueOf(value))))
+	}
+
+	// the values have already been read from or written to the database so they can always be encoded
+	encoded, _ := json.Marshal(changes)
+
+	return &AuditLog{
+		Entity:    stmt.Table,
+		EntityID:  strings.Join(keys, "/"),
+		Action:    action,
+		Changes:   string(encoded),
+		ActorID:   actorID,
+		IPAddress: contextString(stmt.Context, common.ClientIPContextKey),
+		Device:    contextString(stmt.Context, common.DeviceContextKey),
+	}
+}
+
+func isAudited(stmt *gorm.Statement) bool {
+	return stmt.Schema != nil && auditedTables[stmt.Table]
+}
+
+func auditedFields(s *schema.Schema) []*schema.Field {
+	fields := []*schema.Field{}
+	for _, field := range s.Fields {
+		if field.DBName == "" || !field.Readable || unauditedColumns[field.DBName] {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func auditValue(field *schema.Field, value interface{}) interface{} {
+	if redactedColumns[field.DBName] {
+		return redactedValue
+	}
+	return value
+}
+
+func sameValue(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// eachRow calls fn with every record of a statement, which may be a single record or a batch
+func eachRow(stmt *gorm.Statement, fn func(row reflect.Value)) {
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			fn(reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		fn(stmt.ReflectValue)
+	}
+}
+
+func contextString(ctx context.Context, key common.ContextKey) *string {
+	value, ok := ctx.Value(key).(string)
+	if !ok || value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,59 @@
+package gorm_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+)
+
+func TestPGInstance_AuditLog(t *testing.T) {
+	token, err := utils.GenerateJWTToken(userID)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), common.AuthTokenContextKey, token.Token)
+	ctx = context.WithValue(ctx, common.ClientIPContextKey, "41.90.64.1")
+	ctx = context.WithValue(ctx, common.DeviceContextKey, "till-1")
+
+	if err := testingDB.UpdateProduct(ctx, &gorm.Product{ID: productID}, map[string]interface{}{"description": "Audited product"}); err != nil {
+		t.Fatalf("PGInstance.UpdateProduct() error = %v", err)
+	}
+
+	product, err := testingDB.GetProductByID(ctx, productID)
+	if err != nil {
+		t.Fatalf("PGInstance.GetProductByID() error = %v", err)
+	}
+	if product.UpdatedBy == nil || *product.UpdatedBy != userID {
+		t.Errorf("PGInstance.UpdateProduct() updated_by = %v, want %v", product.UpdatedBy, userID)
+	}
+
+	entity, entityID := "smartduka_product", productID
+	logs, err := testingDB.GetAuditLogs(ctx, &dto.AuditLogFilter{Entity: &entity, EntityID: &entityID}, 1)
+	if err != nil {
+		t.Fatalf("PGInstance.GetAuditLogs() error = %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("PGInstance.GetAuditLogs() got %d entries, want 1", len(logs))
+	}
+
+	got := logs[0]
+	if got.Action != enums.AuditActionUpdate {
+		t.Errorf("PGInstance.GetAuditLogs() action = %v, want %v", got.Action, enums.AuditActionUpdate)
+	}
+	if got.ActorID == nil || *got.ActorID != userID {
+		t.Errorf("PGInstance.GetAuditLogs() actor = %v, want %v", got.ActorID, userID)
+	}
+	if got.IPAddress == nil || *got.IPAddress != "41.90.64.1" || got.Device == nil || *got.Device != "till-1" {
+		t.Errorf("PGInstance.GetAuditLogs() expected the request's IP address and device, got %v %v", got.IPAddress, got.Device)
+	}
+	if !strings.Contains(got.Changes, `"description"`) || strings.Contains(got.Changes, `"updated_at"`) {
+		t.Errorf("PGInstance.GetAuditLogs() changes = %s, want only the description", got.Changes)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"html/template"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -25,28 +26,51 @@ var (
 	userID             = "6ecbbc80-24c8-421a-9f1a-e14e12678ee0"
 	productID          = "6ecbbc80-24c8-421a-9f1a-e14e12678ee0"
 	saleID             = "6ecbbc80-24c8-421a-9f1a-e14e12678ee0"
+	shiftID            = "6ecbbc80-24c8-421a-9f1a-e14e12678ee0"
 	testPhone          = "+254722000000"
 	testIdentifier     = "123456789"
 	testQuantity       = 10.00
 	testOTP            = "1234"
 )
 
+// fixtureTimeFormat writes times that both Postgres and SQLite read back
+const fixtureTimeFormat = "2006-01-02 15:04:05.999999-07:00"
+
 func TestMain(m *testing.M) {
 	log.Printf("Setting tests up ...")
 
-	isLocalDB := testutils.CheckIfCurrentDBIsLocal()
-	if !isLocalDB {
-		fmt.Println("Cannot run tests. The current database is not a local database.")
-		os.Exit(1)
-	}
-
-	log.Println("setting up test database")
+	// the suite runs against Postgres unless DATABASE_BACKEND=sqlite, which uses a throwaway database
+	dialect := "postgres"
+	var dir string
 	var err error
+	if os.Getenv(gorm.DatabaseBackend) == "sqlite" {
+		dialect = "sqlite"
 
-	testingDB, err = gorm.NewPGInstance()
-	if err != nil {
-		fmt.Println("failed to initialize db:", err)
-		os.Exit(1)
+		dir, err = os.MkdirTemp("", "smartduka")
+		if err != nil {
+			fmt.Println("failed to create the sqlite directory:", err)
+			os.Exit(1)
+		}
+
+		log.Println("setting up test database")
+		testingDB, err = gorm.NewSQLiteInstance(filepath.Join(dir, "test.db"))
+		if err != nil {
+			fmt.Println("failed to initialize db:", err)
+			os.Exit(1)
+		}
+	} else {
+		isLocalDB := testutils.CheckIfCurrentDBIsLocal()
+		if !isLocalDB {
+			fmt.Println("Cannot run tests. The current database is not a local database.")
+			os.Exit(1)
+		}
+
+		log.Println("setting up test database")
+		testingDB, err = gorm.NewPGInstance()
+		if err != nil {
+			fmt.Println("failed to initialize db:", err)
+			os.Exit(1)
+		}
 	}
 	db, err = testingDB.DB.DB()
 	if err != nil {
@@ -59,17 +83,20 @@ func TestMain(m *testing.M) {
 
 	fixtures, err = testfixtures.New(
 		testfixtures.Database(db),
-		testfixtures.Dialect("postgres"),
+		testfixtures.Dialect(dialect),
 		testfixtures.Template(),
 		testfixtures.TemplateData(template.FuncMap{
 			"salt":                  salt,
 			"hash":                  encryptedPin,
 			"valid_to":              time.Now().Add(500).String(),
+			"pin_valid_to":          time.Now().UTC().Add(24 * time.Hour).Format(fixtureTimeFormat),
+			"otp_valid_until":       time.Now().UTC().Add(time.Hour).Format(fixtureTimeFormat),
 			"test_user_id":          userID,
 			"test_phone":            "\"" + testPhone + "\"",
 			"test_identifier_value": "\"" + testIdentifier + "\"",
 			"test_product_id":       productID,
 			"test_sale_id":          saleID,
+			"test_shift_id":         shiftID,
 			"test_quantity_id":      testQuantity,
 			"test_otp":              "\"" + testOTP + "\"",
 		}),
@@ -80,6 +107,7 @@ func TestMain(m *testing.M) {
 			"../../../../../../fixtures/smartduka_user.yml",
 			"../../../../../../fixtures/smartduka_contact.yml",
 			"../../../../../../fixtures/smartduka_product.yml",
+			"../../../../../../fixtures/smartduka_shift.yml",
 			"../../../../../../fixtures/smartduka_sale.yml",
 			"../../../../../../fixtures/smartduka_user_pin.yml",
 			"../../../../../../fixtures/smartduka_user_otp.yml",
@@ -102,7 +130,12 @@ func TestMain(m *testing.M) {
 	}
 
 	log.Printf("Running tests ...")
-	os.Exit(m.Run())
+	code := m.Run()
+
+	if dir != "" {
+		_ = os.RemoveAll(dir)
+	}
+	os.Exit(code)
 }
 
 func prepareTestDatabase() error {
@@ -0,0 +1,11 @@
+package gorm_test
+
+import (
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/contract"
+)
+
+func TestPGInstance_Contract(t *testing.T) {
+	contract.Run(t, testingDB)
+}
@@ -2,21 +2,40 @@ package gorm
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Create holds all the database record creation methods
 type Create interface {
-	RegisterUser(ctx context.Context, user *User, contact *Contact) (*User, error)
+	RegisterUser(ctx context.Context, user *User, contact *Contact, identifier *Identifier, pin *UserPIN) (*User, error)
 	SaveOTP(ctx context.Context, otp *OTP) (*OTP, error)
 	SavePIN(ctx context.Context, pinData *UserPIN) (*UserPIN, error)
 
 	AddProduct(ctx context.Context, product *Product) (*Product, error)
-	AddSaleRecord(ctx context.Context, sale *Sale) (*Sale, error)
+	AddSaleRecord(ctx context.Context, sale *Sale, costing enums.CostingMethod) (*Sale, error)
+	AddStockReceipt(ctx context.Context, receipt *StockReceipt) (*StockReceipt, error)
+	ImportProducts(ctx context.Context, products []*Product, dryRun bool) (created int, updated int, err error)
+
+	OpenShift(ctx context.Context, shift *Shift) (*Shift, error)
+	AddCashMovement(ctx context.Context, movement *CashMovement) (*CashMovement, error)
+
+	SaveSyncOperation(ctx context.Context, operation *SyncOperation) (*SyncOperation, error)
+	SaveSyncDevice(ctx context.Context, device *SyncDevice) error
+
+	ReserveIdempotencyKey(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, bool, error)
 }
 
-// RegisterUser creates a new user record.
+// RegisterUser creates a new user record together with their contact and, when given, their identification
+// document and initial PIN. Either all of them are saved or none is.
 // The user can be a resident or a staff member
-func (db *PGInstance) RegisterUser(ctx context.Context, user *User, contact *Contact) (*User, error) {
+func (db *PGInstance) RegisterUser(ctx context.Context, user *User, contact *Contact, identifier *Identifier, pin *UserPIN) (*User, error) {
 	tx := db.DB.WithContext(ctx).Begin()
 
 	// create user
@@ -32,6 +51,22 @@ func (db *PGInstance) RegisterUser(ctx context.Context, user *User, contact *Con
 		return nil, err
 	}
 
+	if identifier != nil {
+		identifier.UserID = user.ID
+		if err := tx.Create(&identifier).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if pin != nil {
+		pin.UserID = *user.ID
+		if err := tx.Create(&pin).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return nil, err
@@ -66,11 +101,377 @@ func (db *PGInstance) AddProduct(ctx context.Context, product *Product) (*Produc
 	return product, nil
 }
 
-// AddSaleRecord adds sale record in the database
-func (db *PGInstance) AddSaleRecord(ctx context.Context, sale *Sale) (*Sale, error) {
-	if err := db.DB.WithContext(ctx).Create(&sale).Error; err != nil {
+// AddSaleRecord adds sale record in the database.
+// The cost of the goods sold is worked out using the shop's costing method and the product's stock is reduced
+func (db *PGInstance) AddSaleRecord(ctx context.Context, sale *Sale, costing enums.CostingMethod) (*Sale, error) {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	if sale.ShiftID != nil {
+		if _, err := lockOpenShift(tx, *sale.ShiftID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	var product Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&Product{ID: sale.ProductID}).First(&product).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to get product %s: %w", sale.ProductID, err)
+	}
+
+	var cost money.Money
+	var err error
+	switch costing {
+	case enums.CostingMethodWeightedAverage:
+		cost = product.CostPrice.Mul(sale.Quantity)
+	case enums.CostingMethodFIFO:
+		if sale.Quantity.IsNegative() {
+			// returned goods go back into stock at the current cost price
+			cost = product.CostPrice.Mul(sale.Quantity)
+			break
+		}
+		cost, err = consumeStockReceipts(tx, &product, sale.Quantity)
+	default:
+		err = fmt.Errorf("invalid costing method: %s", costing)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	sale.CostOfGoods = cost
+
+	remaining := product.Quantity.Sub(sale.Quantity)
+	if err := tx.Model(&product).Updates(map[string]interface{}{
+		"quantity": remaining,
+		"in_stock": !remaining.IsNegative() && !remaining.IsZero(),
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to update product stock: %v", err)
+	}
+
+	if err := tx.Create(sale).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
 	return sale, nil
 }
+
+// consumeStockReceipts takes the quantity sold from the oldest stock receipts first and returns what those units cost.
+// Any units sold beyond what has been received are valued at the product's cost price
+func consumeStockReceipts(tx *gorm.DB, product *Product, quantity money.Decimal) (money.Money, error) {
+	var receipts []*StockReceipt
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND active = ? AND remaining_quantity > 0", product.ID, true).
+		Order("created_at, id").Find(&receipts).Error; err != nil {
+		return money.Money{}, fmt.Errorf("failed to get stock receipts: %v", err)
+	}
+
+	cost := money.Zero(product.Currency)
+	outstanding := quantity
+	for _, receipt := range receipts {
+		if outstanding.IsZero() || outstanding.IsNegative() {
+			break
+		}
+
+		taken := receipt.RemainingQuantity
+		if outstanding.Cmp(taken) < 0 {
+			taken = outstanding
+		}
+
+		var err error
+		cost, err = cost.Add(receipt.UnitCost.Mul(taken))
+		if err != nil {
+			return money.Money{}, err
+		}
+
+		if err := tx.Model(receipt).Update("remaining_quantity", receipt.RemainingQuantity.Sub(taken)).Error; err != nil {
+			return money.Money{}, fmt.Errorf("failed to update stock receipt: %v", err)
+		}
+		outstanding = outstanding.Sub(taken)
+	}
+
+	if !outstanding.IsZero() && !outstanding.IsNegative() {
+		return cost.Add(product.CostPrice.Mul(outstanding))
+	}
+
+	return cost, nil
+}
+
+// AddStockReceipt records a delivery of stock.
+// The product's stock is increased and its cost price becomes the weighted average cost of the units in stock
+func (db *PGInstance) AddStockReceipt(ctx context.Context, receipt *StockReceipt) (*StockReceipt, error) {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	var product Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&Product{ID: receipt.ProductID}).First(&product).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to get product %s: %w", receipt.ProductID, err)
+	}
+
+	costPrice := receipt.UnitCost
+	stock := product.Quantity.Add(receipt.Quantity)
+	if !product.Quantity.IsNegative() && !product.Quantity.IsZero() {
+		value, err := product.CostPrice.Mul(product.Quantity).Add(receipt.UnitCost.Mul(receipt.Quantity))
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		costPrice, err = value.Div(stock)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Model(&product).Updates(map[string]interface{}{
+		"quantity":   stock,
+		"cost_price": costPrice,
+		"in_stock":   !stock.IsNegative() && !stock.IsZero(),
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to update product stock: %v", err)
+	}
+
+	receipt.RemainingQuantity = receipt.Quantity
+	if err := tx.Create(receipt).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// ErrShiftClosed is returned when a closed shift or one of its sales is changed
+var ErrShiftClosed = errors.New("the shift has been closed")
+
+// lockOpenShift fetches a shift and locks it until the end of the transaction.
+// It fails if the shift has been closed
+func lockOpenShift(tx *gorm.DB, shiftID string) (*Shift, error) {
+	var shift Shift
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&Shift{ID: shiftID}).First(&shift).Error; err != nil {
+		return nil, fmt.Errorf("failed to get shift %s: %v", shiftID, err)
+	}
+
+	if shift.Status != enums.ShiftStatusOpen {
+		return nil, fmt.Errorf("shift %s: %w", shiftID, ErrShiftClosed)
+	}
+
+	return &shift, nil
+}
+
+// OpenShift starts a cashier's shift with the cash float placed in the drawer.
+// A cashier can only have one open shift at a time
+func (db *PGInstance) OpenShift(ctx context.Context, shift *Shift) (*Shift, error) {
+	var open int64
+	if err := db.DB.WithContext(ctx).Model(&Shift{}).Where(&Shift{CashierID: shift.CashierID, Status: enums.ShiftStatusOpen}).Count(&open).Error; err != nil {
+		return nil, fmt.Errorf("failed to check for an open shift: %v", err)
+	}
+	if open > 0 {
+		return nil, fmt.Errorf("cashier %s already has an open shift", shift.CashierID)
+	}
+
+	if err := db.DB.WithContext(ctx).Create(shift).Error; err != nil {
+		return nil, fmt.Errorf("failed to open shift: %v", err)
+	}
+
+	return shift, nil
+}
+
+// AddCashMovement records cash put into or taken out of the drawer during an open shift
+func (db *PGInstance) AddCashMovement(ctx context.Context, movement *CashMovement) (*CashMovement, error) {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	if _, err := lockOpenShift(tx, movement.ShiftID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Create(movement).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to record cash movement: %v", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return movement, nil
+}
+
+// ImportProducts creates or updates products in a single transaction. A product is matched by its SKU when it has one
+// and otherwise by its name. The stock level of a matched product is replaced by the imported quantity.
+// A dry run makes the same changes and rolls them back so that the counts can be previewed
+func (db *PGInstance) ImportProducts(ctx context.Context, products []*Product, dryRun bool) (created int, updated int, err error) {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	for _, product := range products {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("active = ?", true)
+		if product.SKU != nil {
+			query = query.Where("sku = ?", *product.SKU)
+		} else {
+			query = query.Where("LOWER(name) = LOWER(?)", product.Name)
+		}
+
+		var existing []*Product
+		if err := query.Limit(1).Find(&existing).Error; err != nil {
+			tx.Rollback()
+			return 0, 0, fmt.Errorf("failed to find product %s: %v", product.Name, err)
+		}
+
+		if len(existing) == 0 {
+			if err := tx.Create(product).Error; err != nil {
+				tx.Rollback()
+				return 0, 0, fmt.Errorf("failed to create product %s: %v", product.Name, err)
+			}
+			created++
+			continue
+		}
+
+		if err := tx.Model(existing[0]).Updates(map[string]interface{}{
+			"sku":          product.SKU,
+			"name":         product.Name,
+			"category":     product.Category,
+			"quantity":     product.Quantity,
+			"unit":         product.Unit,
+			"price":        product.Price,
+			"cost_price":   product.CostPrice,
+			"vat":          product.VAT,
+			"description":  product.Description,
+			"manufacturer": product.Manufacturer,
+			"in_stock":     product.InStock,
+			"updated_by":   product.CreatedBy,
+		}).Error; err != nil {
+			tx.Rollback()
+			return 0, 0, fmt.Errorf("failed to update product %s: %v", product.Name, err)
+		}
+		updated++
+	}
+
+	if dryRun {
+		tx.Rollback()
+		return created, updated, nil
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return 0, 0, err
+	}
+
+	return created, updated, nil
+}
+
+// SaveSyncOperation records the outcome of applying an operation pushed by an offline client.
+// An operation is only recorded once, the outcome recorded first is returned when it is saved again
+func (db *PGInstance) SaveSyncOperation(ctx context.Context, operation *SyncOperation) (*SyncOperation, error) {
+	if err := db.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(operation).Error; err != nil {
+		return nil, fmt.Errorf("failed to save sync operation: %v", err)
+	}
+
+	var saved SyncOperation
+	if err := db.DB.WithContext(ctx).Where(&SyncOperation{ID: operation.ID}).First(&saved).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sync operation: %v", err)
+	}
+
+	return &saved, nil
+}
+
+// SaveSyncDevice creates or updates the sync state of a device. Only the times and cursor that are set are updated
+func (db *PGInstance) SaveSyncDevice(ctx context.Context, device *SyncDevice) error {
+	now := time.Now().UTC()
+	device.CreatedAt = now
+	device.UpdatedAt = now
+
+	columns := []string{"user_id", "updated_at"}
+	if device.Cursor != nil {
+		columns = append(columns, "cursor")
+	}
+	if device.LastPushedAt != nil {
+		columns = append(columns, "last_pushed_at")
+	}
+	if device.LastPulledAt != nil {
+		columns = append(columns, "last_pulled_at")
+	}
+
+	err := db.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(device).Error
+	if err != nil {
+		return fmt.Errorf("failed to save sync device: %v", err)
+	}
+
+	return nil
+}
+
+// ReserveIdempotencyKey reserves a key for the request that is about to be processed.
+// When the key is held by a request that is still running or has completed, the stored key is returned and nothing is reserved.
+// A key whose request failed or whose response has expired is reserved again
+func (db *PGInstance) ReserveIdempotencyKey(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, bool, error) {
+	now := time.Now().UTC()
+	key.CreatedAt = now
+	key.UpdatedAt = now
+
+	tx := db.DB.WithContext(ctx).Begin()
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %v", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		if err := tx.Commit().Error; err != nil {
+			return nil, false, fmt.Errorf("failed to commit idempotency key: %v", err)
+		}
+		return key, true, nil
+	}
+
+	var stored IdempotencyKey
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(`scope = ? AND "key" = ?`, key.Scope, key.Key).First(&stored).Error; err != nil {
+		tx.Rollback()
+		return nil, false, fmt.Errorf("failed to get idempotency key: %v", err)
+	}
+
+	running := stored.CompletedAt == nil && stored.LockedUntil.After(now)
+	completed := stored.CompletedAt != nil && stored.ExpiresAt.After(now)
+	if running || completed {
+		if err := tx.Commit().Error; err != nil {
+			return nil, false, fmt.Errorf("failed to commit idempotency key: %v", err)
+		}
+		return &stored, false, nil
+	}
+
+	err := tx.Model(&IdempotencyKey{}).Where(`scope = ? AND "key" = ?`, key.Scope, key.Key).Updates(map[string]interface{}{
+		"updated_at":            now,
+		"request_hash":          key.RequestHash,
+		"locked_until":          key.LockedUntil,
+		"expires_at":            key.ExpiresAt,
+		"completed_at":          nil,
+		"response_status":       nil,
+		"response_content_type": nil,
+		"response_body":         nil,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %v", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, false, fmt.Errorf("failed to commit idempotency key: %v", err)
+	}
+
+	return key, true, nil
+}
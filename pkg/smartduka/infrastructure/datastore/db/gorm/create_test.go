@@ -8,12 +8,16 @@ import (
 	"github.com/brianvoe/gofakeit"
 	"github.com/google/uuid"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
 )
 
 func TestPGInstance_RegisterUser(t *testing.T) {
 	invalidID := "invalid"
 
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
 	type args struct {
 		ctx     context.Context
 		user    *gorm.User
@@ -51,7 +55,7 @@ func TestPGInstance_RegisterUser(t *testing.T) {
 		{
 			name: "Sad case: unable to register user",
 			args: args{
-				ctx: context.Background(),
+				ctx: cancelled,
 				user: &gorm.User{
 					ID:        &invalidID,
 					FirstName: gofakeit.FirstName(),
@@ -75,7 +79,7 @@ func TestPGInstance_RegisterUser(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := testingDB.RegisterUser(tt.args.ctx, tt.args.user, tt.args.contact)
+			_, err := testingDB.RegisterUser(tt.args.ctx, tt.args.user, tt.args.contact, nil, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("PGInstance.RegisterUser() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -84,6 +88,8 @@ func TestPGInstance_RegisterUser(t *testing.T) {
 }
 
 func TestPGInstance_SaveOTP(t *testing.T) {
+	unknownUserID := "userID"
+
 	type args struct {
 		ctx context.Context
 		otp *gorm.OTP
@@ -104,7 +110,7 @@ func TestPGInstance_SaveOTP(t *testing.T) {
 					PhoneNumber: gofakeit.PhoneFormatted(),
 					OTP:         "1200",
 					Flavour:     "PRO",
-					UserID:      userID,
+					UserID:      &userID,
 				},
 			},
 			wantErr: false,
@@ -119,7 +125,7 @@ func TestPGInstance_SaveOTP(t *testing.T) {
 					PhoneNumber: gofakeit.PhoneFormatted(),
 					OTP:         "1200",
 					Flavour:     "PRO",
-					UserID:      "userID",
+					UserID:      &unknownUserID,
 				},
 			},
 			want:    nil,
@@ -159,6 +165,7 @@ func TestPGInstance_SavePIN(t *testing.T) {
 					ValidTo:   time.Now().Add(time.Hour * 3),
 					HashedPIN: "hashed",
 					Salt:      "salt",
+					Flavour:   enums.FlavourPro,
 					UserID:    userID,
 				},
 			},
@@ -215,11 +222,11 @@ func TestPGInstance_AddProduct(t *testing.T) {
 					ID:           uuid.NewString(),
 					Active:       true,
 					Name:         gofakeit.BeerName(),
-					Category:     "DETERGENT",
-					Quantity:     12.00,
+					Category:     "FOOD_STUFF",
+					Quantity:     money.DecimalFromInt(12),
 					Unit:         "DOZEN",
-					Price:        900.89,
-					VAT:          16.00,
+					Price:        money.MustParse("900.89", money.CurrencyKES),
+					VAT:          money.DecimalFromInt(16),
 					Description:  gofakeit.HipsterSentence(30),
 					Manufacturer: gofakeit.BeerMalt(),
 					InStock:      true,
@@ -235,11 +242,11 @@ func TestPGInstance_AddProduct(t *testing.T) {
 					ID:           "test",
 					Active:       true,
 					Name:         gofakeit.BeerName(),
-					Category:     "DETERGENT",
-					Quantity:     12,
+					Category:     "FOOD_STUFF",
+					Quantity:     money.DecimalFromInt(12),
 					Unit:         "DOZEN",
-					Price:        900.89,
-					VAT:          16.00,
+					Price:        money.MustParse("900.89", money.CurrencyKES),
+					VAT:          money.DecimalFromInt(16),
 					Description:  gofakeit.HipsterSentence(30),
 					Manufacturer: gofakeit.BeerMalt(),
 					InStock:      true,
@@ -261,8 +268,9 @@ func TestPGInstance_AddProduct(t *testing.T) {
 
 func TestPGInstance_AddSaleRecord(t *testing.T) {
 	type args struct {
-		ctx  context.Context
-		sale *gorm.Sale
+		ctx     context.Context
+		sale    *gorm.Sale
+		costing enums.CostingMethod
 	}
 	tests := []struct {
 		name    string
@@ -280,10 +288,46 @@ func TestPGInstance_AddSaleRecord(t *testing.T) {
 					},
 					ID:        uuid.NewString(),
 					ProductID: productID,
-					Quantity:  2.00,
+					Quantity:  money.DecimalFromInt(2),
+					Unit:      "DOZEN",
+					Price:     money.MustParse("15.40", money.CurrencyKES),
+				},
+				costing: enums.CostingMethodWeightedAverage,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: record sale using FIFO costing",
+			args: args{
+				ctx: context.Background(),
+				sale: &gorm.Sale{
+					Base: gorm.Base{
+						CreatedBy: &userID,
+					},
+					ProductID: productID,
+					Quantity:  money.DecimalFromInt(1),
 					Unit:      "DOZEN",
-					Price:     15.40,
+					Price:     money.MustParse("15.40", money.CurrencyKES),
+				},
+				costing: enums.CostingMethodFIFO,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: record sale and queue its tax invoice",
+			args: args{
+				ctx: context.Background(),
+				sale: &gorm.Sale{
+					Base: gorm.Base{
+						CreatedBy: &userID,
+					},
+					ProductID:  productID,
+					Quantity:   money.DecimalFromInt(1),
+					Unit:       "DOZEN",
+					Price:      money.MustParse("15.40", money.CurrencyKES),
+					TaxInvoice: &gorm.TaxInvoice{},
 				},
+				costing: enums.CostingMethodWeightedAverage,
 			},
 			wantErr: false,
 		},
@@ -297,21 +341,395 @@ func TestPGInstance_AddSaleRecord(t *testing.T) {
 					},
 					ID:        uuid.NewString(),
 					ProductID: uuid.NewString(),
-					Quantity:  97.0,
+					Quantity:  money.DecimalFromInt(97),
 					Unit:      "DOZEN",
-					Price:     15.40,
+					Price:     money.MustParse("15.40", money.CurrencyKES),
 				},
+				costing: enums.CostingMethodWeightedAverage,
 			},
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := testingDB.AddSaleRecord(tt.args.ctx, tt.args.sale)
+			got, err := testingDB.AddSaleRecord(tt.args.ctx, tt.args.sale, tt.args.costing)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("PGInstance.AddSaleRecord() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if got != nil && got.TaxInvoice != nil && got.TaxInvoice.SaleID != got.ID {
+				t.Errorf("PGInstance.AddSaleRecord() tax invoice queued for sale %v, want %v", got.TaxInvoice.SaleID, got.ID)
+			}
+		})
+	}
+}
+
+func TestPGInstance_AddStockReceipt(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		receipt *gorm.StockReceipt
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "Happy case: receive stock",
+			args: args{
+				ctx: context.Background(),
+				receipt: &gorm.StockReceipt{
+					Base: gorm.Base{
+						CreatedBy: &userID,
+					},
+					Active:    true,
+					ProductID: productID,
+					Quantity:  money.DecimalFromInt(10),
+					UnitCost:  money.MustParse("12.00", money.CurrencyKES),
+					Supplier:  gofakeit.Company(),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Sad case: product does not exist",
+			args: args{
+				ctx: context.Background(),
+				receipt: &gorm.StockReceipt{
+					Base: gorm.Base{
+						CreatedBy: &userID,
+					},
+					Active:    true,
+					ProductID: uuid.NewString(),
+					Quantity:  money.DecimalFromInt(10),
+					UnitCost:  money.MustParse("12.00", money.CurrencyKES),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testingDB.AddStockReceipt(tt.args.ctx, tt.args.receipt)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.AddStockReceipt() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func TestPGInstance_OpenShift(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		shift *gorm.Shift
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "Sad case: cashier already has an open shift",
+			args: args{
+				ctx: context.Background(),
+				shift: &gorm.Shift{
+					Base: gorm.Base{
+						CreatedBy: &userID,
+					},
+					Active:       true,
+					CashierID:    userID,
+					OpeningFloat: money.MustParse("500.00", money.CurrencyKES),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testingDB.OpenShift(tt.args.ctx, tt.args.shift)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.OpenShift() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func TestPGInstance_AddCashMovement(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		movement *gorm.CashMovement
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "Happy case: pay out petty cash",
+			args: args{
+				ctx: context.Background(),
+				movement: &gorm.CashMovement{
+					Base: gorm.Base{
+						CreatedBy: &userID,
+					},
+					Active:  true,
+					ShiftID: shiftID,
+					Type:    enums.CashMovementTypeCashOut,
+					Amount:  money.MustParse("50.00", money.CurrencyKES),
+					Reason:  "Cleaning supplies",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Sad case: shift does not exist",
+			args: args{
+				ctx: context.Background(),
+				movement: &gorm.CashMovement{
+					Base: gorm.Base{
+						CreatedBy: &userID,
+					},
+					Active:  true,
+					ShiftID: uuid.NewString(),
+					Type:    enums.CashMovementTypeDrop,
+					Amount:  money.MustParse("50.00", money.CurrencyKES),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testingDB.AddCashMovement(tt.args.ctx, tt.args.movement)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.AddCashMovement() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func TestPGInstance_ImportProducts(t *testing.T) {
+	sku := gofakeit.UUID()
+
+	type args struct {
+		ctx      context.Context
+		products []*gorm.Product
+		dryRun   bool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantCreated int
+		wantUpdated int
+		wantErr     bool
+	}{
+		{
+			name: "Happy case: preview updating an existing product and creating a new one",
+			args: args{
+				ctx: context.Background(),
+				products: []*gorm.Product{
+					{
+						Base:     gorm.Base{CreatedBy: &userID},
+						Active:   true,
+						Name:     "panadol",
+						Category: "MEDICINE",
+						Quantity: money.DecimalFromInt(20),
+						Unit:     "ONE",
+						Price:    money.MustParse("780.00", money.CurrencyKES),
+						InStock:  true,
+					},
+					{
+						Base:     gorm.Base{CreatedBy: &userID},
+						Active:   true,
+						SKU:      &sku,
+						Name:     gofakeit.BeerName(),
+						Category: "FOOD_STUFF",
+						Unit:     "BAG",
+						Price:    money.MustParse("210.00", money.CurrencyKES),
+					},
+				},
+				dryRun: true,
+			},
+			wantCreated: 1,
+			wantUpdated: 1,
+			wantErr:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, updated, err := testingDB.ImportProducts(tt.args.ctx, tt.args.products, tt.args.dryRun)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.ImportProducts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if created != tt.wantCreated || updated != tt.wantUpdated {
+				t.Errorf("PGInstance.ImportProducts() = %v, %v, want %v, %v", created, updated, tt.wantCreated, tt.wantUpdated)
+			}
+		})
+	}
+}
+
+func TestPGInstance_SaveSyncOperation(t *testing.T) {
+	ctx := context.Background()
+	deviceID := uuid.NewString()
+
+	if err := testingDB.SaveSyncDevice(ctx, &gorm.SyncDevice{ID: deviceID, UserID: userID}); err != nil {
+		t.Fatalf("PGInstance.SaveSyncDevice() error = %v", err)
+	}
+
+	pushedAt := time.Now()
+	if err := testingDB.SaveSyncDevice(ctx, &gorm.SyncDevice{ID: deviceID, UserID: userID, LastPushedAt: &pushedAt}); err != nil {
+		t.Errorf("PGInstance.SaveSyncDevice() error = %v, expected the device to be updated", err)
+	}
+
+	operationID := uuid.NewString()
+	applied := &gorm.SyncOperation{
+		ID:         operationID,
+		DeviceID:   deviceID,
+		Type:       enums.SyncOperationTypeSale,
+		Status:     enums.SyncOperationStatusApplied,
+		RecordID:   &operationID,
+		OccurredAt: time.Now().UTC(),
+	}
+	flagged := enums.SyncConflictNegativeStock
+
+	tests := []struct {
+		name       string
+		operation  *gorm.SyncOperation
+		wantStatus enums.SyncOperationStatus
+		wantErr    bool
+	}{
+		{
+			name:       "Happy case: record an operation",
+			operation:  applied,
+			wantStatus: enums.SyncOperationStatusApplied,
+		},
+		{
+			name: "Happy case: the outcome recorded first is kept",
+			operation: &gorm.SyncOperation{
+				ID:         operationID,
+				DeviceID:   deviceID,
+				Type:       enums.SyncOperationTypeSale,
+				Status:     enums.SyncOperationStatusFlagged,
+				Conflict:   &flagged,
+				OccurredAt: time.Now().UTC(),
+			},
+			wantStatus: enums.SyncOperationStatusApplied,
+		},
+		{
+			name: "Sad case: unknown device",
+			operation: &gorm.SyncOperation{
+				ID:         uuid.NewString(),
+				DeviceID:   uuid.NewString(),
+				Type:       enums.SyncOperationTypeSale,
+				Status:     enums.SyncOperationStatusApplied,
+				OccurredAt: time.Now().UTC(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testingDB.SaveSyncOperation(ctx, tt.operation)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.SaveSyncOperation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.Status != tt.wantStatus {
+				t.Errorf("PGInstance.SaveSyncOperation() status = %v, want %v", got.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPGInstance_ReserveIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	scope := uuid.NewString()
+
+	newKey := func(key string) *gorm.IdempotencyKey {
+		now := time.Now().UTC()
+		return &gorm.IdempotencyKey{
+			Scope:       scope,
+			Key:         key,
+			RequestHash: "hash",
+			LockedUntil: now.Add(time.Minute),
+			ExpiresAt:   now.Add(time.Hour),
+		}
+	}
+
+	completedAt := time.Now().UTC()
+	status := 200
+	contentType := "application/json"
+	body := []byte(`{"status":"ok"}`)
+
+	tests := []struct {
+		name          string
+		key           string
+		before        func(key string)
+		wantReserved  bool
+		wantCompleted bool
+	}{
+		{
+			name:         "Happy case: reserve a new key",
+			key:          "first",
+			wantReserved: true,
+		},
+		{
+			name: "Happy case: a key held by a running request is not reserved",
+			key:  "running",
+			before: func(key string) {
+				_, _, _ = testingDB.ReserveIdempotencyKey(ctx, newKey(key))
+			},
+			wantReserved: false,
+		},
+		{
+			name: "Happy case: a released key is reserved again",
+			key:  "released",
+			before: func(key string) {
+				_, _, _ = testingDB.ReserveIdempotencyKey(ctx, newKey(key))
+				_ = testingDB.ReleaseIdempotencyKey(ctx, scope, key)
+			},
+			wantReserved: true,
+		},
+		{
+			name: "Happy case: a completed key returns the stored response",
+			key:  "completed",
+			before: func(key string) {
+				_, _, _ = testingDB.ReserveIdempotencyKey(ctx, newKey(key))
+				completed := newKey(key)
+				completed.CompletedAt = &completedAt
+				completed.ResponseStatus = &status
+				completed.ResponseContentType = &contentType
+				completed.ResponseBody = body
+				_ = testingDB.CompleteIdempotencyKey(ctx, completed)
+			},
+			wantReserved:  false,
+			wantCompleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != nil {
+				tt.before(tt.key)
+			}
+
+			got, reserved, err := testingDB.ReserveIdempotencyKey(ctx, newKey(tt.key))
+			if err != nil {
+				t.Errorf("PGInstance.ReserveIdempotencyKey() error = %v", err)
+				return
+			}
+			if reserved != tt.wantReserved {
+				t.Errorf("PGInstance.ReserveIdempotencyKey() reserved = %v, want %v", reserved, tt.wantReserved)
+			}
+			if (got.CompletedAt != nil) != tt.wantCompleted {
+				t.Errorf("PGInstance.ReserveIdempotencyKey() completed = %v, want %v", got.CompletedAt != nil, tt.wantCompleted)
+			}
+			if tt.wantCompleted && string(got.ResponseBody) != string(body) {
+				t.Errorf("PGInstance.ReserveIdempotencyKey() response = %s, want %s", got.ResponseBody, body)
+			}
 		})
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
 
 	log "github.com/sirupsen/logrus"
 
@@ -38,6 +39,11 @@ const (
 	DBPASSWORD = "POSTGRES_PASSWORD"
 	// DBName ...
 	DBName = "POSTGRES_DB"
+
+	// DatabaseBackend selects the database the service runs on, `postgres`, `sqlite` or `memory`. It defaults to postgres
+	DatabaseBackend = "DATABASE_BACKEND"
+	// SQLitePath is the file holding the SQLite database
+	SQLitePath = "SQLITE_PATH"
 )
 
 type connectionConfig struct {
@@ -52,17 +58,46 @@ type connectionConfig struct {
 	asCloudInstance bool
 }
 
-// PGInstance box for postgres client. We use this instead of a global variable
+// PGInstance box for postgres client. We use this instead of a global variable.
+// It also serves a SQLite database, see NewSQLiteInstance
 type PGInstance struct {
 	DB *gorm.DB
 }
 
+// Repository is a datastore that can create, query, update and delete every record
+type Repository interface {
+	Create
+	Query
+	Update
+	Delete
+}
+
+// NewDatabaseInstance connects to the database selected by the DATABASE_BACKEND environment variable
+func NewDatabaseInstance() (*PGInstance, error) {
+	switch backend := os.Getenv(DatabaseBackend); backend {
+	case "", "postgres":
+		return NewPGInstance()
+	case "sqlite":
+		return NewSQLiteInstance(helpers.MustGetEnvVar(SQLitePath))
+	default:
+		return nil, fmt.Errorf("unknown database backend %q", backend)
+	}
+}
+
+// IsSQLite returns true if the instance is connected to a SQLite database
+func (db *PGInstance) IsSQLite() bool {
+	return db.DB.Dialector.Name() == "sqlite"
+}
+
 // NewPGInstance creates a new instance of postgres client
 func NewPGInstance() (*PGInstance, error) {
 	db := startDatabase()
 	if db == nil {
 		return nil, fmt.Errorf("failed to start database: %v", db)
 	}
+	if err := registerCallbacks(db, extension.NewExtension()); err != nil {
+		return nil, fmt.Errorf("failed to register database callbacks: %v", err)
+	}
 	pg := &PGInstance{DB: db}
 
 	return pg, nil
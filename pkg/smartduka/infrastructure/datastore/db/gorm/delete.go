@@ -0,0 +1,181 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSaleReported is returned when a sale that has been reported to KRA is deleted or restored
+var ErrSaleReported = errors.New("the sale has been reported to KRA")
+
+// Delete holds all the database record delete methods.
+// Users, contacts, identifiers, products and sales are soft deleted: they are hidden from queries until they are restored or purged
+type Delete interface {
+	DeleteUser(ctx context.Context, userID string) error
+	DeleteProduct(ctx context.Context, productID string) error
+	DeleteSale(ctx context.Context, saleID string) error
+
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// DeleteUser soft deletes a user together with their contacts and identification documents
+func (db *PGInstance) DeleteUser(ctx context.Context, userID string) error {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	result := tx.Where("id = ?", userID).Delete(&User{})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete user: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete user %s: %w", userID, gorm.ErrRecordNotFound)
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&Contact{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete user contacts: %v", err)
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&Identifier{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete user identifiers: %v", err)
+	}
+
+	return tx.Commit().Error
+}
+
+// DeleteProduct soft deletes a product. Its sales keep referring to it.
+// The product's update time moves on so that the deletion is pulled by offline tills as a change
+func (db *PGInstance) DeleteProduct(ctx context.Context, productID string) error {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	result := tx.Where(&Product{ID: productID}).Delete(&Product{})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete product: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete product %s: %w", productID, gorm.ErrRecordNotFound)
+	}
+
+	if err := tx.Unscoped().Model(&Product{}).Where("id = ?", productID).Update("updated_at", time.Now().UTC()).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete product: %v", err)
+	}
+
+	return tx.Commit().Error
+}
+
+// DeleteSale soft deletes a sale recorded in error and puts what it sold back in stock.
+// Sales reported to KRA or made in a closed shift cannot be deleted, a return has to be recorded instead
+func (db *PGInstance) DeleteSale(ctx context.Context, saleID string) error {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	var sale Sale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&Sale{ID: saleID}).First(&sale).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to get sale %s: %w", saleID, err)
+	}
+
+	if err := checkSaleChangeable(tx, &sale); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := restock(tx, sale.ProductID, sale.Quantity); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Delete(&sale).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete sale: %v", err)
+	}
+
+	return tx.Commit().Error
+}
+
+// checkSaleChangeable refuses to delete or restore a sale that has been reported to KRA or closed in a Z-report
+func checkSaleChangeable(tx *gorm.DB, sale *Sale) error {
+	var reported int64
+	if err := tx.Model(&TaxInvoice{}).Where("sale_id = ? AND status <> ?", sale.ID, enums.TaxInvoiceStatusRejected).
+		Count(&reported).Error; err != nil {
+		return fmt.Errorf("failed to check the sale's tax invoice: %v", err)
+	}
+	if reported > 0 {
+		return fmt.Errorf("sale %s: %w", sale.ID, ErrSaleReported)
+	}
+
+	if sale.ShiftID != nil {
+		if _, err := lockOpenShift(tx, *sale.ShiftID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restock adds a quantity to a product's stock. A negative quantity takes it out of stock
+func restock(tx *gorm.DB, productID string, quantity money.Decimal) error {
+	var product Product
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where(&Product{ID: productID}).First(&product).Error; err != nil {
+		return fmt.Errorf("failed to get product %s: %v", productID, err)
+	}
+
+	stock := product.Quantity.Add(quantity)
+	if err := tx.Unscoped().Model(&product).Updates(map[string]interface{}{
+		"quantity": stock,
+		"in_stock": !stock.IsNegative() && !stock.IsZero(),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update product stock: %v", err)
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently removes records deleted before the given time, at most limit of each kind at a time.
+// Records that are still referred to e.g a product with sales are kept
+func (db *PGInstance) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	purged := 0
+
+	// dependent records go first so that what they refer to can be purged in the same run
+	for _, model := range []interface{}{&Contact{}, &Identifier{}, &Sale{}, &Product{}, &User{}} {
+		var ids []string
+		if err := db.DB.WithContext(ctx).Unscoped().Model(model).Where("deleted_at < ?", before.UTC()).
+			Order("deleted_at").Limit(limit).Pluck("id", &ids).Error; err != nil {
+			return purged, fmt.Errorf("failed to find deleted records: %v", err)
+		}
+
+		for _, id := range ids {
+			err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				if _, ok := model.(*User); ok {
+					// the credentials of a deleted user are of no use to anyone
+					if err := tx.Where("user_id = ?", id).Delete(&UserPIN{}).Error; err != nil {
+						return err
+					}
+					if err := tx.Where("user_id = ?", id).Delete(&OTP{}).Error; err != nil {
+						return err
+					}
+				}
+
+				return tx.Unscoped().Where("id = ?", id).Delete(model).Error
+			})
+			if err != nil {
+				// still referred to by another record
+				continue
+			}
+			purged++
+		}
+	}
+
+	return purged, nil
+}
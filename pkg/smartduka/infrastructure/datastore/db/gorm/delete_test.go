@@ -0,0 +1,97 @@
+package gorm_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+)
+
+func TestPGInstance_DeleteSale(t *testing.T) {
+	ctx := context.Background()
+
+	before, err := testingDB.GetProductByID(ctx, productID)
+	if err != nil {
+		t.Fatalf("PGInstance.GetProductByID() error = %v", err)
+	}
+
+	if err := testingDB.DeleteSale(ctx, saleID); err != nil {
+		t.Fatalf("PGInstance.DeleteSale() error = %v", err)
+	}
+	if _, err := testingDB.GetSaleByID(ctx, saleID); err == nil {
+		t.Errorf("PGInstance.GetSaleByID() expected a deleted sale to be hidden")
+	}
+
+	restocked, err := testingDB.GetProductByID(ctx, productID)
+	if err != nil {
+		t.Fatalf("PGInstance.GetProductByID() error = %v", err)
+	}
+	if restocked.Quantity.Cmp(before.Quantity) <= 0 {
+		t.Errorf("PGInstance.DeleteSale() stock = %v, expected more than %v", restocked.Quantity, before.Quantity)
+	}
+
+	if err := testingDB.DeleteSale(ctx, saleID); err == nil {
+		t.Errorf("PGInstance.DeleteSale() expected an error deleting a deleted sale")
+	}
+
+	if _, err := testingDB.RestoreSale(ctx, saleID); err != nil {
+		t.Fatalf("PGInstance.RestoreSale() error = %v", err)
+	}
+	after, err := testingDB.GetProductByID(ctx, productID)
+	if err != nil {
+		t.Fatalf("PGInstance.GetProductByID() error = %v", err)
+	}
+	if after.Quantity.Cmp(before.Quantity) != 0 {
+		t.Errorf("PGInstance.RestoreSale() stock = %v, want %v", after.Quantity, before.Quantity)
+	}
+}
+
+func TestPGInstance_PurgeDeleted(t *testing.T) {
+	ctx := context.Background()
+
+	product, err := testingDB.AddProduct(ctx, &gorm.Product{
+		Base:     gorm.Base{CreatedBy: &userID},
+		Active:   true,
+		Name:     gofakeit.BeerName(),
+		Category: "FOOD_STUFF",
+		Quantity: money.DecimalFromInt(1),
+		Unit:     "DOZEN",
+		Price:    money.MustParse("100", money.CurrencyKES),
+		VAT:      money.DecimalFromInt(16),
+	})
+	if err != nil {
+		t.Fatalf("PGInstance.AddProduct() error = %v", err)
+	}
+
+	if err := testingDB.DeleteProduct(ctx, product.ID); err != nil {
+		t.Fatalf("PGInstance.DeleteProduct() error = %v", err)
+	}
+	if _, err := testingDB.GetProductByID(ctx, product.ID); err == nil {
+		t.Errorf("PGInstance.GetProductByID() expected a deleted product to be hidden")
+	}
+
+	// records deleted within the retention period are kept
+	if _, err := testingDB.PurgeDeleted(ctx, time.Now().Add(-time.Hour), 100); err != nil {
+		t.Fatalf("PGInstance.PurgeDeleted() error = %v", err)
+	}
+	if _, err := testingDB.RestoreProduct(ctx, product.ID); err != nil {
+		t.Fatalf("PGInstance.RestoreProduct() error = %v", err)
+	}
+
+	if err := testingDB.DeleteProduct(ctx, product.ID); err != nil {
+		t.Fatalf("PGInstance.DeleteProduct() error = %v", err)
+	}
+	purged, err := testingDB.PurgeDeleted(ctx, time.Now().Add(time.Minute), 100)
+	if err != nil {
+		t.Fatalf("PGInstance.PurgeDeleted() error = %v", err)
+	}
+	if purged == 0 {
+		t.Errorf("PGInstance.PurgeDeleted() expected the product to be purged")
+	}
+	if _, err := testingDB.RestoreProduct(ctx, product.ID); err == nil {
+		t.Errorf("PGInstance.RestoreProduct() expected an error restoring a purged product")
+	}
+}
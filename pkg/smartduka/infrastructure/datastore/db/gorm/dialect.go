@@ -0,0 +1,141 @@
+package gorm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dialect writes the SQL that differs between the databases the datastore runs on
+type dialect interface {
+	// containsFold matches a text column against a LIKE pattern ignoring case
+	containsFold(column string) string
+
+	// localTime converts a UTC timestamp column of a record made in the period [from, to) into the shop's
+	// wall clock time. It returns the expression and its arguments
+	localTime(column string, location *time.Location, from, to time.Time) (string, []interface{})
+
+	// period truncates a wall clock time to the start of its day, ISO week or month formatted as YYYY-MM-DD
+	period(unit string, localTime string) string
+
+	// isoDayOfWeek is the day of the week of a wall clock time, Monday is 1 and Sunday is 7
+	isoDayOfWeek(localTime string) string
+
+	// hourOfDay is the hour of a wall clock time
+	hourOfDay(localTime string) string
+}
+
+// dialect returns the SQL dialect of the database the instance is connected to
+func (db *PGInstance) dialect() dialect {
+	if db.IsSQLite() {
+		return sqliteDialect{}
+	}
+	return postgresDialect{}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) containsFold(column string) string {
+	return column + " ILIKE ?"
+}
+
+func (postgresDialect) localTime(column string, location *time.Location, from, to time.Time) (string, []interface{}) {
+	return fmt.Sprintf("((%s AT TIME ZONE 'UTC') AT TIME ZONE ?)", column), []interface{}{location.String()}
+}
+
+func (postgresDialect) period(unit string, localTime string) string {
+	return fmt.Sprintf("to_char(date_trunc('%s', %s), 'YYYY-MM-DD')", unit, localTime)
+}
+
+func (postgresDialect) isoDayOfWeek(localTime string) string {
+	return fmt.Sprintf("CAST(EXTRACT(ISODOW FROM %s) AS INTEGER)", localTime)
+}
+
+func (postgresDialect) hourOfDay(localTime string) string {
+	return fmt.Sprintf("CAST(EXTRACT(HOUR FROM %s) AS INTEGER)", localTime)
+}
+
+type sqliteDialect struct{}
+
+// containsFold relies on SQLite's LIKE, which ignores the case of ASCII letters
+func (sqliteDialect) containsFold(column string) string {
+	return column + " LIKE ?"
+}
+
+// localTime shifts timestamps by the UTC offset the shop had at the time since SQLite has no timezone database.
+// The offsets and the moments they change e.g for daylight saving are worked out in Go for the period
+func (sqliteDialect) localTime(column string, location *time.Location, from, to time.Time) (string, []interface{}) {
+	_, offset := from.In(location).Zone()
+	modifier := func(offset int) string { return fmt.Sprintf("%+d seconds", offset) }
+
+	var cases strings.Builder
+	args := []interface{}{}
+	for _, change := range offsetChanges(location, from, to) {
+		cases.WriteString(fmt.Sprintf("WHEN %s < ? THEN ? ", column))
+		args = append(args, change.at.UTC(), modifier(offset))
+		offset = change.offset
+	}
+	if len(args) == 0 {
+		return fmt.Sprintf("datetime(%s, ?)", column), []interface{}{modifier(offset)}
+	}
+
+	args = append(args, modifier(offset))
+	return fmt.Sprintf("datetime(%s, CASE %sELSE ? END)", column, cases.String()), args
+}
+
+// offsetChange is a moment a timezone's UTC offset changes and the offset from then on
+type offsetChange struct {
+	at     time.Time
+	offset int
+}
+
+// offsetChanges lists the moments in the period [from, to) when the timezone's UTC offset changes, in order.
+// Offsets change on the hour or half hour so the period is searched an hour at a time
+func offsetChanges(location *time.Location, from, to time.Time) []offsetChange {
+	changes := []offsetChange{}
+	_, offset := from.In(location).Zone()
+	for start := from; start.Before(to); start = start.Add(time.Hour) {
+		end := start.Add(time.Hour)
+		if _, next := end.In(location).Zone(); next == offset {
+			continue
+		}
+
+		// narrow down to the second the offset changes
+		low, high := start, end
+		for high.Sub(low) > time.Second {
+			middle := low.Add(high.Sub(low) / 2)
+			if _, at := middle.In(location).Zone(); at == offset {
+				low = middle
+			} else {
+				high = middle
+			}
+		}
+		_, offset = high.In(location).Zone()
+		if high.Before(to) {
+			changes = append(changes, offsetChange{at: high, offset: offset})
+		}
+	}
+
+	return changes
+}
+
+func (sqliteDialect) period(unit string, localTime string) string {
+	switch unit {
+	case "week":
+		// the Monday on or before the date
+		return fmt.Sprintf("date(%s, '-6 days', 'weekday 1')", localTime)
+	case "month":
+		return fmt.Sprintf("date(%s, 'start of month')", localTime)
+	default:
+		return fmt.Sprintf("date(%s)", localTime)
+	}
+}
+
+func (sqliteDialect) isoDayOfWeek(localTime string) string {
+	// %w counts from Sunday as 0
+	return fmt.Sprintf("((CAST(strftime('%%w', %s) AS INTEGER) + 6) %% 7 + 1)", localTime)
+}
+
+func (sqliteDialect) hourOfDay(localTime string) string {
+	return fmt.Sprintf("CAST(strftime('%%H', %s) AS INTEGER)", localTime)
+}
@@ -0,0 +1,344 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// models are the gorm models backed by a table created in the SQL migrations
+var models = []interface{}{
+	&User{}, &Contact{}, &Identifier{}, &UserPIN{}, &OTP{}, &Product{}, &Sale{}, &TaxInvoice{}, &StockReceipt{},
+	&Shift{}, &CashMovement{}, &SyncDevice{}, &SyncOperation{}, &IdempotencyKey{}, &AuditLog{},
+}
+
+// enumColumns are the columns holding an enum and the values each one accepts.
+// The migrations must restrict every one of them to exactly these values with a "<table>_<column>_check" constraint
+var enumColumns = map[string][]string{
+	"smartduka_contact.flavour":            enumValues(enums.AllFlavour),
+	"smartduka_identifier.identifier_type": enumValues(enums.AllIdentifierType),
+	"smartduka_user_pin.flavour":           enumValues(enums.AllFlavour),
+	"smartduka_user_otp.flavour":           enumValues(enums.AllFlavour),
+	"smartduka_product.category":           enumValues(enums.AllCategory),
+	"smartduka_product.unit":               enumValues(enums.AllUnit),
+	"smartduka_sale.unit":                  enumValues(enums.AllUnit),
+	"smartduka_sale.payment_method":        enumValues(enums.AllPaymentMethod),
+	"smartduka_tax_invoice.status":         enumValues(enums.AllTaxInvoiceStatus),
+	"smartduka_shift.status":               enumValues(enums.AllShiftStatus),
+	"smartduka_cash_movement.type":         enumValues(enums.AllCashMovementType),
+	"smartduka_sync_operation.type":        enumValues(enums.AllSyncOperationType),
+	"smartduka_sync_operation.status":      enumValues(enums.AllSyncOperationStatus),
+	"smartduka_sync_operation.conflict":    enumValues(enums.AllSyncConflict),
+	"smartduka_audit_log.action":           enumValues(enums.AllAuditAction),
+}
+
+var enumPackage = reflect.TypeOf(enums.FlavourPro).PkgPath()
+
+var quoted = regexp.MustCompile(`'([^']*)'`)
+
+// declaredType splits a column type as SQLite keeps it, e.g `numeric(14,2)` or `varchar(20)`
+var declaredType = regexp.MustCompile(`^([a-z ]+?)\s*(?:\((\d+)(?:,\s*(\d+))?\))?$`)
+
+// sqliteCheck finds the named CHECK constraints in the statement that created a SQLite table
+var sqliteCheck = regexp.MustCompile(`CONSTRAINT "(\w+)" CHECK \(([^)]*)\)`)
+
+func enumValues[T ~string](all []T) []string {
+	values := []string{}
+	for _, value := range all {
+		values = append(values, string(value))
+	}
+	return values
+}
+
+type column struct {
+	Table      string  `gorm:"column:table_name"`
+	Name       string  `gorm:"column:column_name"`
+	IsNullable string  `gorm:"column:is_nullable"`
+	Default    *string `gorm:"column:default"`
+	DataType   string  `gorm:"column:data_type"`
+	Precision  *int    `gorm:"column:numeric_precision"`
+	Scale      *int    `gorm:"column:numeric_scale"`
+	Length     *int    `gorm:"column:character_maximum_length"`
+}
+
+// kind groups the column's type with the others that hold the same Go values, e.g `uuid` and `text` both hold strings
+func (c column) kind() string {
+	switch c.DataType {
+	case "character varying", "varchar", "character", "char", "text", "uuid", "jsonb", "json":
+		return "string"
+	case "boolean", "bool":
+		return "bool"
+	case "integer", "int", "bigint", "smallint":
+		return "int"
+	case "double precision", "real", "float":
+		return "float"
+	case "timestamp without time zone", "timestamp with time zone", "timestamp", "datetime", "date":
+		return "time"
+	case "bytea", "blob":
+		return "bytes"
+	}
+	return c.DataType
+}
+
+// fieldKind is the kind of column a model's field needs, together with the precision and scale of a numeric one
+func fieldKind(field *schema.Field) (kind string, precision int, scale int) {
+	switch field.DataType {
+	case schema.String:
+		return "string", 0, 0
+	case schema.Bool:
+		return "bool", 0, 0
+	case schema.Int, schema.Uint:
+		return "int", 0, 0
+	case schema.Float:
+		return "float", 0, 0
+	case schema.Time:
+		return "time", 0, 0
+	case schema.Bytes:
+		return "bytes", 0, 0
+	}
+
+	// types of their own name their column type, e.g `numeric(14,2)`
+	declared := parseDeclaredType(string(field.DataType))
+	precision, scale = field.Precision, field.Scale
+	if declared.Precision != nil {
+		precision = *declared.Precision
+	}
+	if declared.Scale != nil {
+		scale = *declared.Scale
+	}
+	return declared.kind(), precision, scale
+}
+
+// parseDeclaredType reads a column type written the way it is in SQL, e.g `numeric(14,2)`
+func parseDeclaredType(declared string) column {
+	parsed := column{DataType: strings.ToLower(strings.TrimSpace(declared))}
+	match := declaredType.FindStringSubmatch(parsed.DataType)
+	if match == nil {
+		return parsed
+	}
+
+	parsed.DataType = match[1]
+	if match[2] == "" {
+		return parsed
+	}
+	size, _ := strconv.Atoi(match[2])
+	switch parsed.DataType {
+	case "numeric", "decimal":
+		parsed.DataType = "numeric"
+		parsed.Precision = &size
+		scale := 0
+		if match[3] != "" {
+			scale, _ = strconv.Atoi(match[3])
+		}
+		parsed.Scale = &scale
+	default:
+		parsed.Length = &size
+	}
+	return parsed
+}
+
+// typeProblem describes how a column's type does not fit the field a model keeps in it, if it does not
+func typeProblem(name string, c column, field *schema.Field, values []string) string {
+	kind, precision, scale := fieldKind(field)
+	if c.kind() != kind {
+		return fmt.Sprintf("column %s is %s but the model holds %s", name, c.DataType, field.DataType)
+	}
+
+	if kind == "numeric" && (c.Precision == nil || c.Scale == nil || *c.Precision != precision || *c.Scale != scale) {
+		return fmt.Sprintf("column %s is %s but the model uses numeric(%d,%d)", name, describeNumeric(c), precision, scale)
+	}
+
+	if c.Length == nil {
+		return ""
+	}
+	longest := field.Size
+	for _, value := range values {
+		if len(value) > longest {
+			longest = len(value)
+		}
+	}
+	if longest > *c.Length {
+		return fmt.Sprintf("column %s holds at most %d characters but the model needs %d", name, *c.Length, longest)
+	}
+
+	return ""
+}
+
+func describeNumeric(c column) string {
+	if c.Precision == nil || c.Scale == nil {
+		return c.DataType
+	}
+	return fmt.Sprintf("numeric(%d,%d)", *c.Precision, *c.Scale)
+}
+
+type checkConstraint struct {
+	Name       string `gorm:"column:conname"`
+	Definition string `gorm:"column:definition"`
+}
+
+// CheckSchemaDrift compares the migrated schema with the gorm models. It returns a problem for every
+// table or column a model uses that does not exist, every column whose type, numeric precision and scale or length
+// does not fit the model's field, every NOT NULL column without a default that no model sets,
+// and every enum column whose CHECK constraint is missing or accepts different values from the enum
+func (db *PGInstance) CheckSchemaDrift(ctx context.Context) ([]string, error) {
+	columns, err := db.schemaColumns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := db.schemaChecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := map[string]map[string]column{}
+	for _, c := range columns {
+		if tables[c.Table] == nil {
+			tables[c.Table] = map[string]column{}
+		}
+		tables[c.Table][c.Name] = c
+	}
+
+	constraints := map[string]string{}
+	for _, check := range checks {
+		constraints[check.Name] = check.Definition
+	}
+
+	problems := []string{}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db.DB}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("failed to parse model %T: %v", model, err)
+		}
+		table := stmt.Schema.Table
+
+		existing, ok := tables[table]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("table %s is missing", table))
+			continue
+		}
+
+		mapped := map[string]bool{}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			mapped[field.DBName] = true
+			name := table + "." + field.DBName
+
+			c, ok := existing[field.DBName]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("column %s is missing", name))
+				continue
+			}
+
+			values, isEnum := enumColumns[name]
+			if problem := typeProblem(name, c, field, values); problem != "" {
+				problems = append(problems, problem)
+			}
+
+			if !isEnum {
+				fieldType := field.FieldType
+				if fieldType.Kind() == reflect.Ptr {
+					fieldType = fieldType.Elem()
+				}
+				if fieldType.PkgPath() == enumPackage {
+					problems = append(problems, fmt.Sprintf("column %s holds %s but its values are not listed", name, fieldType.Name()))
+				}
+				continue
+			}
+
+			constraint := table + "_" + field.DBName + "_check"
+			definition, ok := constraints[constraint]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("column %s has no %s constraint", name, constraint))
+				continue
+			}
+
+			allowed := []string{}
+			for _, match := range quoted.FindAllStringSubmatch(definition, -1) {
+				allowed = append(allowed, match[1])
+			}
+			sort.Strings(allowed)
+			want := append([]string{}, values...)
+			sort.Strings(want)
+			if strings.Join(allowed, ",") != strings.Join(want, ",") {
+				problems = append(problems, fmt.Sprintf("constraint %s allows %v but the enum has %v", constraint, allowed, want))
+			}
+		}
+
+		for name, c := range existing {
+			if !mapped[name] && c.IsNullable == "NO" && c.Default == nil {
+				problems = append(problems, fmt.Sprintf("column %s.%s is NOT NULL without a default but no model sets it", table, name))
+			}
+		}
+	}
+
+	sort.Strings(problems)
+
+	return problems, nil
+}
+
+// schemaColumns lists the columns of every table in the database
+func (db *PGInstance) schemaColumns(ctx context.Context) ([]column, error) {
+	query := `SELECT table_name, column_name, is_nullable, column_default AS "default",
+		data_type, numeric_precision, numeric_scale, character_maximum_length
+		FROM information_schema.columns WHERE table_schema = current_schema()`
+	if db.IsSQLite() {
+		query = `SELECT m.name AS table_name, p.name AS column_name,
+			CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 'NO' ELSE 'YES' END AS is_nullable, p.dflt_value AS "default",
+			p.type AS data_type
+			FROM sqlite_master m, pragma_table_info(m.name) p WHERE m.type = 'table'`
+	}
+
+	var columns []column
+	if err := db.DB.WithContext(ctx).Raw(query).Scan(&columns).Error; err != nil {
+		return nil, fmt.Errorf("failed to read the schema: %v", err)
+	}
+
+	if db.IsSQLite() {
+		// SQLite keeps the type as it was declared, size and all
+		for i, c := range columns {
+			declared := parseDeclaredType(c.DataType)
+			columns[i].DataType, columns[i].Precision, columns[i].Scale, columns[i].Length =
+				declared.DataType, declared.Precision, declared.Scale, declared.Length
+		}
+	}
+
+	return columns, nil
+}
+
+// schemaChecks lists the CHECK constraints of every table in the database
+func (db *PGInstance) schemaChecks(ctx context.Context) ([]checkConstraint, error) {
+	if !db.IsSQLite() {
+		var checks []checkConstraint
+		if err := db.DB.WithContext(ctx).Raw(`SELECT conname, pg_get_constraintdef(oid) AS definition
+			FROM pg_constraint WHERE contype = 'c' AND connamespace = current_schema()::regnamespace`).Scan(&checks).Error; err != nil {
+			return nil, fmt.Errorf("failed to read the schema constraints: %v", err)
+		}
+		return checks, nil
+	}
+
+	// SQLite only keeps the statement that created each table
+	var statements []string
+	if err := db.DB.WithContext(ctx).Raw(`SELECT sql FROM sqlite_master WHERE type = 'table'`).Scan(&statements).Error; err != nil {
+		return nil, fmt.Errorf("failed to read the schema constraints: %v", err)
+	}
+
+	checks := []checkConstraint{}
+	for _, statement := range statements {
+		for _, match := range sqliteCheck.FindAllStringSubmatch(statement, -1) {
+			checks = append(checks, checkConstraint{Name: match[1], Definition: match[2]})
+		}
+	}
+
+	return checks, nil
+}
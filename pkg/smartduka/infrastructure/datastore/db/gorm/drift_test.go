@@ -0,0 +1,40 @@
+package gorm_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+)
+
+func TestPGInstance_CheckSchemaDrift(t *testing.T) {
+	problems, err := testingDB.CheckSchemaDrift(context.Background())
+	if err != nil {
+		t.Fatalf("PGInstance.CheckSchemaDrift() error = %v", err)
+	}
+	for _, problem := range problems {
+		t.Errorf("PGInstance.CheckSchemaDrift() %s", problem)
+	}
+}
+
+// the SQLite schema is kept by hand next to the migrations so it is checked whichever database the suite runs against
+func TestPGInstance_CheckSchemaDrift_SQLite(t *testing.T) {
+	sqlite, err := gorm.NewSQLiteInstance(filepath.Join(t.TempDir(), "drift.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteInstance() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if db, err := sqlite.DB.DB(); err == nil {
+			db.Close()
+		}
+	})
+
+	problems, err := sqlite.CheckSchemaDrift(context.Background())
+	if err != nil {
+		t.Fatalf("PGInstance.CheckSchemaDrift() error = %v", err)
+	}
+	for _, problem := range problems {
+		t.Errorf("PGInstance.CheckSchemaDrift() %s", problem)
+	}
+}
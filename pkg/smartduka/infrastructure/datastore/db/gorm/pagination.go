@@ -0,0 +1,103 @@
+package gorm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"gorm.io/gorm"
+)
+
+// productSortColumns are the columns products can be sorted by
+var productSortColumns = map[enums.ProductSortField]string{
+	enums.ProductSortFieldName:      "smartduka_product.name",
+	enums.ProductSortFieldPrice:     "smartduka_product.price",
+	enums.ProductSortFieldCreatedAt: "smartduka_product.created_at",
+}
+
+// userSortColumns are the columns users can be sorted by
+var userSortColumns = map[enums.UserSortField]string{
+	enums.UserSortFieldUsername:  "smartduka_user.username",
+	enums.UserSortFieldCreatedAt: "smartduka_user.created_at",
+}
+
+// ProductCursor marks a product's place in a list of products sorted by field
+func ProductCursor(product *Product, field enums.ProductSortField) pagination.Cursor {
+	switch field {
+	case enums.ProductSortFieldName:
+		return pagination.Cursor{Value: product.Name, ID: product.ID}
+	case enums.ProductSortFieldPrice:
+		return pagination.Cursor{Value: product.Price.String(), ID: product.ID}
+	default:
+		return pagination.Cursor{Value: product.CreatedAt.UTC().Format(time.RFC3339Nano), ID: product.ID}
+	}
+}
+
+// ProductSortValue reads the value of the sort field from the cursor of a list of products
+func ProductSortValue(field enums.ProductSortField, cursor *pagination.Cursor) (interface{}, error) {
+	switch field {
+	case enums.ProductSortFieldName:
+		return cursor.Value, nil
+	case enums.ProductSortFieldPrice:
+		price, err := money.Parse(cursor.Value, money.DefaultCurrency)
+		if err != nil {
+			return nil, domain.Errorf(domain.Validation, "invalid cursor")
+		}
+		return price, nil
+	default:
+		return parseCursorTime(cursor)
+	}
+}
+
+// UserCursor marks a user's place in a list of users sorted by field
+func UserCursor(user *User, field enums.UserSortField) pagination.Cursor {
+	if field == enums.UserSortFieldUsername {
+		return pagination.Cursor{Value: user.UserName, ID: *user.ID}
+	}
+	return pagination.Cursor{Value: user.CreatedAt.UTC().Format(time.RFC3339Nano), ID: *user.ID}
+}
+
+// UserSortValue reads the value of the sort field from the cursor of a list of users
+func UserSortValue(field enums.UserSortField, cursor *pagination.Cursor) (interface{}, error) {
+	if field == enums.UserSortFieldUsername {
+		return cursor.Value, nil
+	}
+	return parseCursorTime(cursor)
+}
+
+// SaleCursor marks a sale's place in a list of sales, which are always sorted by when they were made
+func SaleCursor(sale *Sale) pagination.Cursor {
+	return pagination.Cursor{Value: sale.CreatedAt.UTC().Format(time.RFC3339Nano), ID: sale.ID}
+}
+
+// SaleSortValue reads when the last sale on a page was made from its cursor
+func SaleSortValue(cursor *pagination.Cursor) (interface{}, error) {
+	return parseCursorTime(cursor)
+}
+
+func parseCursorTime(cursor *pagination.Cursor) (interface{}, error) {
+	at, err := time.Parse(time.RFC3339Nano, cursor.Value)
+	if err != nil {
+		return nil, domain.Errorf(domain.Validation, "invalid cursor")
+	}
+	return at, nil
+}
+
+// keyset orders a query by a column and then the ID, so that records with the same value keep their order
+// from page to page, and starts it right after the record the cursor marks
+func keyset(query *gorm.DB, column string, idColumn string, direction enums.SortDirection, after *pagination.Cursor, value interface{}) *gorm.DB {
+	order, comparison := "ASC", ">"
+	if direction == enums.SortDirectionDesc {
+		order, comparison = "DESC", "<"
+	}
+
+	id := fmt.Sprintf("CAST(%s AS TEXT)", idColumn)
+	if after != nil {
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", column, id, comparison), value, after.ID)
+	}
+
+	return query.Order(fmt.Sprintf("%s %s, %s %s", column, order, id, order))
+}
@@ -3,29 +3,91 @@ package gorm
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/search"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+const (
+	// saleLineValueSQL is the value of a sale line rounded to the nearest cent, less any discount given on it
+	saleLineValueSQL = "(ROUND(smartduka_sale.price * smartduka_sale.quantity, 2) - smartduka_sale.discount)"
+
+	// saleRevenueSQL sums the value of each sale line
+	saleRevenueSQL = "COALESCE(SUM" + saleLineValueSQL + ", 0)"
+
+	// saleQuantitySQL sums the quantities sold
+	saleQuantitySQL = "COALESCE(SUM(smartduka_sale.quantity), 0)"
+
+	// saleCostSQL sums the cost of the goods sold
+	saleCostSQL = "COALESCE(SUM(smartduka_sale.cost_of_goods), 0)"
+
+	// saleBelowCostSQL counts the sales made for less than the goods cost
+	saleBelowCostSQL = "COUNT(*) FILTER (WHERE " + saleLineValueSQL + " < smartduka_sale.cost_of_goods)"
+
+	// cashierNameSQL is the full name of the user who made a sale, or their username if they have no name
+	cashierNameSQL = "NULLIF(TRIM(COALESCE(smartduka_user.first_name, '') || ' ' || COALESCE(smartduka_user.last_name, '')), '')"
+)
+
+// ErrRecordNotFound is returned when a lookup matches nothing
+var ErrRecordNotFound = gorm.ErrRecordNotFound
+
 // Query holds all the database record query methods
 type Query interface {
 	GetUserProfileByUserID(ctx context.Context, userID *string) (*User, error)
 	GetUserProfileByPhoneNumber(ctx context.Context, phoneNumber string, flavour enums.Flavour) (*User, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	GetUserByIdentifier(ctx context.Context, identifierType enums.IdentifierType, value string) (*User, error)
 	GetUserPINByUserID(ctx context.Context, userID string, flavour enums.Flavour) (*UserPIN, error)
+	GetValidOTP(ctx context.Context, phoneNumber string, flavour enums.Flavour, otp string) (*OTP, error)
 	SearchUser(ctx context.Context, searchTerm string) ([]*User, error)
+	ListUsers(ctx context.Context, filter *dto.UserFilter, sortBy dto.UserSort, after *pagination.Cursor, limit int) ([]*User, error)
+	GetUsersByIDs(ctx context.Context, ids []string) ([]*User, error)
+	GetContactsByUserIDs(ctx context.Context, userIDs []string) ([]*Contact, error)
 
 	GetProductByID(ctx context.Context, id string) (*Product, error)
-	GetDailySale(ctx context.Context) ([]*Sale, error)
-	SearchProduct(ctx context.Context, searchTerm string) ([]*Product, error)
+	GetProductsByIDs(ctx context.Context, ids []string) ([]*Product, error)
+	GetSalesByShiftIDs(ctx context.Context, shiftIDs []string) ([]*Sale, error)
+	GetDailySale(ctx context.Context, location *time.Location) ([]*Sale, error)
+	GetSaleByID(ctx context.Context, id string) (*Sale, error)
+	GetStockReceiptByID(ctx context.Context, id string) (*StockReceipt, error)
+	SearchProduct(ctx context.Context, searchTerm string, limit int) ([]*Product, error)
+	StreamProducts(ctx context.Context, fn func(product *Product) error) error
+	StreamSales(ctx context.Context, from, to time.Time, fn func(sale *SaleExport) error) error
+	ListProducts(ctx context.Context, filter *dto.ProductFilter, sortBy dto.ProductSort, after *pagination.Cursor, limit int) ([]*Product, error)
+	ListSales(ctx context.Context, filter *dto.SaleFilter, direction enums.SortDirection, after *pagination.Cursor, limit int) ([]*Sale, error)
+
+	GetSalesSummary(ctx context.Context, from, to time.Time, interval enums.ReportInterval, location *time.Location) ([]*SalesSummary, error)
+	GetSalesBreakdown(ctx context.Context, from, to time.Time, grouping enums.SalesGrouping) ([]*SalesBreakdown, error)
+	GetTopProducts(ctx context.Context, from, to time.Time, ranking enums.ProductRanking, limit int) ([]*ProductSales, error)
+	GetHourlySales(ctx context.Context, from, to time.Time, location *time.Location) ([]*HourlySales, error)
+	GetProfitReport(ctx context.Context, from, to time.Time, grouping enums.ProfitGrouping, location *time.Location) ([]*ProfitSummary, error)
+
+	GetOpenShift(ctx context.Context, cashierID string) (*Shift, error)
+	GetShiftByID(ctx context.Context, id string) (*Shift, error)
+	GetShiftTenders(ctx context.Context, shiftID string) ([]*ShiftTender, error)
+	GetShiftTotals(ctx context.Context, shiftID string) (*ShiftTotals, error)
+	GetShiftCashMovements(ctx context.Context, shiftID string) ([]*ShiftCashMovements, error)
+	GetShiftExpectedCash(ctx context.Context, shiftID string) (money.Money, error)
+
+	GetSyncOperation(ctx context.Context, id string) (*SyncOperation, error)
+	GetSyncDevice(ctx context.Context, id string) (*SyncDevice, error)
+	GetProductChanges(ctx context.Context, since time.Time, afterID string, limit int) ([]*Product, error)
+
+	GetAuditLogs(ctx context.Context, filter *dto.AuditLogFilter, limit int) ([]*AuditLog, error)
 }
 
 // GetUserProfileByUserID fetches a user profile using the user ID
 func (db *PGInstance) GetUserProfileByUserID(ctx context.Context, userID *string) (*User, error) {
 	var user User
-	if err := db.DB.Where(&User{ID: userID, Active: true}).Preload(clause.Associations).First(&user).Error; err != nil {
-		return nil, fmt.Errorf("failed to get user by user ID %v: %v", userID, err)
+	if err := db.DB.WithContext(ctx).Where(&User{ID: userID, Active: true}).Preload(clause.Associations).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user by user ID %v: %w", userID, err)
 	}
 	return &user, nil
 }
@@ -34,35 +96,74 @@ func (db *PGInstance) GetUserProfileByUserID(ctx context.Context, userID *string
 func (db *PGInstance) GetUserProfileByPhoneNumber(ctx context.Context, phoneNumber string, flavour enums.Flavour) (*User, error) {
 	var user *User
 
-	if err := db.DB.Joins("JOIN smartduka_contact on smartduka_user.id = smartduka_contact.user_id").Where("smartduka_contact.contact_value = ? AND smartduka_contact.flavour = ?", phoneNumber, flavour).Preload(clause.Associations).First(&user).Error; err != nil {
-		return nil, fmt.Errorf("failed to get user by phonenumber %v: %v", phoneNumber, err)
+	if err := db.DB.WithContext(ctx).Joins("JOIN smartduka_contact on smartduka_user.id = smartduka_contact.user_id").Where("smartduka_contact.contact_value = ? AND smartduka_contact.flavour = ?", phoneNumber, flavour).Preload(clause.Associations).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user by phonenumber %v: %w", phoneNumber, err)
 	}
 
 	return user, nil
 }
 
+// GetUserByUsername fetches the user who has taken a username
+func (db *PGInstance) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	var user User
+	if err := db.DB.WithContext(ctx).Where(&User{UserName: username}).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user by username %v: %w", username, err)
+	}
+
+	return &user, nil
+}
+
+// GetUserByIdentifier fetches the user identified by a document e.g their national ID
+func (db *PGInstance) GetUserByIdentifier(ctx context.Context, identifierType enums.IdentifierType, value string) (*User, error) {
+	var user User
+	if err := db.DB.WithContext(ctx).Joins("JOIN smartduka_identifier ON smartduka_user.id = smartduka_identifier.user_id").
+		Where("smartduka_identifier.identifier_type = ? AND smartduka_identifier.identifier_value = ? AND smartduka_identifier.deleted_at IS NULL", identifierType, value).
+		First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user by %v %v: %w", identifierType, value, err)
+	}
+
+	return &user, nil
+}
+
 // GetUserPINByUserID fetches a user's pin using the user ID and Flavour
 func (db *PGInstance) GetUserPINByUserID(ctx context.Context, userID string, flavour enums.Flavour) (*UserPIN, error) {
 	if !flavour.IsValid() {
 		return nil, fmt.Errorf("flavour is not valid")
 	}
 	var pin UserPIN
-	if err := db.DB.Where(&UserPIN{UserID: userID, Active: true}).First(&pin).Error; err != nil {
-		return nil, fmt.Errorf("failed to get pin: %v", err)
+	if err := db.DB.Where(&UserPIN{UserID: userID, Flavour: flavour, Active: true}).First(&pin).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pin: %w", err)
 	}
 
 	return &pin, nil
 }
 
+// GetValidOTP fetches an OTP sent to a phone number that has neither been used nor expired
+func (db *PGInstance) GetValidOTP(ctx context.Context, phoneNumber string, flavour enums.Flavour, otp string) (*OTP, error) {
+	var found OTP
+	if err := db.DB.WithContext(ctx).Where(&OTP{PhoneNumber: phoneNumber, Flavour: flavour, OTP: otp, IsValid: true}).
+		Where("valid_until > ?", time.Now()).First(&found).Error; err != nil {
+		return nil, fmt.Errorf("failed to get otp: %w", err)
+	}
+
+	return &found, nil
+}
+
 // SearchUser searches for a user using the search term
 func (db *PGInstance) SearchUser(ctx context.Context, searchTerm string) ([]*User, error) {
+	dialect := db.dialect()
+	conditions := []string{}
+	for _, column := range []string{"smartduka_contact.contact_value", "smartduka_user.first_name", "smartduka_user.last_name", "smartduka_user.username"} {
+		conditions = append(conditions, dialect.containsFold(column))
+	}
+	pattern := "%" + searchTerm + "%"
+
 	var users []*User
-	if err := db.DB.Joins("JOIN smartduka_contact on smartduka_user.id = smartduka_contact.user_id").
-		Where("smartduka_contact.contact_value ILIKE ? OR smartduka_user.first_name ILIKE ? "+
-			"OR smartduka_user.last_name ILIKE ? OR smartduka_user.username ILIKE ?", "%"+searchTerm+"%", "%"+searchTerm+"%", "%"+searchTerm+"%", "%"+searchTerm+"%").
+	if err := db.DB.WithContext(ctx).Joins("JOIN smartduka_contact on smartduka_user.id = smartduka_contact.user_id").
+		Where(strings.Join(conditions, " OR "), pattern, pattern, pattern, pattern).
 		Where("smartduka_user.active = ?", true).
 		Preload(clause.Associations).Find(&users).Error; err != nil {
-		return nil, fmt.Errorf("failed to search user: %v", err)
+		return nil, fmt.Errorf("failed to search user: %w", err)
 	}
 
 	return users, nil
@@ -79,27 +180,635 @@ func (db *PGInstance) GetProductByID(ctx context.Context, id string) (*Product,
 	return product, nil
 }
 
-// GetDailySale retrieves daily sales
-func (db *PGInstance) GetDailySale(ctx context.Context) ([]*Sale, error) {
+// GetUsersByIDs retrieves the users with the IDs, including deleted ones so that the records they made can name them
+func (db *PGInstance) GetUsersByIDs(ctx context.Context, ids []string) ([]*User, error) {
+	var users []*User
+	if err := db.DB.WithContext(ctx).Unscoped().Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetContactsByUserIDs retrieves the contacts of the users with the IDs
+func (db *PGInstance) GetContactsByUserIDs(ctx context.Context, userIDs []string) ([]*Contact, error) {
+	var contacts []*Contact
+	if err := db.DB.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&contacts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get contacts: %w", err)
+	}
+
+	return contacts, nil
+}
+
+// GetProductsByIDs retrieves the products with the IDs, including deleted ones so that past sales can name them
+func (db *PGInstance) GetProductsByIDs(ctx context.Context, ids []string) ([]*Product, error) {
+	var products []*Product
+	if err := db.DB.WithContext(ctx).Unscoped().Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	return products, nil
+}
+
+// GetSalesByShiftIDs retrieves the active sales made during the shifts with the IDs in the order they were made
+func (db *PGInstance) GetSalesByShiftIDs(ctx context.Context, shiftIDs []string) ([]*Sale, error) {
+	var sales []*Sale
+	if err := db.DB.WithContext(ctx).Where("shift_id IN ? AND active = ?", shiftIDs, true).
+		Order("created_at, id").Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sales: %w", err)
+	}
+
+	return sales, nil
+}
+
+// GetSaleByID retrieves a sale and its tax invoice using its ID
+func (db *PGInstance) GetSaleByID(ctx context.Context, id string) (*Sale, error) {
+	var sale Sale
+	if err := db.DB.WithContext(ctx).Preload("TaxInvoice").Where(&Sale{ID: id}).First(&sale).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sale: %w", err)
+	}
+
+	return &sale, nil
+}
+
+// GetStockReceiptByID retrieves a delivery of stock using its ID
+func (db *PGInstance) GetStockReceiptByID(ctx context.Context, id string) (*StockReceipt, error) {
+	var receipt StockReceipt
+	if err := db.DB.WithContext(ctx).Where(&StockReceipt{ID: id}).First(&receipt).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stock receipt: %w", err)
+	}
+
+	return &receipt, nil
+}
+
+// GetDailySale retrieves the sales made since midnight in the shop's timezone
+func (db *PGInstance) GetDailySale(ctx context.Context, location *time.Location) ([]*Sale, error) {
 	var sale []*Sale
 
-	last24hrs := time.Now().Add(time.Hour * -24)
-	if err := db.DB.Model(&Sale{}).Joins("JOIN smartduka_product on smartduka_sale.product_id = smartduka_product.id").Where("smartduka_sale.created_at > ?", last24hrs).
-		Preload(clause.Associations).Find(&sale).Error; err != nil {
+	now := time.Now().In(location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	if err := db.DB.WithContext(ctx).Model(&Sale{}).Where("smartduka_sale.created_at >= ? AND smartduka_sale.active = ?", midnight.UTC(), true).
+		Preload("Product", unscoped).Preload("TaxInvoice").Find(&sale).Error; err != nil {
 		return nil, err
 	}
 
 	return sale, nil
 }
 
-// SearchProduct searches a product using the term provided by the user
-func (db *PGInstance) SearchProduct(ctx context.Context, searchTerm string) ([]*Product, error) {
+// SearchProduct finds the active products matching a search term, best matches first.
+// Postgres matches the term against the products' search vector and the trigram index of their names.
+// SQLite has neither, so LIKE narrows the products down and they are matched and ranked in Go
+func (db *PGInstance) SearchProduct(ctx context.Context, searchTerm string, limit int) ([]*Product, error) {
+	query := search.Parse(searchTerm)
+	if query.IsEmpty() {
+		return []*Product{}, nil
+	}
+
 	var products []*Product
+	if db.IsSQLite() {
+		match, args := sqliteSearchCondition(db.dialect(), query)
+		if err := db.DB.WithContext(ctx).Model(&Product{}).Where("smartduka_product.active = ?", true).
+			Where(match, args...).Find(&products).Error; err != nil {
+			return nil, fmt.Errorf("failed to search product: %w", err)
+		}
+		return RankProducts(products, query, limit), nil
+	}
 
-	if err := db.DB.Model(&Product{}).Where("smartduka_product.name ILIKE ? AND smartduka_product.active = ?", "%"+searchTerm+"%", true).
-		Preload(clause.Associations).Find(&products).Error; err != nil {
-		return nil, fmt.Errorf("failed to search product: %v", err)
+	tsquery := query.TSQuery()
+	if err := db.DB.WithContext(ctx).Model(&Product{}).
+		Where("smartduka_product.active = ?", true).
+		Where("(smartduka_product.search_vector @@ to_tsquery('simple', ?) OR ? <% smartduka_product.name)", tsquery, query.Term).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(smartduka_product.search_vector, to_tsquery('simple', ?)) + word_similarity(?, smartduka_product.name) DESC, smartduka_product.name",
+			Vars:               []interface{}{tsquery, query.Term},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to search product: %w", err)
 	}
 
 	return products, nil
 }
+
+// sqliteSearchCondition narrows the products down to those worth ranking: the ones containing every word of the
+// term or one of its synonyms, and the ones whose name shares a fragment with the term so typos still match.
+// LIKE cannot tell where words start, so it lets through more products than the ranking keeps
+func sqliteSearchCondition(dialect dialect, query search.Query) (string, []interface{}) {
+	columns := []string{"smartduka_product.name", "smartduka_product.manufacturer", "smartduka_product.description"}
+
+	words, args := []string{}, []interface{}{}
+	for _, alternatives := range query.Words {
+		conditions := []string{}
+		for _, word := range alternatives {
+			for _, column := range columns {
+				conditions = append(conditions, dialect.containsFold(column))
+				args = append(args, "%"+word+"%")
+			}
+		}
+		words = append(words, "("+strings.Join(conditions, " OR ")+")")
+	}
+
+	conditions := []string{"(" + strings.Join(words, " AND ") + ")"}
+	for _, fragment := range query.Fragments() {
+		conditions = append(conditions, dialect.containsFold("smartduka_product.name"))
+		args = append(args, "%"+fragment+"%")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// RankProducts sorts the products matching a search query from the best match to the worst and keeps the first limit of them
+func RankProducts(products []*Product, query search.Query, limit int) []*Product {
+	return search.Rank(products, query, limit, func(product *Product) (string, string, string) {
+		return product.Name, product.Manufacturer, product.Description
+	})
+}
+
+// ListUsers fetches a page of the users matching the filter, starting after the cursor
+func (db *PGInstance) ListUsers(ctx context.Context, filter *dto.UserFilter, sortBy dto.UserSort, after *pagination.Cursor, limit int) ([]*User, error) {
+	query := db.DB.WithContext(ctx).Model(&User{})
+	if filter.SearchTerm != nil {
+		dialect := db.dialect()
+		pattern := "%" + *filter.SearchTerm + "%"
+		contact := "EXISTS (SELECT 1 FROM smartduka_contact WHERE smartduka_contact.user_id = smartduka_user.id " +
+			"AND smartduka_contact.deleted_at IS NULL AND " + dialect.containsFold("smartduka_contact.contact_value") + ")"
+		conditions := []string{contact}
+		for _, column := range []string{"smartduka_user.first_name", "smartduka_user.last_name", "smartduka_user.username"} {
+			conditions = append(conditions, dialect.containsFold(column))
+		}
+		query = query.Where(strings.Join(conditions, " OR "), pattern, pattern, pattern, pattern)
+	}
+	if filter.Active != nil {
+		query = query.Where("smartduka_user.active = ?", *filter.Active)
+	}
+
+	var value interface{}
+	if after != nil {
+		var err error
+		if value, err = UserSortValue(sortBy.Field, after); err != nil {
+			return nil, err
+		}
+	}
+
+	var users []*User
+	if err := keyset(query, userSortColumns[sortBy.Field], "smartduka_user.id", sortBy.Direction, after, value).
+		Limit(limit).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return users, nil
+}
+
+// ListProducts fetches a page of the products matching the filter, starting after the cursor
+func (db *PGInstance) ListProducts(ctx context.Context, filter *dto.ProductFilter, sortBy dto.ProductSort, after *pagination.Cursor, limit int) ([]*Product, error) {
+	query := db.DB.WithContext(ctx).Model(&Product{})
+	if filter.SearchTerm != nil {
+		query = query.Where(db.dialect().containsFold("smartduka_product.name"), "%"+*filter.SearchTerm+"%")
+	}
+	if filter.Active != nil {
+		query = query.Where("smartduka_product.active = ?", *filter.Active)
+	}
+	if filter.Category != nil {
+		query = query.Where("smartduka_product.category = ?", *filter.Category)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("smartduka_product.price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("smartduka_product.price <= ?", *filter.MaxPrice)
+	}
+
+	var value interface{}
+	if after != nil {
+		var err error
+		if value, err = ProductSortValue(sortBy.Field, after); err != nil {
+			return nil, err
+		}
+	}
+
+	var products []*Product
+	if err := keyset(query, productSortColumns[sortBy.Field], "smartduka_product.id", sortBy.Direction, after, value).
+		Limit(limit).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	return products, nil
+}
+
+// ListSales fetches a page of the active sales matching the filter in the order they were made, starting after the cursor
+func (db *PGInstance) ListSales(ctx context.Context, filter *dto.SaleFilter, direction enums.SortDirection, after *pagination.Cursor, limit int) ([]*Sale, error) {
+	query := db.DB.WithContext(ctx).Model(&Sale{}).Where("smartduka_sale.active = ?", true)
+	if filter.From != nil {
+		query = query.Where("smartduka_sale.created_at >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		query = query.Where("smartduka_sale.created_at < ?", filter.To.UTC())
+	}
+	if filter.ProductID != nil {
+		query = query.Where("smartduka_sale.product_id = ?", *filter.ProductID)
+	}
+	if filter.PaymentMethod != nil {
+		query = query.Where("smartduka_sale.payment_method = ?", *filter.PaymentMethod)
+	}
+
+	var value interface{}
+	if after != nil {
+		var err error
+		if value, err = SaleSortValue(after); err != nil {
+			return nil, err
+		}
+	}
+
+	var sales []*Sale
+	if err := keyset(query, "smartduka_sale.created_at", "smartduka_sale.id", direction, after, value).
+		Preload("TaxInvoice").Limit(limit).Find(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sales: %w", err)
+	}
+
+	return sales, nil
+}
+
+// unscoped includes deleted records. Sales keep showing the products they sold after those products are deleted
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// salesWithin scopes a query to the active sales made in the period [from, to)
+func (db *PGInstance) salesWithin(ctx context.Context, from, to time.Time) *gorm.DB {
+	return db.DB.WithContext(ctx).Model(&Sale{}).
+		Where("smartduka_sale.created_at >= ? AND smartduka_sale.created_at < ?", from.UTC(), to.UTC()).
+		Where("smartduka_sale.active = ?", true)
+}
+
+// GetSalesSummary totals the sales made in the period [from, to) by calendar day, week or month in the shop's timezone
+func (db *PGInstance) GetSalesSummary(ctx context.Context, from, to time.Time, interval enums.ReportInterval, location *time.Location) ([]*SalesSummary, error) {
+	var unit string
+	switch interval {
+	case enums.ReportIntervalDay:
+		unit = "day"
+	case enums.ReportIntervalWeek:
+		unit = "week"
+	case enums.ReportIntervalMonth:
+		unit = "month"
+	default:
+		return nil, fmt.Errorf("invalid report interval: %s", interval)
+	}
+
+	dialect := db.dialect()
+	localTime, zone := dialect.localTime("smartduka_sale.created_at", location, from, to)
+
+	var rows []*struct {
+		SalesSummary
+		Day string `gorm:"column:day"`
+	}
+	selection := fmt.Sprintf("%s AS day, %s AS revenue, %s AS quantity, COUNT(*) AS transactions",
+		dialect.period(unit, localTime), saleRevenueSQL, saleQuantitySQL)
+	if err := db.salesWithin(ctx, from, to).Select(selection, zone...).
+		Group("day").Order("day").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to summarize sales: %w", err)
+	}
+
+	// the period is the shop's wall clock date and is anchored back to its timezone
+	summaries := []*SalesSummary{}
+	for _, row := range rows {
+		period, err := time.ParseInLocation("2006-01-02", row.Day, location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sales period %s: %w", row.Day, err)
+		}
+		row.Period = period
+		summaries = append(summaries, &row.SalesSummary)
+	}
+
+	return summaries, nil
+}
+
+// GetSalesBreakdown totals the sales made in the period [from, to) by cashier, product category or payment method
+func (db *PGInstance) GetSalesBreakdown(ctx context.Context, from, to time.Time, grouping enums.SalesGrouping) ([]*SalesBreakdown, error) {
+	query := db.salesWithin(ctx, from, to)
+
+	var key, label string
+	switch grouping {
+	case enums.SalesGroupingCashier:
+		query = query.Joins("LEFT JOIN smartduka_user ON smartduka_user.id = smartduka_sale.created_by")
+		key = "COALESCE(CAST(smartduka_sale.created_by AS TEXT), '')"
+		label = "COALESCE(" + cashierNameSQL + ", smartduka_user.username, 'Unknown')"
+	case enums.SalesGroupingCategory:
+		query = query.Joins("JOIN smartduka_product ON smartduka_product.id = smartduka_sale.product_id")
+		key = "smartduka_product.category"
+		label = "smartduka_product.category"
+	case enums.SalesGroupingPaymentMethod:
+		key = "smartduka_sale.payment_method"
+		label = "smartduka_sale.payment_method"
+	default:
+		return nil, fmt.Errorf("invalid sales grouping: %s", grouping)
+	}
+
+	var breakdown []*SalesBreakdown
+	selection := fmt.Sprintf("%s AS key, %s AS label, %s AS revenue, %s AS quantity, COUNT(*) AS transactions",
+		key, label, saleRevenueSQL, saleQuantitySQL)
+	if err := query.Select(selection).Group("key, label").Order("revenue DESC").Scan(&breakdown).Error; err != nil {
+		return nil, fmt.Errorf("failed to break down sales: %w", err)
+	}
+
+	return breakdown, nil
+}
+
+// GetTopProducts returns the best selling products in the period [from, to) ranked by revenue or quantity sold
+func (db *PGInstance) GetTopProducts(ctx context.Context, from, to time.Time, ranking enums.ProductRanking, limit int) ([]*ProductSales, error) {
+	var order string
+	switch ranking {
+	case enums.ProductRankingRevenue:
+		order = "revenue DESC, quantity DESC"
+	case enums.ProductRankingQuantity:
+		order = "quantity DESC, revenue DESC"
+	default:
+		return nil, fmt.Errorf("invalid product ranking: %s", ranking)
+	}
+
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be greater than zero")
+	}
+
+	var products []*ProductSales
+	selection := fmt.Sprintf("smartduka_product.id AS product_id, smartduka_product.name AS name, smartduka_product.category AS category, "+
+		"%s AS revenue, %s AS quantity, COUNT(*) AS transactions", saleRevenueSQL, saleQuantitySQL)
+	if err := db.salesWithin(ctx, from, to).
+		Joins("JOIN smartduka_product ON smartduka_product.id = smartduka_sale.product_id").
+		Select(selection).
+		Group("smartduka_product.id, smartduka_product.name, smartduka_product.category").
+		Order(order + ", name").Limit(limit).Scan(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get top products: %w", err)
+	}
+
+	return products, nil
+}
+
+// GetHourlySales totals the sales made in the period [from, to) by day of the week and hour of the day in the shop's timezone.
+// Days of the week follow ISO 8601 i.e Monday is 1 and Sunday is 7
+func (db *PGInstance) GetHourlySales(ctx context.Context, from, to time.Time, location *time.Location) ([]*HourlySales, error) {
+	dialect := db.dialect()
+	localTime, zone := dialect.localTime("smartduka_sale.created_at", location, from, to)
+
+	var sales []*HourlySales
+	selection := fmt.Sprintf("%s AS day_of_week, %s AS hour, %s AS revenue, COUNT(*) AS transactions",
+		dialect.isoDayOfWeek(localTime), dialect.hourOfDay(localTime), saleRevenueSQL)
+	args := append(append([]interface{}{}, zone...), zone...)
+	if err := db.salesWithin(ctx, from, to).Select(selection, args...).
+		Group("day_of_week, hour").Order("day_of_week, hour").Scan(&sales).Error; err != nil {
+		return nil, fmt.Errorf("failed to get hourly sales: %w", err)
+	}
+
+	return sales, nil
+}
+
+// GetProfitReport totals the revenue and cost of the goods sold in the period [from, to) for each product,
+// category or calendar day, week or month in the shop's timezone
+func (db *PGInstance) GetProfitReport(ctx context.Context, from, to time.Time, grouping enums.ProfitGrouping, location *time.Location) ([]*ProfitSummary, error) {
+	query := db.salesWithin(ctx, from, to).Joins("JOIN smartduka_product ON smartduka_product.id = smartduka_sale.product_id")
+	args := []interface{}{}
+
+	grossProfitOrder := fmt.Sprintf("%s - %s DESC, label", saleRevenueSQL, saleCostSQL)
+
+	var key, label, order string
+	switch grouping {
+	case enums.ProfitGroupingProduct:
+		key = "CAST(smartduka_product.id AS TEXT)"
+		label = "smartduka_product.name"
+		order = grossProfitOrder
+	case enums.ProfitGroupingCategory:
+		key = "smartduka_product.category"
+		label = "smartduka_product.category"
+		order = grossProfitOrder
+	case enums.ProfitGroupingDay, enums.ProfitGroupingWeek, enums.ProfitGroupingMonth:
+		dialect := db.dialect()
+		localTime, zone := dialect.localTime("smartduka_sale.created_at", location, from, to)
+		period := dialect.period(strings.ToLower(grouping.String()), localTime)
+		key = period
+		label = period
+		order = "key"
+		args = append(append(args, zone...), zone...)
+	default:
+		return nil, fmt.Errorf("invalid profit grouping: %s", grouping)
+	}
+
+	var report []*ProfitSummary
+	selection := fmt.Sprintf("%s AS key, %s AS label, %s AS revenue, %s AS cost_of_goods, %s AS quantity, COUNT(*) AS transactions, %s AS below_cost_transactions",
+		key, label, saleRevenueSQL, saleCostSQL, saleQuantitySQL, saleBelowCostSQL)
+	if err := query.Select(selection, args...).Group("key, label").Order(order).Scan(&report).Error; err != nil {
+		return nil, fmt.Errorf("failed to get profit report: %w", err)
+	}
+
+	return report, nil
+}
+
+// GetOpenShift fetches the shift a cashier currently has open
+func (db *PGInstance) GetOpenShift(ctx context.Context, cashierID string) (*Shift, error) {
+	var shift Shift
+	if err := db.DB.WithContext(ctx).Where(&Shift{CashierID: cashierID, Status: enums.ShiftStatusOpen}).First(&shift).Error; err != nil {
+		return nil, fmt.Errorf("failed to get open shift: %w", err)
+	}
+
+	return &shift, nil
+}
+
+// GetShiftByID fetches a shift using its ID
+func (db *PGInstance) GetShiftByID(ctx context.Context, id string) (*Shift, error) {
+	var shift Shift
+	if err := db.DB.WithContext(ctx).Where(&Shift{ID: id}).First(&shift).Error; err != nil {
+		return nil, fmt.Errorf("failed to get shift: %w", err)
+	}
+
+	return &shift, nil
+}
+
+// GetShiftTenders totals the sales made during a shift by payment method
+func (db *PGInstance) GetShiftTenders(ctx context.Context, shiftID string) ([]*ShiftTender, error) {
+	var tenders []*ShiftTender
+	selection := fmt.Sprintf("smartduka_sale.payment_method AS payment_method, %s AS amount, COUNT(*) AS transactions", saleRevenueSQL)
+	if err := db.DB.WithContext(ctx).Model(&Sale{}).Where("smartduka_sale.shift_id = ? AND smartduka_sale.active = ?", shiftID, true).
+		Select(selection).Group("payment_method").Order("payment_method").Scan(&tenders).Error; err != nil {
+		return nil, fmt.Errorf("failed to get shift tenders: %w", err)
+	}
+
+	return tenders, nil
+}
+
+// GetShiftTotals totals the sales, returns, discounts and VAT of a shift.
+// Returns are sale lines with a negative quantity. Prices include VAT at the product's rate
+func (db *PGInstance) GetShiftTotals(ctx context.Context, shiftID string) (*ShiftTotals, error) {
+	var totals ShiftTotals
+	selection := fmt.Sprintf("COALESCE(SUM(ROUND(smartduka_sale.price * smartduka_sale.quantity, 2)) FILTER (WHERE smartduka_sale.quantity > 0), 0) AS gross_sales, "+
+		"COALESCE(-SUM(ROUND(smartduka_sale.price * smartduka_sale.quantity, 2)) FILTER (WHERE smartduka_sale.quantity < 0), 0) AS returns, "+
+		"COALESCE(SUM(smartduka_sale.discount), 0) AS discounts, "+
+		"COALESCE(SUM(ROUND(%s * smartduka_product.vat / (100 + smartduka_product.vat), 2)), 0) AS vat, "+
+		"COUNT(*) AS transactions", saleLineValueSQL)
+	if err := db.DB.WithContext(ctx).Model(&Sale{}).
+		Joins("JOIN smartduka_product ON smartduka_product.id = smartduka_sale.product_id").
+		Where("smartduka_sale.shift_id = ? AND smartduka_sale.active = ?", shiftID, true).
+		Select(selection).Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get shift totals: %w", err)
+	}
+
+	return &totals, nil
+}
+
+// GetShiftCashMovements totals the cash put into or taken out of the drawer during a shift by type of movement
+func (db *PGInstance) GetShiftCashMovements(ctx context.Context, shiftID string) ([]*ShiftCashMovements, error) {
+	var movements []*ShiftCashMovements
+	if err := db.DB.WithContext(ctx).Model(&CashMovement{}).Where("shift_id = ? AND active = ?", shiftID, true).
+		Select("type, COALESCE(SUM(amount), 0) AS amount").Group("type").Order("type").Scan(&movements).Error; err != nil {
+		return nil, fmt.Errorf("failed to get shift cash movements: %w", err)
+	}
+
+	return movements, nil
+}
+
+// GetShiftExpectedCash works out the cash that should be in the drawer of a shift
+func (db *PGInstance) GetShiftExpectedCash(ctx context.Context, shiftID string) (money.Money, error) {
+	shift, err := db.GetShiftByID(ctx, shiftID)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	return expectedCash(db.DB.WithContext(ctx), shift)
+}
+
+// expectedCash is the opening float plus the cash taken in sales and paid into the drawer,
+// less the cash paid out of or dropped from the drawer
+func expectedCash(tx *gorm.DB, shift *Shift) (money.Money, error) {
+	var sales struct {
+		Amount money.Money `gorm:"column:amount"`
+	}
+	if err := tx.Model(&Sale{}).Where("smartduka_sale.shift_id = ? AND smartduka_sale.active = ? AND smartduka_sale.payment_method = ?", shift.ID, true, enums.PaymentMethodCash).
+		Select(saleRevenueSQL + " AS amount").Scan(&sales).Error; err != nil {
+		return money.Money{}, fmt.Errorf("failed to total cash sales: %w", err)
+	}
+
+	var movements struct {
+		Amount money.Money `gorm:"column:amount"`
+	}
+	if err := tx.Model(&CashMovement{}).Where("shift_id = ? AND active = ?", shift.ID, true).
+		Select("COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE -amount END), 0) AS amount", enums.CashMovementTypeCashIn).
+		Scan(&movements).Error; err != nil {
+		return money.Money{}, fmt.Errorf("failed to total cash movements: %w", err)
+	}
+
+	return money.Sum(shift.Currency, shift.OpeningFloat, money.New(sales.Amount.Amount, shift.Currency), money.New(movements.Amount.Amount, shift.Currency))
+}
+
+// StreamProducts passes each active product to fn in order of name without loading them all into memory
+func (db *PGInstance) StreamProducts(ctx context.Context, fn func(product *Product) error) error {
+	rows, err := db.DB.WithContext(ctx).Model(&Product{}).Where("active = ?", true).Order("name").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product Product
+		if err := db.DB.ScanRows(rows, &product); err != nil {
+			return fmt.Errorf("failed to read product: %w", err)
+		}
+		// hooks are not run when scanning rows one at a time
+		if err := product.AfterFind(db.DB); err != nil {
+			return err
+		}
+
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// StreamSales passes each sale made in the period [from, to) to fn in the order they were made
+// without loading them all into memory
+func (db *PGInstance) StreamSales(ctx context.Context, from, to time.Time, fn func(sale *SaleExport) error) error {
+	rows, err := db.salesWithin(ctx, from, to).
+		Joins("JOIN smartduka_product ON smartduka_product.id = smartduka_sale.product_id").
+		Joins("LEFT JOIN smartduka_user ON smartduka_user.id = smartduka_sale.created_by").
+		Select("smartduka_sale.*, smartduka_product.name AS product_name, smartduka_product.category AS product_category, " +
+			"COALESCE(" + cashierNameSQL + ", smartduka_user.username, '') AS cashier_name").
+		Order("smartduka_sale.created_at, smartduka_sale.id").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query sales: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sale SaleExport
+		if err := db.DB.ScanRows(rows, &sale); err != nil {
+			return fmt.Errorf("failed to read sale: %w", err)
+		}
+		if err := sale.AfterFind(db.DB); err != nil {
+			return err
+		}
+
+		if err := fn(&sale); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetSyncOperation fetches the recorded outcome of an operation pushed by an offline client
+func (db *PGInstance) GetSyncOperation(ctx context.Context, id string) (*SyncOperation, error) {
+	var operation SyncOperation
+	if err := db.DB.WithContext(ctx).Where(&SyncOperation{ID: id}).First(&operation).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sync operation: %w", err)
+	}
+
+	return &operation, nil
+}
+
+// GetSyncDevice fetches the sync state of a device
+func (db *PGInstance) GetSyncDevice(ctx context.Context, id string) (*SyncDevice, error) {
+	var device SyncDevice
+	if err := db.DB.WithContext(ctx).Where(&SyncDevice{ID: id}).First(&device).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sync device: %w", err)
+	}
+
+	return &device, nil
+}
+
+// GetProductChanges fetches the products changed after the given update time and ID, oldest change first.
+// Deactivated and deleted products are included so that clients can remove them
+func (db *PGInstance) GetProductChanges(ctx context.Context, since time.Time, afterID string, limit int) ([]*Product, error) {
+	var products []*Product
+	if err := db.DB.WithContext(ctx).Unscoped().
+		Where("(updated_at, CAST(id AS TEXT)) > (?, ?)", since.UTC(), afterID).
+		Order("updated_at, CAST(id AS TEXT)").Limit(limit).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get product changes: %w", err)
+	}
+
+	return products, nil
+}
+
+// GetAuditLogs fetches the audit log, most recent change first
+func (db *PGInstance) GetAuditLogs(ctx context.Context, filter *dto.AuditLogFilter, limit int) ([]*AuditLog, error) {
+	query := db.DB.WithContext(ctx).Model(&AuditLog{})
+	if filter.Entity != nil {
+		query = query.Where("entity = ?", *filter.Entity)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", filter.To.UTC())
+	}
+
+	var logs []*AuditLog
+	if err := query.Order("created_at DESC").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+
+	return logs, nil
+}
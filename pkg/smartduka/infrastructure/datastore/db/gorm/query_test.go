@@ -3,13 +3,18 @@ package gorm_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
 )
 
 func TestPGInstance_GetUserProfileByUserID(t *testing.T) {
 	invalidUserID := "invalid"
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
 	type args struct {
 		ctx    context.Context
 		userID *string
@@ -36,6 +41,14 @@ func TestPGInstance_GetUserProfileByUserID(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Sad case: the request was cancelled",
+			args: args{
+				ctx:    cancelled,
+				userID: &userID,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -49,6 +62,9 @@ func TestPGInstance_GetUserProfileByUserID(t *testing.T) {
 }
 
 func TestPGInstance_GetUserProfileByPhoneNumber(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
 	type args struct {
 		ctx         context.Context
 		phoneNumber string
@@ -77,6 +93,15 @@ func TestPGInstance_GetUserProfileByPhoneNumber(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Sad case: the request was cancelled",
+			args: args{
+				ctx:         cancelled,
+				phoneNumber: testPhone,
+				flavour:     "PRO",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -133,6 +158,9 @@ func TestPGInstance_GetUserPINByUserID(t *testing.T) {
 }
 
 func TestPGInstance_SearchUser(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
 	type args struct {
 		ctx        context.Context
 		searchTerm string
@@ -154,7 +182,7 @@ func TestPGInstance_SearchUser(t *testing.T) {
 		{
 			name: "Sad case: unable to search user",
 			args: args{
-				ctx:        context.Background(),
+				ctx:        cancelled,
 				searchTerm: "test",
 			},
 			wantErr: true,
@@ -212,7 +240,8 @@ func TestPGInstance_GetProductByID(t *testing.T) {
 
 func TestPGInstance_GetDailySale(t *testing.T) {
 	type args struct {
-		ctx context.Context
+		ctx      context.Context
+		location *time.Location
 	}
 	tests := []struct {
 		name    string
@@ -223,14 +252,15 @@ func TestPGInstance_GetDailySale(t *testing.T) {
 		{
 			name: "Happy case: Get daily sales",
 			args: args{
-				ctx: context.Background(),
+				ctx:      context.Background(),
+				location: time.UTC,
 			},
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := testingDB.GetDailySale(tt.args.ctx)
+			_, err := testingDB.GetDailySale(tt.args.ctx, tt.args.location)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("PGInstance.GetDailySale() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -239,16 +269,76 @@ func TestPGInstance_GetDailySale(t *testing.T) {
 	}
 }
 
-func TestPGInstance_SearchProduct(t *testing.T) {
+func TestPGInstance_GetSaleByID(t *testing.T) {
 	type args struct {
-		ctx        context.Context
-		searchTerm string
+		ctx context.Context
+		id  string
 	}
 	tests := []struct {
 		name    string
 		args    args
-		want    []*gorm.Product
 		wantErr bool
+	}{
+		{
+			name: "Happy case: get sale by id",
+			args: args{
+				ctx: context.Background(),
+				id:  saleID,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Sad case: unable to get sale by id",
+			args: args{
+				ctx: context.Background(),
+				id:  "saleID",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testingDB.GetSaleByID(tt.args.ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.GetSaleByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.ProductID != productID {
+				t.Errorf("PGInstance.GetSaleByID() product = %v, want %v", got.ProductID, productID)
+			}
+		})
+	}
+}
+
+func TestPGInstance_SearchProduct(t *testing.T) {
+	ctx := context.Background()
+	product, err := testingDB.AddProduct(ctx, &gorm.Product{
+		Base:         gorm.Base{CreatedBy: &userID},
+		Active:       true,
+		Name:         "Zyrtecol Allergy",
+		Category:     string(enums.CategoryMedicine),
+		Quantity:     money.DecimalFromInt(10),
+		Unit:         string(enums.UnitSingle),
+		Price:        money.New(10000, money.DefaultCurrency),
+		CostPrice:    money.New(5000, money.DefaultCurrency),
+		VAT:          money.DecimalFromInt(16),
+		Description:  "dawa ya mafua",
+		Manufacturer: "Kwality Pharma",
+		InStock:      true,
+	})
+	if err != nil {
+		t.Fatalf("AddProduct() error = %v", err)
+	}
+
+	type args struct {
+		ctx        context.Context
+		searchTerm string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantFound bool
+		wantErr   bool
 	}{
 		{
 			name: "Happy case: search product",
@@ -258,14 +348,534 @@ func TestPGInstance_SearchProduct(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Happy case: prefixes of words in the name and manufacturer",
+			args: args{
+				ctx:        context.Background(),
+				searchTerm: "zyrt kwal",
+			},
+			wantFound: true,
+		},
+		{
+			name: "Happy case: synonym of a word in the description",
+			args: args{
+				ctx:        context.Background(),
+				searchTerm: "zyrtecol medicine",
+			},
+			wantFound: true,
+		},
+		{
+			name: "Happy case: typo",
+			args: args{
+				ctx:        context.Background(),
+				searchTerm: "zyrtecl",
+			},
+			wantFound: true,
+		},
+		{
+			name: "Sad case: a word the product does not have",
+			args: args{
+				ctx:        context.Background(),
+				searchTerm: "zyrt sugar",
+			},
+			wantFound: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := testingDB.SearchProduct(tt.args.ctx, tt.args.searchTerm)
+			got, err := testingDB.SearchProduct(tt.args.ctx, tt.args.searchTerm, 20)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("PGInstance.SearchProduct() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			found := false
+			for _, p := range got {
+				found = found || p.ID == product.ID
+			}
+			if found != tt.wantFound {
+				t.Errorf("PGInstance.SearchProduct() found the product = %v, want %v", found, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestPGInstance_GetSalesSummary(t *testing.T) {
+	ctx := context.Background()
+	nairobi, err := time.LoadLocation("Africa/Nairobi")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	product, err := testingDB.AddProduct(ctx, &gorm.Product{
+		Base:      gorm.Base{CreatedBy: &userID},
+		Active:    true,
+		Name:      "Summarized product",
+		Category:  string(enums.CategoryFoodStuff),
+		Quantity:  money.DecimalFromInt(10),
+		Unit:      string(enums.UnitSingle),
+		Price:     money.New(10000, money.DefaultCurrency),
+		CostPrice: money.New(5000, money.DefaultCurrency),
+		VAT:       money.DecimalFromInt(16),
+		InStock:   true,
+	})
+	if err != nil {
+		t.Fatalf("AddProduct() error = %v", err)
+	}
+
+	// the sales are made on 4th and 5th March 2002 in Nairobi, far from the sales the other tests make.
+	// The last is made after midnight in Nairobi but before midnight in UTC
+	// London moved its clocks forward on 31st March 2002 so the same time in UTC falls on a later day after the change
+	day := time.Date(2002, 3, 4, 0, 0, 0, 0, nairobi)
+	summerTime := time.Date(2002, 3, 30, 0, 0, 0, 0, london)
+	for _, sale := range []struct {
+		soldAt   time.Time
+		quantity int64
+		discount int64
+	}{
+		{soldAt: day.Add(10 * time.Hour), quantity: 2, discount: 1000},
+		{soldAt: day.Add(23*time.Hour + 30*time.Minute), quantity: 1},
+		{soldAt: day.Add(24*time.Hour + 30*time.Minute), quantity: 1},
+		{soldAt: time.Date(2002, 3, 30, 23, 30, 0, 0, time.UTC), quantity: 1},
+		{soldAt: time.Date(2002, 3, 31, 23, 30, 0, 0, time.UTC), quantity: 1},
+	} {
+		_, err := testingDB.AddSaleRecord(ctx, &gorm.Sale{
+			Base:          gorm.Base{CreatedBy: &userID, CreatedAt: sale.soldAt},
+			Active:        true,
+			ProductID:     product.ID,
+			Quantity:      money.DecimalFromInt(sale.quantity),
+			Unit:          string(enums.UnitSingle),
+			Price:         money.New(10000, money.DefaultCurrency),
+			Discount:      money.New(sale.discount, money.DefaultCurrency),
+			PaymentMethod: enums.PaymentMethodCash,
+		}, enums.CostingMethodFIFO)
+		if err != nil {
+			t.Fatalf("AddSaleRecord() error = %v", err)
+		}
+	}
+
+	type args struct {
+		ctx      context.Context
+		from     time.Time
+		to       time.Time
+		interval enums.ReportInterval
+		location *time.Location
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []*gorm.SalesSummary
+		wantErr bool
+	}{
+		{
+			name: "Happy case: summarize sales by day",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				interval: enums.ReportIntervalDay,
+				location: time.UTC,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: summarize sales by month",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, -1, 0),
+				to:       time.Now().AddDate(0, 0, 1),
+				interval: enums.ReportIntervalMonth,
+				location: time.UTC,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: sales are totalled on the days they were made in the shop's timezone",
+			args: args{
+				ctx:      context.Background(),
+				from:     day,
+				to:       day.AddDate(0, 0, 2),
+				interval: enums.ReportIntervalDay,
+				location: nairobi,
+			},
+			want: []*gorm.SalesSummary{
+				{Period: day, Revenue: money.New(29000, money.DefaultCurrency), Quantity: money.DecimalFromInt(3), Transactions: 2},
+				{Period: day.AddDate(0, 0, 1), Revenue: money.New(10000, money.DefaultCurrency), Quantity: money.DecimalFromInt(1), Transactions: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: the same sales fall on a single day in UTC",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Date(2002, 3, 4, 0, 0, 0, 0, time.UTC),
+				to:       time.Date(2002, 3, 6, 0, 0, 0, 0, time.UTC),
+				interval: enums.ReportIntervalDay,
+				location: time.UTC,
+			},
+			want: []*gorm.SalesSummary{
+				{Period: time.Date(2002, 3, 4, 0, 0, 0, 0, time.UTC), Revenue: money.New(39000, money.DefaultCurrency), Quantity: money.DecimalFromInt(4), Transactions: 3},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: sales are totalled on the days they were made across a daylight saving change",
+			args: args{
+				ctx:      context.Background(),
+				from:     summerTime,
+				to:       summerTime.AddDate(0, 0, 3),
+				interval: enums.ReportIntervalDay,
+				location: london,
+			},
+			want: []*gorm.SalesSummary{
+				{Period: summerTime, Revenue: money.New(10000, money.DefaultCurrency), Quantity: money.DecimalFromInt(1), Transactions: 1},
+				{Period: summerTime.AddDate(0, 0, 2), Revenue: money.New(10000, money.DefaultCurrency), Quantity: money.DecimalFromInt(1), Transactions: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Sad case: invalid interval",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				interval: "invalid",
+				location: time.UTC,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testingDB.GetSalesSummary(tt.args.ctx, tt.args.from, tt.args.to, tt.args.interval, tt.args.location)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.GetSalesSummary() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want == nil {
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("PGInstance.GetSalesSummary() returned %d periods, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !got[i].Period.Equal(want.Period) || got[i].Revenue.Amount != want.Revenue.Amount ||
+					got[i].Quantity.Cmp(want.Quantity) != 0 || got[i].Transactions != want.Transactions {
+					t.Errorf("PGInstance.GetSalesSummary() period %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPGInstance_GetSalesBreakdown(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		from     time.Time
+		to       time.Time
+		grouping enums.SalesGrouping
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []*gorm.SalesBreakdown
+		wantErr bool
+	}{
+		{
+			name: "Happy case: break down sales by cashier",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				grouping: enums.SalesGroupingCashier,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: break down sales by category",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				grouping: enums.SalesGroupingCategory,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: break down sales by payment method",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				grouping: enums.SalesGroupingPaymentMethod,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Sad case: invalid grouping",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				grouping: "invalid",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testingDB.GetSalesBreakdown(tt.args.ctx, tt.args.from, tt.args.to, tt.args.grouping)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.GetSalesBreakdown() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func TestPGInstance_GetTopProducts(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		from    time.Time
+		to      time.Time
+		ranking enums.ProductRanking
+		limit   int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []*gorm.ProductSales
+		wantErr bool
+	}{
+		{
+			name: "Happy case: top products by revenue",
+			args: args{
+				ctx:     context.Background(),
+				from:    time.Now().AddDate(0, 0, -7),
+				to:      time.Now().AddDate(0, 0, 1),
+				ranking: enums.ProductRankingRevenue,
+				limit:   5,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: top products by quantity",
+			args: args{
+				ctx:     context.Background(),
+				from:    time.Now().AddDate(0, 0, -7),
+				to:      time.Now().AddDate(0, 0, 1),
+				ranking: enums.ProductRankingQuantity,
+				limit:   5,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Sad case: invalid limit",
+			args: args{
+				ctx:     context.Background(),
+				from:    time.Now().AddDate(0, 0, -7),
+				to:      time.Now().AddDate(0, 0, 1),
+				ranking: enums.ProductRankingQuantity,
+				limit:   0,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testingDB.GetTopProducts(tt.args.ctx, tt.args.from, tt.args.to, tt.args.ranking, tt.args.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.GetTopProducts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
 		})
 	}
 }
+
+func TestPGInstance_GetHourlySales(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		from     time.Time
+		to       time.Time
+		location *time.Location
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []*gorm.HourlySales
+		wantErr bool
+	}{
+		{
+			name: "Happy case: get hourly sales",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				location: time.UTC,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testingDB.GetHourlySales(tt.args.ctx, tt.args.from, tt.args.to, tt.args.location)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.GetHourlySales() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func TestPGInstance_GetProfitReport(t *testing.T) {
+	location, _ := time.LoadLocation("Africa/Nairobi")
+
+	type args struct {
+		ctx      context.Context
+		from     time.Time
+		to       time.Time
+		grouping enums.ProfitGrouping
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []*gorm.ProfitSummary
+		wantErr bool
+	}{
+		{
+			name: "Happy case: profit by product",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				grouping: enums.ProfitGroupingProduct,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: profit by category",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				grouping: enums.ProfitGroupingCategory,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Happy case: profit by month",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				grouping: enums.ProfitGroupingMonth,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Sad case: invalid grouping",
+			args: args{
+				ctx:      context.Background(),
+				from:     time.Now().AddDate(0, 0, -7),
+				to:       time.Now().AddDate(0, 0, 1),
+				grouping: "invalid",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testingDB.GetProfitReport(tt.args.ctx, tt.args.from, tt.args.to, tt.args.grouping, location)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PGInstance.GetProfitReport() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func TestPGInstance_GetShiftReport(t *testing.T) {
+	ctx := context.Background()
+
+	shift, err := testingDB.GetOpenShift(ctx, userID)
+	if err != nil {
+		t.Errorf("PGInstance.GetOpenShift() error = %v", err)
+		return
+	}
+
+	if _, err := testingDB.GetShiftTenders(ctx, shift.ID); err != nil {
+		t.Errorf("PGInstance.GetShiftTenders() error = %v", err)
+	}
+
+	if _, err := testingDB.GetShiftTotals(ctx, shift.ID); err != nil {
+		t.Errorf("PGInstance.GetShiftTotals() error = %v", err)
+	}
+
+	if _, err := testingDB.GetShiftCashMovements(ctx, shift.ID); err != nil {
+		t.Errorf("PGInstance.GetShiftCashMovements() error = %v", err)
+	}
+
+	expected, err := testingDB.GetShiftExpectedCash(ctx, shift.ID)
+	if err != nil {
+		t.Errorf("PGInstance.GetShiftExpectedCash() error = %v", err)
+		return
+	}
+	if expected.IsNegative() {
+		t.Errorf("PGInstance.GetShiftExpectedCash() = %v, expected the opening float and cash sales", expected)
+	}
+}
+
+func TestPGInstance_StreamProducts(t *testing.T) {
+	count := 0
+	err := testingDB.StreamProducts(context.Background(), func(product *gorm.Product) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Errorf("PGInstance.StreamProducts() error = %v", err)
+		return
+	}
+	if count == 0 {
+		t.Errorf("PGInstance.StreamProducts() expected at least one product")
+	}
+}
+
+func TestPGInstance_StreamSales(t *testing.T) {
+	err := testingDB.StreamSales(context.Background(), time.Now().AddDate(0, 0, -7), time.Now().AddDate(0, 0, 1), func(sale *gorm.SaleExport) error {
+		if sale.ProductName == "" {
+			t.Errorf("PGInstance.StreamSales() expected the product name of sale %s", sale.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("PGInstance.StreamSales() error = %v", err)
+	}
+}
+
+func TestPGInstance_GetProductChanges(t *testing.T) {
+	ctx := context.Background()
+
+	all, err := testingDB.GetProductChanges(ctx, time.Time{}, "", 1000)
+	if err != nil {
+		t.Errorf("PGInstance.GetProductChanges() error = %v", err)
+		return
+	}
+	if len(all) == 0 {
+		t.Errorf("PGInstance.GetProductChanges() expected the fixture products from an empty cursor")
+		return
+	}
+
+	last := all[len(all)-1]
+	rest, err := testingDB.GetProductChanges(ctx, last.UpdatedAt, last.ID, 1000)
+	if err != nil {
+		t.Errorf("PGInstance.GetProductChanges() error = %v", err)
+		return
+	}
+	if len(rest) != 0 {
+		t.Errorf("PGInstance.GetProductChanges() returned %d changes after the last change", len(rest))
+	}
+}
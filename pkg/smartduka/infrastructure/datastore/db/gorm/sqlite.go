@@ -0,0 +1,84 @@
+package gorm
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	sqliteschema "github.com/oryx-systems/smartduka/db/sqlite"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// NewSQLiteInstance opens the SQLite database at path, creating it and its tables if they do not exist.
+// It serves a single shop running on one machine
+func NewSQLiteInstance(path string) (*PGInstance, error) {
+	// foreign keys are off by default in SQLite. Writers wait for each other instead of failing
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=1&_journal_mode=WAL&_busy_timeout=10000&_txlock=immediate", path)
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+		NowFunc: func() time.Time {
+			return time.Now().UTC()
+		},
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s: %v", path, err)
+	}
+
+	if err := db.Exec(sqliteschema.Schema).Error; err != nil {
+		return nil, fmt.Errorf("failed to create the sqlite schema: %v", err)
+	}
+
+	// SQLite compares timestamps as text, which only orders them correctly when they are all in the same zone
+	if err := db.Callback().Create().Before("gorm:create").Register("smartduka:utc", utcTimes); err != nil {
+		return nil, fmt.Errorf("failed to register database callbacks: %v", err)
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("smartduka:utc", utcTimes); err != nil {
+		return nil, fmt.Errorf("failed to register database callbacks: %v", err)
+	}
+
+	if err := registerCallbacks(db, extension.NewExtension()); err != nil {
+		return nil, fmt.Errorf("failed to register database callbacks: %v", err)
+	}
+
+	return &PGInstance{DB: db}, nil
+}
+
+// utcTimes converts the times about to be written to UTC
+func utcTimes(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+
+	if values, ok := db.Statement.Dest.(map[string]interface{}); ok {
+		for column, value := range values {
+			if t, ok := value.(time.Time); ok {
+				values[column] = t.UTC()
+			}
+		}
+	}
+
+	stmt := db.Statement
+	for _, field := range stmt.Schema.Fields {
+		eachRow(stmt, func(row reflect.Value) {
+			value, isZero := field.ValueOf(stmt.Context, row)
+			if isZero {
+				return
+			}
+
+			switch t := value.(type) {
+			case time.Time:
+				db.AddError(field.Set(stmt.Context, row, t.UTC()))
+			case *time.Time:
+				utc := t.UTC()
+				db.AddError(field.Set(stmt.Context, row, &utc))
+			}
+		})
+	}
+}
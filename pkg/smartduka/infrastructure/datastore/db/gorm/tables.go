@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
 	"gorm.io/gorm"
 )
 
@@ -19,16 +20,25 @@ type Base struct {
 // User models the system user
 type User struct {
 	Base
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at"`
 
-	ID        *string `gorm:"column:id"`
-	FirstName string  `gorm:"column:first_name"`
-	LastName  string  `gorm:"column:last_name"`
-	Active    bool    `gorm:"column:active"`
-	UserName  string  `gorm:"column:username"`
-	UserType  string  `gorm:"column:user_type"`
-	PushToken string  `gorm:"column:push_token"`
-	Email     string  `gorm:"column:email"`
-	Contacts  Contact `gorm:"ForeignKey:user_id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;not null"`
+	ID         *string `gorm:"column:id"`
+	FirstName  string  `gorm:"column:first_name"`
+	MiddleName string  `gorm:"column:middle_name"`
+	LastName   string  `gorm:"column:last_name"`
+	Active     bool    `gorm:"column:active"`
+	UserName   string  `gorm:"column:username"`
+	UserType   string  `gorm:"column:user_type"`
+	PushToken  string  `gorm:"column:push_token"`
+	Email      string  `gorm:"column:email"`
+	Residence  string  `gorm:"column:residence"`
+
+	// a frozen user cannot log in. Tokens issued before TokensRevokedAt are refused
+	FrozenReason    string     `gorm:"column:frozen_reason"`
+	FrozenAt        *time.Time `gorm:"column:frozen_at"`
+	TokensRevokedAt *time.Time `gorm:"column:tokens_revoked_at"`
+
+	Contacts Contact `gorm:"ForeignKey:user_id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;not null"`
 }
 
 // BeforeCreate is a hook run before creating a user
@@ -49,6 +59,7 @@ func (User) TableName() string {
 // Contact is a contact model for a user
 type Contact struct {
 	Base
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at"`
 
 	ID           string        `gorm:"column:id"`
 	Active       bool          `gorm:"column:active"`
@@ -72,17 +83,43 @@ func (Contact) TableName() string {
 	return "smartduka_contact"
 }
 
+// Identifier is a document that identifies a user e.g their national ID
+type Identifier struct {
+	Base
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at"`
+
+	ID              string               `gorm:"column:id"`
+	Active          bool                 `gorm:"column:active"`
+	IdentifierType  enums.IdentifierType `gorm:"column:identifier_type"`
+	IdentifierValue string               `gorm:"column:identifier_value"`
+	UserID          *string              `gorm:"column:user_id"`
+}
+
+// BeforeCreate is a hook run before creating an identifier
+func (i *Identifier) BeforeCreate(tx *gorm.DB) (err error) {
+	i.CreatedAt = time.Now()
+	i.ID = uuid.New().String()
+
+	return
+}
+
+// TableName customizes how the table name is generated
+func (Identifier) TableName() string {
+	return "smartduka_identifier"
+}
+
 // UserPIN models the user's PIN table
 type UserPIN struct {
 	Base
 
-	ID        string    `gorm:"column:id"`
-	Active    bool      `gorm:"column:active"`
-	ValidFrom time.Time `gorm:"column:valid_from"`
-	ValidTo   time.Time `gorm:"column:valid_to"`
-	HashedPIN string    `gorm:"column:hashed_pin"`
-	Salt      string    `gorm:"column:salt"`
-	UserID    string    `gorm:"column:user_id"`
+	ID        string        `gorm:"column:id"`
+	Active    bool          `gorm:"column:active"`
+	Flavour   enums.Flavour `gorm:"column:flavour"`
+	ValidFrom time.Time     `gorm:"column:valid_from"`
+	ValidTo   time.Time     `gorm:"column:valid_to"`
+	HashedPIN string        `gorm:"column:hashed_pin"`
+	Salt      string        `gorm:"column:salt"`
+	UserID    string        `gorm:"column:user_id"`
 }
 
 // BeforeCreate is a hook run before creating user PIN
@@ -109,7 +146,8 @@ type OTP struct {
 	PhoneNumber string        `gorm:"column:phone_number"`
 	OTP         string        `gorm:"column:otp"`
 	Flavour     enums.Flavour `gorm:"column:flavour"`
-	UserID      string        `gorm:"column:user_id"`
+	// UserID is empty for an OTP that verifies the phone number of a user who is registering
+	UserID *string `gorm:"column:user_id"`
 }
 
 // BeforeCreate is a hook run before creating an OTP
@@ -127,20 +165,50 @@ func (OTP) TableName() string {
 // Sale is used to show sales data
 type Sale struct {
 	Base
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at"`
 
-	ID        string  `gorm:"column:id"`
-	Active    bool    `gorm:"column:active"`
-	ProductID string  `gorm:"column:product_id"`
-	Quantity  float64 `gorm:"column:quantity"`
-	Unit      string  `gorm:"column:unit"`
-	Price     float64 `gorm:"column:price"`
-	Product   Product `gorm:"ForeignKey:product_id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;not null"`
+	ID            string              `gorm:"column:id"`
+	Active        bool                `gorm:"column:active"`
+	ProductID     string              `gorm:"column:product_id"`
+	Quantity      money.Decimal       `gorm:"column:quantity"`
+	Unit          string              `gorm:"column:unit"`
+	Price         money.Money         `gorm:"column:price"`
+	Discount      money.Money         `gorm:"column:discount"`
+	CostOfGoods   money.Money         `gorm:"column:cost_of_goods"`
+	Currency      money.Currency      `gorm:"column:currency"`
+	PaymentMethod enums.PaymentMethod `gorm:"column:payment_method"`
+	ShiftID       *string             `gorm:"column:shift_id"`
+	Product       Product             `gorm:"ForeignKey:product_id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;not null"`
+	TaxInvoice    *TaxInvoice         `gorm:"foreignKey:SaleID"`
 }
 
 // BeforeCreate is a hook run before creating an OTP
 func (s *Sale) BeforeCreate(tx *gorm.DB) (err error) {
-	s.Base.CreatedAt = time.Now()
-	s.ID = uuid.New().String()
+	// sale timestamps are kept in UTC so that reports can convert them to the shop's timezone.
+	// Sales made offline keep the time and ID given to them by the client
+	if s.Base.CreatedAt.IsZero() {
+		s.Base.CreatedAt = time.Now()
+	}
+	s.Base.CreatedAt = s.Base.CreatedAt.UTC()
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.PaymentMethod == "" {
+		s.PaymentMethod = enums.PaymentMethodCash
+	}
+	s.Currency = s.Price.Currency
+	if s.Currency == "" {
+		s.Currency = money.DefaultCurrency
+	}
+	return
+}
+
+// AfterFind is a hook run after fetching a sale. The price column only holds
+// the amount so the currency is restored from its own column
+func (s *Sale) AfterFind(tx *gorm.DB) (err error) {
+	s.Price = money.New(s.Price.Amount, s.Currency)
+	s.Discount = money.New(s.Discount.Amount, s.Currency)
+	s.CostOfGoods = money.New(s.CostOfGoods.Amount, s.Currency)
 	return
 }
 
@@ -149,27 +217,77 @@ func (Sale) TableName() string {
 	return "smartduka_sale"
 }
 
+// TaxInvoice is the submission of a sale to KRA eTIMS. Pending invoices form an outbox
+// that is retried until KRA either accepts or rejects them
+type TaxInvoice struct {
+	Base
+
+	ID               string                 `gorm:"column:id"`
+	SaleID           string                 `gorm:"column:sale_id"`
+	Status           enums.TaxInvoiceStatus `gorm:"column:status"`
+	Attempts         int                    `gorm:"column:attempts"`
+	NextAttemptAt    time.Time              `gorm:"column:next_attempt_at"`
+	LastError        *string                `gorm:"column:last_error"`
+	InvoiceNumber    *string                `gorm:"column:invoice_number"`
+	ReceiptSignature *string                `gorm:"column:receipt_signature"`
+	QRData           *string                `gorm:"column:qr_data"`
+	SubmittedAt      *time.Time             `gorm:"column:submitted_at"`
+}
+
+// BeforeCreate is a hook run before queueing a tax invoice
+func (t *TaxInvoice) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now().UTC()
+	t.Base.CreatedAt = now
+	t.ID = uuid.New().String()
+	t.Status = enums.TaxInvoiceStatusPending
+	if t.NextAttemptAt.IsZero() {
+		t.NextAttemptAt = now
+	}
+	return
+}
+
+// TableName customizes how the table name is generated
+func (TaxInvoice) TableName() string {
+	return "smartduka_tax_invoice"
+}
+
 // Product is used to display product info
 type Product struct {
 	Base
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at"`
 
-	ID           string  `gorm:"column:id"`
-	Active       bool    `gorm:"column:active"`
-	Name         string  `gorm:"column:name"`
-	Category     string  `gorm:"column:category"`
-	Quantity     float64 `gorm:"column:quantity"`
-	Unit         string  `gorm:"column:unit"`
-	Price        float64 `gorm:"column:price"`
-	VAT          float64 `gorm:"column:vat"`
-	Description  string  `gorm:"column:description"`
-	Manufacturer string  `gorm:"column:manufacturer"`
-	InStock      bool    `gorm:"column:in_stock"`
+	ID           string         `gorm:"column:id"`
+	Active       bool           `gorm:"column:active"`
+	SKU          *string        `gorm:"column:sku"`
+	Name         string         `gorm:"column:name"`
+	Category     string         `gorm:"column:category"`
+	Quantity     money.Decimal  `gorm:"column:quantity"`
+	Unit         string         `gorm:"column:unit"`
+	Price        money.Money    `gorm:"column:price"`
+	CostPrice    money.Money    `gorm:"column:cost_price"`
+	Currency     money.Currency `gorm:"column:currency"`
+	VAT          money.Decimal  `gorm:"column:vat;type:numeric(7,4)"`
+	Description  string         `gorm:"column:description"`
+	Manufacturer string         `gorm:"column:manufacturer"`
+	InStock      bool           `gorm:"column:in_stock"`
 }
 
 // BeforeCreate is a hook run before creating an OTP
 func (p *Product) BeforeCreate(tx *gorm.DB) (err error) {
 	p.Base.CreatedAt = time.Now()
 	p.ID = uuid.New().String()
+	p.Currency = p.Price.Currency
+	if p.Currency == "" {
+		p.Currency = money.DefaultCurrency
+	}
+	return
+}
+
+// AfterFind is a hook run after fetching a product. The price column only holds
+// the amount so the currency is restored from its own column
+func (p *Product) AfterFind(tx *gorm.DB) (err error) {
+	p.Price = money.New(p.Price.Amount, p.Currency)
+	p.CostPrice = money.New(p.CostPrice.Amount, p.Currency)
 	return
 }
 
@@ -177,3 +295,290 @@ func (p *Product) BeforeCreate(tx *gorm.DB) (err error) {
 func (Product) TableName() string {
 	return "smartduka_product"
 }
+
+// StockReceipt records a delivery of stock and what it cost.
+// RemainingQuantity tracks the units of the delivery that are yet to be sold for FIFO costing
+type StockReceipt struct {
+	Base
+
+	ID                string         `gorm:"column:id"`
+	Active            bool           `gorm:"column:active"`
+	ProductID         string         `gorm:"column:product_id"`
+	Quantity          money.Decimal  `gorm:"column:quantity"`
+	RemainingQuantity money.Decimal  `gorm:"column:remaining_quantity"`
+	UnitCost          money.Money    `gorm:"column:unit_cost"`
+	Currency          money.Currency `gorm:"column:currency"`
+	Supplier          string         `gorm:"column:supplier"`
+}
+
+// BeforeCreate is a hook run before creating a stock receipt
+func (s *StockReceipt) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.Base.CreatedAt.IsZero() {
+		s.Base.CreatedAt = time.Now()
+	}
+	s.Base.CreatedAt = s.Base.CreatedAt.UTC()
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	s.Currency = s.UnitCost.Currency
+	if s.Currency == "" {
+		s.Currency = money.DefaultCurrency
+	}
+	return
+}
+
+// AfterFind is a hook run after fetching a stock receipt
+func (s *StockReceipt) AfterFind(tx *gorm.DB) (err error) {
+	s.UnitCost = money.New(s.UnitCost.Amount, s.Currency)
+	return
+}
+
+// TableName customizes how the table name is generated
+func (StockReceipt) TableName() string {
+	return "smartduka_stock_receipt"
+}
+
+// Shift is a cashier's session at the till. A closed shift is locked and no longer takes sales or cash movements
+type Shift struct {
+	Base
+
+	ID           string            `gorm:"column:id"`
+	Active       bool              `gorm:"column:active"`
+	CashierID    string            `gorm:"column:cashier_id"`
+	Status       enums.ShiftStatus `gorm:"column:status"`
+	OpeningFloat money.Money       `gorm:"column:opening_float"`
+	ExpectedCash money.Money       `gorm:"column:expected_cash"`
+	CountedCash  money.Money       `gorm:"column:counted_cash"`
+	OverShort    money.Money       `gorm:"column:over_short"`
+	Currency     money.Currency    `gorm:"column:currency"`
+	OpenedAt     time.Time         `gorm:"column:opened_at"`
+	ClosedAt     *time.Time        `gorm:"column:closed_at"`
+}
+
+// BeforeCreate is a hook run before opening a shift
+func (s *Shift) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now().UTC()
+	s.Base.CreatedAt = now
+	s.ID = uuid.New().String()
+	s.OpenedAt = now
+	s.Status = enums.ShiftStatusOpen
+	s.Currency = s.OpeningFloat.Currency
+	if s.Currency == "" {
+		s.Currency = money.DefaultCurrency
+	}
+	return
+}
+
+// AfterFind is a hook run after fetching a shift
+func (s *Shift) AfterFind(tx *gorm.DB) (err error) {
+	s.OpeningFloat = money.New(s.OpeningFloat.Amount, s.Currency)
+	s.ExpectedCash = money.New(s.ExpectedCash.Amount, s.Currency)
+	s.CountedCash = money.New(s.CountedCash.Amount, s.Currency)
+	s.OverShort = money.New(s.OverShort.Amount, s.Currency)
+	return
+}
+
+// TableName customizes how the table name is generated
+func (Shift) TableName() string {
+	return "smartduka_shift"
+}
+
+// CashMovement is cash put into or taken out of the drawer during a shift other than through a sale
+type CashMovement struct {
+	Base
+
+	ID       string                 `gorm:"column:id"`
+	Active   bool                   `gorm:"column:active"`
+	ShiftID  string                 `gorm:"column:shift_id"`
+	Type     enums.CashMovementType `gorm:"column:type"`
+	Amount   money.Money            `gorm:"column:amount"`
+	Currency money.Currency         `gorm:"column:currency"`
+	Reason   string                 `gorm:"column:reason"`
+}
+
+// BeforeCreate is a hook run before recording a cash movement
+func (c *CashMovement) BeforeCreate(tx *gorm.DB) (err error) {
+	c.Base.CreatedAt = time.Now().UTC()
+	c.ID = uuid.New().String()
+	c.Currency = c.Amount.Currency
+	if c.Currency == "" {
+		c.Currency = money.DefaultCurrency
+	}
+	return
+}
+
+// AfterFind is a hook run after fetching a cash movement
+func (c *CashMovement) AfterFind(tx *gorm.DB) (err error) {
+	c.Amount = money.New(c.Amount.Amount, c.Currency)
+	return
+}
+
+// TableName customizes how the table name is generated
+func (CashMovement) TableName() string {
+	return "smartduka_cash_movement"
+}
+
+// SyncDevice is the sync state of a till that works offline
+type SyncDevice struct {
+	Base
+
+	ID           string     `gorm:"column:id"`
+	UserID       string     `gorm:"column:user_id"`
+	Cursor       *string    `gorm:"column:cursor"`
+	LastPushedAt *time.Time `gorm:"column:last_pushed_at"`
+	LastPulledAt *time.Time `gorm:"column:last_pulled_at"`
+}
+
+// TableName customizes how the table name is generated
+func (SyncDevice) TableName() string {
+	return "smartduka_sync_device"
+}
+
+// SyncOperation is a change pushed by an offline client and the outcome of applying it.
+// Its ID is generated by the client so that pushing the same change again returns the recorded outcome
+type SyncOperation struct {
+	Base
+
+	ID         string                    `gorm:"column:id"`
+	DeviceID   string                    `gorm:"column:device_id"`
+	Type       enums.SyncOperationType   `gorm:"column:type"`
+	Status     enums.SyncOperationStatus `gorm:"column:status"`
+	Conflict   *enums.SyncConflict       `gorm:"column:conflict"`
+	Message    *string                   `gorm:"column:message"`
+	RecordID   *string                   `gorm:"column:record_id"`
+	OccurredAt time.Time                 `gorm:"column:occurred_at"`
+}
+
+// BeforeCreate is a hook run before recording a sync operation
+func (s *SyncOperation) BeforeCreate(tx *gorm.DB) (err error) {
+	s.Base.CreatedAt = time.Now().UTC()
+	return
+}
+
+// TableName customizes how the table name is generated
+func (SyncOperation) TableName() string {
+	return "smartduka_sync_operation"
+}
+
+// IdempotencyKey records a request made with an Idempotency-Key header and the response it got.
+// Keys are scoped to the user that made the request, anonymous requests share the empty scope
+type IdempotencyKey struct {
+	Base
+
+	Scope               string     `gorm:"column:scope"`
+	Key                 string     `gorm:"column:key"`
+	RequestHash         string     `gorm:"column:request_hash"`
+	LockedUntil         time.Time  `gorm:"column:locked_until"`
+	ExpiresAt           time.Time  `gorm:"column:expires_at"`
+	CompletedAt         *time.Time `gorm:"column:completed_at"`
+	ResponseStatus      *int       `gorm:"column:response_status"`
+	ResponseContentType *string    `gorm:"column:response_content_type"`
+	ResponseBody        []byte     `gorm:"column:response_body"`
+}
+
+// TableName customizes how the table name is generated
+func (IdempotencyKey) TableName() string {
+	return "smartduka_idempotency_key"
+}
+
+// AuditLog is an append-only record of a change made to an audited table
+type AuditLog struct {
+	ID        string            `gorm:"column:id"`
+	CreatedAt time.Time         `gorm:"column:created_at"`
+	Entity    string            `gorm:"column:entity"`
+	EntityID  string            `gorm:"column:entity_id"`
+	Action    enums.AuditAction `gorm:"column:action"`
+	Changes   string            `gorm:"column:changes"`
+	ActorID   *string           `gorm:"column:actor_id"`
+	IPAddress *string           `gorm:"column:ip_address"`
+	Device    *string           `gorm:"column:device"`
+}
+
+// BeforeCreate is a hook run before recording a change
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New().String()
+	a.CreatedAt = time.Now().UTC()
+	return
+}
+
+// TableName customizes how the table name is generated
+func (AuditLog) TableName() string {
+	return "smartduka_audit_log"
+}
+
+// SalesSummary is an aggregate of the sales made within a calendar period
+type SalesSummary struct {
+	Period       time.Time     `gorm:"column:period"`
+	Revenue      money.Money   `gorm:"column:revenue"`
+	Quantity     money.Decimal `gorm:"column:quantity"`
+	Transactions int           `gorm:"column:transactions"`
+}
+
+// SalesBreakdown is an aggregate of sales sharing a cashier, category or payment method
+type SalesBreakdown struct {
+	Key          string        `gorm:"column:key"`
+	Label        string        `gorm:"column:label"`
+	Revenue      money.Money   `gorm:"column:revenue"`
+	Quantity     money.Decimal `gorm:"column:quantity"`
+	Transactions int           `gorm:"column:transactions"`
+}
+
+// ProductSales is an aggregate of the sales of a single product
+type ProductSales struct {
+	ProductID    string        `gorm:"column:product_id"`
+	Name         string        `gorm:"column:name"`
+	Category     string        `gorm:"column:category"`
+	Revenue      money.Money   `gorm:"column:revenue"`
+	Quantity     money.Decimal `gorm:"column:quantity"`
+	Transactions int           `gorm:"column:transactions"`
+}
+
+// HourlySales is an aggregate of the sales made within an hour of a given day of the week
+type HourlySales struct {
+	DayOfWeek    int         `gorm:"column:day_of_week"`
+	Hour         int         `gorm:"column:hour"`
+	Revenue      money.Money `gorm:"column:revenue"`
+	Transactions int         `gorm:"column:transactions"`
+}
+
+// ProfitSummary is an aggregate of the revenue and cost of the goods sold for a product, category or calendar period
+type ProfitSummary struct {
+	Key                   string        `gorm:"column:key"`
+	Label                 string        `gorm:"column:label"`
+	Revenue               money.Money   `gorm:"column:revenue"`
+	CostOfGoods           money.Money   `gorm:"column:cost_of_goods"`
+	Quantity              money.Decimal `gorm:"column:quantity"`
+	Transactions          int           `gorm:"column:transactions"`
+	BelowCostTransactions int           `gorm:"column:below_cost_transactions"`
+}
+
+// ShiftTender is the total taken through a payment method during a shift
+type ShiftTender struct {
+	PaymentMethod enums.PaymentMethod `gorm:"column:payment_method"`
+	Amount        money.Money         `gorm:"column:amount"`
+	Transactions  int                 `gorm:"column:transactions"`
+}
+
+// ShiftTotals is an aggregate of the sales, returns, discounts and VAT of a shift
+type ShiftTotals struct {
+	GrossSales   money.Money `gorm:"column:gross_sales"`
+	Returns      money.Money `gorm:"column:returns"`
+	Discounts    money.Money `gorm:"column:discounts"`
+	VAT          money.Money `gorm:"column:vat"`
+	Transactions int         `gorm:"column:transactions"`
+}
+
+// ShiftCashMovements is the total of each type of cash movement made during a shift
+type ShiftCashMovements struct {
+	Type   enums.CashMovementType `gorm:"column:type"`
+	Amount money.Money            `gorm:"column:amount"`
+}
+
+// SaleExport is a sale together with the product sold and the cashier who sold it
+type SaleExport struct {
+	Sale
+
+	ProductName     string `gorm:"column:product_name"`
+	ProductCategory string `gorm:"column:product_category"`
+	CashierName     string `gorm:"column:cashier_name"`
+}
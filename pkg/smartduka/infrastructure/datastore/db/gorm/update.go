@@ -4,20 +4,38 @@ import (
 	"context"
 	"fmt"
 
+	"time"
+
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Update holds all the database record update methods
 type Update interface {
 	InvalidatePIN(ctx context.Context, userID string, flavour enums.Flavour) error
+	InvalidateOTP(ctx context.Context, id string) error
 	UpdateUser(ctx context.Context, user *User, updateData map[string]interface{}) error
 
 	UpdateProduct(ctx context.Context, product *Product, updateData map[string]interface{}) error
+
+	CloseShift(ctx context.Context, shiftID string, countedCash money.Money) (*Shift, error)
+
+	ClaimPendingTaxInvoices(ctx context.Context, limit int, lease time.Duration) ([]*TaxInvoice, error)
+	UpdateTaxInvoice(ctx context.Context, invoice *TaxInvoice, updateData map[string]interface{}) error
+
+	CompleteIdempotencyKey(ctx context.Context, key *IdempotencyKey) error
+	ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error
+
+	RestoreUser(ctx context.Context, userID string) (*User, error)
+	RestoreProduct(ctx context.Context, productID string) (*Product, error)
+	RestoreSale(ctx context.Context, saleID string) (*Sale, error)
 }
 
 // InvalidatePIN invalidates a pin that is linked to the user profile when a new one is created
 func (db *PGInstance) InvalidatePIN(ctx context.Context, userID string, flavour enums.Flavour) error {
-	err := db.DB.WithContext(ctx).Model(&UserPIN{}).Where(&UserPIN{UserID: userID, Active: true}).Select("active").Updates(UserPIN{Active: false}).Error
+	err := db.DB.WithContext(ctx).Model(&UserPIN{}).Where(&UserPIN{UserID: userID, Flavour: flavour, Active: true}).Select("active").Updates(UserPIN{Active: false}).Error
 	if err != nil {
 		return fmt.Errorf("an error occurred while invalidating the pin: %v", err)
 	}
@@ -25,6 +43,19 @@ func (db *PGInstance) InvalidatePIN(ctx context.Context, userID string, flavour
 	return nil
 }
 
+// InvalidateOTP uses up an OTP so that it cannot be used again. It fails if the OTP has already been used
+func (db *PGInstance) InvalidateOTP(ctx context.Context, id string) error {
+	result := db.DB.WithContext(ctx).Model(&OTP{}).Where("id = ? AND is_valid = ?", id, true).Update("is_valid", false)
+	if result.Error != nil {
+		return fmt.Errorf("an error occurred while invalidating the otp: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("otp %s has already been used: %w", id, ErrRecordNotFound)
+	}
+
+	return nil
+}
+
 // UpdateUser updates a user record
 func (db *PGInstance) UpdateUser(ctx context.Context, user *User, updateData map[string]interface{}) error {
 	err := db.DB.WithContext(ctx).Model(&user).Updates(updateData).Error
@@ -44,3 +75,208 @@ func (db *PGInstance) UpdateProduct(ctx context.Context, product *Product, updat
 
 	return nil
 }
+
+// CloseShift locks a shift against further sales and cash movements.
+// The cash counted in the drawer is compared with what was expected to find any overage or shortage
+func (db *PGInstance) CloseShift(ctx context.Context, shiftID string, countedCash money.Money) (*Shift, error) {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	shift, err := lockOpenShift(tx, shiftID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	expected, err := expectedCash(tx, shift)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	overShort, err := countedCash.Sub(expected)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	closedAt := time.Now().UTC()
+	if err := tx.Model(shift).Updates(map[string]interface{}{
+		"status":        enums.ShiftStatusClosed,
+		"expected_cash": expected,
+		"counted_cash":  countedCash,
+		"over_short":    overShort,
+		"closed_at":     closedAt,
+		"updated_at":    closedAt,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to close shift: %v", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	shift.Status = enums.ShiftStatusClosed
+	shift.ExpectedCash = expected
+	shift.CountedCash = countedCash
+	shift.OverShort = overShort
+	shift.ClosedAt = &closedAt
+
+	return shift, nil
+}
+
+// ClaimPendingTaxInvoices picks the pending tax invoices that are due for submission.
+// Claimed invoices are not due again until the lease has passed so that other instances of the service skip them
+func (db *PGInstance) ClaimPendingTaxInvoices(ctx context.Context, limit int, lease time.Duration) ([]*TaxInvoice, error) {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	now := time.Now().UTC()
+	var invoices []*TaxInvoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", enums.TaxInvoiceStatusPending, now).
+		Order("next_attempt_at").Limit(limit).Find(&invoices).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to get pending tax invoices: %v", err)
+	}
+
+	for _, invoice := range invoices {
+		invoice.NextAttemptAt = now.Add(lease)
+		if err := tx.Model(invoice).Update("next_attempt_at", invoice.NextAttemptAt).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to claim tax invoice: %v", err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+// UpdateTaxInvoice records the outcome of submitting a tax invoice
+func (db *PGInstance) UpdateTaxInvoice(ctx context.Context, invoice *TaxInvoice, updateData map[string]interface{}) error {
+	err := db.DB.WithContext(ctx).Model(invoice).Updates(updateData).Error
+	if err != nil {
+		return fmt.Errorf("an error occurred while updating the tax invoice: %v", err)
+	}
+
+	return nil
+}
+
+// CompleteIdempotencyKey stores the response to the request holding a key so that it can be replayed
+func (db *PGInstance) CompleteIdempotencyKey(ctx context.Context, key *IdempotencyKey) error {
+	err := db.DB.WithContext(ctx).Model(&IdempotencyKey{}).
+		Where(`scope = ? AND "key" = ? AND completed_at IS NULL`, key.Scope, key.Key).
+		Updates(map[string]interface{}{
+			"updated_at":            time.Now().UTC(),
+			"completed_at":          key.CompletedAt,
+			"expires_at":            key.ExpiresAt,
+			"response_status":       key.ResponseStatus,
+			"response_content_type": key.ResponseContentType,
+			"response_body":         key.ResponseBody,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %v", err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey frees a key whose request failed so that the request can be retried with it
+func (db *PGInstance) ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error {
+	now := time.Now().UTC()
+	err := db.DB.WithContext(ctx).Model(&IdempotencyKey{}).
+		Where(`scope = ? AND "key" = ? AND completed_at IS NULL`, scope, key).
+		Updates(map[string]interface{}{
+			"updated_at":   now,
+			"locked_until": now,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+
+	return nil
+}
+
+// RestoreUser brings back a deleted user together with the contacts and identification documents that were
+// deleted with them. It fails if another user has taken their username, phone number or document in the meantime
+func (db *PGInstance) RestoreUser(ctx context.Context, userID string) (*User, error) {
+	tx := db.DB.WithContext(ctx).Begin()
+
+	var user User
+	if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", userID).First(&user).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to get deleted user %s: %w", userID, err)
+	}
+
+	if err := tx.Unscoped().Model(&Contact{}).Where("user_id = ? AND deleted_at >= ?", userID, user.DeletedAt.Time).
+		Update("deleted_at", nil).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to restore user contacts: %v", err)
+	}
+
+	if err := tx.Unscoped().Model(&Identifier{}).Where("user_id = ? AND deleted_at >= ?", userID, user.DeletedAt.Time).
+		Update("deleted_at", nil).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to restore user identifiers: %v", err)
+	}
+
+	if err := tx.Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to restore user: %v", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// RestoreProduct brings back a deleted product.
+// It fails if another product has taken its SKU in the meantime
+func (db *PGInstance) RestoreProduct(ctx context.Context, productID string) (*Product, error) {
+	var product Product
+	if err := db.DB.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", productID).First(&product).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted product %s: %w", productID, err)
+	}
+
+	if err := db.DB.WithContext(ctx).Unscoped().Model(&product).Update("deleted_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore product: %v", err)
+	}
+
+	return &product, nil
+}
+
+// RestoreSale brings back a sale that was deleted in error and takes what it sold out of stock again
+func (db *PGInstance) RestoreSale(ctx context.Context, saleID string) (*Sale, error) {
+	var sale Sale
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NOT NULL", saleID).First(&sale).Error; err != nil {
+			return fmt.Errorf("failed to get deleted sale %s: %w", saleID, err)
+		}
+
+		if err := checkSaleChangeable(tx, &sale); err != nil {
+			return err
+		}
+
+		if err := restock(tx, sale.ProductID, money.Decimal{}.Sub(sale.Quantity)); err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&sale).Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore sale: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sale, nil
+}
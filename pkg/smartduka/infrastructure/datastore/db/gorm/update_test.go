@@ -0,0 +1,60 @@
+package gorm_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+)
+
+func TestPGInstance_ClaimPendingTaxInvoices(t *testing.T) {
+	ctx := context.Background()
+
+	sale, err := testingDB.AddSaleRecord(ctx, &gorm.Sale{
+		Base: gorm.Base{
+			CreatedBy: &userID,
+		},
+		ProductID:  productID,
+		Quantity:   money.DecimalFromInt(1),
+		Unit:       "DOZEN",
+		Price:      money.MustParse("15.40", money.CurrencyKES),
+		TaxInvoice: &gorm.TaxInvoice{},
+	}, enums.CostingMethodWeightedAverage)
+	if err != nil {
+		t.Fatalf("failed to record sale: %v", err)
+	}
+
+	claimed, err := testingDB.ClaimPendingTaxInvoices(ctx, 100, time.Minute)
+	if err != nil {
+		t.Errorf("PGInstance.ClaimPendingTaxInvoices() error = %v", err)
+		return
+	}
+
+	found := false
+	for _, invoice := range claimed {
+		found = found || invoice.ID == sale.TaxInvoice.ID
+	}
+	if !found {
+		t.Errorf("PGInstance.ClaimPendingTaxInvoices() did not claim the invoice of sale %v", sale.ID)
+	}
+
+	again, err := testingDB.ClaimPendingTaxInvoices(ctx, 100, time.Minute)
+	if err != nil {
+		t.Errorf("PGInstance.ClaimPendingTaxInvoices() error = %v", err)
+		return
+	}
+	for _, invoice := range again {
+		if invoice.ID == sale.TaxInvoice.ID {
+			t.Errorf("PGInstance.ClaimPendingTaxInvoices() claimed an invoice that is still leased")
+		}
+	}
+
+	if err := testingDB.UpdateTaxInvoice(ctx, sale.TaxInvoice, map[string]interface{}{
+		"status": enums.TaxInvoiceStatusSubmitted,
+	}); err != nil {
+		t.Errorf("PGInstance.UpdateTaxInvoice() error = %v", err)
+	}
+}
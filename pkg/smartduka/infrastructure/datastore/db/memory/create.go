@@ -0,0 +1,530 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+)
+
+// RegisterUser creates a new user record together with their contact and, when given, their identification
+// document and initial PIN
+func (s *Store) RegisterUser(ctx context.Context, user *gorm.User, contact *gorm.Contact, identifier *gorm.Identifier, pin *gorm.UserPIN) (*gorm.User, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	_ = user.BeforeCreate(nil)
+	s.created(ctx, &user.Base)
+	if err := s.checkUser(user); err != nil {
+		return nil, err
+	}
+
+	contact.UserID = user.ID
+	_ = contact.BeforeCreate(nil)
+	s.created(ctx, &contact.Base)
+
+	stored := clone(user)
+	stored.Contacts = gorm.Contact{}
+	s.users = append(s.users, stored)
+
+	// the user is only kept if everything registered with them can be saved
+	err := s.checkContact(contact)
+	if err == nil && identifier != nil {
+		identifier.UserID = user.ID
+		_ = identifier.BeforeCreate(nil)
+		s.created(ctx, &identifier.Base)
+		err = s.checkIdentifier(identifier)
+	}
+	if err == nil && pin != nil {
+		pin.UserID = *user.ID
+		_ = pin.BeforeCreate(nil)
+		s.created(ctx, &pin.Base)
+		err = s.checkPIN(pin)
+	}
+	if err != nil {
+		s.users = s.users[:len(s.users)-1]
+		return nil, err
+	}
+
+	s.contacts = append(s.contacts, clone(contact))
+	if identifier != nil {
+		s.identifiers = append(s.identifiers, clone(identifier))
+	}
+	if pin != nil {
+		s.pins = append(s.pins, clone(pin))
+	}
+
+	return user, nil
+}
+
+// SaveOTP saves an OTP
+func (s *Store) SaveOTP(ctx context.Context, otp *gorm.OTP) (*gorm.OTP, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	_ = otp.BeforeCreate(nil)
+	s.created(ctx, &otp.Base)
+	if err := s.checkOTP(otp); err != nil {
+		return nil, err
+	}
+	s.otps = append(s.otps, clone(otp))
+
+	return otp, nil
+}
+
+// SavePIN saves a pin
+func (s *Store) SavePIN(ctx context.Context, pinData *gorm.UserPIN) (*gorm.UserPIN, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	_ = pinData.BeforeCreate(nil)
+	s.created(ctx, &pinData.Base)
+	if err := s.checkPIN(pinData); err != nil {
+		return nil, err
+	}
+	s.pins = append(s.pins, clone(pinData))
+
+	return pinData, nil
+}
+
+// AddProduct adds a product
+func (s *Store) AddProduct(ctx context.Context, product *gorm.Product) (*gorm.Product, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	_ = product.BeforeCreate(nil)
+	s.created(ctx, &product.Base)
+	if err := s.checkProduct(s.products, product); err != nil {
+		return nil, err
+	}
+
+	stored := clone(product)
+	_ = stored.AfterFind(nil)
+	s.products = append(s.products, stored)
+
+	return product, nil
+}
+
+// AddSaleRecord records a sale. The cost of the goods sold is worked out using the shop's costing method
+// and the product's stock is reduced
+func (s *Store) AddSaleRecord(ctx context.Context, sale *gorm.Sale, costing enums.CostingMethod) (*gorm.Sale, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	if sale.ShiftID != nil {
+		if _, err := s.openShift(*sale.ShiftID); err != nil {
+			return nil, err
+		}
+	}
+
+	product := s.findProduct(sale.ProductID, false)
+	if product == nil {
+		return nil, fmt.Errorf("failed to get product %s: %w", sale.ProductID, errRecordNotFound)
+	}
+
+	var cost money.Money
+	var consumed map[*gorm.StockReceipt]money.Decimal
+	var err error
+	switch costing {
+	case enums.CostingMethodWeightedAverage:
+		cost = product.CostPrice.Mul(sale.Quantity)
+	case enums.CostingMethodFIFO:
+		if sale.Quantity.IsNegative() {
+			// returned goods go back into stock at the current cost price
+			cost = product.CostPrice.Mul(sale.Quantity)
+			break
+		}
+		cost, consumed, err = s.consumeStockReceipts(product, sale.Quantity)
+	default:
+		err = fmt.Errorf("invalid costing method: %s", costing)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sale.CostOfGoods = cost
+
+	_ = sale.BeforeCreate(nil)
+	s.created(ctx, &sale.Base)
+	if err := s.checkSale(sale); err != nil {
+		return nil, err
+	}
+
+	var invoice *gorm.TaxInvoice
+	if sale.TaxInvoice != nil {
+		invoice = sale.TaxInvoice
+		invoice.SaleID = sale.ID
+		_ = invoice.BeforeCreate(nil)
+		s.created(ctx, &invoice.Base)
+		if err := checkConstraint("smartduka_tax_invoice", "status", invoice.Status.IsValid()); err != nil {
+			return nil, err
+		}
+	}
+
+	for receipt, remaining := range consumed {
+		receipt.RemainingQuantity = remaining
+		s.updated(ctx, &receipt.Base)
+	}
+
+	remaining := product.Quantity.Sub(sale.Quantity)
+	product.Quantity = remaining
+	product.InStock = !remaining.IsNegative() && !remaining.IsZero()
+	s.updated(ctx, &product.Base)
+
+	stored := clone(sale)
+	stored.Product = gorm.Product{}
+	stored.TaxInvoice = nil
+	_ = stored.AfterFind(nil)
+	s.sales = append(s.sales, stored)
+	if invoice != nil {
+		s.invoices = append(s.invoices, clone(invoice))
+	}
+
+	return sale, nil
+}
+
+// consumeStockReceipts takes the quantity sold from the oldest stock receipts first and returns what those units cost
+// together with what is left of each receipt used. Any units sold beyond what has been received are valued at
+// the product's cost price
+func (s *Store) consumeStockReceipts(product *gorm.Product, quantity money.Decimal) (money.Money, map[*gorm.StockReceipt]money.Decimal, error) {
+	receipts := []*gorm.StockReceipt{}
+	for _, receipt := range s.receipts {
+		if receipt.ProductID == product.ID && receipt.Active && !receipt.RemainingQuantity.IsNegative() && !receipt.RemainingQuantity.IsZero() {
+			receipts = append(receipts, receipt)
+		}
+	}
+	sort.SliceStable(receipts, func(i, j int) bool {
+		if !receipts[i].CreatedAt.Equal(receipts[j].CreatedAt) {
+			return receipts[i].CreatedAt.Before(receipts[j].CreatedAt)
+		}
+		return receipts[i].ID < receipts[j].ID
+	})
+
+	cost := money.Zero(product.Currency)
+	consumed := map[*gorm.StockReceipt]money.Decimal{}
+	outstanding := quantity
+	for _, receipt := range receipts {
+		if outstanding.IsZero() || outstanding.IsNegative() {
+			break
+		}
+
+		taken := receipt.RemainingQuantity
+		if outstanding.Cmp(taken) < 0 {
+			taken = outstanding
+		}
+
+		var err error
+		cost, err = cost.Add(receipt.UnitCost.Mul(taken))
+		if err != nil {
+			return money.Money{}, nil, err
+		}
+
+		consumed[receipt] = receipt.RemainingQuantity.Sub(taken)
+		outstanding = outstanding.Sub(taken)
+	}
+
+	if !outstanding.IsZero() && !outstanding.IsNegative() {
+		total, err := cost.Add(product.CostPrice.Mul(outstanding))
+		return total, consumed, err
+	}
+
+	return cost, consumed, nil
+}
+
+// AddStockReceipt records a delivery of stock.
+// The product's stock is increased and its cost price becomes the weighted average cost of the units in stock
+func (s *Store) AddStockReceipt(ctx context.Context, receipt *gorm.StockReceipt) (*gorm.StockReceipt, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	product := s.findProduct(receipt.ProductID, false)
+	if product == nil {
+		return nil, fmt.Errorf("failed to get product %s: %w", receipt.ProductID, errRecordNotFound)
+	}
+
+	costPrice := receipt.UnitCost
+	stock := product.Quantity.Add(receipt.Quantity)
+	if !product.Quantity.IsNegative() && !product.Quantity.IsZero() {
+		value, err := product.CostPrice.Mul(product.Quantity).Add(receipt.UnitCost.Mul(receipt.Quantity))
+		if err != nil {
+			return nil, err
+		}
+
+		costPrice, err = value.Div(stock)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	receipt.RemainingQuantity = receipt.Quantity
+	_ = receipt.BeforeCreate(nil)
+	s.created(ctx, &receipt.Base)
+	if err := notNull("smartduka_stock_receipt", "created_by", receipt.CreatedBy != nil); err != nil {
+		return nil, err
+	}
+
+	product.Quantity = stock
+	product.CostPrice = money.New(costPrice.Amount, product.Currency)
+	product.InStock = !stock.IsNegative() && !stock.IsZero()
+	s.updated(ctx, &product.Base)
+
+	stored := clone(receipt)
+	_ = stored.AfterFind(nil)
+	s.receipts = append(s.receipts, stored)
+
+	return receipt, nil
+}
+
+// OpenShift starts a cashier's shift with the cash float placed in the drawer.
+// A cashier can only have one open shift at a time
+func (s *Store) OpenShift(ctx context.Context, shift *gorm.Shift) (*gorm.Shift, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, other := range s.shifts {
+		if other.CashierID == shift.CashierID && other.Status == enums.ShiftStatusOpen {
+			return nil, fmt.Errorf("cashier %s already has an open shift", shift.CashierID)
+		}
+	}
+
+	_ = shift.BeforeCreate(nil)
+	s.created(ctx, &shift.Base)
+	if err := s.checkShift(shift); err != nil {
+		return nil, fmt.Errorf("failed to open shift: %w", err)
+	}
+
+	stored := clone(shift)
+	_ = stored.AfterFind(nil)
+	s.shifts = append(s.shifts, stored)
+
+	return shift, nil
+}
+
+// AddCashMovement records cash put into or taken out of the drawer during an open shift
+func (s *Store) AddCashMovement(ctx context.Context, movement *gorm.CashMovement) (*gorm.CashMovement, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	if _, err := s.openShift(movement.ShiftID); err != nil {
+		return nil, err
+	}
+
+	_ = movement.BeforeCreate(nil)
+	s.created(ctx, &movement.Base)
+	err := firstError(
+		notNull("smartduka_cash_movement", "created_by", movement.CreatedBy != nil),
+		checkConstraint("smartduka_cash_movement", "type", movement.Type.IsValid()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record cash movement: %w", err)
+	}
+
+	stored := clone(movement)
+	_ = stored.AfterFind(nil)
+	s.movements = append(s.movements, stored)
+
+	return movement, nil
+}
+
+// ImportProducts creates or updates products all at once. A product is matched by its SKU when it has one
+// and otherwise by its name. The stock level of a matched product is replaced by the imported quantity.
+// A dry run works out the same changes without keeping them so that the counts can be previewed
+func (s *Store) ImportProducts(ctx context.Context, products []*gorm.Product, dryRun bool) (created int, updated int, err error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, 0, err
+	}
+	defer s.mu.Unlock()
+
+	// changes are made to copies which replace the stored products once every one has been imported
+	imported := make([]*gorm.Product, len(s.products))
+	for i, product := range s.products {
+		imported[i] = clone(product)
+	}
+
+	for _, product := range products {
+		var existing *gorm.Product
+		for _, candidate := range imported {
+			if !candidate.Active || candidate.DeletedAt.Valid {
+				continue
+			}
+			if product.SKU != nil && candidate.SKU != nil && *candidate.SKU == *product.SKU ||
+				product.SKU == nil && strings.EqualFold(candidate.Name, product.Name) {
+				existing = candidate
+				break
+			}
+		}
+
+		if existing == nil {
+			_ = product.BeforeCreate(nil)
+			s.created(ctx, &product.Base)
+			if err := s.checkProduct(imported, product); err != nil {
+				return 0, 0, fmt.Errorf("failed to create product %s: %w", product.Name, err)
+			}
+
+			stored := clone(product)
+			_ = stored.AfterFind(nil)
+			imported = append(imported, stored)
+			created++
+			continue
+		}
+
+		existing.SKU = product.SKU
+		existing.Name = product.Name
+		existing.Category = product.Category
+		existing.Quantity = product.Quantity
+		existing.Unit = product.Unit
+		existing.Price = money.New(product.Price.Amount, existing.Currency)
+		existing.CostPrice = money.New(product.CostPrice.Amount, existing.Currency)
+		existing.VAT = product.VAT
+		existing.Description = product.Description
+		existing.Manufacturer = product.Manufacturer
+		existing.InStock = product.InStock
+		existing.UpdatedBy = product.CreatedBy
+		s.updated(ctx, &existing.Base)
+		if err := s.checkProduct(imported, existing); err != nil {
+			return 0, 0, fmt.Errorf("failed to update product %s: %w", product.Name, err)
+		}
+		updated++
+	}
+
+	if !dryRun {
+		s.products = imported
+	}
+
+	return created, updated, nil
+}
+
+// SaveSyncOperation records the outcome of applying an operation pushed by an offline client.
+// An operation is only recorded once, the outcome recorded first is returned when it is saved again
+func (s *Store) SaveSyncOperation(ctx context.Context, operation *gorm.SyncOperation) (*gorm.SyncOperation, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, saved := range s.operations {
+		if saved.ID == operation.ID {
+			return clone(saved), nil
+		}
+	}
+
+	_ = operation.BeforeCreate(nil)
+	s.created(ctx, &operation.Base)
+
+	deviceExists := false
+	for _, device := range s.devices {
+		deviceExists = deviceExists || device.ID == operation.DeviceID
+	}
+	err := firstError(
+		foreignKey("smartduka_sync_operation", "device_id", deviceExists),
+		checkConstraint("smartduka_sync_operation", "type", operation.Type.IsValid()),
+		checkConstraint("smartduka_sync_operation", "status", operation.Status.IsValid()),
+		checkConstraint("smartduka_sync_operation", "conflict", operation.Conflict == nil || operation.Conflict.IsValid()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save sync operation: %w", err)
+	}
+
+	saved := clone(operation)
+	s.operations = append(s.operations, saved)
+
+	return clone(saved), nil
+}
+
+// SaveSyncDevice creates or updates the sync state of a device. Only the times and cursor that are set are updated
+func (s *Store) SaveSyncDevice(ctx context.Context, device *gorm.SyncDevice) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	device.CreatedAt = now
+	device.UpdatedAt = now
+
+	if err := foreignKey("smartduka_sync_device", "user_id", s.userExists(&device.UserID)); err != nil {
+		return fmt.Errorf("failed to save sync device: %w", err)
+	}
+
+	for _, saved := range s.devices {
+		if saved.ID != device.ID {
+			continue
+		}
+
+		saved.UserID = device.UserID
+		saved.UpdatedAt = now
+		if device.Cursor != nil {
+			saved.Cursor = device.Cursor
+		}
+		if device.LastPushedAt != nil {
+			saved.LastPushedAt = device.LastPushedAt
+		}
+		if device.LastPulledAt != nil {
+			saved.LastPulledAt = device.LastPulledAt
+		}
+		return nil
+	}
+
+	s.created(ctx, &device.Base)
+	s.devices = append(s.devices, clone(device))
+
+	return nil
+}
+
+// ReserveIdempotencyKey reserves a key for the request that is about to be processed.
+// When the key is held by a request that is still running or has completed, the stored key is returned and nothing is reserved.
+// A key whose request failed or whose response has expired is reserved again
+func (s *Store) ReserveIdempotencyKey(ctx context.Context, key *gorm.IdempotencyKey) (*gorm.IdempotencyKey, bool, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, false, err
+	}
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	key.CreatedAt = now
+	key.UpdatedAt = now
+
+	stored := s.findIdempotencyKey(key.Scope, key.Key)
+	if stored == nil {
+		s.created(ctx, &key.Base)
+		s.keys = append(s.keys, clone(key))
+		return key, true, nil
+	}
+
+	running := stored.CompletedAt == nil && stored.LockedUntil.After(now)
+	completed := stored.CompletedAt != nil && stored.ExpiresAt.After(now)
+	if running || completed {
+		return clone(stored), false, nil
+	}
+
+	stored.RequestHash = key.RequestHash
+	stored.LockedUntil = key.LockedUntil
+	stored.ExpiresAt = key.ExpiresAt
+	stored.CompletedAt = nil
+	stored.ResponseStatus = nil
+	stored.ResponseContentType = nil
+	stored.ResponseBody = nil
+	s.updated(ctx, &stored.Base)
+
+	return key, true, nil
+}
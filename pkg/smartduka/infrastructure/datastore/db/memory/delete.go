@@ -0,0 +1,267 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+)
+
+// DeleteUser soft deletes a user together with their contacts and identification documents
+func (s *Store) DeleteUser(ctx context.Context, userID string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	user := s.findUser(userID, false)
+	if user == nil {
+		return fmt.Errorf("failed to delete user %s: %w", userID, errRecordNotFound)
+	}
+
+	now := time.Now().UTC()
+	user.DeletedAt.Time, user.DeletedAt.Valid = now, true
+	for _, contact := range s.contacts {
+		if contact.UserID != nil && *contact.UserID == userID && !contact.DeletedAt.Valid {
+			contact.DeletedAt.Time, contact.DeletedAt.Valid = now, true
+		}
+	}
+	for _, identifier := range s.identifiers {
+		if identifier.UserID != nil && *identifier.UserID == userID && !identifier.DeletedAt.Valid {
+			identifier.DeletedAt.Time, identifier.DeletedAt.Valid = now, true
+		}
+	}
+
+	return nil
+}
+
+// DeleteProduct soft deletes a product. Its sales keep referring to it
+func (s *Store) DeleteProduct(ctx context.Context, productID string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	product := s.findProduct(productID, false)
+	if product == nil {
+		return fmt.Errorf("failed to delete product %s: %w", productID, errRecordNotFound)
+	}
+	product.DeletedAt.Time, product.DeletedAt.Valid = time.Now().UTC(), true
+	s.updated(ctx, &product.Base)
+
+	return nil
+}
+
+// DeleteSale soft deletes a sale recorded in error and puts what it sold back in stock.
+// Sales reported to KRA or made in a closed shift cannot be deleted, a return has to be recorded instead
+func (s *Store) DeleteSale(ctx context.Context, saleID string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	sale := s.findSale(saleID, false)
+	if sale == nil {
+		return fmt.Errorf("failed to get sale %s: %w", saleID, errRecordNotFound)
+	}
+
+	if err := s.checkSaleChangeable(sale); err != nil {
+		return err
+	}
+
+	s.restock(ctx, sale.ProductID, sale.Quantity)
+	sale.DeletedAt.Time, sale.DeletedAt.Valid = time.Now().UTC(), true
+
+	return nil
+}
+
+// checkSaleChangeable refuses to delete or restore a sale that has been reported to KRA or closed in a Z-report
+func (s *Store) checkSaleChangeable(sale *gorm.Sale) error {
+	if invoice := s.findTaxInvoice(sale.ID); invoice != nil && invoice.Status != enums.TaxInvoiceStatusRejected {
+		return fmt.Errorf("sale %s: %w", sale.ID, gorm.ErrSaleReported)
+	}
+
+	if sale.ShiftID != nil {
+		if _, err := s.openShift(*sale.ShiftID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restock adds a quantity to a product's stock. A negative quantity takes it out of stock
+func (s *Store) restock(ctx context.Context, productID string, quantity money.Decimal) {
+	product := s.findProduct(productID, true)
+	stock := product.Quantity.Add(quantity)
+	product.Quantity = stock
+	product.InStock = !stock.IsNegative() && !stock.IsZero()
+	s.updated(ctx, &product.Base)
+}
+
+// deletion is a soft deleted record waiting to be purged
+type deletion struct {
+	id        string
+	deletedAt time.Time
+}
+
+// due lists the records deleted before the given time, oldest first and at most limit of them
+func due(deletions []deletion, before time.Time, limit int) []string {
+	sort.SliceStable(deletions, func(i, j int) bool { return deletions[i].deletedAt.Before(deletions[j].deletedAt) })
+
+	ids := []string{}
+	for _, d := range deletions {
+		if d.deletedAt.Before(before) && len(ids) < limit {
+			ids = append(ids, d.id)
+		}
+	}
+	return ids
+}
+
+// PurgeDeleted permanently removes records deleted before the given time, at most limit of each kind at a time.
+// Records that are still referred to e.g a product with sales are kept
+func (s *Store) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	purged := 0
+
+	// dependent records go first so that what they refer to can be purged in the same run
+	deletions := []deletion{}
+	for _, contact := range s.contacts {
+		if contact.DeletedAt.Valid {
+			deletions = append(deletions, deletion{contact.ID, contact.DeletedAt.Time})
+		}
+	}
+	for _, id := range due(deletions, before, limit) {
+		s.contacts = remove(s.contacts, func(contact *gorm.Contact) bool { return contact.ID == id })
+		purged++
+	}
+
+	deletions = []deletion{}
+	for _, identifier := range s.identifiers {
+		if identifier.DeletedAt.Valid {
+			deletions = append(deletions, deletion{identifier.ID, identifier.DeletedAt.Time})
+		}
+	}
+	for _, id := range due(deletions, before, limit) {
+		s.identifiers = remove(s.identifiers, func(identifier *gorm.Identifier) bool { return identifier.ID == id })
+		purged++
+	}
+
+	deletions = []deletion{}
+	for _, sale := range s.sales {
+		if sale.DeletedAt.Valid {
+			deletions = append(deletions, deletion{sale.ID, sale.DeletedAt.Time})
+		}
+	}
+	for _, id := range due(deletions, before, limit) {
+		if s.findTaxInvoice(id) != nil {
+			continue
+		}
+		s.sales = remove(s.sales, func(sale *gorm.Sale) bool { return sale.ID == id })
+		purged++
+	}
+
+	deletions = []deletion{}
+	for _, product := range s.products {
+		if product.DeletedAt.Valid {
+			deletions = append(deletions, deletion{product.ID, product.DeletedAt.Time})
+		}
+	}
+	for _, id := range due(deletions, before, limit) {
+		if s.productReferenced(id) {
+			continue
+		}
+		s.products = remove(s.products, func(product *gorm.Product) bool { return product.ID == id })
+		purged++
+	}
+
+	deletions = []deletion{}
+	for _, user := range s.users {
+		if user.DeletedAt.Valid {
+			deletions = append(deletions, deletion{*user.ID, user.DeletedAt.Time})
+		}
+	}
+	for _, id := range due(deletions, before, limit) {
+		if s.userReferenced(id) {
+			continue
+		}
+		// the credentials of a deleted user are of no use to anyone
+		s.pins = remove(s.pins, func(pin *gorm.UserPIN) bool { return pin.UserID == id })
+		s.otps = remove(s.otps, func(otp *gorm.OTP) bool { return otp.UserID != nil && *otp.UserID == id })
+		s.users = remove(s.users, func(user *gorm.User) bool { return *user.ID == id })
+		purged++
+	}
+
+	return purged, nil
+}
+
+// productReferenced is true if a sale or stock receipt refers to the product
+func (s *Store) productReferenced(productID string) bool {
+	for _, sale := range s.sales {
+		if sale.ProductID == productID {
+			return true
+		}
+	}
+	for _, receipt := range s.receipts {
+		if receipt.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// userReferenced is true if a record other than the user's credentials refers to the user
+func (s *Store) userReferenced(userID string) bool {
+	is := func(id *string) bool { return id != nil && *id == userID }
+
+	for _, contact := range s.contacts {
+		if is(contact.UserID) {
+			return true
+		}
+	}
+	for _, identifier := range s.identifiers {
+		if is(identifier.UserID) {
+			return true
+		}
+	}
+	for _, product := range s.products {
+		if is(product.CreatedBy) || is(product.UpdatedBy) {
+			return true
+		}
+	}
+	for _, sale := range s.sales {
+		if is(sale.CreatedBy) {
+			return true
+		}
+	}
+	for _, shift := range s.shifts {
+		if shift.CashierID == userID {
+			return true
+		}
+	}
+	for _, device := range s.devices {
+		if device.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// remove drops the records matching fn
+func remove[T any](records []*T, fn func(record *T) bool) []*T {
+	kept := []*T{}
+	for _, record := range records {
+		if !fn(record) {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
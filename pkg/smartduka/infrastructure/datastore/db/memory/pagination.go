@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
+)
+
+// sortKey is what a record is sorted by: the value of the sort field and then its ID
+type sortKey struct {
+	value interface{}
+	id    string
+}
+
+// compareValues orders two values of a sort field
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case money.Money:
+		other := b.(money.Money)
+		switch {
+		case a.Amount < other.Amount:
+			return -1
+		case a.Amount > other.Amount:
+			return 1
+		}
+		return 0
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+func (k sortKey) compare(other sortKey) int {
+	if c := compareValues(k.value, other.value); c != 0 {
+		return c
+	}
+	return strings.Compare(k.id, other.id)
+}
+
+// page sorts records by their keys, like the keyset pagination of the gorm datastore,
+// and returns at most limit of those after the cursor's record
+func page[T any](records []*T, keyOf func(record *T) sortKey, direction enums.SortDirection, after *pagination.Cursor, value interface{}, limit int) []*T {
+	sign := 1
+	if direction == enums.SortDirectionDesc {
+		sign = -1
+	}
+	sort.SliceStable(records, func(i, j int) bool { return sign*keyOf(records[i]).compare(keyOf(records[j])) < 0 })
+
+	paged := []*T{}
+	for _, record := range records {
+		if after != nil && sign*keyOf(record).compare(sortKey{value, after.ID}) <= 0 {
+			continue
+		}
+		if len(paged) == limit {
+			break
+		}
+		paged = append(paged, record)
+	}
+	return paged
+}
@@ -0,0 +1,1056 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/search"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+)
+
+// GetUserProfileByUserID fetches an active user profile using the user ID
+func (s *Store) GetUserProfileByUserID(ctx context.Context, userID *string) (*gorm.User, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if (userID == nil || *user.ID == *userID) && user.Active && !user.DeletedAt.Valid {
+			return s.withContact(user), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get user by user ID %v: %w", userID, errRecordNotFound)
+}
+
+// GetUserProfileByPhoneNumber fetches a user profile using the phone number
+func (s *Store) GetUserProfileByPhoneNumber(ctx context.Context, phoneNumber string, flavour enums.Flavour) (*gorm.User, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, contact := range s.contacts {
+		if contact.ContactValue != phoneNumber || contact.Flavour != flavour {
+			continue
+		}
+		if user := s.findUser(*contact.UserID, false); user != nil {
+			return s.withContact(user), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get user by phonenumber %v: %w", phoneNumber, errRecordNotFound)
+}
+
+// GetUserByUsername fetches the user who has taken a username
+func (s *Store) GetUserByUsername(ctx context.Context, username string) (*gorm.User, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.UserName == username && !user.DeletedAt.Valid {
+			return clone(user), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get user by username %v: %w", username, errRecordNotFound)
+}
+
+// GetUserByIdentifier fetches the user identified by a document e.g their national ID
+func (s *Store) GetUserByIdentifier(ctx context.Context, identifierType enums.IdentifierType, value string) (*gorm.User, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, identifier := range s.identifiers {
+		if identifier.IdentifierType != identifierType || identifier.IdentifierValue != value || identifier.DeletedAt.Valid {
+			continue
+		}
+		if user := s.findUser(*identifier.UserID, false); user != nil {
+			return clone(user), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get user by %v %v: %w", identifierType, value, errRecordNotFound)
+}
+
+// GetUserPINByUserID fetches a user's active pin using the user ID and Flavour
+func (s *Store) GetUserPINByUserID(ctx context.Context, userID string, flavour enums.Flavour) (*gorm.UserPIN, error) {
+	if !flavour.IsValid() {
+		return nil, fmt.Errorf("flavour is not valid")
+	}
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, pin := range s.pins {
+		if pin.UserID == userID && pin.Flavour == flavour && pin.Active {
+			return clone(pin), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get pin: %w", errRecordNotFound)
+}
+
+// GetValidOTP fetches an OTP sent to a phone number that has neither been used nor expired
+func (s *Store) GetValidOTP(ctx context.Context, phoneNumber string, flavour enums.Flavour, otp string) (*gorm.OTP, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, stored := range s.otps {
+		if stored.PhoneNumber == phoneNumber && stored.Flavour == flavour && stored.OTP == otp && stored.IsValid && stored.ValidUntil.After(now) {
+			return clone(stored), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get otp: %w", errRecordNotFound)
+}
+
+// SearchUser searches the active users for a term in their contact, names or username ignoring case.
+// A user is returned once for each of their contacts
+func (s *Store) SearchUser(ctx context.Context, searchTerm string) ([]*gorm.User, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	term := strings.ToLower(searchTerm)
+	users := []*gorm.User{}
+	for _, contact := range s.contacts {
+		user := s.findUser(*contact.UserID, false)
+		if user == nil || !user.Active {
+			continue
+		}
+
+		for _, value := range []string{contact.ContactValue, user.FirstName, user.LastName, user.UserName} {
+			if strings.Contains(strings.ToLower(value), term) {
+				users = append(users, s.withContact(user))
+				break
+			}
+		}
+	}
+
+	return users, nil
+}
+
+// GetProductByID retrieves a product using its ID
+func (s *Store) GetProductByID(ctx context.Context, id string) (*gorm.Product, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	product := s.findProduct(id, false)
+	if product == nil {
+		return nil, errRecordNotFound
+	}
+
+	return clone(product), nil
+}
+
+// GetSaleByID retrieves a sale and its tax invoice using its ID
+func (s *Store) GetSaleByID(ctx context.Context, id string) (*gorm.Sale, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	sale := s.findSale(id, false)
+	if sale == nil {
+		return nil, fmt.Errorf("failed to get sale: %w", errRecordNotFound)
+	}
+
+	return s.withTaxInvoice(sale), nil
+}
+
+// GetStockReceiptByID retrieves a delivery of stock using its ID
+func (s *Store) GetStockReceiptByID(ctx context.Context, id string) (*gorm.StockReceipt, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, receipt := range s.receipts {
+		if receipt.ID == id {
+			return clone(receipt), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get stock receipt: %w", errRecordNotFound)
+}
+
+// GetDailySale retrieves the active sales made since midnight in the shop's timezone
+// together with the products they sold, even those deleted since
+func (s *Store) GetDailySale(ctx context.Context, location *time.Location) ([]*gorm.Sale, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	now := time.Now().In(location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	sales := []*gorm.Sale{}
+	for _, sale := range s.sales {
+		if sale.DeletedAt.Valid || !sale.Active || sale.CreatedAt.Before(midnight) {
+			continue
+		}
+
+		copied := s.withTaxInvoice(sale)
+		if product := s.findProduct(sale.ProductID, true); product != nil {
+			copied.Product = *product
+		}
+		sales = append(sales, copied)
+	}
+
+	return sales, nil
+}
+
+// GetUsersByIDs fetches the users with the IDs, including deleted ones
+func (s *Store) GetUsersByIDs(ctx context.Context, ids []string) ([]*gorm.User, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	wanted := set(ids)
+	users := []*gorm.User{}
+	for _, user := range s.users {
+		if wanted[*user.ID] {
+			users = append(users, clone(user))
+		}
+	}
+
+	return users, nil
+}
+
+// GetContactsByUserIDs fetches the contacts of the users with the IDs
+func (s *Store) GetContactsByUserIDs(ctx context.Context, userIDs []string) ([]*gorm.Contact, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	wanted := set(userIDs)
+	contacts := []*gorm.Contact{}
+	for _, contact := range s.contacts {
+		if contact.UserID != nil && wanted[*contact.UserID] && !contact.DeletedAt.Valid {
+			contacts = append(contacts, clone(contact))
+		}
+	}
+
+	return contacts, nil
+}
+
+// GetProductsByIDs fetches the products with the IDs, including deleted ones
+func (s *Store) GetProductsByIDs(ctx context.Context, ids []string) ([]*gorm.Product, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	wanted := set(ids)
+	products := []*gorm.Product{}
+	for _, product := range s.products {
+		if wanted[product.ID] {
+			products = append(products, clone(product))
+		}
+	}
+
+	return products, nil
+}
+
+// GetSalesByShiftIDs fetches the active sales made during the shifts with the IDs in the order they were made
+func (s *Store) GetSalesByShiftIDs(ctx context.Context, shiftIDs []string) ([]*gorm.Sale, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	wanted := set(shiftIDs)
+	sales := []*gorm.Sale{}
+	for _, sale := range s.sales {
+		if sale.ShiftID != nil && wanted[*sale.ShiftID] && sale.Active && !sale.DeletedAt.Valid {
+			sales = append(sales, clone(sale))
+		}
+	}
+	sort.SliceStable(sales, func(i, j int) bool {
+		if !sales[i].CreatedAt.Equal(sales[j].CreatedAt) {
+			return sales[i].CreatedAt.Before(sales[j].CreatedAt)
+		}
+		return sales[i].ID < sales[j].ID
+	})
+
+	return sales, nil
+}
+
+// SearchProduct finds the active products matching a search term, best matches first
+func (s *Store) SearchProduct(ctx context.Context, searchTerm string, limit int) ([]*gorm.Product, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	products := []*gorm.Product{}
+	for _, product := range s.products {
+		if product.Active && !product.DeletedAt.Valid {
+			products = append(products, clone(product))
+		}
+	}
+
+	return gorm.RankProducts(products, search.Parse(searchTerm), limit), nil
+}
+
+// ListUsers fetches a page of the users matching the filter, starting after the cursor
+func (s *Store) ListUsers(ctx context.Context, filter *dto.UserFilter, sortBy dto.UserSort, after *pagination.Cursor, limit int) ([]*gorm.User, error) {
+	var value interface{}
+	if after != nil {
+		var err error
+		if value, err = gorm.UserSortValue(sortBy.Field, after); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	users := []*gorm.User{}
+	for _, user := range s.users {
+		if user.DeletedAt.Valid || (filter.Active != nil && user.Active != *filter.Active) {
+			continue
+		}
+		if filter.SearchTerm != nil {
+			term := strings.ToLower(*filter.SearchTerm)
+			contact := s.contactOf(*user.ID)
+			matches := false
+			for _, value := range []string{contact.ContactValue, user.FirstName, user.LastName, user.UserName} {
+				matches = matches || (value != "" && strings.Contains(strings.ToLower(value), term))
+			}
+			if !matches {
+				continue
+			}
+		}
+		users = append(users, user)
+	}
+
+	keyOf := func(user *gorm.User) sortKey {
+		cursor := gorm.UserCursor(user, sortBy.Field)
+		value, _ := gorm.UserSortValue(sortBy.Field, &cursor)
+		return sortKey{value, cursor.ID}
+	}
+
+	paged := []*gorm.User{}
+	for _, user := range page(users, keyOf, sortBy.Direction, after, value, limit) {
+		paged = append(paged, clone(user))
+	}
+
+	return paged, nil
+}
+
+// ListProducts fetches a page of the products matching the filter, starting after the cursor
+func (s *Store) ListProducts(ctx context.Context, filter *dto.ProductFilter, sortBy dto.ProductSort, after *pagination.Cursor, limit int) ([]*gorm.Product, error) {
+	var value interface{}
+	if after != nil {
+		var err error
+		if value, err = gorm.ProductSortValue(sortBy.Field, after); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	products := []*gorm.Product{}
+	for _, product := range s.products {
+		switch {
+		case product.DeletedAt.Valid:
+		case filter.SearchTerm != nil && !strings.Contains(strings.ToLower(product.Name), strings.ToLower(*filter.SearchTerm)):
+		case filter.Active != nil && product.Active != *filter.Active:
+		case filter.Category != nil && product.Category != filter.Category.String():
+		case filter.MinPrice != nil && product.Price.Amount < filter.MinPrice.Amount:
+		case filter.MaxPrice != nil && product.Price.Amount > filter.MaxPrice.Amount:
+		default:
+			products = append(products, product)
+		}
+	}
+
+	keyOf := func(product *gorm.Product) sortKey {
+		cursor := gorm.ProductCursor(product, sortBy.Field)
+		value, _ := gorm.ProductSortValue(sortBy.Field, &cursor)
+		return sortKey{value, cursor.ID}
+	}
+
+	paged := []*gorm.Product{}
+	for _, product := range page(products, keyOf, sortBy.Direction, after, value, limit) {
+		paged = append(paged, clone(product))
+	}
+
+	return paged, nil
+}
+
+// ListSales fetches a page of the active sales matching the filter in the order they were made, starting after the cursor
+func (s *Store) ListSales(ctx context.Context, filter *dto.SaleFilter, direction enums.SortDirection, after *pagination.Cursor, limit int) ([]*gorm.Sale, error) {
+	var value interface{}
+	if after != nil {
+		var err error
+		if value, err = gorm.SaleSortValue(after); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	sales := []*gorm.Sale{}
+	for _, sale := range s.sales {
+		switch {
+		case sale.DeletedAt.Valid || !sale.Active:
+		case filter.From != nil && sale.CreatedAt.Before(*filter.From):
+		case filter.To != nil && !sale.CreatedAt.Before(*filter.To):
+		case filter.ProductID != nil && sale.ProductID != *filter.ProductID:
+		case filter.PaymentMethod != nil && sale.PaymentMethod != *filter.PaymentMethod:
+		default:
+			sales = append(sales, sale)
+		}
+	}
+
+	keyOf := func(sale *gorm.Sale) sortKey {
+		cursor := gorm.SaleCursor(sale)
+		value, _ := gorm.SaleSortValue(&cursor)
+		return sortKey{value, cursor.ID}
+	}
+
+	paged := []*gorm.Sale{}
+	for _, sale := range page(sales, keyOf, direction, after, value, limit) {
+		paged = append(paged, s.withTaxInvoice(sale))
+	}
+
+	return paged, nil
+}
+
+// salesWithin lists the active sales made in the period [from, to)
+func (s *Store) salesWithin(from, to time.Time) []*gorm.Sale {
+	sales := []*gorm.Sale{}
+	for _, sale := range s.sales {
+		if !sale.DeletedAt.Valid && sale.Active && !sale.CreatedAt.Before(from) && sale.CreatedAt.Before(to) {
+			sales = append(sales, sale)
+		}
+	}
+	return sales
+}
+
+// lineValue is the value of a sale line in minor units rounded to the nearest one, less any discount given on it
+func lineValue(sale *gorm.Sale) int64 {
+	return sale.Price.Mul(sale.Quantity).Amount - sale.Discount.Amount
+}
+
+// period is the start of the calendar day, ISO week or month in which a sale was made in the shop's timezone
+func period(unit string, at time.Time, location *time.Location) string {
+	local := at.In(location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	switch unit {
+	case "week":
+		// Monday is the first day of an ISO week
+		day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		day = day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.Format("2006-01-02")
+}
+
+// cashierName is the full name of the user who made a sale, their username if they have no name or
+// fallback if the user does not exist
+func (s *Store) cashierName(userID *string, fallback string) string {
+	if userID == nil {
+		return fallback
+	}
+	user := s.findUser(*userID, true)
+	if user == nil {
+		return fallback
+	}
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		return name
+	}
+	return user.UserName
+}
+
+// aggregate totals a group of sales
+type aggregate struct {
+	key          string
+	label        string
+	revenue      int64
+	cost         int64
+	quantity     money.Decimal
+	transactions int
+	belowCost    int
+}
+
+func (a *aggregate) add(sale *gorm.Sale) {
+	value := lineValue(sale)
+	a.revenue += value
+	a.cost += sale.CostOfGoods.Amount
+	a.quantity = a.quantity.Add(sale.Quantity)
+	a.transactions++
+	if value < sale.CostOfGoods.Amount {
+		a.belowCost++
+	}
+}
+
+// group totals sales by the key and label given to each one, in the order each key is first seen
+func group(sales []*gorm.Sale, keyOf func(sale *gorm.Sale) (key string, label string)) []*aggregate {
+	groups := []*aggregate{}
+	byKey := map[string]*aggregate{}
+	for _, sale := range sales {
+		key, label := keyOf(sale)
+		total, ok := byKey[key+"\x00"+label]
+		if !ok {
+			total = &aggregate{key: key, label: label}
+			byKey[key+"\x00"+label] = total
+			groups = append(groups, total)
+		}
+		total.add(sale)
+	}
+	return groups
+}
+
+// GetSalesSummary totals the sales made in the period [from, to) by calendar day, week or month in the shop's timezone
+func (s *Store) GetSalesSummary(ctx context.Context, from, to time.Time, interval enums.ReportInterval, location *time.Location) ([]*gorm.SalesSummary, error) {
+	var unit string
+	switch interval {
+	case enums.ReportIntervalDay:
+		unit = "day"
+	case enums.ReportIntervalWeek:
+		unit = "week"
+	case enums.ReportIntervalMonth:
+		unit = "month"
+	default:
+		return nil, fmt.Errorf("invalid report interval: %s", interval)
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	groups := group(s.salesWithin(from, to), func(sale *gorm.Sale) (string, string) {
+		day := period(unit, sale.CreatedAt, location)
+		return day, day
+	})
+	sort.Slice(groups, func(i, j int) bool { return groups[i].key < groups[j].key })
+
+	summaries := []*gorm.SalesSummary{}
+	for _, total := range groups {
+		day, err := time.ParseInLocation("2006-01-02", total.key, location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sales period %s: %w", total.key, err)
+		}
+		summaries = append(summaries, &gorm.SalesSummary{
+			Period:       day,
+			Revenue:      money.New(total.revenue, money.DefaultCurrency),
+			Quantity:     total.quantity,
+			Transactions: total.transactions,
+		})
+	}
+
+	return summaries, nil
+}
+
+// GetSalesBreakdown totals the sales made in the period [from, to) by cashier, product category or payment method
+func (s *Store) GetSalesBreakdown(ctx context.Context, from, to time.Time, grouping enums.SalesGrouping) ([]*gorm.SalesBreakdown, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var keyOf func(sale *gorm.Sale) (string, string)
+	switch grouping {
+	case enums.SalesGroupingCashier:
+		keyOf = func(sale *gorm.Sale) (string, string) {
+			key := ""
+			if sale.CreatedBy != nil {
+				key = *sale.CreatedBy
+			}
+			return key, s.cashierName(sale.CreatedBy, "Unknown")
+		}
+	case enums.SalesGroupingCategory:
+		keyOf = func(sale *gorm.Sale) (string, string) {
+			category := s.findProduct(sale.ProductID, true).Category
+			return category, category
+		}
+	case enums.SalesGroupingPaymentMethod:
+		keyOf = func(sale *gorm.Sale) (string, string) {
+			return sale.PaymentMethod.String(), sale.PaymentMethod.String()
+		}
+	default:
+		return nil, fmt.Errorf("invalid sales grouping: %s", grouping)
+	}
+
+	groups := group(s.salesWithin(from, to), keyOf)
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].revenue > groups[j].revenue })
+
+	breakdown := []*gorm.SalesBreakdown{}
+	for _, total := range groups {
+		breakdown = append(breakdown, &gorm.SalesBreakdown{
+			Key:          total.key,
+			Label:        total.label,
+			Revenue:      money.New(total.revenue, money.DefaultCurrency),
+			Quantity:     total.quantity,
+			Transactions: total.transactions,
+		})
+	}
+
+	return breakdown, nil
+}
+
+// GetTopProducts returns the best selling products in the period [from, to) ranked by revenue or quantity sold
+func (s *Store) GetTopProducts(ctx context.Context, from, to time.Time, ranking enums.ProductRanking, limit int) ([]*gorm.ProductSales, error) {
+	if ranking != enums.ProductRankingRevenue && ranking != enums.ProductRankingQuantity {
+		return nil, fmt.Errorf("invalid product ranking: %s", ranking)
+	}
+
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be greater than zero")
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	groups := group(s.salesWithin(from, to), func(sale *gorm.Sale) (string, string) {
+		return sale.ProductID, s.findProduct(sale.ProductID, true).Name
+	})
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		byRevenue := a.revenue - b.revenue
+		byQuantity := a.quantity.Cmp(b.quantity)
+		if ranking == enums.ProductRankingQuantity && byQuantity != 0 {
+			return byQuantity > 0
+		}
+		if byRevenue != 0 {
+			return byRevenue > 0
+		}
+		if byQuantity != 0 {
+			return byQuantity > 0
+		}
+		return a.label < b.label
+	})
+	if len(groups) > limit {
+		groups = groups[:limit]
+	}
+
+	products := []*gorm.ProductSales{}
+	for _, total := range groups {
+		products = append(products, &gorm.ProductSales{
+			ProductID:    total.key,
+			Name:         total.label,
+			Category:     s.findProduct(total.key, true).Category,
+			Revenue:      money.New(total.revenue, money.DefaultCurrency),
+			Quantity:     total.quantity,
+			Transactions: total.transactions,
+		})
+	}
+
+	return products, nil
+}
+
+// GetHourlySales totals the sales made in the period [from, to) by day of the week and hour of the day in the shop's timezone.
+// Days of the week follow ISO 8601 i.e Monday is 1 and Sunday is 7
+func (s *Store) GetHourlySales(ctx context.Context, from, to time.Time, location *time.Location) ([]*gorm.HourlySales, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	groups := group(s.salesWithin(from, to), func(sale *gorm.Sale) (string, string) {
+		local := sale.CreatedAt.In(location)
+		return fmt.Sprintf("%d %02d", (int(local.Weekday())+6)%7+1, local.Hour()), ""
+	})
+	sort.Slice(groups, func(i, j int) bool { return groups[i].key < groups[j].key })
+
+	sales := []*gorm.HourlySales{}
+	for _, total := range groups {
+		hourly := &gorm.HourlySales{
+			Revenue:      money.New(total.revenue, money.DefaultCurrency),
+			Transactions: total.transactions,
+		}
+		if _, err := fmt.Sscanf(total.key, "%d %d", &hourly.DayOfWeek, &hourly.Hour); err != nil {
+			return nil, fmt.Errorf("failed to get hourly sales: %w", err)
+		}
+		sales = append(sales, hourly)
+	}
+
+	return sales, nil
+}
+
+// GetProfitReport totals the revenue and cost of the goods sold in the period [from, to) for each product,
+// category or calendar day, week or month in the shop's timezone
+func (s *Store) GetProfitReport(ctx context.Context, from, to time.Time, grouping enums.ProfitGrouping, location *time.Location) ([]*gorm.ProfitSummary, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	byPeriod := false
+	var keyOf func(sale *gorm.Sale) (string, string)
+	switch grouping {
+	case enums.ProfitGroupingProduct:
+		keyOf = func(sale *gorm.Sale) (string, string) {
+			return sale.ProductID, s.findProduct(sale.ProductID, true).Name
+		}
+	case enums.ProfitGroupingCategory:
+		keyOf = func(sale *gorm.Sale) (string, string) {
+			category := s.findProduct(sale.ProductID, true).Category
+			return category, category
+		}
+	case enums.ProfitGroupingDay, enums.ProfitGroupingWeek, enums.ProfitGroupingMonth:
+		byPeriod = true
+		unit := strings.ToLower(grouping.String())
+		keyOf = func(sale *gorm.Sale) (string, string) {
+			day := period(unit, sale.CreatedAt, location)
+			return day, day
+		}
+	default:
+		return nil, fmt.Errorf("invalid profit grouping: %s", grouping)
+	}
+
+	groups := group(s.salesWithin(from, to), keyOf)
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if byPeriod {
+			return a.key < b.key
+		}
+		if profitA, profitB := a.revenue-a.cost, b.revenue-b.cost; profitA != profitB {
+			return profitA > profitB
+		}
+		return a.label < b.label
+	})
+
+	report := []*gorm.ProfitSummary{}
+	for _, total := range groups {
+		report = append(report, &gorm.ProfitSummary{
+			Key:                   total.key,
+			Label:                 total.label,
+			Revenue:               money.New(total.revenue, money.DefaultCurrency),
+			CostOfGoods:           money.New(total.cost, money.DefaultCurrency),
+			Quantity:              total.quantity,
+			Transactions:          total.transactions,
+			BelowCostTransactions: total.belowCost,
+		})
+	}
+
+	return report, nil
+}
+
+// GetOpenShift fetches the shift a cashier currently has open
+func (s *Store) GetOpenShift(ctx context.Context, cashierID string) (*gorm.Shift, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, shift := range s.shifts {
+		if shift.CashierID == cashierID && shift.Status == enums.ShiftStatusOpen {
+			return clone(shift), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get open shift: %w", errRecordNotFound)
+}
+
+// GetShiftByID fetches a shift using its ID
+func (s *Store) GetShiftByID(ctx context.Context, id string) (*gorm.Shift, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	shift := s.findShift(id)
+	if shift == nil {
+		return nil, fmt.Errorf("failed to get shift: %w", errRecordNotFound)
+	}
+
+	return clone(shift), nil
+}
+
+// shiftSales lists the active sales made during a shift
+func (s *Store) shiftSales(shiftID string) []*gorm.Sale {
+	sales := []*gorm.Sale{}
+	for _, sale := range s.sales {
+		if sale.ShiftID != nil && *sale.ShiftID == shiftID && sale.Active && !sale.DeletedAt.Valid {
+			sales = append(sales, sale)
+		}
+	}
+	return sales
+}
+
+// GetShiftTenders totals the sales made during a shift by payment method
+func (s *Store) GetShiftTenders(ctx context.Context, shiftID string) ([]*gorm.ShiftTender, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	groups := group(s.shiftSales(shiftID), func(sale *gorm.Sale) (string, string) {
+		return sale.PaymentMethod.String(), ""
+	})
+	sort.Slice(groups, func(i, j int) bool { return groups[i].key < groups[j].key })
+
+	tenders := []*gorm.ShiftTender{}
+	for _, total := range groups {
+		tenders = append(tenders, &gorm.ShiftTender{
+			PaymentMethod: enums.PaymentMethod(total.key),
+			Amount:        money.New(total.revenue, money.DefaultCurrency),
+			Transactions:  total.transactions,
+		})
+	}
+
+	return tenders, nil
+}
+
+// GetShiftTotals totals the sales, returns, discounts and VAT of a shift.
+// Returns are sale lines with a negative quantity. Prices include VAT at the product's rate
+func (s *Store) GetShiftTotals(ctx context.Context, shiftID string) (*gorm.ShiftTotals, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var gross, returns, discounts, vat int64
+	sales := s.shiftSales(shiftID)
+	for _, sale := range sales {
+		value := sale.Price.Mul(sale.Quantity).Amount
+		if sale.Quantity.IsNegative() {
+			returns -= value
+		} else if !sale.Quantity.IsZero() {
+			gross += value
+		}
+		discounts += sale.Discount.Amount
+
+		_, lineVAT := money.New(lineValue(sale), money.DefaultCurrency).VATInclusive(s.findProduct(sale.ProductID, true).VAT)
+		vat += lineVAT.Amount
+	}
+
+	return &gorm.ShiftTotals{
+		GrossSales:   money.New(gross, money.DefaultCurrency),
+		Returns:      money.New(returns, money.DefaultCurrency),
+		Discounts:    money.New(discounts, money.DefaultCurrency),
+		VAT:          money.New(vat, money.DefaultCurrency),
+		Transactions: len(sales),
+	}, nil
+}
+
+// GetShiftCashMovements totals the cash put into or taken out of the drawer during a shift by type of movement
+func (s *Store) GetShiftCashMovements(ctx context.Context, shiftID string) ([]*gorm.ShiftCashMovements, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	totals := map[enums.CashMovementType]int64{}
+	for _, movement := range s.movements {
+		if movement.ShiftID == shiftID && movement.Active {
+			totals[movement.Type] += movement.Amount.Amount
+		}
+	}
+
+	movements := []*gorm.ShiftCashMovements{}
+	for movementType, amount := range totals {
+		movements = append(movements, &gorm.ShiftCashMovements{
+			Type:   movementType,
+			Amount: money.New(amount, money.DefaultCurrency),
+		})
+	}
+	sort.Slice(movements, func(i, j int) bool { return movements[i].Type < movements[j].Type })
+
+	return movements, nil
+}
+
+// GetShiftExpectedCash works out the cash that should be in the drawer of a shift
+func (s *Store) GetShiftExpectedCash(ctx context.Context, shiftID string) (money.Money, error) {
+	if err := s.lock(ctx); err != nil {
+		return money.Money{}, err
+	}
+	defer s.mu.Unlock()
+
+	shift := s.findShift(shiftID)
+	if shift == nil {
+		return money.Money{}, fmt.Errorf("failed to get shift: %w", errRecordNotFound)
+	}
+
+	return s.expectedCash(shift)
+}
+
+// expectedCash is the opening float plus the cash taken in sales and paid into the drawer,
+// less the cash paid out of or dropped from the drawer
+func (s *Store) expectedCash(shift *gorm.Shift) (money.Money, error) {
+	var sales int64
+	for _, sale := range s.shiftSales(shift.ID) {
+		if sale.PaymentMethod == enums.PaymentMethodCash {
+			sales += lineValue(sale)
+		}
+	}
+
+	var movements int64
+	for _, movement := range s.movements {
+		if movement.ShiftID != shift.ID || !movement.Active {
+			continue
+		}
+		if movement.Type == enums.CashMovementTypeCashIn {
+			movements += movement.Amount.Amount
+		} else {
+			movements -= movement.Amount.Amount
+		}
+	}
+
+	return money.Sum(shift.Currency, shift.OpeningFloat, money.New(sales, shift.Currency), money.New(movements, shift.Currency))
+}
+
+// StreamProducts passes each active product to fn in order of name
+func (s *Store) StreamProducts(ctx context.Context, fn func(product *gorm.Product) error) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+
+	products := []*gorm.Product{}
+	for _, product := range s.products {
+		if product.Active && !product.DeletedAt.Valid {
+			products = append(products, clone(product))
+		}
+	}
+	s.mu.Unlock()
+
+	sort.SliceStable(products, func(i, j int) bool { return products[i].Name < products[j].Name })
+
+	// fn is called without holding the lock so that it can use the store
+	for _, product := range products {
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StreamSales passes each sale made in the period [from, to) to fn in the order they were made
+func (s *Store) StreamSales(ctx context.Context, from, to time.Time, fn func(sale *gorm.SaleExport) error) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+
+	sales := []*gorm.SaleExport{}
+	for _, sale := range s.salesWithin(from, to) {
+		product := s.findProduct(sale.ProductID, true)
+		sales = append(sales, &gorm.SaleExport{
+			Sale:            *clone(sale),
+			ProductName:     product.Name,
+			ProductCategory: product.Category,
+			CashierName:     s.cashierName(sale.CreatedBy, ""),
+		})
+	}
+	s.mu.Unlock()
+
+	sort.SliceStable(sales, func(i, j int) bool {
+		if !sales[i].CreatedAt.Equal(sales[j].CreatedAt) {
+			return sales[i].CreatedAt.Before(sales[j].CreatedAt)
+		}
+		return sales[i].ID < sales[j].ID
+	})
+
+	for _, sale := range sales {
+		if err := fn(sale); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetSyncOperation fetches the recorded outcome of an operation pushed by an offline client
+func (s *Store) GetSyncOperation(ctx context.Context, id string) (*gorm.SyncOperation, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, operation := range s.operations {
+		if operation.ID == id {
+			return clone(operation), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get sync operation: %w", errRecordNotFound)
+}
+
+// GetSyncDevice fetches the sync state of a device
+func (s *Store) GetSyncDevice(ctx context.Context, id string) (*gorm.SyncDevice, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	for _, device := range s.devices {
+		if device.ID == id {
+			return clone(device), nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get sync device: %w", errRecordNotFound)
+}
+
+// Deactivated and deleted products are included so that clients can remove them
+// Deactivated products are included so that clients can remove them
+func (s *Store) GetProductChanges(ctx context.Context, since time.Time, afterID string, limit int) ([]*gorm.Product, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	products := []*gorm.Product{}
+	for _, product := range s.products {
+		if product.UpdatedAt.After(since) || product.UpdatedAt.Equal(since) && product.ID > afterID {
+			products = append(products, clone(product))
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		if !products[i].UpdatedAt.Equal(products[j].UpdatedAt) {
+			return products[i].UpdatedAt.Before(products[j].UpdatedAt)
+		}
+		return products[i].ID < products[j].ID
+	})
+	if limit >= 0 && len(products) > limit {
+		products = products[:limit]
+	}
+
+	return products, nil
+}
+
+// GetAuditLogs returns an empty log since the store does not keep one
+func (s *Store) GetAuditLogs(ctx context.Context, filter *dto.AuditLogFilter, limit int) ([]*gorm.AuditLog, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return []*gorm.AuditLog{}, nil
+}
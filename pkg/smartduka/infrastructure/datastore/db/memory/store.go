@@ -0,0 +1,416 @@
+// Package memory keeps the datastore's records in memory so that usecase and HTTP tests can run without a database.
+// It behaves like the gorm datastore: the same references, uniqueness and enum values are enforced,
+// deleted records are hidden and inactive ones are filtered out. Changes are not recorded in the audit log
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// errRecordNotFound is returned when a lookup matches nothing, the same error gorm returns
+var errRecordNotFound = gorm.ErrRecordNotFound
+
+// schemas caches the parsed gorm models used to apply updates given as column maps
+var schemas = &sync.Map{}
+
+// Store holds every table of the datastore. Each method holds the store's lock for its whole run so that
+// it is applied in full or not at all, like a database transaction
+type Store struct {
+	mu  sync.Mutex
+	ext extension.Extension
+
+	users       []*gorm.User
+	contacts    []*gorm.Contact
+	identifiers []*gorm.Identifier
+	pins        []*gorm.UserPIN
+	otps        []*gorm.OTP
+	products    []*gorm.Product
+	sales       []*gorm.Sale
+	receipts    []*gorm.StockReceipt
+	invoices    []*gorm.TaxInvoice
+	shifts      []*gorm.Shift
+	movements   []*gorm.CashMovement
+	devices     []*gorm.SyncDevice
+	operations  []*gorm.SyncOperation
+	keys        []*gorm.IdempotencyKey
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{ext: extension.NewExtension()}
+}
+
+// lock waits for exclusive use of the store. It fails without locking if the context is done
+func (s *Store) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	return nil
+}
+
+// actor is the logged in user making a change
+func (s *Store) actor(ctx context.Context) *string {
+	userID, err := s.ext.GetLoggedInUserUID(ctx)
+	if err != nil || userID == "" {
+		return nil
+	}
+	return &userID
+}
+
+// created stamps a new record with its creation time and the logged in user when it does not name a creator
+func (s *Store) created(ctx context.Context, base *gorm.Base) {
+	now := time.Now().UTC()
+	if base.CreatedAt.IsZero() {
+		base.CreatedAt = now
+	}
+	if base.UpdatedAt.IsZero() {
+		base.UpdatedAt = now
+	}
+
+	userID := s.actor(ctx)
+	if base.CreatedBy == nil || *base.CreatedBy == "" {
+		base.CreatedBy = userID
+	}
+	if base.UpdatedBy == nil || *base.UpdatedBy == "" {
+		base.UpdatedBy = userID
+	}
+}
+
+// updated stamps a changed record with the time and the logged in user
+func (s *Store) updated(ctx context.Context, base *gorm.Base) {
+	base.UpdatedAt = time.Now().UTC()
+	if userID := s.actor(ctx); userID != nil {
+		base.UpdatedBy = userID
+	}
+}
+
+// clone copies a record so that callers cannot change what is stored
+func clone[T any](record *T) *T {
+	copied := *record
+	return &copied
+}
+
+// assign sets the columns of a record from a map of column names to values, as gorm's Updates does
+func assign(ctx context.Context, record interface{}, values map[string]interface{}) error {
+	s, err := schema.Parse(record, schemas, schema.NamingStrategy{SingularTable: true})
+	if err != nil {
+		return fmt.Errorf("failed to parse %T: %w", record, err)
+	}
+
+	for column, value := range values {
+		field := s.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			return fmt.Errorf("column %s does not exist in %s", column, s.Table)
+		}
+		if err := field.Set(ctx, reflect.ValueOf(record), value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkConstraint fails like a CHECK constraint when a column holds a value it does not allow
+func checkConstraint(table, column string, ok bool) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf(`new row for relation "%s" violates check constraint "%s_%s_check"`, table, table, column)
+}
+
+// foreignKey fails like a foreign key constraint when a column refers to a record that does not exist
+func foreignKey(table, column string, ok bool) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf(`insert or update on table "%s" violates foreign key constraint "%s_%s_fkey"`, table, table, column)
+}
+
+// notNull fails like a NOT NULL constraint when a required column is empty
+func notNull(table, column string, ok bool) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf(`null value in column "%s" of relation "%s" violates not-null constraint`, column, table)
+}
+
+// firstError returns the first of the errors that is not nil
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// set lists the keys of a lookup
+func set(keys []string) map[string]bool {
+	lookup := map[string]bool{}
+	for _, key := range keys {
+		lookup[key] = true
+	}
+	return lookup
+}
+
+func (s *Store) userExists(id *string) bool {
+	if id == nil {
+		return false
+	}
+	for _, user := range s.users {
+		if *user.ID == *id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUser checks a user against the others. A username can only be taken by one user who is not deleted
+func (s *Store) checkUser(user *gorm.User) error {
+	if user.UserName == "" || user.DeletedAt.Valid {
+		return nil
+	}
+	for _, other := range s.users {
+		if *other.ID != *user.ID && other.UserName == user.UserName && !other.DeletedAt.Valid {
+			return fmt.Errorf(`duplicate key value violates unique constraint "smartduka_user_username_idx"`)
+		}
+	}
+	return nil
+}
+
+// checkContact checks a contact against the others. A phone number can only be used once in each flavour
+func (s *Store) checkContact(contact *gorm.Contact) error {
+	err := firstError(
+		checkConstraint("smartduka_contact", "flavour", contact.Flavour.IsValid()),
+		foreignKey("smartduka_contact", "user_id", s.userExists(contact.UserID)),
+	)
+	if err != nil {
+		return err
+	}
+
+	if contact.ContactType != "PHONE" || contact.DeletedAt.Valid {
+		return nil
+	}
+	for _, other := range s.contacts {
+		if other.ID != contact.ID && other.ContactType == "PHONE" && other.ContactValue == contact.ContactValue &&
+			other.Flavour == contact.Flavour && !other.DeletedAt.Valid {
+			return fmt.Errorf(`duplicate key value violates unique constraint "smartduka_contact_phone_flavour_idx"`)
+		}
+	}
+	return nil
+}
+
+// checkIdentifier checks an identifier against the others. A document can only identify one user
+func (s *Store) checkIdentifier(identifier *gorm.Identifier) error {
+	err := firstError(
+		checkConstraint("smartduka_identifier", "identifier_type", identifier.IdentifierType.IsValid()),
+		foreignKey("smartduka_identifier", "user_id", s.userExists(identifier.UserID)),
+	)
+	if err != nil {
+		return err
+	}
+
+	if identifier.DeletedAt.Valid {
+		return nil
+	}
+	for _, other := range s.identifiers {
+		if other.ID != identifier.ID && other.IdentifierType == identifier.IdentifierType &&
+			other.IdentifierValue == identifier.IdentifierValue && !other.DeletedAt.Valid {
+			return fmt.Errorf(`duplicate key value violates unique constraint "smartduka_identifier_value_idx"`)
+		}
+	}
+	return nil
+}
+
+func (s *Store) checkPIN(pin *gorm.UserPIN) error {
+	return firstError(
+		checkConstraint("smartduka_user_pin", "flavour", pin.Flavour.IsValid()),
+		foreignKey("smartduka_user_pin", "user_id", s.userExists(&pin.UserID)),
+	)
+}
+
+func (s *Store) checkOTP(otp *gorm.OTP) error {
+	return firstError(
+		checkConstraint("smartduka_user_otp", "flavour", otp.Flavour.IsValid()),
+		foreignKey("smartduka_user_otp", "user_id", otp.UserID == nil || s.userExists(otp.UserID)),
+	)
+}
+
+// checkProduct checks a product against the others in products. An active product's SKU must be unique
+func (s *Store) checkProduct(products []*gorm.Product, product *gorm.Product) error {
+	err := firstError(
+		notNull("smartduka_product", "created_by", product.CreatedBy != nil),
+		foreignKey("smartduka_product", "created_by", s.userExists(product.CreatedBy)),
+		foreignKey("smartduka_product", "updated_by", product.UpdatedBy == nil || s.userExists(product.UpdatedBy)),
+		checkConstraint("smartduka_product", "category", enums.Category(product.Category).IsValid()),
+		checkConstraint("smartduka_product", "unit", enums.Unit(product.Unit).IsValid()),
+	)
+	if err != nil {
+		return err
+	}
+
+	if product.SKU == nil || !product.Active || product.DeletedAt.Valid {
+		return nil
+	}
+	for _, other := range products {
+		if other.ID != product.ID && other.SKU != nil && *other.SKU == *product.SKU && other.Active && !other.DeletedAt.Valid {
+			return fmt.Errorf(`duplicate key value violates unique constraint "smartduka_product_sku_idx"`)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) checkSale(sale *gorm.Sale) error {
+	return firstError(
+		notNull("smartduka_sale", "created_by", sale.CreatedBy != nil),
+		foreignKey("smartduka_sale", "created_by", s.userExists(sale.CreatedBy)),
+		checkConstraint("smartduka_sale", "unit", enums.Unit(sale.Unit).IsValid()),
+		checkConstraint("smartduka_sale", "payment_method", sale.PaymentMethod.IsValid()),
+	)
+}
+
+func (s *Store) checkShift(shift *gorm.Shift) error {
+	err := firstError(
+		notNull("smartduka_shift", "created_by", shift.CreatedBy != nil),
+		foreignKey("smartduka_shift", "cashier_id", s.userExists(&shift.CashierID)),
+		checkConstraint("smartduka_shift", "status", shift.Status.IsValid()),
+	)
+	if err != nil {
+		return err
+	}
+
+	if shift.Status != enums.ShiftStatusOpen {
+		return nil
+	}
+	for _, other := range s.shifts {
+		if other.ID != shift.ID && other.CashierID == shift.CashierID && other.Status == enums.ShiftStatusOpen {
+			return fmt.Errorf(`duplicate key value violates unique constraint "smartduka_shift_open_cashier_idx"`)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) checkTaxInvoice(invoice *gorm.TaxInvoice) error {
+	err := firstError(
+		foreignKey("smartduka_tax_invoice", "sale_id", s.findSale(invoice.SaleID, true) != nil),
+		checkConstraint("smartduka_tax_invoice", "status", invoice.Status.IsValid()),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range s.invoices {
+		if other.ID != invoice.ID && other.SaleID == invoice.SaleID {
+			return fmt.Errorf(`duplicate key value violates unique constraint "smartduka_tax_invoice_sale_id_key"`)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) findUser(id string, unscoped bool) *gorm.User {
+	for _, user := range s.users {
+		if *user.ID == id && (unscoped || !user.DeletedAt.Valid) {
+			return user
+		}
+	}
+	return nil
+}
+
+func (s *Store) findProduct(id string, unscoped bool) *gorm.Product {
+	for _, product := range s.products {
+		if product.ID == id && (unscoped || !product.DeletedAt.Valid) {
+			return product
+		}
+	}
+	return nil
+}
+
+func (s *Store) findSale(id string, unscoped bool) *gorm.Sale {
+	for _, sale := range s.sales {
+		if sale.ID == id && (unscoped || !sale.DeletedAt.Valid) {
+			return sale
+		}
+	}
+	return nil
+}
+
+func (s *Store) findShift(id string) *gorm.Shift {
+	for _, shift := range s.shifts {
+		if shift.ID == id {
+			return shift
+		}
+	}
+	return nil
+}
+
+func (s *Store) findTaxInvoice(saleID string) *gorm.TaxInvoice {
+	for _, invoice := range s.invoices {
+		if invoice.SaleID == saleID {
+			return invoice
+		}
+	}
+	return nil
+}
+
+func (s *Store) findIdempotencyKey(scope string, key string) *gorm.IdempotencyKey {
+	for _, stored := range s.keys {
+		if stored.Scope == scope && stored.Key == key {
+			return stored
+		}
+	}
+	return nil
+}
+
+// contactOf is the contact preloaded with a user
+func (s *Store) contactOf(userID string) gorm.Contact {
+	var contact gorm.Contact
+	for _, stored := range s.contacts {
+		if stored.UserID != nil && *stored.UserID == userID && !stored.DeletedAt.Valid {
+			contact = *stored
+		}
+	}
+	return contact
+}
+
+// withContact copies a user together with their contact
+func (s *Store) withContact(user *gorm.User) *gorm.User {
+	copied := clone(user)
+	copied.Contacts = s.contactOf(*user.ID)
+	return copied
+}
+
+// withTaxInvoice copies a sale together with its tax invoice
+func (s *Store) withTaxInvoice(sale *gorm.Sale) *gorm.Sale {
+	copied := clone(sale)
+	if invoice := s.findTaxInvoice(sale.ID); invoice != nil {
+		copied.TaxInvoice = clone(invoice)
+	}
+	return copied
+}
+
+// openShift fetches a shift. It fails if the shift has been closed
+func (s *Store) openShift(shiftID string) (*gorm.Shift, error) {
+	shift := s.findShift(shiftID)
+	if shift == nil {
+		return nil, fmt.Errorf("failed to get shift %s: %w", shiftID, errRecordNotFound)
+	}
+
+	if shift.Status != enums.ShiftStatusOpen {
+		return nil, fmt.Errorf("shift %s: %w", shiftID, gorm.ErrShiftClosed)
+	}
+
+	return shift, nil
+}
@@ -0,0 +1,12 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/contract"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/memory"
+)
+
+func TestStore(t *testing.T) {
+	contract.Run(t, memory.NewStore())
+}
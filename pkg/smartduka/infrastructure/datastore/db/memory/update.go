@@ -0,0 +1,364 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+)
+
+// errMissingWhereClause is returned when updating a record without its ID, with the same message as gorm
+var errMissingWhereClause = errors.New("WHERE conditions required")
+
+// InvalidatePIN deactivates the pins a user has for a flavour
+func (s *Store) InvalidatePIN(ctx context.Context, userID string, flavour enums.Flavour) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	for _, pin := range s.pins {
+		if pin.UserID == userID && pin.Flavour == flavour && pin.Active {
+			pin.Active = false
+			s.updated(ctx, &pin.Base)
+		}
+	}
+
+	return nil
+}
+
+// InvalidateOTP uses up an OTP so that it cannot be used again. It fails if the OTP has already been used
+func (s *Store) InvalidateOTP(ctx context.Context, id string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	for _, otp := range s.otps {
+		if otp.ID == id && otp.IsValid {
+			otp.IsValid = false
+			s.updated(ctx, &otp.Base)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("otp %s has already been used: %w", id, errRecordNotFound)
+}
+
+// UpdateUser updates a user record. Nothing is changed if the user does not exist
+func (s *Store) UpdateUser(ctx context.Context, user *gorm.User, updateData map[string]interface{}) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if user.ID == nil || *user.ID == "" {
+		return fmt.Errorf("an error occurred while updating the user: %v", errMissingWhereClause)
+	}
+
+	stored := s.findUser(*user.ID, false)
+	if stored == nil {
+		return nil
+	}
+
+	updated := clone(stored)
+	s.updated(ctx, &updated.Base)
+	if err := assign(ctx, updated, updateData); err != nil {
+		return fmt.Errorf("an error occurred while updating the user: %w", err)
+	}
+	s.stampUpdatedBy(ctx, &updated.Base)
+	*stored = *updated
+
+	return nil
+}
+
+// UpdateProduct updates product details. Nothing is changed if the product does not exist
+func (s *Store) UpdateProduct(ctx context.Context, product *gorm.Product, updateData map[string]interface{}) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if product.ID == "" {
+		return fmt.Errorf("an error occurred while updating the product: %v", errMissingWhereClause)
+	}
+
+	stored := s.findProduct(product.ID, false)
+	if stored == nil {
+		return nil
+	}
+
+	updated := clone(stored)
+	s.updated(ctx, &updated.Base)
+	if err := assign(ctx, updated, updateData); err != nil {
+		return fmt.Errorf("an error occurred while updating the product: %w", err)
+	}
+	s.stampUpdatedBy(ctx, &updated.Base)
+	// only the amount of a price is stored, its currency comes from the currency column
+	_ = updated.AfterFind(nil)
+	if err := s.checkProduct(s.products, updated); err != nil {
+		return fmt.Errorf("an error occurred while updating the product: %w", err)
+	}
+	*stored = *updated
+
+	return nil
+}
+
+// stampUpdatedBy records the logged in user as the last to change a record even when the update names someone else
+func (s *Store) stampUpdatedBy(ctx context.Context, base *gorm.Base) {
+	if userID := s.actor(ctx); userID != nil {
+		base.UpdatedBy = userID
+	}
+}
+
+// CloseShift locks a shift against further sales and cash movements.
+// The cash counted in the drawer is compared with what was expected to find any overage or shortage
+func (s *Store) CloseShift(ctx context.Context, shiftID string, countedCash money.Money) (*gorm.Shift, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	shift, err := s.openShift(shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := s.expectedCash(shift)
+	if err != nil {
+		return nil, err
+	}
+
+	overShort, err := countedCash.Sub(expected)
+	if err != nil {
+		return nil, err
+	}
+
+	closedAt := time.Now().UTC()
+	shift.Status = enums.ShiftStatusClosed
+	shift.ExpectedCash = expected
+	shift.CountedCash = money.New(countedCash.Amount, shift.Currency)
+	shift.OverShort = overShort
+	shift.ClosedAt = &closedAt
+	s.updated(ctx, &shift.Base)
+
+	closed := clone(shift)
+	closed.CountedCash = countedCash
+
+	return closed, nil
+}
+
+// ClaimPendingTaxInvoices picks the pending tax invoices that are due for submission.
+// Claimed invoices are not due again until the lease has passed
+func (s *Store) ClaimPendingTaxInvoices(ctx context.Context, limit int, lease time.Duration) ([]*gorm.TaxInvoice, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	due := []*gorm.TaxInvoice{}
+	for _, invoice := range s.invoices {
+		if invoice.Status == enums.TaxInvoiceStatusPending && !invoice.NextAttemptAt.After(now) {
+			due = append(due, invoice)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if limit >= 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	invoices := []*gorm.TaxInvoice{}
+	for _, invoice := range due {
+		invoice.NextAttemptAt = now.Add(lease)
+		s.updated(ctx, &invoice.Base)
+		invoices = append(invoices, clone(invoice))
+	}
+
+	return invoices, nil
+}
+
+// UpdateTaxInvoice records the outcome of submitting a tax invoice
+func (s *Store) UpdateTaxInvoice(ctx context.Context, invoice *gorm.TaxInvoice, updateData map[string]interface{}) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if invoice.ID == "" {
+		return fmt.Errorf("an error occurred while updating the tax invoice: %v", errMissingWhereClause)
+	}
+
+	for _, stored := range s.invoices {
+		if stored.ID != invoice.ID {
+			continue
+		}
+
+		updated := clone(stored)
+		s.updated(ctx, &updated.Base)
+		if err := assign(ctx, updated, updateData); err != nil {
+			return fmt.Errorf("an error occurred while updating the tax invoice: %w", err)
+		}
+		s.stampUpdatedBy(ctx, &updated.Base)
+		if err := s.checkTaxInvoice(updated); err != nil {
+			return fmt.Errorf("an error occurred while updating the tax invoice: %w", err)
+		}
+		*stored = *updated
+	}
+
+	return nil
+}
+
+// CompleteIdempotencyKey stores the response to the request holding a key so that it can be replayed
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, key *gorm.IdempotencyKey) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	stored := s.findIdempotencyKey(key.Scope, key.Key)
+	if stored == nil || stored.CompletedAt != nil {
+		return nil
+	}
+
+	stored.CompletedAt = key.CompletedAt
+	stored.ExpiresAt = key.ExpiresAt
+	stored.ResponseStatus = key.ResponseStatus
+	stored.ResponseContentType = key.ResponseContentType
+	stored.ResponseBody = key.ResponseBody
+	s.updated(ctx, &stored.Base)
+
+	return nil
+}
+
+// ReleaseIdempotencyKey frees a key whose request failed so that the request can be retried with it
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	stored := s.findIdempotencyKey(scope, key)
+	if stored == nil || stored.CompletedAt != nil {
+		return nil
+	}
+
+	s.updated(ctx, &stored.Base)
+	stored.LockedUntil = stored.UpdatedAt
+
+	return nil
+}
+
+// RestoreUser brings back a deleted user together with the contacts and identification documents that were
+// deleted with them. It fails if another user has taken their username, phone number or document in the meantime
+func (s *Store) RestoreUser(ctx context.Context, userID string) (*gorm.User, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	user := s.findUser(userID, true)
+	if user == nil || !user.DeletedAt.Valid {
+		return nil, fmt.Errorf("failed to get deleted user %s: %w", userID, errRecordNotFound)
+	}
+	deletedAt := user.DeletedAt.Time
+
+	// records deleted before the user were deleted on their own and stay deleted
+	restoredWith := func(ownerID *string, deleted bool, at time.Time) bool {
+		return ownerID != nil && *ownerID == userID && deleted && !at.Before(deletedAt)
+	}
+
+	restored := *user
+	restored.DeletedAt.Valid = false
+	if err := s.checkUser(&restored); err != nil {
+		return nil, fmt.Errorf("failed to restore user: %w", err)
+	}
+	contacts := []*gorm.Contact{}
+	for _, contact := range s.contacts {
+		if restoredWith(contact.UserID, contact.DeletedAt.Valid, contact.DeletedAt.Time) {
+			restoredContact := *contact
+			restoredContact.DeletedAt.Valid = false
+			if err := s.checkContact(&restoredContact); err != nil {
+				return nil, fmt.Errorf("failed to restore user contacts: %w", err)
+			}
+			contacts = append(contacts, contact)
+		}
+	}
+	identifiers := []*gorm.Identifier{}
+	for _, identifier := range s.identifiers {
+		if restoredWith(identifier.UserID, identifier.DeletedAt.Valid, identifier.DeletedAt.Time) {
+			restoredIdentifier := *identifier
+			restoredIdentifier.DeletedAt.Valid = false
+			if err := s.checkIdentifier(&restoredIdentifier); err != nil {
+				return nil, fmt.Errorf("failed to restore user identifiers: %w", err)
+			}
+			identifiers = append(identifiers, identifier)
+		}
+	}
+
+	for _, contact := range contacts {
+		contact.DeletedAt.Valid = false
+		s.updated(ctx, &contact.Base)
+	}
+	for _, identifier := range identifiers {
+		identifier.DeletedAt.Valid = false
+		s.updated(ctx, &identifier.Base)
+	}
+
+	user.DeletedAt.Valid = false
+	s.updated(ctx, &user.Base)
+
+	return clone(user), nil
+}
+
+// RestoreProduct brings back a deleted product.
+// It fails if another product has taken its SKU in the meantime
+func (s *Store) RestoreProduct(ctx context.Context, productID string) (*gorm.Product, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	product := s.findProduct(productID, true)
+	if product == nil || !product.DeletedAt.Valid {
+		return nil, fmt.Errorf("failed to get deleted product %s: %w", productID, errRecordNotFound)
+	}
+
+	restored := clone(product)
+	restored.DeletedAt.Valid = false
+	s.updated(ctx, &restored.Base)
+	if err := s.checkProduct(s.products, restored); err != nil {
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+	*product = *restored
+
+	return clone(restored), nil
+}
+
+// RestoreSale brings back a sale that was deleted in error and takes what it sold out of stock again
+func (s *Store) RestoreSale(ctx context.Context, saleID string) (*gorm.Sale, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	sale := s.findSale(saleID, true)
+	if sale == nil || !sale.DeletedAt.Valid {
+		return nil, fmt.Errorf("failed to get deleted sale %s: %w", saleID, errRecordNotFound)
+	}
+
+	if err := s.checkSaleChangeable(sale); err != nil {
+		return nil, err
+	}
+
+	s.restock(ctx, sale.ProductID, money.Decimal{}.Sub(sale.Quantity))
+	sale.DeletedAt.Valid = false
+	s.updated(ctx, &sale.Base)
+
+	return clone(sale), nil
+}
@@ -0,0 +1,346 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// lockID identifies the advisory lock held while the schema is being changed.
+// A second process that tries to migrate at the same time waits for the first to finish
+const lockID = 4318265490
+
+// versionTable records the schema version. Its layout is the one used by golang-migrate
+// so that databases migrated with its CLI carry on from where they are
+const versionTable = "schema_migrations"
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned change to the database schema and the change that undoes it
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is whether a migration has been applied to the database
+type Status struct {
+	Migration *Migration
+	Applied   bool
+}
+
+// Migrator applies and rolls back the schema migrations of a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+}
+
+// NewMigrator reads the migrations in the root of source. Every migration must have both an up and a down file
+func NewMigrator(db *sql.DB, source fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %v", entry.Name(), err)
+		}
+		if version == 0 {
+			return nil, fmt.Errorf("migration versions start at 1: %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := []*Migration{}
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrations returns every migration from the oldest to the newest
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// Latest returns the version of the newest migration
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the schema version of the database.
+// A dirty version is one whose migration failed part way and has to be fixed by hand and then forced
+func (m *Migrator) Version(ctx context.Context) (version uint, dirty bool, err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to connect to the database: %v", err)
+	}
+	defer conn.Close()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return 0, false, err
+	}
+
+	return currentVersion(ctx, conn)
+}
+
+// Status lists every migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []*Status{}
+	for _, migration := range m.migrations {
+		statuses = append(statuses, &Status{Migration: migration, Applied: migration.Version <= version})
+	}
+
+	return statuses, nil
+}
+
+// Check returns an error if the database schema is older than the newest migration or a migration failed part way.
+// A newer schema is allowed so that the previous release keeps running while a new one is rolled out
+func (m *Migrator) Check(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("migration %d failed part way, fix the schema and run `migrate force`", version)
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("the database schema is at version %d but version %d is needed, run `migrate up`", version, m.Latest())
+	}
+
+	return nil
+}
+
+// Up applies the next steps migrations, or all of them if steps is not positive. It returns the number applied
+func (m *Migrator) Up(ctx context.Context, steps int) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *sql.Conn, version uint) error {
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if steps > 0 && applied == steps {
+				break
+			}
+
+			if err := run(ctx, conn, migration.Version, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %v", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the last steps migrations, or all of them if steps is not positive. It returns the number rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	err := m.locked(ctx, func(conn *sql.Conn, version uint) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if steps > 0 && rolledBack == steps {
+				break
+			}
+
+			previous := uint(0)
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			if err := run(ctx, conn, migration.Version, migration.Down, previous); err != nil {
+				return fmt.Errorf("failed to roll back migration %d_%s: %v", migration.Version, migration.Name, err)
+			}
+			rolledBack++
+		}
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Force records the schema as being at a version without running any migration.
+// It is used to clear a dirty version once the schema has been fixed by hand
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	known := version == 0
+	for _, migration := range m.migrations {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return fmt.Errorf("there is no migration %d", version)
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the database: %v", err)
+	}
+	defer conn.Close()
+
+	if err := lock(ctx, conn); err != nil {
+		return err
+	}
+	defer unlock(conn)
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return setVersion(ctx, conn, version, false)
+}
+
+// locked runs fn on a connection holding the migration lock. Migrations are refused while the version is dirty
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, version uint) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the database: %v", err)
+	}
+	defer conn.Close()
+
+	if err := lock(ctx, conn); err != nil {
+		return err
+	}
+	defer unlock(conn)
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+
+	// the version is read once the lock is held since another process may have just migrated
+	version, dirty, err := currentVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d failed part way, fix the schema and run `migrate force`", version)
+	}
+
+	return fn(conn, version)
+}
+
+// run executes a migration file. The version is marked dirty until the file has run successfully
+func run(ctx context.Context, conn *sql.Conn, version uint, statements string, after uint) error {
+	if err := setVersion(ctx, conn, version, true); err != nil {
+		return err
+	}
+
+	// the files hold several statements in their own transaction, which is only allowed without arguments
+	if _, err := conn.ExecContext(ctx, statements); err != nil {
+		// a failed statement leaves the file's transaction open
+		_, _ = conn.ExecContext(ctx, "ROLLBACK")
+		return err
+	}
+
+	return setVersion(ctx, conn, after, false)
+}
+
+func lock(ctx context.Context, conn *sql.Conn) error {
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("failed to take the migration lock: %v", err)
+	}
+	return nil
+}
+
+func unlock(conn *sql.Conn) {
+	// the lock is released with the session if this fails
+	_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+}
+
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q ("version" bigint NOT NULL PRIMARY KEY, "dirty" boolean NOT NULL)`, versionTable)
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create the %s table: %v", versionTable, err)
+	}
+	return nil
+}
+
+func currentVersion(ctx context.Context, conn *sql.Conn) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRowContext(ctx, fmt.Sprintf(`SELECT "version", "dirty" FROM %q LIMIT 1`, versionTable)).Scan(&version, &dirty)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("failed to read the schema version: %v", err)
+	}
+
+	return uint(version), dirty, nil
+}
+
+func setVersion(ctx context.Context, conn *sql.Conn, version uint, dirty bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to record the schema version: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %q", versionTable)); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to record the schema version: %v", err)
+	}
+
+	// no row is kept for an empty schema, as golang-migrate does
+	if version > 0 || dirty {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %q ("version", "dirty") VALUES ($1, $2)`, versionTable), int64(version), dirty); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to record the schema version: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record the schema version: %v", err)
+	}
+
+	return nil
+}
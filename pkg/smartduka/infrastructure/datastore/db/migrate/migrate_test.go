@@ -0,0 +1,181 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/oryx-systems/smartduka/db/migrations"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/testutils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/migrate"
+)
+
+func TestNewMigrator(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  fstest.MapFS
+		want    []uint
+		wantErr bool
+	}{
+		{
+			name: "Happy case: migrations are ordered by version",
+			source: fstest.MapFS{
+				"000010_second.up.sql":   {Data: []byte("SELECT 1")},
+				"000010_second.down.sql": {Data: []byte("SELECT 1")},
+				"000002_first.up.sql":    {Data: []byte("SELECT 1")},
+				"000002_first.down.sql":  {Data: []byte("SELECT 1")},
+				"migrations.go":          {Data: []byte("package migrations")},
+			},
+			want: []uint{2, 10},
+		},
+		{
+			name: "Sad case: a migration without a down file",
+			source: fstest.MapFS{
+				"000001_initial.up.sql": {Data: []byte("SELECT 1")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Sad case: two migrations with the same version",
+			source: fstest.MapFS{
+				"000001_initial.up.sql":   {Data: []byte("SELECT 1")},
+				"000001_initial.down.sql": {Data: []byte("SELECT 1")},
+				"000001_other.up.sql":     {Data: []byte("SELECT 1")},
+				"000001_other.down.sql":   {Data: []byte("SELECT 1")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := migrate.NewMigrator(nil, tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewMigrator() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			versions := []uint{}
+			for _, migration := range got.Migrations() {
+				versions = append(versions, migration.Version)
+			}
+			if fmt.Sprint(versions) != fmt.Sprint(tt.want) {
+				t.Errorf("NewMigrator() versions = %v, want %v", versions, tt.want)
+			}
+		})
+	}
+}
+
+// TestMigrator_RoundTrip applies every migration, rolls it back and applies it again in a scratch schema.
+// Rolling a migration back must leave the schema exactly as it was before it was applied
+func TestMigrator_RoundTrip(t *testing.T) {
+	if !testutils.CheckIfCurrentDBIsLocal() {
+		t.Skip("the migrations are only tested against a local database")
+	}
+
+	ctx := context.Background()
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		os.Getenv(gorm.DBHost), os.Getenv(gorm.DBPort), os.Getenv(gorm.DBUser), os.Getenv(gorm.DBPASSWORD), os.Getenv(gorm.DBName))
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+	defer admin.Close()
+
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err := admin.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA %q", schema)); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	defer func() {
+		_, _ = admin.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA %q CASCADE", schema))
+	}()
+
+	db, err := sql.Open("postgres", dsn+" search_path="+schema)
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrate.NewMigrator(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+
+	before := snapshot(ctx, t, db)
+	for _, migration := range migrator.Migrations() {
+		name := fmt.Sprintf("%d_%s", migration.Version, migration.Name)
+
+		if _, err := migrator.Up(ctx, 1); err != nil {
+			t.Fatalf("Migrator.Up() %s error = %v", name, err)
+		}
+		after := snapshot(ctx, t, db)
+
+		if _, err := migrator.Down(ctx, 1); err != nil {
+			t.Fatalf("Migrator.Down() %s error = %v", name, err)
+		}
+		if got := snapshot(ctx, t, db); got != before {
+			t.Errorf("Migrator.Down() %s did not undo the migration:\n%s\nwant:\n%s", name, got, before)
+		}
+
+		if _, err := migrator.Up(ctx, 1); err != nil {
+			t.Fatalf("Migrator.Up() %s error = %v", name, err)
+		}
+		if got := snapshot(ctx, t, db); got != after {
+			t.Errorf("Migrator.Up() %s is not repeatable:\n%s\nwant:\n%s", name, got, after)
+		}
+
+		before = after
+	}
+
+	if err := migrator.Check(ctx); err != nil {
+		t.Errorf("Migrator.Check() error = %v", err)
+	}
+}
+
+// snapshot describes the tables, indexes, constraints, triggers and functions of the current schema
+func snapshot(ctx context.Context, t *testing.T, db *sql.DB) string {
+	rows, err := db.QueryContext(ctx, `
+		SELECT table_name || '.' || column_name || ' ' || data_type || ' ' || COALESCE(numeric_precision::text, '') || ','
+			|| COALESCE(numeric_scale::text, '') || ' ' || COALESCE(character_maximum_length::text, '') || ' ' || is_nullable
+			|| ' ' || COALESCE(column_default, '')
+		FROM information_schema.columns WHERE table_schema = current_schema()
+		UNION ALL
+		SELECT indexdef FROM pg_indexes WHERE schemaname = current_schema()
+		UNION ALL
+		SELECT conrelid::regclass || ' ' || conname || ' ' || pg_get_constraintdef(oid)
+		FROM pg_constraint WHERE connamespace = current_schema()::regnamespace
+		UNION ALL
+		SELECT tgrelid::regclass || ' ' || tgname FROM pg_trigger
+		WHERE NOT tgisinternal AND tgrelid IN (SELECT oid FROM pg_class WHERE relnamespace = current_schema()::regnamespace)
+		UNION ALL
+		SELECT proname FROM pg_proc WHERE pronamespace = current_schema()::regnamespace
+		ORDER BY 1`)
+	if err != nil {
+		t.Fatalf("failed to describe the schema: %v", err)
+	}
+	defer rows.Close()
+
+	lines := []string{}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			t.Fatalf("failed to describe the schema: %v", err)
+		}
+		if !strings.Contains(line, "schema_migrations") {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
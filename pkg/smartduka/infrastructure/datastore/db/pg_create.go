@@ -2,22 +2,27 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
 )
 
-// RegisterUser registers a new user in the database
-func (d *DbServiceImpl) RegisterUser(ctx context.Context, user *domain.User, contact *domain.Contact) (*domain.User, error) {
+// RegisterUser registers a new user in the database together with their contact and, when given,
+// their identification document and initial PIN
+func (d *DbServiceImpl) RegisterUser(ctx context.Context, user *domain.User, contact *domain.Contact, identifier *domain.Identifier, pin *domain.UserPIN) (*domain.User, error) {
 	usr := &gorm.User{
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Active:    user.Active,
-		UserName:  user.UserName,
-		UserType:  user.UserType,
-		PushToken: user.DeviceToken,
-		Email:     user.Email,
+		FirstName:  user.FirstName,
+		MiddleName: user.MiddleName,
+		Residence:  user.Residence,
+		LastName:   user.LastName,
+		Active:     user.Active,
+		UserName:   user.UserName,
+		UserType:   user.UserType,
+		PushToken:  user.DeviceToken,
+		Email:      user.Email,
 	}
 
 	contactData := &gorm.Contact{
@@ -28,21 +33,47 @@ func (d *DbServiceImpl) RegisterUser(ctx context.Context, user *domain.User, con
 		UserID:       usr.ID,
 	}
 
-	response, err := d.create.RegisterUser(ctx, usr, contactData)
+	var identifierData *gorm.Identifier
+	if identifier != nil {
+		identifierData = &gorm.Identifier{
+			Active:          identifier.Active,
+			IdentifierType:  identifier.IdentifierType,
+			IdentifierValue: identifier.IdentifierValue,
+		}
+	}
+
+	var pinData *gorm.UserPIN
+	if pin != nil {
+		pinData = &gorm.UserPIN{
+			Active:    pin.Active,
+			Flavour:   pin.Flavour,
+			ValidFrom: pin.ValidFrom,
+			ValidTo:   pin.ValidTo,
+			HashedPIN: pin.HashedPIN,
+			Salt:      pin.Salt,
+		}
+	}
+
+	response, err := d.create.RegisterUser(ctx, usr, contactData, identifierData, pinData)
 	if err != nil {
-		return nil, err
+		return nil, conflict(err, "this user is already registered")
 	}
 
 	return &domain.User{
-		ID:          *response.ID,
-		FirstName:   response.FirstName,
-		LastName:    response.LastName,
-		Active:      response.Active,
-		UserName:    response.UserName,
-		UserType:    response.UserType,
-		UserContact: domain.Contact{},
-		DeviceToken: response.PushToken,
-		Email:       response.Email,
+		ID:              *response.ID,
+		FirstName:       response.FirstName,
+		MiddleName:      response.MiddleName,
+		Residence:       response.Residence,
+		LastName:        response.LastName,
+		Active:          response.Active,
+		UserName:        response.UserName,
+		UserType:        response.UserType,
+		FrozenReason:    response.FrozenReason,
+		FrozenAt:        response.FrozenAt,
+		TokensRevokedAt: response.TokensRevokedAt,
+		UserContact:     domain.Contact{},
+		DeviceToken:     response.PushToken,
+		Email:           response.Email,
 	}, nil
 }
 
@@ -54,7 +85,7 @@ func (d *DbServiceImpl) SaveOTP(ctx context.Context, otp *domain.OTP) (*domain.O
 		PhoneNumber: otp.PhoneNumber,
 		OTP:         otp.OTP,
 		Flavour:     otp.Flavour,
-		UserID:      otp.UserID,
+		UserID:      stringPointer(otp.UserID),
 	}
 
 	result, err := d.create.SaveOTP(ctx, otpData)
@@ -69,7 +100,7 @@ func (d *DbServiceImpl) SaveOTP(ctx context.Context, otp *domain.OTP) (*domain.O
 		PhoneNumber: result.PhoneNumber,
 		OTP:         result.OTP,
 		Flavour:     result.Flavour,
-		UserID:      result.UserID,
+		UserID:      stringValue(result.UserID),
 	}, nil
 }
 
@@ -77,6 +108,7 @@ func (d *DbServiceImpl) SaveOTP(ctx context.Context, otp *domain.OTP) (*domain.O
 func (d *DbServiceImpl) SavePIN(ctx context.Context, pinInput *domain.UserPIN) (*domain.UserPIN, error) {
 	pinObj := &gorm.UserPIN{
 		UserID:    pinInput.UserID,
+		Flavour:   pinInput.Flavour,
 		HashedPIN: pinInput.HashedPIN,
 		ValidFrom: pinInput.ValidFrom,
 		ValidTo:   pinInput.ValidTo,
@@ -86,12 +118,13 @@ func (d *DbServiceImpl) SavePIN(ctx context.Context, pinInput *domain.UserPIN) (
 
 	result, err := d.create.SavePIN(ctx, pinObj)
 	if err != nil {
-		return nil, fmt.Errorf("failed to save user pin: %v", err)
+		return nil, fmt.Errorf("failed to save user pin: %w", err)
 	}
 
 	return &domain.UserPIN{
 		ID:        result.ID,
 		Active:    result.Active,
+		Flavour:   result.Flavour,
 		ValidFrom: result.ValidFrom,
 		ValidTo:   result.ValidTo,
 		HashedPIN: result.HashedPIN,
@@ -103,12 +136,17 @@ func (d *DbServiceImpl) SavePIN(ctx context.Context, pinInput *domain.UserPIN) (
 // Adds a product into the database
 func (d *DbServiceImpl) AddProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
 	productObj := &gorm.Product{
+		Base: gorm.Base{
+			CreatedBy: stringPointer(product.CreatedBy),
+		},
 		Active:       product.Active,
 		Name:         product.Name,
 		Category:     product.Category,
 		Quantity:     product.Quantity,
 		Unit:         product.Unit,
 		Price:        product.Price,
+		CostPrice:    product.CostPrice,
+		VAT:          product.VAT,
 		Description:  product.Description,
 		Manufacturer: product.Manufacturer,
 		InStock:      product.InStock,
@@ -127,6 +165,8 @@ func (d *DbServiceImpl) AddProduct(ctx context.Context, product *domain.Product)
 		Quantity:     result.Quantity,
 		Unit:         result.Unit,
 		Price:        result.Price,
+		CostPrice:    result.CostPrice,
+		VAT:          result.VAT,
 		Description:  result.Description,
 		Manufacturer: result.Manufacturer,
 		InStock:      result.InStock,
@@ -134,25 +174,267 @@ func (d *DbServiceImpl) AddProduct(ctx context.Context, product *domain.Product)
 }
 
 // AddSaleRecord adds sale record in the database
-func (d *DbServiceImpl) AddSaleRecord(ctx context.Context, sale *domain.Sale) (*domain.Sale, error) {
+func (d *DbServiceImpl) AddSaleRecord(ctx context.Context, sale *domain.Sale, costing enums.CostingMethod) (*domain.Sale, error) {
 	saleObj := &gorm.Sale{
-		ProductID: sale.ProductID,
-		Quantity:  sale.Quantity,
-		Unit:      sale.Unit,
-		Price:     sale.Price,
+		Base: gorm.Base{
+			CreatedAt: sale.SoldAt,
+			CreatedBy: stringPointer(sale.SoldBy),
+		},
+		ID:            sale.ID,
+		ProductID:     sale.ProductID,
+		Quantity:      sale.Quantity,
+		Unit:          sale.Unit,
+		Price:         sale.Price,
+		Discount:      sale.Discount,
+		PaymentMethod: sale.PaymentMethod,
+	}
+	if sale.ShiftID != "" {
+		saleObj.ShiftID = &sale.ShiftID
+	}
+	if sale.TaxInvoice != nil {
+		// the tax invoice is queued in the same transaction as the sale so that no sale misses being reported
+		saleObj.TaxInvoice = &gorm.TaxInvoice{NextAttemptAt: sale.TaxInvoice.NextAttemptAt}
 	}
 
-	result, err := d.create.AddSaleRecord(ctx, saleObj)
+	result, err := d.create.AddSaleRecord(ctx, saleObj, costing)
+	if errors.Is(err, gorm.ErrShiftClosed) {
+		return nil, domain.WrapError(domain.Conflict, err, "open a shift before recording sales")
+	}
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "the product does not exist")
 	}
 
 	return &domain.Sale{
-		ID:        result.ID,
-		ProductID: result.ProductID,
-		Quantity:  result.Quantity,
-		Unit:      result.Unit,
-		Price:     result.Price,
-		SoldBy:    *result.Base.CreatedBy,
+		ID:            result.ID,
+		ProductID:     result.ProductID,
+		Quantity:      result.Quantity,
+		Unit:          result.Unit,
+		Price:         result.Price,
+		Discount:      result.Discount,
+		CostOfGoods:   result.CostOfGoods,
+		PaymentMethod: result.PaymentMethod,
+		ShiftID:       sale.ShiftID,
+		SoldBy:        stringValue(result.Base.CreatedBy),
+		SoldAt:        result.CreatedAt,
+		TaxInvoice:    mapTaxInvoice(result.TaxInvoice),
+	}, nil
+}
+
+// AddStockReceipt records a delivery of stock in the database
+func (d *DbServiceImpl) AddStockReceipt(ctx context.Context, receipt *domain.StockReceipt) (*domain.StockReceipt, error) {
+	receiptObj := &gorm.StockReceipt{
+		Base: gorm.Base{
+			CreatedAt: receipt.ReceivedAt,
+			CreatedBy: stringPointer(receipt.ReceivedBy),
+		},
+		ID:        receipt.ID,
+		Active:    true,
+		ProductID: receipt.ProductID,
+		Quantity:  receipt.Quantity,
+		UnitCost:  receipt.UnitCost,
+		Supplier:  receipt.Supplier,
+	}
+
+	result, err := d.create.AddStockReceipt(ctx, receiptObj)
+	if err != nil {
+		return nil, notFound(fmt.Errorf("failed to add stock receipt: %w", err), "the product does not exist")
+	}
+
+	return &domain.StockReceipt{
+		ID:                result.ID,
+		ProductID:         result.ProductID,
+		Quantity:          result.Quantity,
+		RemainingQuantity: result.RemainingQuantity,
+		UnitCost:          result.UnitCost,
+		Supplier:          result.Supplier,
+		ReceivedBy:        stringValue(result.Base.CreatedBy),
+		ReceivedAt:        result.CreatedAt,
+	}, nil
+}
+
+// OpenShift starts a cashier's shift in the database
+func (d *DbServiceImpl) OpenShift(ctx context.Context, shift *domain.Shift) (*domain.Shift, error) {
+	shiftObj := &gorm.Shift{
+		Base: gorm.Base{
+			CreatedBy: stringPointer(shift.CashierID),
+		},
+		Active:       true,
+		CashierID:    shift.CashierID,
+		OpeningFloat: shift.OpeningFloat,
+	}
+
+	result, err := d.create.OpenShift(ctx, shiftObj)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapShift(result), nil
+}
+
+// AddCashMovement records a cash movement in the database
+func (d *DbServiceImpl) AddCashMovement(ctx context.Context, movement *domain.CashMovement) (*domain.CashMovement, error) {
+	movementObj := &gorm.CashMovement{
+		Base: gorm.Base{
+			CreatedBy: stringPointer(movement.RecordedBy),
+		},
+		Active:  true,
+		ShiftID: movement.ShiftID,
+		Type:    movement.Type,
+		Amount:  movement.Amount,
+		Reason:  movement.Reason,
+	}
+
+	result, err := d.create.AddCashMovement(ctx, movementObj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CashMovement{
+		ID:         result.ID,
+		ShiftID:    result.ShiftID,
+		Type:       result.Type,
+		Amount:     result.Amount,
+		Reason:     result.Reason,
+		RecordedBy: stringValue(result.Base.CreatedBy),
 	}, nil
 }
+
+// mapShift converts a shift database record into its domain representation
+func mapShift(shift *gorm.Shift) *domain.Shift {
+	return &domain.Shift{
+		ID:           shift.ID,
+		CashierID:    shift.CashierID,
+		Status:       shift.Status,
+		OpeningFloat: shift.OpeningFloat,
+		ExpectedCash: shift.ExpectedCash,
+		CountedCash:  shift.CountedCash,
+		OverShort:    shift.OverShort,
+		OpenedAt:     shift.OpenedAt,
+		ClosedAt:     shift.ClosedAt,
+	}
+}
+
+// ImportProducts creates or updates products in the database. Nothing is saved on a dry run
+func (d *DbServiceImpl) ImportProducts(ctx context.Context, products []*domain.Product, importedBy string, dryRun bool) (created int, updated int, err error) {
+	records := []*gorm.Product{}
+	for _, product := range products {
+		record := &gorm.Product{
+			Base: gorm.Base{
+				CreatedBy: &importedBy,
+			},
+			Active:       true,
+			Name:         product.Name,
+			Category:     product.Category,
+			Quantity:     product.Quantity,
+			Unit:         product.Unit,
+			Price:        product.Price,
+			CostPrice:    product.CostPrice,
+			VAT:          product.VAT,
+			Description:  product.Description,
+			Manufacturer: product.Manufacturer,
+			InStock:      product.InStock,
+		}
+		if product.SKU != "" {
+			sku := product.SKU
+			record.SKU = &sku
+		}
+		records = append(records, record)
+	}
+
+	return d.create.ImportProducts(ctx, records, dryRun)
+}
+
+// SaveSyncOperation records the outcome of applying an operation pushed by an offline client
+func (d *DbServiceImpl) SaveSyncOperation(ctx context.Context, operation *domain.SyncOperation) (*domain.SyncOperation, error) {
+	operationObj := &gorm.SyncOperation{
+		ID:         operation.ID,
+		DeviceID:   operation.DeviceID,
+		Type:       operation.Type,
+		Status:     operation.Status,
+		OccurredAt: operation.OccurredAt.UTC(),
+	}
+	if operation.Conflict != "" {
+		operationObj.Conflict = &operation.Conflict
+	}
+	if operation.Message != "" {
+		operationObj.Message = &operation.Message
+	}
+	if operation.RecordID != "" {
+		operationObj.RecordID = &operation.RecordID
+	}
+
+	result, err := d.create.SaveSyncOperation(ctx, operationObj)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapSyncOperation(result), nil
+}
+
+// SaveSyncDevice creates or updates the sync state of a device
+func (d *DbServiceImpl) SaveSyncDevice(ctx context.Context, device *domain.SyncDevice) error {
+	deviceObj := &gorm.SyncDevice{
+		ID:           device.ID,
+		UserID:       device.UserID,
+		LastPushedAt: device.LastPushedAt,
+		LastPulledAt: device.LastPulledAt,
+	}
+	if device.Cursor != "" {
+		deviceObj.Cursor = &device.Cursor
+	}
+
+	return d.create.SaveSyncDevice(ctx, deviceObj)
+}
+
+// ReserveIdempotencyKey reserves a key for a request. When the key is already held the stored key is returned instead
+func (d *DbServiceImpl) ReserveIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error) {
+	stored, reserved, err := d.create.ReserveIdempotencyKey(ctx, &gorm.IdempotencyKey{
+		Scope:       key.Scope,
+		Key:         key.Key,
+		RequestHash: key.RequestHash,
+		LockedUntil: key.LockedUntil,
+		ExpiresAt:   key.ExpiresAt,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return mapIdempotencyKey(stored), reserved, nil
+}
+
+func mapIdempotencyKey(key *gorm.IdempotencyKey) *domain.IdempotencyKey {
+	result := &domain.IdempotencyKey{
+		Scope:        key.Scope,
+		Key:          key.Key,
+		RequestHash:  key.RequestHash,
+		LockedUntil:  key.LockedUntil,
+		ExpiresAt:    key.ExpiresAt,
+		CompletedAt:  key.CompletedAt,
+		ResponseBody: key.ResponseBody,
+	}
+	if key.ResponseStatus != nil {
+		result.ResponseStatus = *key.ResponseStatus
+	}
+	if key.ResponseContentType != nil {
+		result.ResponseContentType = *key.ResponseContentType
+	}
+
+	return result
+}
+
+// stringPointer returns nil for an empty string so that an unknown user is stored as NULL.
+// The database layer then records the logged in user instead
+func stringPointer(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// stringValue returns the value of an optional column, or an empty string when it is NULL
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// DeleteUser soft deletes a user and their contacts
+func (d *DbServiceImpl) DeleteUser(ctx context.Context, userID string) error {
+	return notFound(d.delete.DeleteUser(ctx, userID), "the user does not exist")
+}
+
+// DeleteProduct soft deletes a product
+func (d *DbServiceImpl) DeleteProduct(ctx context.Context, productID string) error {
+	return notFound(d.delete.DeleteProduct(ctx, productID), "the product does not exist")
+}
+
+// DeleteSale soft deletes a sale and returns what it sold to stock
+func (d *DbServiceImpl) DeleteSale(ctx context.Context, saleID string) error {
+	return unchangeable(notFound(d.delete.DeleteSale(ctx, saleID), "the sale does not exist"))
+}
+
+// PurgeDeleted permanently removes records that were deleted before the given time
+func (d *DbServiceImpl) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	return d.delete.PurgeDeleted(ctx, before, limit)
+}
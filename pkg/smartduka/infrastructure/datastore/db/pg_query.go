@@ -2,17 +2,26 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
 )
 
 // GetUserProfileByUserID fetches and returns a userprofile using their user ID
 func (d *DbServiceImpl) GetUserProfileByUserID(ctx context.Context, userID string) (*domain.User, error) {
 	user, err := d.query.GetUserProfileByUserID(ctx, &userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user profile by user ID: %v", err)
+		return nil, notFound(err, "the user does not exist")
 	}
 
 	// contact := &domain.Contact{
@@ -25,13 +34,18 @@ func (d *DbServiceImpl) GetUserProfileByUserID(ctx context.Context, userID strin
 	// }
 
 	return &domain.User{
-		ID:          *user.ID,
-		FirstName:   user.FirstName,
-		LastName:    user.LastName,
-		Active:      user.Active,
-		UserName:    user.UserName,
-		UserType:    user.UserType,
-		DeviceToken: user.PushToken,
+		ID:              *user.ID,
+		FirstName:       user.FirstName,
+		MiddleName:      user.MiddleName,
+		Residence:       user.Residence,
+		LastName:        user.LastName,
+		Active:          user.Active,
+		UserName:        user.UserName,
+		UserType:        user.UserType,
+		FrozenReason:    user.FrozenReason,
+		FrozenAt:        user.FrozenAt,
+		TokensRevokedAt: user.TokensRevokedAt,
+		DeviceToken:     user.PushToken,
 	}, nil
 }
 
@@ -39,7 +53,7 @@ func (d *DbServiceImpl) GetUserProfileByUserID(ctx context.Context, userID strin
 func (d *DbServiceImpl) GetUserProfileByPhoneNumber(ctx context.Context, phoneNumber string, flavour enums.Flavour) (*domain.User, error) {
 	user, err := d.query.GetUserProfileByPhoneNumber(ctx, phoneNumber, flavour)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user profile by phonenumber: %v", err)
+		return nil, notFound(err, "no user has this phone number")
 	}
 
 	// contact := &domain.Contact{
@@ -52,13 +66,56 @@ func (d *DbServiceImpl) GetUserProfileByPhoneNumber(ctx context.Context, phoneNu
 	// }
 
 	return &domain.User{
-		ID:          *user.ID,
-		FirstName:   user.FirstName,
-		LastName:    user.LastName,
-		Active:      user.Active,
-		UserName:    user.UserName,
-		UserType:    user.UserType,
-		DeviceToken: user.PushToken,
+		ID:              *user.ID,
+		FirstName:       user.FirstName,
+		MiddleName:      user.MiddleName,
+		Residence:       user.Residence,
+		LastName:        user.LastName,
+		Active:          user.Active,
+		UserName:        user.UserName,
+		UserType:        user.UserType,
+		FrozenReason:    user.FrozenReason,
+		FrozenAt:        user.FrozenAt,
+		TokensRevokedAt: user.TokensRevokedAt,
+		DeviceToken:     user.PushToken,
+	}, nil
+}
+
+// GetUserByUsername fetches the user who has taken a username
+func (d *DbServiceImpl) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	user, err := d.query.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, notFound(err, "no user has this username")
+	}
+
+	return mapUser(user), nil
+}
+
+// GetUserByIdentifier fetches the user identified by a document e.g their national ID
+func (d *DbServiceImpl) GetUserByIdentifier(ctx context.Context, identifierType enums.IdentifierType, value string) (*domain.User, error) {
+	user, err := d.query.GetUserByIdentifier(ctx, identifierType, value)
+	if err != nil {
+		return nil, notFound(err, "no user has this identification document")
+	}
+
+	return mapUser(user), nil
+}
+
+// GetValidOTP fetches an OTP sent to a phone number that has neither been used nor expired
+func (d *DbServiceImpl) GetValidOTP(ctx context.Context, phoneNumber string, flavour enums.Flavour, otp string) (*domain.OTP, error) {
+	result, err := d.query.GetValidOTP(ctx, phoneNumber, flavour, otp)
+	if err != nil {
+		return nil, notFound(err, "the verification code is wrong or has expired")
+	}
+
+	return &domain.OTP{
+		ID:          result.ID,
+		IsValid:     result.IsValid,
+		ValidUntil:  result.ValidUntil,
+		PhoneNumber: result.PhoneNumber,
+		OTP:         result.OTP,
+		Flavour:     result.Flavour,
+		UserID:      stringValue(result.UserID),
 	}, nil
 }
 
@@ -69,11 +126,12 @@ func (d *DbServiceImpl) GetUserPINByUserID(ctx context.Context, userID string, f
 	}
 	pinData, err := d.query.GetUserPINByUserID(ctx, userID, flavour)
 	if err != nil {
-		return nil, fmt.Errorf("failed query and retrieve user PIN data: %s", err)
+		return nil, notFound(err, "the user has not set a PIN")
 	}
 
 	return &domain.UserPIN{
 		UserID:    pinData.UserID,
+		Flavour:   pinData.Flavour,
 		HashedPIN: pinData.HashedPIN,
 		ValidFrom: pinData.ValidFrom,
 		ValidTo:   pinData.ValidTo,
@@ -88,7 +146,7 @@ func (d *DbServiceImpl) SearchUser(ctx context.Context, searchTerm string) ([]*d
 
 	records, err := d.query.SearchUser(ctx, searchTerm)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search user: %v", err)
+		return nil, fmt.Errorf("failed to search user: %w", err)
 	}
 
 	for _, record := range records {
@@ -102,30 +160,710 @@ func (d *DbServiceImpl) SearchUser(ctx context.Context, searchTerm string) ([]*d
 		// }
 
 		users = append(users, &domain.User{
-			ID:          *record.ID,
-			FirstName:   record.FirstName,
-			LastName:    record.LastName,
-			Active:      record.Active,
-			UserName:    record.UserName,
-			UserType:    record.UserType,
-			DeviceToken: record.PushToken,
+			ID:              *record.ID,
+			FirstName:       record.FirstName,
+			MiddleName:      record.MiddleName,
+			Residence:       record.Residence,
+			LastName:        record.LastName,
+			Active:          record.Active,
+			UserName:        record.UserName,
+			UserType:        record.UserType,
+			FrozenReason:    record.FrozenReason,
+			FrozenAt:        record.FrozenAt,
+			TokensRevokedAt: record.TokensRevokedAt,
+			DeviceToken:     record.PushToken,
 		})
 	}
 
 	return users, nil
 }
 
+// ListUsers fetches a page of the users matching the filter
+func (d *DbServiceImpl) ListUsers(ctx context.Context, filter *dto.UserFilter, sortBy dto.UserSort, after *pagination.Cursor, limit int) (*domain.UserConnection, error) {
+	// one record more than asked for tells whether there is another page
+	records, err := d.query.ListUsers(ctx, filter, sortBy, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &domain.UserConnection{Edges: []*domain.UserEdge{}, PageInfo: &domain.PageInfo{}}
+	for i, record := range records {
+		if i == limit {
+			connection.PageInfo.HasNextPage = true
+			break
+		}
+
+		cursor := pagination.Encode(gorm.UserCursor(record, sortBy.Field))
+		connection.Edges = append(connection.Edges, &domain.UserEdge{Cursor: cursor, Node: mapUser(record)})
+		connection.PageInfo.EndCursor = &cursor
+	}
+
+	return connection, nil
+}
+
+// GetUsersByIDs retrieves the users with the IDs, including deleted ones
+func (d *DbServiceImpl) GetUsersByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	records, err := d.query.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	users := []*domain.User{}
+	for _, record := range records {
+		users = append(users, mapUser(record))
+	}
+
+	return users, nil
+}
+
+// GetContactsByUserIDs retrieves the contacts of the users with the IDs
+func (d *DbServiceImpl) GetContactsByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Contact, error) {
+	records, err := d.query.GetContactsByUserIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	contacts := []*domain.Contact{}
+	for _, record := range records {
+		contacts = append(contacts, &domain.Contact{
+			ID:           record.ID,
+			Active:       record.Active,
+			ContactType:  record.ContactType,
+			ContactValue: record.ContactValue,
+			Flavour:      record.Flavour,
+			UserID:       *record.UserID,
+		})
+	}
+
+	return contacts, nil
+}
+
+// mapUser converts a user record and its contact to their domain representation
+func mapUser(record *gorm.User) *domain.User {
+	return &domain.User{
+		ID:              *record.ID,
+		FirstName:       record.FirstName,
+		MiddleName:      record.MiddleName,
+		Residence:       record.Residence,
+		LastName:        record.LastName,
+		Active:          record.Active,
+		UserName:        record.UserName,
+		UserType:        record.UserType,
+		FrozenReason:    record.FrozenReason,
+		FrozenAt:        record.FrozenAt,
+		TokensRevokedAt: record.TokensRevokedAt,
+		DeviceToken:     record.PushToken,
+		Email:           record.Email,
+		UserContact: domain.Contact{
+			ID:           record.Contacts.ID,
+			Active:       record.Contacts.Active,
+			ContactType:  record.Contacts.ContactType,
+			ContactValue: record.Contacts.ContactValue,
+			Flavour:      record.Contacts.Flavour,
+			UserID:       *record.ID,
+		},
+	}
+}
+
 // GetProductByID retrieves a product using its ID
 func (d *DbServiceImpl) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
-	return nil, nil
+	product, err := d.query.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, notFound(err, "the product does not exist")
+	}
+
+	return mapProduct(product), nil
+}
+
+// GetProductsByIDs retrieves the products with the IDs, including deleted ones
+func (d *DbServiceImpl) GetProductsByIDs(ctx context.Context, ids []string) ([]*domain.Product, error) {
+	records, err := d.query.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	products := []*domain.Product{}
+	for _, record := range records {
+		products = append(products, mapProduct(record))
+	}
+
+	return products, nil
+}
+
+// GetSalesByShiftIDs retrieves the active sales made during the shifts with the IDs in the order they were made
+func (d *DbServiceImpl) GetSalesByShiftIDs(ctx context.Context, shiftIDs []string) ([]*domain.Sale, error) {
+	records, err := d.query.GetSalesByShiftIDs(ctx, shiftIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := []*domain.Sale{}
+	for _, record := range records {
+		sales = append(sales, mapSale(record))
+	}
+
+	return sales, nil
+}
+
+// GetDailySale retrieves the sales made today in the shop's timezone
+func (d *DbServiceImpl) GetDailySale(ctx context.Context, location *time.Location) ([]*domain.Sale, error) {
+	var sales []*domain.Sale
+
+	records, err := d.query.GetDailySale(ctx, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily sales: %w", err)
+	}
+
+	for _, record := range records {
+		sales = append(sales, mapSale(record))
+	}
+
+	return sales, nil
+}
+
+// GetSaleByID retrieves a sale using its ID
+func (d *DbServiceImpl) GetSaleByID(ctx context.Context, id string) (*domain.Sale, error) {
+	sale, err := d.query.GetSaleByID(ctx, id)
+	if err != nil {
+		return nil, notFound(err, "the sale does not exist")
+	}
+
+	return mapSale(sale), nil
+}
+
+// GetStockReceiptByID retrieves a delivery of stock using its ID
+func (d *DbServiceImpl) GetStockReceiptByID(ctx context.Context, id string) (*domain.StockReceipt, error) {
+	receipt, err := d.query.GetStockReceiptByID(ctx, id)
+	if err != nil {
+		return nil, notFound(err, "the stock receipt does not exist")
+	}
+
+	result := &domain.StockReceipt{
+		ID:                receipt.ID,
+		ProductID:         receipt.ProductID,
+		Quantity:          receipt.Quantity,
+		RemainingQuantity: receipt.RemainingQuantity,
+		UnitCost:          receipt.UnitCost,
+		Supplier:          receipt.Supplier,
+		ReceivedAt:        receipt.CreatedAt,
+	}
+	if receipt.CreatedBy != nil {
+		result.ReceivedBy = *receipt.CreatedBy
+	}
+
+	return result, nil
+}
+
+// ListProducts fetches a page of the products matching the filter
+func (d *DbServiceImpl) ListProducts(ctx context.Context, filter *dto.ProductFilter, sortBy dto.ProductSort, after *pagination.Cursor, limit int) (*domain.ProductConnection, error) {
+	records, err := d.query.ListProducts(ctx, filter, sortBy, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &domain.ProductConnection{Edges: []*domain.ProductEdge{}, PageInfo: &domain.PageInfo{}}
+	for i, record := range records {
+		if i == limit {
+			connection.PageInfo.HasNextPage = true
+			break
+		}
+
+		cursor := pagination.Encode(gorm.ProductCursor(record, sortBy.Field))
+		connection.Edges = append(connection.Edges, &domain.ProductEdge{Cursor: cursor, Node: mapProduct(record)})
+		connection.PageInfo.EndCursor = &cursor
+	}
+
+	return connection, nil
+}
+
+// ListSales fetches a page of the sales matching the filter in the order they were made
+func (d *DbServiceImpl) ListSales(ctx context.Context, filter *dto.SaleFilter, direction enums.SortDirection, after *pagination.Cursor, limit int) (*domain.SaleConnection, error) {
+	records, err := d.query.ListSales(ctx, filter, direction, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &domain.SaleConnection{Edges: []*domain.SaleEdge{}, PageInfo: &domain.PageInfo{}}
+	for i, record := range records {
+		if i == limit {
+			connection.PageInfo.HasNextPage = true
+			break
+		}
+
+		cursor := pagination.Encode(gorm.SaleCursor(record))
+		connection.Edges = append(connection.Edges, &domain.SaleEdge{Cursor: cursor, Node: mapSale(record)})
+		connection.PageInfo.EndCursor = &cursor
+	}
+
+	return connection, nil
+}
+
+// SearchProduct finds the active products matching a search term, best matches first
+func (d *DbServiceImpl) SearchProduct(ctx context.Context, searchTerm string, limit int) ([]*domain.Product, error) {
+	records, err := d.query.SearchProduct(ctx, searchTerm, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	products := []*domain.Product{}
+	for _, record := range records {
+		products = append(products, mapProduct(record))
+	}
+
+	return products, nil
+}
+
+// GetSalesSummary totals sales by calendar day, week or month in the shop's timezone
+func (d *DbServiceImpl) GetSalesSummary(ctx context.Context, from, to time.Time, interval enums.ReportInterval, location *time.Location) ([]*domain.SalesSummary, error) {
+	var summaries []*domain.SalesSummary
+
+	records, err := d.query.GetSalesSummary(ctx, from, to, interval, location)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		summaries = append(summaries, &domain.SalesSummary{
+			Period:       record.Period,
+			Revenue:      record.Revenue,
+			Quantity:     record.Quantity,
+			Transactions: record.Transactions,
+		})
+	}
+
+	return summaries, nil
+}
+
+// GetSalesBreakdown totals sales by cashier, product category or payment method
+func (d *DbServiceImpl) GetSalesBreakdown(ctx context.Context, from, to time.Time, grouping enums.SalesGrouping) ([]*domain.SalesBreakdown, error) {
+	var breakdown []*domain.SalesBreakdown
+
+	records, err := d.query.GetSalesBreakdown(ctx, from, to, grouping)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		breakdown = append(breakdown, &domain.SalesBreakdown{
+			Key:          record.Key,
+			Label:        record.Label,
+			Revenue:      record.Revenue,
+			Quantity:     record.Quantity,
+			Transactions: record.Transactions,
+		})
+	}
+
+	return breakdown, nil
+}
+
+// GetTopProducts returns the best selling products ranked by revenue or quantity sold
+func (d *DbServiceImpl) GetTopProducts(ctx context.Context, from, to time.Time, ranking enums.ProductRanking, limit int) ([]*domain.ProductSales, error) {
+	var products []*domain.ProductSales
+
+	records, err := d.query.GetTopProducts(ctx, from, to, ranking, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		products = append(products, &domain.ProductSales{
+			ProductID:    record.ProductID,
+			Name:         record.Name,
+			Category:     record.Category,
+			Revenue:      record.Revenue,
+			Quantity:     record.Quantity,
+			Transactions: record.Transactions,
+		})
+	}
+
+	return products, nil
+}
+
+// GetHourlySales totals sales by day of the week and hour of the day in the shop's timezone
+func (d *DbServiceImpl) GetHourlySales(ctx context.Context, from, to time.Time, location *time.Location) ([]*domain.HourlySales, error) {
+	var sales []*domain.HourlySales
+
+	records, err := d.query.GetHourlySales(ctx, from, to, location)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		sales = append(sales, &domain.HourlySales{
+			DayOfWeek:    record.DayOfWeek,
+			Hour:         record.Hour,
+			Revenue:      record.Revenue,
+			Transactions: record.Transactions,
+		})
+	}
+
+	return sales, nil
+}
+
+// GetProfitReport totals the revenue and cost of the goods sold by product, category or calendar period
+func (d *DbServiceImpl) GetProfitReport(ctx context.Context, from, to time.Time, grouping enums.ProfitGrouping, location *time.Location) ([]*domain.ProfitSummary, error) {
+	var report []*domain.ProfitSummary
+
+	records, err := d.query.GetProfitReport(ctx, from, to, grouping, location)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		report = append(report, &domain.ProfitSummary{
+			Key:                   record.Key,
+			Label:                 record.Label,
+			Revenue:               record.Revenue,
+			CostOfGoods:           record.CostOfGoods,
+			Quantity:              record.Quantity,
+			Transactions:          record.Transactions,
+			BelowCostTransactions: record.BelowCostTransactions,
+		})
+	}
+
+	return report, nil
+}
+
+// GetOpenShift fetches the shift a cashier currently has open
+func (d *DbServiceImpl) GetOpenShift(ctx context.Context, cashierID string) (*domain.Shift, error) {
+	shift, err := d.query.GetOpenShift(ctx, cashierID)
+	if err != nil {
+		return nil, notFound(err, "the cashier has no open shift")
+	}
+
+	return mapShift(shift), nil
+}
+
+// GetShiftByID fetches a shift using its ID
+func (d *DbServiceImpl) GetShiftByID(ctx context.Context, id string) (*domain.Shift, error) {
+	shift, err := d.query.GetShiftByID(ctx, id)
+	if err != nil {
+		return nil, notFound(err, "the shift does not exist")
+	}
+
+	return mapShift(shift), nil
+}
+
+// GetShiftTenders totals the sales made during a shift by payment method
+func (d *DbServiceImpl) GetShiftTenders(ctx context.Context, shiftID string) ([]*domain.TenderTotal, error) {
+	var tenders []*domain.TenderTotal
+
+	records, err := d.query.GetShiftTenders(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		tenders = append(tenders, &domain.TenderTotal{
+			PaymentMethod: record.PaymentMethod,
+			Amount:        record.Amount,
+			Transactions:  record.Transactions,
+		})
+	}
+
+	return tenders, nil
+}
+
+// GetShiftTotals totals the sales, returns, discounts and VAT of a shift
+func (d *DbServiceImpl) GetShiftTotals(ctx context.Context, shiftID string) (*domain.ShiftTotals, error) {
+	totals, err := d.query.GetShiftTotals(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ShiftTotals{
+		GrossSales:   totals.GrossSales,
+		Returns:      totals.Returns,
+		Discounts:    totals.Discounts,
+		VAT:          totals.VAT,
+		Transactions: totals.Transactions,
+	}, nil
 }
 
-// GetDailySale retrieves daily sales
-func (d *DbServiceImpl) GetDailySale(ctx context.Context) ([]*domain.Sale, error) {
-	return nil, nil
+// GetShiftCashMovements totals the cash moved into or out of the drawer during a shift
+func (d *DbServiceImpl) GetShiftCashMovements(ctx context.Context, shiftID string) ([]*domain.CashMovementTotal, error) {
+	var movements []*domain.CashMovementTotal
+
+	records, err := d.query.GetShiftCashMovements(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		movements = append(movements, &domain.CashMovementTotal{
+			Type:   record.Type,
+			Amount: record.Amount,
+		})
+	}
+
+	return movements, nil
 }
 
-// SearchProduct searches a product using the term provided by the user
-func (d *DbServiceImpl) SearchProduct(ctx context.Context, searchTerm string) (*domain.Product, error) {
-	return nil, nil
+// GetShiftExpectedCash works out the cash that should be in the drawer of a shift
+func (d *DbServiceImpl) GetShiftExpectedCash(ctx context.Context, shiftID string) (money.Money, error) {
+	return d.query.GetShiftExpectedCash(ctx, shiftID)
+}
+
+// StreamProducts passes each active product to fn without loading them all into memory
+func (d *DbServiceImpl) StreamProducts(ctx context.Context, fn func(product *domain.Product) error) error {
+	return d.query.StreamProducts(ctx, func(product *gorm.Product) error {
+		return fn(mapProduct(product))
+	})
+}
+
+// StreamSales passes each sale made in the period [from, to) to fn without loading them all into memory
+func (d *DbServiceImpl) StreamSales(ctx context.Context, from, to time.Time, fn func(sale *domain.SaleExport) error) error {
+	return d.query.StreamSales(ctx, from, to, func(record *gorm.SaleExport) error {
+		sale := &domain.SaleExport{
+			Sale: domain.Sale{
+				ID:            record.ID,
+				ProductID:     record.ProductID,
+				Quantity:      record.Quantity,
+				Unit:          record.Unit,
+				Price:         record.Price,
+				Discount:      record.Discount,
+				CostOfGoods:   record.CostOfGoods,
+				PaymentMethod: record.PaymentMethod,
+			},
+			SoldAt:          record.CreatedAt,
+			ProductName:     record.ProductName,
+			ProductCategory: record.ProductCategory,
+			CashierName:     record.CashierName,
+		}
+		if record.CreatedBy != nil {
+			sale.SoldBy = *record.CreatedBy
+		}
+		if record.ShiftID != nil {
+			sale.ShiftID = *record.ShiftID
+		}
+
+		return fn(sale)
+	})
+}
+
+// mapProduct converts a product database record into its domain representation
+func mapProduct(product *gorm.Product) *domain.Product {
+	result := &domain.Product{
+		ID:           product.ID,
+		Active:       product.Active,
+		Name:         product.Name,
+		Category:     product.Category,
+		Quantity:     product.Quantity,
+		Unit:         product.Unit,
+		Price:        product.Price,
+		CostPrice:    product.CostPrice,
+		VAT:          product.VAT,
+		Description:  product.Description,
+		Manufacturer: product.Manufacturer,
+		InStock:      product.InStock,
+		UpdatedAt:    product.UpdatedAt,
+		Deleted:      product.DeletedAt.Valid,
+	}
+	if product.SKU != nil {
+		result.SKU = *product.SKU
+	}
+
+	return result
+}
+
+// mapSale converts a sale record to its domain representation
+func mapSale(record *gorm.Sale) *domain.Sale {
+	sale := &domain.Sale{
+		ID:            record.ID,
+		ProductID:     record.ProductID,
+		Quantity:      record.Quantity,
+		Unit:          record.Unit,
+		Price:         record.Price,
+		Discount:      record.Discount,
+		CostOfGoods:   record.CostOfGoods,
+		PaymentMethod: record.PaymentMethod,
+		SoldAt:        record.CreatedAt,
+		TaxInvoice:    mapTaxInvoice(record.TaxInvoice),
+	}
+	if record.CreatedBy != nil {
+		sale.SoldBy = *record.CreatedBy
+	}
+	if record.ShiftID != nil {
+		sale.ShiftID = *record.ShiftID
+	}
+
+	return sale
+}
+
+// mapTaxInvoice converts a tax invoice record to its domain representation
+func mapTaxInvoice(record *gorm.TaxInvoice) *domain.TaxInvoice {
+	if record == nil {
+		return nil
+	}
+
+	invoice := &domain.TaxInvoice{
+		ID:            record.ID,
+		SaleID:        record.SaleID,
+		Status:        record.Status,
+		Attempts:      record.Attempts,
+		NextAttemptAt: record.NextAttemptAt,
+		SubmittedAt:   record.SubmittedAt,
+	}
+	if record.LastError != nil {
+		invoice.LastError = *record.LastError
+	}
+	if record.InvoiceNumber != nil {
+		invoice.InvoiceNumber = *record.InvoiceNumber
+	}
+	if record.ReceiptSignature != nil {
+		invoice.ReceiptSignature = *record.ReceiptSignature
+	}
+	if record.QRData != nil {
+		invoice.QRData = *record.QRData
+	}
+
+	return invoice
+}
+
+// GetSyncOperation fetches the recorded outcome of an operation pushed by an offline client
+func (d *DbServiceImpl) GetSyncOperation(ctx context.Context, id string) (*domain.SyncOperation, error) {
+	operation, err := d.query.GetSyncOperation(ctx, id)
+	if err != nil {
+		return nil, notFound(err, "the sync operation does not exist")
+	}
+
+	return mapSyncOperation(operation), nil
+}
+
+// GetSyncDevice fetches the sync state of a device
+func (d *DbServiceImpl) GetSyncDevice(ctx context.Context, id string) (*domain.SyncDevice, error) {
+	device, err := d.query.GetSyncDevice(ctx, id)
+	if err != nil {
+		return nil, notFound(err, "the device has never synced")
+	}
+
+	result := &domain.SyncDevice{
+		ID:           device.ID,
+		UserID:       device.UserID,
+		LastPushedAt: device.LastPushedAt,
+		LastPulledAt: device.LastPulledAt,
+	}
+	if device.Cursor != nil {
+		result.Cursor = *device.Cursor
+	}
+
+	return result, nil
+}
+
+// GetProductChanges fetches the products changed after the given update time and ID
+func (d *DbServiceImpl) GetProductChanges(ctx context.Context, since time.Time, afterID string, limit int) ([]*domain.Product, error) {
+	records, err := d.query.GetProductChanges(ctx, since, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	products := []*domain.Product{}
+	for _, record := range records {
+		products = append(products, mapProduct(record))
+	}
+
+	return products, nil
+}
+
+// mapSyncOperation converts a sync operation record to its domain representation
+func mapSyncOperation(record *gorm.SyncOperation) *domain.SyncOperation {
+	operation := &domain.SyncOperation{
+		ID:         record.ID,
+		DeviceID:   record.DeviceID,
+		Type:       record.Type,
+		Status:     record.Status,
+		OccurredAt: record.OccurredAt,
+	}
+	if record.Conflict != nil {
+		operation.Conflict = *record.Conflict
+	}
+	if record.Message != nil {
+		operation.Message = *record.Message
+	}
+	if record.RecordID != nil {
+		operation.RecordID = *record.RecordID
+	}
+
+	return operation
+}
+
+// GetAuditLogs fetches the audit log, most recent change first
+func (d *DbServiceImpl) GetAuditLogs(ctx context.Context, filter *dto.AuditLogFilter, limit int) ([]*domain.AuditLog, error) {
+	records, err := d.query.GetAuditLogs(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := []*domain.AuditLog{}
+	for _, record := range records {
+		changes := map[string]*struct {
+			From json.RawMessage `json:"from"`
+			To   json.RawMessage `json:"to"`
+		}{}
+		if err := json.Unmarshal([]byte(record.Changes), &changes); err != nil {
+			return nil, fmt.Errorf("failed to decode audit log changes: %w", err)
+		}
+
+		entry := &domain.AuditLog{
+			ID:        record.ID,
+			Entity:    record.Entity,
+			EntityID:  record.EntityID,
+			Action:    record.Action,
+			Changes:   []*domain.AuditChange{},
+			ActorID:   stringValue(record.ActorID),
+			IPAddress: stringValue(record.IPAddress),
+			Device:    stringValue(record.Device),
+			CreatedAt: record.CreatedAt,
+		}
+
+		fields := make([]string, 0, len(changes))
+		for field := range changes {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			change := &domain.AuditChange{Field: field}
+			if from := string(changes[field].From); from != "" && from != "null" {
+				change.From = &from
+			}
+			if to := string(changes[field].To); to != "" && to != "null" {
+				change.To = &to
+			}
+			entry.Changes = append(entry.Changes, change)
+		}
+
+		logs = append(logs, entry)
+	}
+
+	return logs, nil
+}
+
+// conflict tells clients that a record clashes with one that already exists, e.g a username that has been taken,
+// without passing on the database's error. Any other error is returned as it is
+func conflict(err error, message string) error {
+	if strings.Contains(err.Error(), "duplicate key value violates unique constraint") || strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return domain.WrapError(domain.Conflict, err, message)
+	}
+	return err
+}
+
+// unchangeable tells clients why a sale can no longer be deleted or restored. Any other error is returned as it is
+func unchangeable(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrSaleReported):
+		return domain.WrapError(domain.Conflict, err, "the sale has been reported to KRA, record a return instead")
+	case errors.Is(err, gorm.ErrShiftClosed):
+		return domain.WrapError(domain.Conflict, err, "the sale's shift has been closed, record a return instead")
+	}
+	return err
+}
+
+// notFound tells clients that a record does not exist without passing on the database's error.
+// Any other error is returned as it is
+func notFound(err error, message string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.WrapError(domain.NotFound, err, message)
+	}
+	return err
 }
@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
 )
@@ -14,6 +16,11 @@ func (d *DbServiceImpl) InvalidatePIN(ctx context.Context, userID string, flavou
 	return d.update.InvalidatePIN(ctx, userID, flavour)
 }
 
+// InvalidateOTP uses up an OTP so that it cannot be used again
+func (d *DbServiceImpl) InvalidateOTP(ctx context.Context, id string) error {
+	return notFound(d.update.InvalidateOTP(ctx, id), "the verification code has already been used")
+}
+
 // UpdateUser updates a user record
 func (d *DbServiceImpl) UpdateUser(ctx context.Context, user *domain.User, updateData map[string]interface{}) error {
 	data := &gorm.User{
@@ -31,3 +38,98 @@ func (d *DbServiceImpl) UpdateProduct(ctx context.Context, product *domain.Produ
 
 	return d.update.UpdateProduct(ctx, data, updateData)
 }
+
+// CloseShift closes and locks a shift in the database
+func (d *DbServiceImpl) CloseShift(ctx context.Context, shiftID string, countedCash money.Money) (*domain.Shift, error) {
+	shift, err := d.update.CloseShift(ctx, shiftID, countedCash)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapShift(shift), nil
+}
+
+// ClaimPendingTaxInvoices picks the pending tax invoices that are due for submission to eTIMS
+func (d *DbServiceImpl) ClaimPendingTaxInvoices(ctx context.Context, limit int, lease time.Duration) ([]*domain.TaxInvoice, error) {
+	records, err := d.update.ClaimPendingTaxInvoices(ctx, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices := []*domain.TaxInvoice{}
+	for _, record := range records {
+		invoices = append(invoices, mapTaxInvoice(record))
+	}
+
+	return invoices, nil
+}
+
+// UpdateTaxInvoice records the outcome of submitting a tax invoice in the database
+func (d *DbServiceImpl) UpdateTaxInvoice(ctx context.Context, invoice *domain.TaxInvoice, updateData map[string]interface{}) error {
+	data := &gorm.TaxInvoice{
+		ID: invoice.ID,
+	}
+
+	return d.update.UpdateTaxInvoice(ctx, data, updateData)
+}
+
+// CompleteIdempotencyKey stores the response to the request holding a key
+func (d *DbServiceImpl) CompleteIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) error {
+	return d.update.CompleteIdempotencyKey(ctx, &gorm.IdempotencyKey{
+		Scope:               key.Scope,
+		Key:                 key.Key,
+		ExpiresAt:           key.ExpiresAt,
+		CompletedAt:         key.CompletedAt,
+		ResponseStatus:      &key.ResponseStatus,
+		ResponseContentType: &key.ResponseContentType,
+		ResponseBody:        key.ResponseBody,
+	})
+}
+
+// ReleaseIdempotencyKey frees a key whose request failed
+func (d *DbServiceImpl) ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error {
+	return d.update.ReleaseIdempotencyKey(ctx, scope, key)
+}
+
+// RestoreUser brings back a deleted user
+func (d *DbServiceImpl) RestoreUser(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := d.update.RestoreUser(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, "the user does not exist")
+	}
+
+	return &domain.User{
+		ID:              *user.ID,
+		FirstName:       user.FirstName,
+		MiddleName:      user.MiddleName,
+		Residence:       user.Residence,
+		LastName:        user.LastName,
+		Active:          user.Active,
+		UserName:        user.UserName,
+		UserType:        user.UserType,
+		FrozenReason:    user.FrozenReason,
+		FrozenAt:        user.FrozenAt,
+		TokensRevokedAt: user.TokensRevokedAt,
+		DeviceToken:     user.PushToken,
+	}, nil
+}
+
+// RestoreProduct brings back a deleted product
+func (d *DbServiceImpl) RestoreProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	product, err := d.update.RestoreProduct(ctx, productID)
+	if err != nil {
+		return nil, notFound(err, "the product does not exist")
+	}
+
+	return mapProduct(product), nil
+}
+
+// RestoreSale brings back a deleted sale
+func (d *DbServiceImpl) RestoreSale(ctx context.Context, saleID string) (*domain.Sale, error) {
+	sale, err := d.update.RestoreSale(ctx, saleID)
+	if err != nil {
+		return nil, unchangeable(notFound(err, "the sale does not exist"))
+	}
+
+	return mapSale(sale), nil
+}
@@ -2,37 +2,107 @@ package datastore
 
 import (
 	"context"
+	"time"
 
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 )
 
 // Create is a collection of methods to carry out create operations on the database
 type Create interface {
-	RegisterUser(ctx context.Context, user *domain.User, contact *domain.Contact) (*domain.User, error)
+	RegisterUser(ctx context.Context, user *domain.User, contact *domain.Contact, identifier *domain.Identifier, pin *domain.UserPIN) (*domain.User, error)
 	SaveOTP(ctx context.Context, otp *domain.OTP) (*domain.OTP, error)
 	SavePIN(ctx context.Context, pinInput *domain.UserPIN) (*domain.UserPIN, error)
 
 	AddProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
-	AddSaleRecord(ctx context.Context, sale *domain.Sale) (*domain.Sale, error)
+	AddSaleRecord(ctx context.Context, sale *domain.Sale, costing enums.CostingMethod) (*domain.Sale, error)
+	AddStockReceipt(ctx context.Context, receipt *domain.StockReceipt) (*domain.StockReceipt, error)
+	ImportProducts(ctx context.Context, products []*domain.Product, importedBy string, dryRun bool) (created int, updated int, err error)
+
+	OpenShift(ctx context.Context, shift *domain.Shift) (*domain.Shift, error)
+	AddCashMovement(ctx context.Context, movement *domain.CashMovement) (*domain.CashMovement, error)
+
+	SaveSyncOperation(ctx context.Context, operation *domain.SyncOperation) (*domain.SyncOperation, error)
+	SaveSyncDevice(ctx context.Context, device *domain.SyncDevice) error
+
+	ReserveIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error)
 }
 
 // Query hold a collection of methods to interact with the querying of any data
 type Query interface {
 	GetUserProfileByUserID(ctx context.Context, userID string) (*domain.User, error)
 	GetUserProfileByPhoneNumber(ctx context.Context, phoneNumber string, flavour enums.Flavour) (*domain.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*domain.User, error)
+	GetUserByIdentifier(ctx context.Context, identifierType enums.IdentifierType, value string) (*domain.User, error)
 	GetUserPINByUserID(ctx context.Context, userID string, flavour enums.Flavour) (*domain.UserPIN, error)
+	GetValidOTP(ctx context.Context, phoneNumber string, flavour enums.Flavour, otp string) (*domain.OTP, error)
 	SearchUser(ctx context.Context, searchTerm string) ([]*domain.User, error)
+	ListUsers(ctx context.Context, filter *dto.UserFilter, sortBy dto.UserSort, after *pagination.Cursor, limit int) (*domain.UserConnection, error)
+	GetUsersByIDs(ctx context.Context, ids []string) ([]*domain.User, error)
+	GetContactsByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Contact, error)
 
 	GetProductByID(ctx context.Context, id string) (*domain.Product, error)
-	GetDailySale(ctx context.Context) ([]*domain.Sale, error)
-	SearchProduct(ctx context.Context, searchTerm string) (*domain.Product, error)
+	GetProductsByIDs(ctx context.Context, ids []string) ([]*domain.Product, error)
+	GetSalesByShiftIDs(ctx context.Context, shiftIDs []string) ([]*domain.Sale, error)
+	GetDailySale(ctx context.Context, location *time.Location) ([]*domain.Sale, error)
+	GetSaleByID(ctx context.Context, id string) (*domain.Sale, error)
+	GetStockReceiptByID(ctx context.Context, id string) (*domain.StockReceipt, error)
+	SearchProduct(ctx context.Context, searchTerm string, limit int) ([]*domain.Product, error)
+	StreamProducts(ctx context.Context, fn func(product *domain.Product) error) error
+	StreamSales(ctx context.Context, from, to time.Time, fn func(sale *domain.SaleExport) error) error
+	ListProducts(ctx context.Context, filter *dto.ProductFilter, sortBy dto.ProductSort, after *pagination.Cursor, limit int) (*domain.ProductConnection, error)
+	ListSales(ctx context.Context, filter *dto.SaleFilter, direction enums.SortDirection, after *pagination.Cursor, limit int) (*domain.SaleConnection, error)
+
+	GetSalesSummary(ctx context.Context, from, to time.Time, interval enums.ReportInterval, location *time.Location) ([]*domain.SalesSummary, error)
+	GetSalesBreakdown(ctx context.Context, from, to time.Time, grouping enums.SalesGrouping) ([]*domain.SalesBreakdown, error)
+	GetTopProducts(ctx context.Context, from, to time.Time, ranking enums.ProductRanking, limit int) ([]*domain.ProductSales, error)
+	GetHourlySales(ctx context.Context, from, to time.Time, location *time.Location) ([]*domain.HourlySales, error)
+	GetProfitReport(ctx context.Context, from, to time.Time, grouping enums.ProfitGrouping, location *time.Location) ([]*domain.ProfitSummary, error)
+
+	GetOpenShift(ctx context.Context, cashierID string) (*domain.Shift, error)
+	GetShiftByID(ctx context.Context, id string) (*domain.Shift, error)
+	GetShiftTenders(ctx context.Context, shiftID string) ([]*domain.TenderTotal, error)
+	GetShiftTotals(ctx context.Context, shiftID string) (*domain.ShiftTotals, error)
+	GetShiftCashMovements(ctx context.Context, shiftID string) ([]*domain.CashMovementTotal, error)
+	GetShiftExpectedCash(ctx context.Context, shiftID string) (money.Money, error)
+
+	GetSyncOperation(ctx context.Context, id string) (*domain.SyncOperation, error)
+	GetSyncDevice(ctx context.Context, id string) (*domain.SyncDevice, error)
+	GetProductChanges(ctx context.Context, since time.Time, afterID string, limit int) ([]*domain.Product, error)
+
+	GetAuditLogs(ctx context.Context, filter *dto.AuditLogFilter, limit int) ([]*domain.AuditLog, error)
 }
 
 // Update is a collection of methods with the ability to update any data
 type Update interface {
 	InvalidatePIN(ctx context.Context, userID string, flavour enums.Flavour) error
+	InvalidateOTP(ctx context.Context, id string) error
 	UpdateUser(ctx context.Context, user *domain.User, updateData map[string]interface{}) error
 
 	UpdateProduct(ctx context.Context, product *domain.Product, updateData map[string]interface{}) error
+
+	CloseShift(ctx context.Context, shiftID string, countedCash money.Money) (*domain.Shift, error)
+
+	ClaimPendingTaxInvoices(ctx context.Context, limit int, lease time.Duration) ([]*domain.TaxInvoice, error)
+	UpdateTaxInvoice(ctx context.Context, invoice *domain.TaxInvoice, updateData map[string]interface{}) error
+
+	CompleteIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) error
+	ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error
+
+	RestoreUser(ctx context.Context, userID string) (*domain.User, error)
+	RestoreProduct(ctx context.Context, productID string) (*domain.Product, error)
+	RestoreSale(ctx context.Context, saleID string) (*domain.Sale, error)
+}
+
+// Delete is a collection of methods to remove data.
+// Records are soft deleted and only purged once they are past their retention period
+type Delete interface {
+	DeleteUser(ctx context.Context, userID string) error
+	DeleteProduct(ctx context.Context, productID string) error
+	DeleteSale(ctx context.Context, saleID string) error
+
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error)
 }
@@ -0,0 +1,178 @@
+package etims
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+)
+
+const (
+	// saveSalesPath is the eTIMS endpoint that registers a sale or refund
+	saveSalesPath = "/trnsSales/saveSales"
+
+	// resultCodeSuccess is the result code eTIMS returns for an accepted invoice
+	resultCodeSuccess = "000"
+
+	// SignatureHeader carries the HMAC-SHA256 signature of a request body made with the communication key
+	SignatureHeader = "X-Signature"
+
+	// dateTimeLayout is how eTIMS formats dates and times
+	dateTimeLayout = "20060102150405"
+
+	requestTimeout = 15 * time.Second
+)
+
+// RejectedError is returned when eTIMS refuses an invoice. Submitting the same invoice again will not succeed
+type RejectedError struct {
+	Code    string
+	Message string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("eTIMS rejected the invoice: %s %s", e.Code, e.Message)
+}
+
+// Result is what eTIMS returns for an accepted invoice
+type Result struct {
+	// InvoiceNumber is the control unit invoice number printed on the receipt
+	InvoiceNumber    string
+	ReceiptSignature string
+	InternalData     string
+	// QRData is encoded in the receipt's QR code so that customers can verify the invoice with KRA
+	QRData string
+}
+
+// ServiceETIMS submits electronic tax invoices to KRA eTIMS
+type ServiceETIMS interface {
+	SubmitInvoice(ctx context.Context, invoice *Invoice) (*Result, error)
+}
+
+// ServiceETIMSImpl is the eTIMS online sales control unit client
+type ServiceETIMSImpl struct {
+	client           *http.Client
+	baseURL          string
+	pin              string
+	branchID         string
+	deviceSerial     string
+	communicationKey string
+}
+
+// NewServiceETIMS initializes an eTIMS client from the environment
+func NewServiceETIMS() (ServiceETIMS, error) {
+	baseURL := os.Getenv(common.ETIMSBaseURLEnvVarName)
+	if baseURL == "" {
+		return nil, fmt.Errorf("the %s environment variable is not set", common.ETIMSBaseURLEnvVarName)
+	}
+
+	pin := os.Getenv(common.ShopKRAPINEnvVarName)
+	if pin == "" {
+		return nil, fmt.Errorf("the %s environment variable is not set", common.ShopKRAPINEnvVarName)
+	}
+
+	communicationKey := os.Getenv(common.ETIMSCommunicationKeyEnvVarName)
+	if communicationKey == "" {
+		return nil, fmt.Errorf("the %s environment variable is not set", common.ETIMSCommunicationKeyEnvVarName)
+	}
+
+	branchID := os.Getenv(common.ETIMSBranchIDEnvVarName)
+	if branchID == "" {
+		branchID = common.DefaultETIMSBranchID
+	}
+
+	return &ServiceETIMSImpl{
+		client:           &http.Client{Timeout: requestTimeout},
+		baseURL:          strings.TrimSuffix(baseURL, "/"),
+		pin:              pin,
+		branchID:         branchID,
+		deviceSerial:     os.Getenv(common.ETIMSDeviceSerialEnvVarName),
+		communicationKey: communicationKey,
+	}, nil
+}
+
+// SubmitInvoice signs and registers an invoice with eTIMS.
+// A *RejectedError is returned when eTIMS refuses the invoice, any other error may be retried
+func (s *ServiceETIMSImpl) SubmitInvoice(ctx context.Context, invoice *Invoice) (*Result, error) {
+	invoice.TIN = s.pin
+	invoice.BranchID = s.branchID
+
+	body, err := json.Marshal(invoice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode invoice: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+saveSalesPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create eTIMS request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("tin", s.pin)
+	req.Header.Set("bhfId", s.branchID)
+	req.Header.Set("dvcSrlNo", s.deviceSerial)
+	req.Header.Set(SignatureHeader, Sign(s.communicationKey, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach eTIMS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("eTIMS is unavailable: %s", resp.Status)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		// the invoice is fine but the device is not, it can be submitted once the credentials are fixed
+		return nil, fmt.Errorf("eTIMS refused the device credentials: %s", resp.Status)
+	}
+
+	var result Response
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode eTIMS response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || result.ResultCode != resultCodeSuccess || result.Data == nil {
+		return nil, &RejectedError{Code: result.ResultCode, Message: result.ResultMessage}
+	}
+
+	return &Result{
+		InvoiceNumber:    fmt.Sprintf("%s/%d", result.Data.ControlUnitID, result.Data.ReceiptNumber),
+		ReceiptSignature: result.Data.ReceiptSignature,
+		InternalData:     result.Data.InternalData,
+		QRData:           common.ETIMSReceiptQRBaseURL + s.pin + s.branchID + result.Data.ReceiptSignature,
+	}, nil
+}
+
+// Sign computes the signature sent with a request body
+func Sign(key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Response is the envelope eTIMS wraps every reply in
+type Response struct {
+	ResultCode    string        `json:"resultCd"`
+	ResultMessage string        `json:"resultMsg"`
+	ResultDate    string        `json:"resultDt"`
+	Data          *ResponseData `json:"data"`
+}
+
+// ResponseData is what eTIMS returns for a registered sale
+type ResponseData struct {
+	ControlUnitID       string `json:"sdcId"`
+	ReceiptNumber       int64  `json:"rcptNo"`
+	TotalReceiptNumber  int64  `json:"totRcptNo"`
+	InternalData        string `json:"intrlData"`
+	ReceiptSignature    string `json:"rcptSign"`
+	ControlUnitDateTime string `json:"sdcDateTime"`
+}
@@ -0,0 +1,158 @@
+package etims_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/etims"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/etims/mock"
+)
+
+const communicationKey = "test-communication-key"
+
+func testSale(quantity string) (*domain.Sale, *domain.Product) {
+	sale := &domain.Sale{
+		ID:            "6ecbbc80-24c8-421a-9f1a-e14e12678ee0",
+		ProductID:     "8c2ff1b6-4a45-4bc5-9e6a-3cfa5bda7d3b",
+		Quantity:      money.MustParseDecimal(quantity),
+		Unit:          "PIECE",
+		Price:         money.MustParse("116.00", money.CurrencyKES),
+		Discount:      money.Zero(money.CurrencyKES),
+		PaymentMethod: enums.PaymentMethodMpesa,
+		SoldAt:        time.Date(2023, 7, 14, 15, 45, 0, 0, time.UTC),
+	}
+	product := &domain.Product{
+		ID:   sale.ProductID,
+		SKU:  "PAN-24",
+		Name: "Panadol Extra",
+		VAT:  money.DecimalFromInt(16),
+	}
+	return sale, product
+}
+
+func TestNewInvoice(t *testing.T) {
+	nairobi := time.FixedZone("EAT", 3*60*60)
+
+	tests := []struct {
+		name            string
+		quantity        string
+		vat             money.Decimal
+		wantReceiptType string
+		wantTaxType     etims.TaxType
+		wantTotal       string
+		wantTax         string
+		wantErr         bool
+	}{
+		{name: "standard rated sale", quantity: "2", vat: money.DecimalFromInt(16), wantReceiptType: "S", wantTaxType: etims.TaxTypeB, wantTotal: "232.00", wantTax: "32.00"},
+		{name: "reduced rate sale", quantity: "1", vat: money.DecimalFromInt(8), wantReceiptType: "S", wantTaxType: etims.TaxTypeE, wantTotal: "116.00", wantTax: "8.59"},
+		{name: "zero rated sale", quantity: "1", vat: money.DecimalFromInt(0), wantReceiptType: "S", wantTaxType: etims.TaxTypeC, wantTotal: "116.00", wantTax: "0.00"},
+		{name: "return is a refund", quantity: "-1", vat: money.DecimalFromInt(16), wantReceiptType: "R", wantTaxType: etims.TaxTypeB, wantTotal: "116.00", wantTax: "16.00"},
+		{name: "unsupported VAT rate", quantity: "1", vat: money.DecimalFromInt(10), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sale, product := testSale(tt.quantity)
+			product.VAT = tt.vat
+
+			got, err := etims.NewInvoice(sale, product, nairobi)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewInvoice() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.ReceiptType != tt.wantReceiptType {
+				t.Errorf("NewInvoice() receipt type = %v, want %v", got.ReceiptType, tt.wantReceiptType)
+			}
+			if got.Items[0].TaxType != tt.wantTaxType {
+				t.Errorf("NewInvoice() tax type = %v, want %v", got.Items[0].TaxType, tt.wantTaxType)
+			}
+			if got.TotalAmount.String() != tt.wantTotal {
+				t.Errorf("NewInvoice() total = %v, want %v", got.TotalAmount, tt.wantTotal)
+			}
+			if got.TotalTaxAmount.String() != tt.wantTax {
+				t.Errorf("NewInvoice() tax = %v, want %v", got.TotalTaxAmount, tt.wantTax)
+			}
+			if got.ConfirmedAt != "20230714184500" {
+				t.Errorf("NewInvoice() confirmed at = %v, want the sale time in the shop's timezone", got.ConfirmedAt)
+			}
+		})
+	}
+}
+
+func TestServiceETIMSImpl_SubmitInvoice(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		offline      bool
+		rejectCode   string
+		wantErr      bool
+		wantRejected bool
+	}{
+		{name: "Happy case: invoice accepted", key: communicationKey},
+		{name: "Sad case: eTIMS is offline", key: communicationKey, offline: true, wantErr: true},
+		{name: "Sad case: request signed with the wrong key", key: "wrong-key", wantErr: true},
+		{name: "Sad case: invoice rejected", key: communicationKey, rejectCode: "910", wantErr: true, wantRejected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := mock.NewETIMSServerMock(communicationKey)
+			defer server.Close()
+			server.SetOffline(tt.offline)
+			server.Reject(tt.rejectCode, "invalid invoice")
+
+			t.Setenv(common.ETIMSBaseURLEnvVarName, server.URL)
+			t.Setenv(common.ShopKRAPINEnvVarName, "P051234567X")
+			t.Setenv(common.ETIMSCommunicationKeyEnvVarName, tt.key)
+
+			service, err := etims.NewServiceETIMS()
+			if err != nil {
+				t.Fatalf("NewServiceETIMS() error = %v", err)
+			}
+
+			sale, product := testSale("1")
+			invoice, err := etims.NewInvoice(sale, product, time.UTC)
+			if err != nil {
+				t.Fatalf("NewInvoice() error = %v", err)
+			}
+
+			got, err := service.SubmitInvoice(context.Background(), invoice)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ServiceETIMSImpl.SubmitInvoice() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var rejected *etims.RejectedError
+			if errors.As(err, &rejected) != tt.wantRejected {
+				t.Errorf("ServiceETIMSImpl.SubmitInvoice() error = %v, wantRejected %v", err, tt.wantRejected)
+				return
+			}
+
+			if tt.wantErr {
+				if len(server.Invoices()) != 0 {
+					t.Errorf("ServiceETIMSImpl.SubmitInvoice() recorded an invoice that was not accepted")
+				}
+				return
+			}
+
+			if got.InvoiceNumber != mock.ControlUnitID+"/1" {
+				t.Errorf("ServiceETIMSImpl.SubmitInvoice() invoice number = %v", got.InvoiceNumber)
+			}
+			if !strings.HasPrefix(got.QRData, common.ETIMSReceiptQRBaseURL+"P051234567X00") {
+				t.Errorf("ServiceETIMSImpl.SubmitInvoice() QR data = %v", got.QRData)
+			}
+			if invoices := server.Invoices(); len(invoices) != 1 || invoices[0].TIN != "P051234567X" {
+				t.Errorf("ServiceETIMSImpl.SubmitInvoice() invoices received = %v", invoices)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package etims
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// TaxType is the eTIMS code for a VAT category
+type TaxType string
+
+const (
+	// TaxTypeB is the standard 16% VAT rate
+	TaxTypeB TaxType = "B"
+
+	// TaxTypeC is zero rated supplies. Products without VAT are reported under it
+	TaxTypeC TaxType = "C"
+
+	// TaxTypeE is the reduced 8% VAT rate
+	TaxTypeE TaxType = "E"
+)
+
+const (
+	receiptTypeSale   = "S"
+	receiptTypeRefund = "R"
+	salesTypeNormal   = "N"
+	salesStatusDone   = "02"
+)
+
+// Invoice is a sale as registered with eTIMS. Amounts include VAT
+type Invoice struct {
+	TIN                 string         `json:"tin"`
+	BranchID            string         `json:"bhfId"`
+	TraderInvoiceNumber string         `json:"trdInvcNo"`
+	ReceiptType         string         `json:"rcptTyCd"`
+	SalesType           string         `json:"salesTyCd"`
+	PaymentType         string         `json:"pmtTyCd"`
+	SalesStatus         string         `json:"salesSttsCd"`
+	ConfirmedAt         string         `json:"cfmDt"`
+	SalesDate           string         `json:"salesDt"`
+	ItemCount           int            `json:"totItemCnt"`
+	TaxableAmountB      json.Number    `json:"taxblAmtB"`
+	TaxableAmountC      json.Number    `json:"taxblAmtC"`
+	TaxableAmountE      json.Number    `json:"taxblAmtE"`
+	TaxRateB            json.Number    `json:"taxRtB"`
+	TaxRateC            json.Number    `json:"taxRtC"`
+	TaxRateE            json.Number    `json:"taxRtE"`
+	TaxAmountB          json.Number    `json:"taxAmtB"`
+	TaxAmountC          json.Number    `json:"taxAmtC"`
+	TaxAmountE          json.Number    `json:"taxAmtE"`
+	TotalTaxableAmount  json.Number    `json:"totTaxblAmt"`
+	TotalTaxAmount      json.Number    `json:"totTaxAmt"`
+	TotalAmount         json.Number    `json:"totAmt"`
+	Items               []*InvoiceItem `json:"itemList"`
+}
+
+// InvoiceItem is a line of an invoice
+type InvoiceItem struct {
+	Sequence       int         `json:"itemSeq"`
+	ItemCode       string      `json:"itemCd"`
+	ItemName       string      `json:"itemNm"`
+	QuantityUnit   string      `json:"qtyUnitCd"`
+	Quantity       json.Number `json:"qty"`
+	UnitPrice      json.Number `json:"prc"`
+	SupplyAmount   json.Number `json:"splyAmt"`
+	DiscountAmount json.Number `json:"dcAmt"`
+	TaxType        TaxType     `json:"taxTyCd"`
+	TaxableAmount  json.Number `json:"taxblAmt"`
+	TaxAmount      json.Number `json:"taxAmt"`
+	TotalAmount    json.Number `json:"totAmt"`
+}
+
+// NewInvoice builds the eTIMS invoice of a sale using the VAT rate of the product sold.
+// Returns are registered as refunds. The sale time is reported in the shop's timezone
+func NewInvoice(sale *domain.Sale, product *domain.Product, location *time.Location) (*Invoice, error) {
+	taxType, err := TaxTypeForRate(product.VAT)
+	if err != nil {
+		return nil, err
+	}
+
+	receiptType := receiptTypeSale
+	quantity, price, discount := sale.Quantity, sale.Price, sale.Discount
+	if quantity.IsNegative() {
+		// refunds are registered with positive amounts
+		receiptType = receiptTypeRefund
+		quantity = quantity.Mul(money.DecimalFromInt(-1))
+		discount = discount.Mul(money.DecimalFromInt(-1))
+	}
+
+	supply := price.Mul(quantity)
+	total, err := supply.Sub(discount)
+	if err != nil {
+		return nil, err
+	}
+	_, vat := total.VATInclusive(product.VAT)
+
+	itemCode := product.SKU
+	if itemCode == "" {
+		itemCode = product.ID
+	}
+
+	zero := amount(money.Zero(price.Currency))
+	soldAt := sale.SoldAt.In(location)
+	invoice := &Invoice{
+		TraderInvoiceNumber: sale.ID,
+		ReceiptType:         receiptType,
+		SalesType:           salesTypeNormal,
+		PaymentType:         paymentType(sale.PaymentMethod),
+		SalesStatus:         salesStatusDone,
+		ConfirmedAt:         soldAt.Format(dateTimeLayout),
+		SalesDate:           soldAt.Format("20060102"),
+		ItemCount:           1,
+		TaxableAmountB:      zero,
+		TaxableAmountC:      zero,
+		TaxableAmountE:      zero,
+		TaxRateB:            "16",
+		TaxRateC:            "0",
+		TaxRateE:            "8",
+		TaxAmountB:          zero,
+		TaxAmountC:          zero,
+		TaxAmountE:          zero,
+		TotalTaxableAmount:  amount(total),
+		TotalTaxAmount:      amount(vat),
+		TotalAmount:         amount(total),
+		Items: []*InvoiceItem{
+			{
+				Sequence:       1,
+				ItemCode:       itemCode,
+				ItemName:       product.Name,
+				QuantityUnit:   sale.Unit,
+				Quantity:       json.Number(quantity.String()),
+				UnitPrice:      amount(price),
+				SupplyAmount:   amount(supply),
+				DiscountAmount: amount(discount),
+				TaxType:        taxType,
+				TaxableAmount:  amount(total),
+				TaxAmount:      amount(vat),
+				TotalAmount:    amount(total),
+			},
+		},
+	}
+
+	switch taxType {
+	case TaxTypeB:
+		invoice.TaxableAmountB, invoice.TaxAmountB = amount(total), amount(vat)
+	case TaxTypeC:
+		invoice.TaxableAmountC, invoice.TaxAmountC = amount(total), amount(vat)
+	case TaxTypeE:
+		invoice.TaxableAmountE, invoice.TaxAmountE = amount(total), amount(vat)
+	}
+
+	return invoice, nil
+}
+
+// TaxTypeForRate returns the eTIMS tax type of a VAT rate
+func TaxTypeForRate(rate money.Decimal) (TaxType, error) {
+	switch {
+	case rate.Cmp(money.DecimalFromInt(16)) == 0:
+		return TaxTypeB, nil
+	case rate.Cmp(money.DecimalFromInt(8)) == 0:
+		return TaxTypeE, nil
+	case rate.IsZero():
+		return TaxTypeC, nil
+	}
+	return "", fmt.Errorf("VAT rate %s%% is not supported by eTIMS", rate)
+}
+
+// paymentType returns the eTIMS code of a payment method
+func paymentType(method enums.PaymentMethod) string {
+	switch method {
+	case enums.PaymentMethodCash:
+		return "01"
+	case enums.PaymentMethodCredit:
+		return "02"
+	case enums.PaymentMethodCard:
+		return "05"
+	case enums.PaymentMethodMpesa:
+		return "06"
+	}
+	return "07"
+}
+
+// amount writes money the way eTIMS expects it, as a JSON number
+func amount(m money.Money) json.Number {
+	return json.Number(m.String())
+}
@@ -0,0 +1,117 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/etims"
+)
+
+// ControlUnitID is the control unit ID the mock server issues invoice numbers under
+const ControlUnitID = "KRACU0100000001"
+
+// ETIMSServerMock is a local stand-in for the KRA eTIMS API. It checks request signatures,
+// records the invoices it accepts and can be told to go offline or reject invoices
+type ETIMSServerMock struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	key           string
+	invoices      []*etims.Invoice
+	offline       bool
+	rejectCode    string
+	rejectMessage string
+}
+
+// NewETIMSServerMock starts a mock eTIMS server that accepts requests signed with the communication key
+func NewETIMSServerMock(communicationKey string) *ETIMSServerMock {
+	m := &ETIMSServerMock{key: communicationKey}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.handle))
+	return m
+}
+
+// SetOffline makes the server fail every request as if eTIMS could not be reached
+func (m *ETIMSServerMock) SetOffline(offline bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offline = offline
+}
+
+// Reject makes the server refuse invoices with the given result code. An empty code accepts them again
+func (m *ETIMSServerMock) Reject(code, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rejectCode, m.rejectMessage = code, message
+}
+
+// Invoices returns the invoices accepted so far
+func (m *ETIMSServerMock) Invoices() []*etims.Invoice {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*etims.Invoice{}, m.invoices...)
+}
+
+func (m *ETIMSServerMock) handle(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.offline {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method != http.MethodPost || r.URL.Path != "/trnsSales/saveSales" {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get(etims.SignatureHeader) != etims.Sign(m.key, body) {
+		m.reply(w, http.StatusUnauthorized, &etims.Response{ResultCode: "894", ResultMessage: "invalid signature"})
+		return
+	}
+
+	invoice := &etims.Invoice{}
+	if err := json.Unmarshal(body, invoice); err != nil {
+		m.reply(w, http.StatusOK, &etims.Response{ResultCode: "899", ResultMessage: err.Error()})
+		return
+	}
+
+	if m.rejectCode != "" {
+		m.reply(w, http.StatusOK, &etims.Response{ResultCode: m.rejectCode, ResultMessage: m.rejectMessage})
+		return
+	}
+
+	m.invoices = append(m.invoices, invoice)
+	receiptNumber := int64(len(m.invoices))
+	now := time.Now().Format("20060102150405")
+	m.reply(w, http.StatusOK, &etims.Response{
+		ResultCode:    "000",
+		ResultMessage: "It is succeeded",
+		ResultDate:    now,
+		Data: &etims.ResponseData{
+			ControlUnitID:       ControlUnitID,
+			ReceiptNumber:       receiptNumber,
+			TotalReceiptNumber:  receiptNumber,
+			InternalData:        fmt.Sprintf("INTRL%020d", receiptNumber),
+			ReceiptSignature:    fmt.Sprintf("SIGN%012d", receiptNumber),
+			ControlUnitDateTime: now,
+		},
+	})
+}
+
+func (m *ETIMSServerMock) reply(w http.ResponseWriter, status int, response *etims.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,78 @@
+// Package pubsub carries events from the usecase that caused them to the clients subscribed to them.
+// InProcess only delivers events within a single server. Deployments running several servers need a PubSub
+// shared between them, such as one built on Postgres LISTEN/NOTIFY, so that a sale recorded on one server
+// reaches the owners watching from another. Payloads are bytes so that they can cross process boundaries
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// subscriberBuffer is the number of events held for a subscriber that has not caught up
+const subscriberBuffer = 64
+
+// PubSub publishes events to topics and delivers them to the topics' subscribers
+type PubSub interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+
+	// Subscribe receives the events published to a topic until ctx is done, when the channel is closed
+	Subscribe(ctx context.Context, topic string) (<-chan []byte, error)
+}
+
+// InProcess delivers events to the subscribers within the same server.
+// Publishing never waits for subscribers: events are dropped for a subscriber whose buffer is full
+type InProcess struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan []byte]struct{}
+}
+
+// NewInProcess creates a pub/sub without subscribers
+func NewInProcess() *InProcess {
+	return &InProcess{subscribers: map[string]map[chan []byte]struct{}{}}
+}
+
+// Publish delivers an event to every subscriber of the topic
+func (p *InProcess) Publish(ctx context.Context, topic string, payload []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for subscriber := range p.subscribers[topic] {
+		select {
+		case subscriber <- payload:
+		default:
+			logrus.Printf("dropped a %s event for a subscriber that is not keeping up", topic)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe receives the events published to a topic until ctx is done
+func (p *InProcess) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	subscriber := make(chan []byte, subscriberBuffer)
+
+	p.mu.Lock()
+	if p.subscribers[topic] == nil {
+		p.subscribers[topic] = map[chan []byte]struct{}{}
+	}
+	p.subscribers[topic][subscriber] = struct{}{}
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.subscribers[topic], subscriber)
+		close(subscriber)
+	}()
+
+	return subscriber, nil
+}
@@ -0,0 +1,128 @@
+package pubsub_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/pubsub"
+)
+
+// receive waits a short while for an event on a subscription
+func receive(events <-chan []byte) ([]byte, bool) {
+	select {
+	case event, ok := <-events:
+		return event, ok
+	case <-time.After(time.Second):
+		return nil, false
+	}
+}
+
+// drain counts the events waiting on a subscription
+func drain(events <-chan []byte) int {
+	count := 0
+	for {
+		select {
+		case <-events:
+			count++
+		default:
+			return count
+		}
+	}
+}
+
+func TestInProcess_Publish(t *testing.T) {
+	ctx := context.Background()
+	p := pubsub.NewInProcess()
+
+	first, err := p.Subscribe(ctx, "sales")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	second, err := p.Subscribe(ctx, "sales")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	other, err := p.Subscribe(ctx, "stock")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	if err := p.Publish(ctx, "sales", []byte("sale 1")); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	for i, events := range []<-chan []byte{first, second} {
+		if event, ok := receive(events); !ok || string(event) != "sale 1" {
+			t.Errorf("subscriber %d received %q, %v, want the sale", i, event, ok)
+		}
+	}
+	if count := drain(other); count != 0 {
+		t.Errorf("a subscriber of another topic received %d events", count)
+	}
+}
+
+func TestInProcess_Subscribe_Cancelled(t *testing.T) {
+	p := pubsub.NewInProcess()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := p.Subscribe(ctx, "sales")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	cancel()
+
+	if _, ok := receive(events); ok {
+		t.Fatalf("the subscription was not closed when its context was cancelled")
+	}
+	// publishing to a subscriber that has gone must not panic on its closed channel
+	if err := p.Publish(context.Background(), "sales", []byte("sale 1")); err != nil {
+		t.Errorf("Publish() error = %v", err)
+	}
+
+	if _, err := p.Subscribe(ctx, "sales"); err == nil {
+		t.Errorf("Subscribe() with a cancelled context should fail")
+	}
+}
+
+func TestInProcess_Publish_SlowSubscriber(t *testing.T) {
+	ctx := context.Background()
+	p := pubsub.NewInProcess()
+
+	slow, err := p.Subscribe(ctx, "sales")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	const published = 200
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < published; i++ {
+			_ = p.Publish(ctx, "sales", []byte(fmt.Sprintf("sale %d", i)))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Publish() blocked on a subscriber that is not reading")
+	}
+
+	// the slow subscriber keeps the oldest events its buffer holds and loses the rest
+	if event, ok := receive(slow); !ok || string(event) != "sale 0" {
+		t.Fatalf("the slow subscriber received %q, %v first, want the first sale", event, ok)
+	}
+	if count := drain(slow) + 1; count >= published {
+		t.Errorf("the slow subscriber received %d of %d events, want the events beyond its buffer dropped", count, published)
+	}
+
+	// once it catches up it receives new events again
+	if err := p.Publish(ctx, "sales", []byte("sale after")); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if event, ok := receive(slow); !ok || string(event) != "sale after" {
+		t.Errorf("the subscriber received %q, %v after catching up, want the new sale", event, ok)
+	}
+}
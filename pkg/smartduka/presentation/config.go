@@ -6,26 +6,62 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	gqlextension "github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/oryx-systems/smartduka/db/migrations"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
 	pgDB "github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/memory"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/migrate"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/etims"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/pubsub"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/generated"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/operation"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/rest"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/audit"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/idempotency"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/live"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/offlinesync"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/otp"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/product"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/report"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/retention"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/shift"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/taxinvoice"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/user"
+	"go.opencensus.io/stats/view"
 )
 
 const serverTimeoutSeconds = 120
 
+const (
+	// taxInvoiceInterval is how often the eTIMS outbox is checked for sales to submit again
+	taxInvoiceInterval = 30 * time.Second
+
+	// taxInvoiceBatchSize is the most sales submitted from the outbox on each check
+	taxInvoiceBatchSize = 50
+
+	// purgeInterval is how often records deleted longer than the retention period ago are purged
+	purgeInterval = 24 * time.Hour
+
+	// purgeBatchSize is the most records of each kind purged on each run
+	purgeBatchSize = 1000
+)
+
 // SmartdukaServiceAllowedOrigins is a list of CORS origins allowed to interact with this service
 var SmartdukaServiceAllowedOrigins = []string{
 	"http://localhost:8080",
@@ -45,6 +81,9 @@ var SmartdukaServiceAllowedHeaders = []string{
 	"Content-Type",
 	"Authorization",
 	"X-Authorization",
+	common.DeviceIDHeader,
+	common.FlavourHeader,
+	rest.IdempotencyKeyHeader,
 }
 
 // PrepareServer sets up the HTTP server
@@ -63,6 +102,7 @@ func PrepareServer(
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     SmartdukaServiceAllowedOrigins,
 		AllowHeaders:     SmartdukaServiceAllowedHeaders,
+		ExposeHeaders:    []string{rest.IdempotentReplayedHeader},
 		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
 		AllowCredentials: true,
 	}))
@@ -95,56 +135,263 @@ func PlaygroundHandler() gin.HandlerFunc {
 	}
 }
 
-// GQLHandler sets up a GraphQL resolver
+// GQLHandler sets up a GraphQL resolver.
+// Operations are limited in complexity and depth and, when an allow list has been configured,
+// the apps may only send the operations they were built with
 func GQLHandler(ctx context.Context,
 	usecase usecases.Smartduka,
-) gin.HandlerFunc {
+) (gin.HandlerFunc, error) {
 	resolver, err := graph.NewResolver(ctx, usecase)
 	if err != nil {
-		helpers.LogStartupError(ctx, err)
+		return nil, err
+	}
+
+	complexityLimit, err := helpers.GetGraphQLComplexityLimit()
+	if err != nil {
+		return nil, err
+	}
+	depthLimit, err := helpers.GetGraphQLDepthLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	server := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  resolver,
+		Complexity: graph.Complexity(),
+	}))
+
+	// subscriptions are served over websockets
+	server.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              rest.WebsocketAuth(usecase.User),
+		Upgrader:              websocket.Upgrader{CheckOrigin: allowedOrigin},
+	})
+	server.AddTransport(transport.Options{})
+	server.AddTransport(transport.GET{})
+	server.AddTransport(transport.POST{})
+	server.AddTransport(transport.MultipartForm{})
+
+	server.SetQueryCache(lru.New(1000))
+	server.SetErrorPresenter(graph.ErrorPresenter)
+	server.SetRecoverFunc(graph.RecoverFunc)
+
+	server.Use(operation.Metrics{})
+	server.Use(gqlextension.Introspection{})
+
+	// listed operations must be known before persisted queries are looked up
+	if path := os.Getenv(common.GraphQLAllowListEnvVarName); path != "" {
+		allowList, err := operation.LoadAllowList(path)
+		if err != nil {
+			return nil, err
+		}
+		server.Use(allowList)
 	}
+	server.Use(gqlextension.AutomaticPersistedQuery{
+		Cache: lru.New(100),
+	})
 
-	server := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))
+	server.Use(gqlextension.FixedComplexityLimit(complexityLimit))
+	server.Use(operation.DepthLimit{Max: depthLimit})
+
+	if err := view.Register(operation.Views...); err != nil {
+		return nil, fmt.Errorf("failed to register GraphQL metrics: %v", err)
+	}
+
+	// every request batches its lookups with its own loaders
+	loaded := graph.LoaderMiddleware(usecase, server)
 
 	return func(c *gin.Context) {
-		server.ServeHTTP(c.Writer, c.Request)
+		loaded.ServeHTTP(c.Writer, c.Request)
+	}, nil
+}
+
+// allowedOrigin checks that a websocket is opened by a client allowed by CORS or one that sent no origin, like the tills
+func allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
 	}
+
+	for _, allowed := range SmartdukaServiceAllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+
+	return false
 }
 
 // StartGinRouter sets up the GIN router
 func StartGinRouter(ctx context.Context) (*gin.Engine, error) {
 	r := gin.Default()
 	r.Use(gin.Recovery())
+	r.Use(rest.RequestMetadataMiddleware())
 
-	pg, err := gorm.NewPGInstance()
+	repository, err := newRepository(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("can't instantiate repository in resolver: %v", err)
+		return nil, err
 	}
 
-	db := pgDB.NewDBService(pg, pg, pg)
+	db := pgDB.NewDBService(repository, repository, repository, repository)
 	ext := extension.NewExtension()
 
-	userUsecase := user.NewUseCasesUser(db, db, db, ext)
+	// sales are only submitted to KRA when eTIMS has been configured
+	var etimsService etims.ServiceETIMS
+	if os.Getenv(common.ETIMSBaseURLEnvVarName) != "" {
+		etimsService, err = etims.NewServiceETIMS()
+		if err != nil {
+			return nil, fmt.Errorf("can't instantiate eTIMS service: %v", err)
+		}
+	}
+	taxInvoiceUsecase := taxinvoice.NewUseCasesTaxInvoice(db, db, etimsService)
+	if taxInvoiceUsecase.Enabled() {
+		StartTaxInvoiceOutbox(ctx, taxInvoiceUsecase)
+	}
+
+	retentionPeriod, err := helpers.GetSoftDeleteRetention()
+	if err != nil {
+		return nil, err
+	}
+	StartPurge(ctx, retention.NewUseCasesRetention(db, retentionPeriod))
+
+	lowStockThreshold, err := helpers.GetLowStockThreshold()
+	if err != nil {
+		return nil, err
+	}
+	// a single server delivers its own events. Several servers behind a load balancer need a shared pub/sub
+	liveUsecase := live.NewUseCasesLive(db, pubsub.NewInProcess(), ext, lowStockThreshold)
+
+	userUsecase := user.NewUseCasesUser(db, db, db, db, ext)
 	otpUsecase := otp.NewUseCaseOTP(db, db)
+	reportUsecase := report.NewUseCasesReport(db)
+	productUsecase := product.NewUseCasesProduct(db, db, db, db, ext, taxInvoiceUsecase, liveUsecase)
+	shiftUsecase := shift.NewUseCasesShift(db, db, db, ext)
 
-	usecases := usecases.NewSmartdukaUsecase(userUsecase, otpUsecase)
+	syncUsecase := offlinesync.NewUseCasesSync(db, db, ext, productUsecase)
+
+	auditUsecase := audit.NewUseCasesAudit(db, ext)
+
+	usecases := usecases.NewSmartdukaUsecase(userUsecase, otpUsecase, reportUsecase, productUsecase, shiftUsecase, syncUsecase, auditUsecase, liveUsecase)
 	h := rest.NewPresentationHandlers(*usecases)
 
+	idempotencyUsecase := idempotency.NewUseCasesIdempotency(db, db, ext)
+
+	// requests made before signing in have no user to keep their idempotency keys apart so they are not replayed
 	api := r.Group("/v1/api")
 	{
 		api.GET("/login_by_phone", h.HandleLoginByPhone())
 		api.POST("/sign_up", h.HandleRegistration())
+		api.POST("/sign_up/otp", h.SendRegistrationOTP())
 		api.GET("/ide", PlaygroundHandler())
 		api.POST("/pin", h.SetUserPIN())
 		api.GET("/user", h.GetUserProfileByPhoneNumber())
 	}
 
+	graphQL, err := GQLHandler(ctx, *usecases)
+	if err != nil {
+		return nil, err
+	}
+	authMiddleware := rest.AuthMiddleware(userUsecase)
+
+	// a GraphQL websocket is authenticated by its init payload. It is the only request let through without a token
+	r.GET("/v1/auth/graphql", rest.WebsocketHandshakeOr(authMiddleware), graphQL)
+
 	// Authenticated routes
 	auth := r.Group("/v1/auth")
-	auth.Use(rest.AuthMiddleware())
+	// keys are checked once the user is known so that each user's keys are kept apart
+	auth.Use(authMiddleware, rest.IdempotencyMiddleware(idempotencyUsecase))
 	{
-		auth.POST("/graphql", GQLHandler(ctx, *usecases))
+		auth.POST("/graphql", graphQL)
+
+		auth.POST("/products/import", h.ImportProducts())
+		auth.GET("/products", h.ListProducts())
+		auth.GET("/products/search", h.SearchProduct())
+		auth.GET("/products/export", h.ExportProducts())
+		auth.GET("/stock/export", h.ExportStock())
+		auth.GET("/sales", h.ListSales())
+		auth.GET("/sales/export", h.ExportSales())
+		auth.GET("/sales/:id/receipt", h.GetReceipt())
+
+		auth.POST("/sync/push", h.SyncPush())
+		auth.GET("/sync/pull", h.SyncPull())
 	}
 
 	return r, nil
 }
+
+// newRepository opens the datastore selected by the DATABASE_BACKEND environment variable.
+// The memory backend keeps nothing between restarts and is meant for tests and demos
+func newRepository(ctx context.Context) (gorm.Repository, error) {
+	if os.Getenv(gorm.DatabaseBackend) == "memory" {
+		return memory.NewStore(), nil
+	}
+
+	pg, err := gorm.NewDatabaseInstance()
+	if err != nil {
+		return nil, fmt.Errorf("can't instantiate repository in resolver: %v", err)
+	}
+
+	if err := checkSchema(ctx, pg); err != nil {
+		return nil, err
+	}
+
+	return pg, nil
+}
+
+// checkSchema refuses to serve requests against a database that has not been migrated to this build's schema.
+// A SQLite schema is brought up to date when the database is opened
+func checkSchema(ctx context.Context, pg *gorm.PGInstance) error {
+	if pg.IsSQLite() {
+		return nil
+	}
+
+	sqlDB, err := pg.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get a connection to the database: %v", err)
+	}
+
+	migrator, err := migrate.NewMigrator(sqlDB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	return migrator.Check(ctx)
+}
+
+// StartTaxInvoiceOutbox periodically submits the sales that could not be submitted to eTIMS when they were made
+func StartTaxInvoiceOutbox(ctx context.Context, usecase taxinvoice.UseCasesTaxInvoice) {
+	ticker := time.NewTicker(taxInvoiceInterval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := usecase.SubmitPendingInvoices(ctx, taxInvoiceBatchSize); err != nil {
+					log.Printf("failed to submit pending tax invoices: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// StartPurge periodically removes the records that have been deleted for longer than the retention period
+func StartPurge(ctx context.Context, usecase retention.UseCasesRetention) {
+	ticker := time.NewTicker(purgeInterval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := usecase.PurgeDeleted(ctx, purgeBatchSize); err != nil {
+					log.Printf("failed to purge deleted records: %v", err)
+				}
+			}
+		}
+	}()
+}
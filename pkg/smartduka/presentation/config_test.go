@@ -0,0 +1,57 @@
+package presentation_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore/db/gorm"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation"
+)
+
+func TestStartGinRouter_Authentication(t *testing.T) {
+	t.Setenv("REPOSITORY", "postgres")
+	t.Setenv(gorm.DatabaseBackend, "memory")
+	router, err := presentation.StartGinRouter(context.Background())
+	if err != nil {
+		t.Fatalf("StartGinRouter() error = %v", err)
+	}
+
+	handshake := map[string]string{
+		"Connection":            "Upgrade",
+		"Upgrade":               "websocket",
+		"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+		"Sec-WebSocket-Version": "13",
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want401 bool
+	}{
+		{name: "Sad case: an export asking for a websocket upgrade", method: http.MethodGet, path: "/v1/auth/sales/export", headers: map[string]string{"Upgrade": "websocket"}, want401: true},
+		{name: "Sad case: an export sending a whole websocket handshake", method: http.MethodGet, path: "/v1/auth/sales/export", headers: handshake, want401: true},
+		{name: "Sad case: a sync push asking for a websocket upgrade", method: http.MethodPost, path: "/v1/auth/sync/push", headers: map[string]string{"Upgrade": "websocket"}, want401: true},
+		{name: "Sad case: a GraphQL query without a token", method: http.MethodGet, path: "/v1/auth/graphql?query={__typename}", want401: true},
+		{name: "Sad case: a GraphQL query pretending to upgrade", method: http.MethodGet, path: "/v1/auth/graphql?query={__typename}", headers: map[string]string{"Upgrade": "websocket"}, want401: true},
+		{name: "Happy case: a GraphQL websocket handshake is left to its init payload", method: http.MethodGet, path: "/v1/auth/graphql", headers: handshake},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if (rec.Code == http.StatusUnauthorized) != tt.want401 {
+				t.Errorf("%s %s status = %d, want 401 %v", tt.method, tt.path, rec.Code, tt.want401)
+			}
+		})
+	}
+}
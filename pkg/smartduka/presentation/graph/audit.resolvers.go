@@ -0,0 +1,25 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.33
+
+import (
+	"context"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/generated"
+)
+
+// AuditLogs is the resolver for the auditLogs field.
+func (r *queryResolver) AuditLogs(ctx context.Context, filter *dto.AuditLogFilter) ([]*domain.AuditLog, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Audit.ListAuditLogs(ctx, filter)
+}
+
+// Query returns generated.QueryResolver implementation.
+func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
+
+type queryResolver struct{ *Resolver }
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/generated"
+)
+
+// Complexity counts the fields selected on a page of a list once for each record on the page,
+// so that asking for the largest pages costs more than asking for a single record
+func Complexity() generated.ComplexityRoot {
+	var root generated.ComplexityRoot
+
+	root.Query.Products = func(childComplexity int, filter *dto.ProductFilter, sort *dto.ProductSort, first *int, after *string) int {
+		return page(childComplexity, first)
+	}
+	root.Query.Sales = func(childComplexity int, filter *dto.SaleFilter, direction *enums.SortDirection, first *int, after *string) int {
+		return page(childComplexity, first)
+	}
+	root.Query.Users = func(childComplexity int, filter *dto.UserFilter, sort *dto.UserSort, first *int, after *string) int {
+		return page(childComplexity, first)
+	}
+	root.Query.SearchProduct = func(childComplexity int, searchTerm string, first *int) int {
+		return page(childComplexity, first)
+	}
+
+	return root
+}
+
+// page is the complexity of a page of records. Invalid page sizes are left for the resolver to reject
+func page(childComplexity int, first *int) int {
+	records, err := pagination.Limit(first)
+	if err != nil {
+		records = 1
+	}
+
+	return 1 + childComplexity*records
+}
@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorPresenter tells clients what kind of error they got in the `code` extension and only shows them messages
+// that are safe to show, in the user's language. Unexpected errors, like the database's, are logged and replaced
+// with a generic message. Invalid inputs list what is wrong with each field in the `fields` extension
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+
+	// errors raised by the server itself when parsing, validating or limiting an operation are already coded
+	if presented.Unwrap() == nil {
+		return presented
+	}
+
+	code := domain.ErrorCodeOf(err)
+	language := utils.GetLanguage(ctx)
+	var coded *domain.Error
+	switch {
+	case errors.As(err, &coded):
+		presented.Message = domain.Localize(coded.Message, language)
+	case argumentError(ctx):
+		code = domain.Validation
+	default:
+		logrus.WithFields(logrus.Fields{
+			"path":  presented.Path.String(),
+			"error": err,
+		}).Error("GraphQL operation failed")
+		presented.Message = domain.UserMessage(err, language)
+	}
+
+	if presented.Extensions == nil {
+		presented.Extensions = map[string]interface{}{}
+	}
+	presented.Extensions["code"] = string(code)
+	if fields := domain.UserFields(err, language); len(fields) > 0 {
+		presented.Extensions["fields"] = fields
+	}
+
+	return presented
+}
+
+// RecoverFunc reports a panic in a resolver and fails the field instead of the server
+func RecoverFunc(ctx context.Context, err interface{}) error {
+	fields := logrus.Fields{
+		"panic": err,
+		"stack": string(debug.Stack()),
+	}
+	if graphql.HasOperationContext(ctx) {
+		fields["operation"] = graphql.GetOperationContext(ctx).OperationName
+	}
+	logrus.WithFields(fields).Error("GraphQL resolver panicked")
+
+	return domain.WrapError(domain.Internal, fmt.Errorf("panic: %v", err), domain.InternalErrorMessage)
+}
+
+// argumentError reports whether an error was raised while reading a field's arguments, before its resolver ran.
+// A field given arguments only has none when they could not be read
+func argumentError(ctx context.Context) bool {
+	field := graphql.GetFieldContext(ctx)
+	return field != nil && field.Args == nil && len(field.Field.Arguments) > 0
+}
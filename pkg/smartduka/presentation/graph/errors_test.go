@@ -0,0 +1,66 @@
+package graph_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestErrorPresenter(t *testing.T) {
+	tests := []struct {
+		name        string
+		language    string
+		err         error
+		wantMessage string
+		wantCode    interface{}
+	}{
+		{
+			name:        "Happy case: a coded error keeps its message",
+			err:         domain.Errorf(domain.NotFound, "no product has this ID"),
+			wantMessage: "no product has this ID",
+			wantCode:    "NOT_FOUND",
+		},
+		{
+			name:        "Happy case: a coded error wrapped by a resolver",
+			err:         fmt.Errorf("failed to sell product: %w", domain.Errorf(domain.Validation, "quantity must be positive")),
+			wantMessage: "quantity must be positive",
+			wantCode:    "VALIDATION",
+		},
+		{
+			name:        "Happy case: a coded error in the user's language",
+			language:    "sw",
+			err:         domain.Errorf(domain.NotFound, "the product does not exist"),
+			wantMessage: "bidhaa hii haipo",
+			wantCode:    "NOT_FOUND",
+		},
+		{
+			name:        "Happy case: an error raised by the server is left as it is",
+			err:         gqlerror.Errorf("operation not allowed"),
+			wantMessage: "operation not allowed",
+		},
+		{
+			name:        "Sad case: an unexpected error is hidden",
+			err:         fmt.Errorf("pq: relation does not exist"),
+			wantMessage: "something went wrong, please try again",
+			wantCode:    "INTERNAL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), common.LanguageContextKey, tt.language)
+			got := graph.ErrorPresenter(ctx, graphql.ErrorOnPath(ctx, tt.err))
+			if got.Message != tt.wantMessage {
+				t.Errorf("ErrorPresenter() message = %q, want %q", got.Message, tt.wantMessage)
+			}
+			if got.Extensions["code"] != tt.wantCode {
+				t.Errorf("ErrorPresenter() code = %v, want %v", got.Extensions["code"], tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.33
+
+import (
+	"context"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/generated"
+)
+
+// SaleCompleted is the resolver for the saleCompleted field.
+func (r *subscriptionResolver) SaleCompleted(ctx context.Context) (<-chan *domain.Sale, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Live.SaleCompleted(ctx)
+}
+
+// StockLevelChanged is the resolver for the stockLevelChanged field.
+func (r *subscriptionResolver) StockLevelChanged(ctx context.Context, productID *string) (<-chan *domain.StockLevel, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Live.StockLevelChanged(ctx, productID)
+}
+
+// LowStockAlert is the resolver for the lowStockAlert field.
+func (r *subscriptionResolver) LowStockAlert(ctx context.Context) (<-chan *domain.LowStockAlert, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Live.LowStockAlert(ctx)
+}
+
+// Subscription returns generated.SubscriptionResolver implementation.
+func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
+
+type subscriptionResolver struct{ *Resolver }
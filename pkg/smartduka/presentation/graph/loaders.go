@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dataloader"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases"
+)
+
+type loadersKey struct{}
+
+// Loaders batch the lookups made by the field resolvers of a single request
+type Loaders struct {
+	Users    *dataloader.Loader[string, *domain.User]
+	Contacts *dataloader.Loader[string, *domain.Contact]
+	Products *dataloader.Loader[string, *domain.Product]
+
+	// ShiftSales loads the sales made during a shift
+	ShiftSales *dataloader.Loader[string, []*domain.Sale]
+}
+
+// NewLoaders creates the loaders of a request
+func NewLoaders(ctx context.Context, smartduka usecases.Smartduka) *Loaders {
+	return &Loaders{
+		Users: dataloader.New(ctx, func(ctx context.Context, ids []string) (map[string]*domain.User, error) {
+			users, err := smartduka.User.GetUsersByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			return byKey(users, func(user *domain.User) string { return user.ID }), nil
+		}),
+		Contacts: dataloader.New(ctx, func(ctx context.Context, userIDs []string) (map[string]*domain.Contact, error) {
+			contacts, err := smartduka.User.GetContactsByUserIDs(ctx, userIDs)
+			if err != nil {
+				return nil, err
+			}
+			return byKey(contacts, func(contact *domain.Contact) string { return contact.UserID }), nil
+		}),
+		Products: dataloader.New(ctx, func(ctx context.Context, ids []string) (map[string]*domain.Product, error) {
+			products, err := smartduka.Product.GetProductsByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			return byKey(products, func(product *domain.Product) string { return product.ID }), nil
+		}),
+		ShiftSales: dataloader.New(ctx, func(ctx context.Context, shiftIDs []string) (map[string][]*domain.Sale, error) {
+			sales, err := smartduka.Product.GetSalesByShiftIDs(ctx, shiftIDs)
+			if err != nil {
+				return nil, err
+			}
+			grouped := map[string][]*domain.Sale{}
+			for _, sale := range sales {
+				grouped[sale.ShiftID] = append(grouped[sale.ShiftID], sale)
+			}
+			return grouped, nil
+		}),
+	}
+}
+
+// LoaderMiddleware gives every request its own loaders so that nothing loaded for one request is seen by another
+func LoaderMiddleware(smartduka usecases.Smartduka, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), loadersKey{}, NewLoaders(r.Context(), smartduka))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// loadersFor returns the loaders of the request being resolved
+func loadersFor(ctx context.Context) (*Loaders, error) {
+	loaders, ok := ctx.Value(loadersKey{}).(*Loaders)
+	if !ok {
+		return nil, fmt.Errorf("the request has no data loaders")
+	}
+	return loaders, nil
+}
+
+// load looks up a record that must exist with one of the request's loaders
+func load[V any](ctx context.Context, loader func(*Loaders) *dataloader.Loader[string, V], kind string, key string) (V, error) {
+	var zero V
+
+	loaders, err := loadersFor(ctx)
+	if err != nil {
+		return zero, err
+	}
+
+	value, ok, err := loader(loaders).Load(ctx, key)
+	if err != nil {
+		return zero, err
+	}
+	if !ok {
+		return zero, fmt.Errorf("%s %s does not exist", kind, key)
+	}
+
+	return value, nil
+}
+
+func users(loaders *Loaders) *dataloader.Loader[string, *domain.User] { return loaders.Users }
+
+func products(loaders *Loaders) *dataloader.Loader[string, *domain.Product] { return loaders.Products }
+
+func byKey[V any](values []V, key func(V) string) map[string]V {
+	keyed := map[string]V{}
+	for _, value := range values {
+		keyed[key(value)] = value
+	}
+	return keyed
+}
@@ -0,0 +1,165 @@
+package graph_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/generated"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/product"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/user"
+)
+
+// countingQuery counts the queries made for each method
+type countingQuery struct {
+	datastore.Query
+
+	mu      sync.Mutex
+	queries map[string]int
+	sales   int
+}
+
+func (c *countingQuery) count(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries[method]++
+}
+
+func (c *countingQuery) ListSales(ctx context.Context, filter *dto.SaleFilter, direction enums.SortDirection, after *pagination.Cursor, limit int) (*domain.SaleConnection, error) {
+	c.count("ListSales")
+
+	connection := &domain.SaleConnection{PageInfo: &domain.PageInfo{}}
+	for i := 0; i < c.sales; i++ {
+		sale := &domain.Sale{
+			ID:        fmt.Sprintf("sale-%d", i),
+			ProductID: fmt.Sprintf("product-%d", i%3),
+			SoldBy:    fmt.Sprintf("user-%d", i%2),
+		}
+		connection.Edges = append(connection.Edges, &domain.SaleEdge{Cursor: sale.ID, Node: sale})
+	}
+	return connection, nil
+}
+
+func (c *countingQuery) GetProductsByIDs(ctx context.Context, ids []string) ([]*domain.Product, error) {
+	c.count("GetProductsByIDs")
+
+	products := []*domain.Product{}
+	for _, id := range ids {
+		products = append(products, &domain.Product{ID: id, Name: id})
+	}
+	return products, nil
+}
+
+func (c *countingQuery) GetUsersByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	c.count("GetUsersByIDs")
+
+	users := []*domain.User{}
+	for _, id := range ids {
+		users = append(users, &domain.User{ID: id, UserName: id})
+	}
+	return users, nil
+}
+
+func (c *countingQuery) GetContactsByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Contact, error) {
+	c.count("GetContactsByUserIDs")
+
+	contacts := []*domain.Contact{}
+	for _, id := range userIDs {
+		contacts = append(contacts, &domain.Contact{ID: "contact-" + id, UserID: id, ContactValue: "0700000000"})
+	}
+	return contacts, nil
+}
+
+func TestLoaderMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		sales       int
+		wantQueries map[string]int
+	}{
+		{
+			name:  "Happy case: a page of sales looks up its products and cashiers once",
+			sales: 20,
+			wantQueries: map[string]int{
+				"ListSales":            1,
+				"GetProductsByIDs":     1,
+				"GetUsersByIDs":        1,
+				"GetContactsByUserIDs": 1,
+			},
+		},
+		{
+			name:  "Happy case: an empty page looks nothing up",
+			sales: 0,
+			wantQueries: map[string]int{
+				"ListSales": 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &countingQuery{queries: map[string]int{}, sales: tt.sales}
+			smartduka := usecases.Smartduka{
+				User:    user.NewUseCasesUser(nil, query, nil, nil, nil),
+				Product: product.NewUseCasesProduct(nil, query, nil, nil, nil, nil, nil),
+			}
+
+			resolver, err := graph.NewResolver(context.Background(), smartduka)
+			if err != nil {
+				t.Fatalf("NewResolver() error = %v", err)
+			}
+			server := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))
+			c := client.New(graph.LoaderMiddleware(smartduka, server))
+
+			var resp struct {
+				Sales struct {
+					Edges []struct {
+						Node struct {
+							ID      string
+							Product struct{ Name string }
+							Cashier struct {
+								Username    string
+								UserContact struct{ ContactValue string }
+							}
+						}
+					}
+				}
+			}
+			err = c.Post(`{ sales(first: 50) { edges { node { id product { name } cashier { username userContact { contactValue } } } } } }`, &resp)
+			if err != nil {
+				t.Fatalf("sales query error = %v", err)
+			}
+
+			if len(resp.Sales.Edges) != tt.sales {
+				t.Fatalf("sales query returned %d sales, want %d", len(resp.Sales.Edges), tt.sales)
+			}
+			for i, edge := range resp.Sales.Edges {
+				if want := fmt.Sprintf("product-%d", i%3); edge.Node.Product.Name != want {
+					t.Errorf("sale %s has product %q, want %q", edge.Node.ID, edge.Node.Product.Name, want)
+				}
+				if want := fmt.Sprintf("user-%d", i%2); edge.Node.Cashier.Username != want {
+					t.Errorf("sale %s has cashier %q, want %q", edge.Node.ID, edge.Node.Cashier.Username, want)
+				}
+			}
+
+			for method, want := range tt.wantQueries {
+				if got := query.queries[method]; got != want {
+					t.Errorf("%s queried %d times, want %d", method, got, want)
+				}
+			}
+			for method, got := range query.queries {
+				if _, ok := tt.wantQueries[method]; !ok {
+					t.Errorf("%s queried %d times, want none", method, got)
+				}
+			}
+		})
+	}
+}
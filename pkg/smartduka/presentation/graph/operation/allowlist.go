@@ -0,0 +1,121 @@
+package operation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// OperationNotAllowed is the code of the error returned for operations that are not on the allow list
+const OperationNotAllowed = "OPERATION_NOT_ALLOWED"
+
+// AllowList only accepts the operations the production apps were built with.
+// Each app names itself with the flavour header and may only send the operations listed for it, either in full
+// or as the SHA-256 hash of a listed operation the way automatic persisted queries are sent.
+// It must be added before the automatic persisted query extension so that listed operations are never unknown
+type AllowList struct {
+	// operations maps each app to the hashes of its operations and the operations themselves
+	operations map[enums.Flavour]map[string]string
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = &AllowList{}
+
+// NewAllowList creates an allow list from the operations each app may send
+func NewAllowList(operations map[enums.Flavour][]string) (*AllowList, error) {
+	list := &AllowList{operations: map[enums.Flavour]map[string]string{}}
+	for flavour, queries := range operations {
+		if !flavour.IsValid() {
+			return nil, fmt.Errorf("invalid flavour in allow list: %s", flavour)
+		}
+
+		list.operations[flavour] = map[string]string{}
+		for _, query := range queries {
+			list.operations[flavour][Hash(query)] = query
+		}
+	}
+
+	return list, nil
+}
+
+// LoadAllowList reads an allow list from a JSON file listing the operations of each app e.g
+// {"PRO": ["query products { ... }"], "CONSUMER": ["..."]}
+func LoadAllowList(path string) (*AllowList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allow list: %v", err)
+	}
+
+	operations := map[enums.Flavour][]string{}
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, fmt.Errorf("failed to decode allow list: %v", err)
+	}
+
+	return NewAllowList(operations)
+}
+
+// ExtensionName names the extension
+func (a *AllowList) ExtensionName() string {
+	return "AllowList"
+}
+
+// Validate checks that the allow list is usable
+func (a *AllowList) Validate(schema graphql.ExecutableSchema) error {
+	if len(a.operations) == 0 {
+		return fmt.Errorf("the allow list has no operations")
+	}
+	return nil
+}
+
+// MutateOperationParameters rejects operations that are not listed for the app that sent them
+// and fills in listed operations sent as a hash
+func (a *AllowList) MutateOperationParameters(ctx context.Context, params *graphql.RawParams) *gqlerror.Error {
+	flavour, _ := ctx.Value(common.FlavourContextKey).(string)
+	operations, ok := a.operations[enums.Flavour(flavour)]
+	if !ok {
+		return notAllowed("the app sending the operation is not allowed to send operations")
+	}
+
+	hash := persistedQueryHash(params)
+	if params.Query != "" {
+		hash = Hash(params.Query)
+	}
+
+	query, ok := operations[hash]
+	if !ok {
+		return notAllowed("the operation is not on the allow list")
+	}
+	params.Query = query
+
+	return nil
+}
+
+// Hash returns the hash a persisted query is sent as
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// persistedQueryHash returns the hash of an operation sent as a persisted query
+func persistedQueryHash(params *graphql.RawParams) string {
+	extension, _ := params.Extensions["persistedQuery"].(map[string]interface{})
+	hash, _ := extension["sha256Hash"].(string)
+	return hash
+}
+
+func notAllowed(message string) *gqlerror.Error {
+	err := gqlerror.Errorf(message)
+	errcode.Set(err, OperationNotAllowed)
+	return err
+}
@@ -0,0 +1,80 @@
+// Package operation guards the GraphQL server against operations that would hammer the database
+// and records how each operation performs
+package operation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// DepthLimitExceeded is the code of the error returned for operations nested too deeply
+const DepthLimitExceeded = "DEPTH_LIMIT_EXCEEDED"
+
+// DepthLimit rejects operations whose fields are nested deeper than Max.
+// Introspection fields are not counted so that the playground and client tooling keep working
+type DepthLimit struct {
+	Max int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = DepthLimit{}
+
+// ExtensionName names the extension
+func (d DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate checks that the limit is usable
+func (d DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	if d.Max < 1 {
+		return fmt.Errorf("the depth limit must be at least 1")
+	}
+	return nil
+}
+
+// MutateOperationContext rejects the operation when it is nested too deeply
+func (d DepthLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if depth := Depth(rc.Operation.SelectionSet); depth > d.Max {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.Max)
+		errcode.Set(err, DepthLimitExceeded)
+		return err
+	}
+
+	return nil
+}
+
+// Depth returns how deeply the fields of a selection set are nested, following fragments
+func Depth(selections ast.SelectionSet) int {
+	deepest := 0
+	for _, selection := range selections {
+		depth := 0
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			depth = 1 + Depth(selection.SelectionSet)
+		case *ast.InlineFragment:
+			depth = Depth(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			// validation has already rejected fragments that spread themselves
+			if selection.Definition != nil {
+				depth = Depth(selection.Definition.SelectionSet)
+			}
+		}
+
+		if depth > deepest {
+			deepest = depth
+		}
+	}
+
+	return deepest
+}
@@ -0,0 +1,136 @@
+package operation
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	gqlextension "github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// The measures recorded for every query and mutation
+var (
+	Latency    = stats.Float64("smartduka/graphql/latency", "How long an operation took to resolve", stats.UnitMilliseconds)
+	Complexity = stats.Int64("smartduka/graphql/complexity", "The complexity of an operation", stats.UnitDimensionless)
+)
+
+// The tags the measures are recorded with
+var (
+	// KeyOperation is the name the client gave the operation
+	KeyOperation = tag.MustNewKey("operation")
+
+	// KeyType tells queries and mutations apart
+	KeyType = tag.MustNewKey("type")
+
+	// KeyStatus is OK or the code of the first error the operation returned
+	KeyStatus = tag.MustNewKey("status")
+)
+
+// Views aggregate the measures for export. They are exported once registered
+var Views = []*view.View{
+	{
+		Name:        "smartduka/graphql/operations",
+		Description: "The number of operations served",
+		Measure:     Latency,
+		TagKeys:     []tag.Key{KeyOperation, KeyType, KeyStatus},
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "smartduka/graphql/latency",
+		Description: "How long operations took to resolve",
+		Measure:     Latency,
+		TagKeys:     []tag.Key{KeyOperation, KeyType, KeyStatus},
+		Aggregation: view.Distribution(5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000),
+	},
+	{
+		Name:        "smartduka/graphql/complexity",
+		Description: "The complexity of the operations served",
+		Measure:     Complexity,
+		TagKeys:     []tag.Key{KeyOperation, KeyType},
+		Aggregation: view.Distribution(10, 50, 100, 250, 500, 1000, 2500, 5000),
+	},
+}
+
+// Metrics records the latency, outcome and complexity of every query and mutation, including the ones rejected
+// before they were resolved. Subscriptions are left out since each event they send is a response of its own
+type Metrics struct{}
+
+var _ interface {
+	graphql.ResponseInterceptor
+	graphql.HandlerExtension
+} = Metrics{}
+
+// ExtensionName names the extension
+func (m Metrics) ExtensionName() string {
+	return "Metrics"
+}
+
+// Validate has nothing to check
+func (m Metrics) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse records the measures of an operation once it has been resolved
+func (m Metrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+
+	rc := graphql.GetOperationContext(ctx)
+	if rc.Operation != nil && rc.Operation.Operation == ast.Subscription {
+		return resp
+	}
+
+	tags := []tag.Mutator{
+		tag.Upsert(KeyOperation, operationName(rc)),
+		tag.Upsert(KeyType, operationType(rc)),
+		tag.Upsert(KeyStatus, status(resp)),
+	}
+	measurements := []stats.Measurement{
+		Latency.M(float64(time.Since(rc.Stats.OperationStart)) / float64(time.Millisecond)),
+	}
+	if complexity := gqlextension.GetComplexityStats(ctx); complexity != nil {
+		measurements = append(measurements, Complexity.M(int64(complexity.Complexity)))
+	}
+
+	if err := stats.RecordWithTags(ctx, tags, measurements...); err != nil {
+		logrus.Printf("failed to record GraphQL operation metrics: %v", err)
+	}
+
+	return resp
+}
+
+func operationName(rc *graphql.OperationContext) string {
+	switch {
+	case rc.OperationName != "":
+		return rc.OperationName
+	case rc.Operation != nil && rc.Operation.Name != "":
+		return rc.Operation.Name
+	default:
+		return "anonymous"
+	}
+}
+
+func operationType(rc *graphql.OperationContext) string {
+	if rc.Operation == nil {
+		return "unknown"
+	}
+	return string(rc.Operation.Operation)
+}
+
+func status(resp *graphql.Response) string {
+	if resp == nil || len(resp.Errors) == 0 {
+		return "OK"
+	}
+
+	if code, ok := resp.Errors[0].Extensions["code"].(string); ok {
+		return code
+	}
+	return "ERROR"
+}
@@ -0,0 +1,93 @@
+package operation_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	gqlextension "github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/generated"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/operation"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases"
+)
+
+const typename = "{ __typename }"
+
+func TestOperationLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowList map[enums.Flavour][]string
+		flavour   string
+		query     string
+		hashOnly  bool
+		wantCode  string
+	}{
+		{name: "Happy case: a shallow and simple operation", query: typename},
+		{name: "Happy case: a listed operation sent as a hash", allowList: map[enums.Flavour][]string{enums.FlavourPro: {typename}}, flavour: "PRO", query: typename, hashOnly: true},
+		{name: "Happy case: a listed operation sent in full", allowList: map[enums.Flavour][]string{enums.FlavourPro: {typename}}, flavour: "PRO", query: typename},
+		{name: "Sad case: an operation nested too deeply", query: "{ sales { edges { node { product { name } } } } }", wantCode: operation.DepthLimitExceeded},
+		{name: "Sad case: a page too large for the complexity limit", query: "{ sales(first: 100) { edges { node { id } } } }", wantCode: "COMPLEXITY_LIMIT_EXCEEDED"},
+		{name: "Sad case: an operation listed for another app", allowList: map[enums.Flavour][]string{enums.FlavourPro: {typename}}, flavour: "CONSUMER", query: typename, wantCode: operation.OperationNotAllowed},
+		{name: "Sad case: an app that does not name itself", allowList: map[enums.Flavour][]string{enums.FlavourPro: {typename}}, query: typename, wantCode: operation.OperationNotAllowed},
+		{name: "Sad case: an operation that is not listed", allowList: map[enums.Flavour][]string{enums.FlavourPro: {typename}}, flavour: "PRO", query: "{ sales { edges { cursor } } }", wantCode: operation.OperationNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, err := graph.NewResolver(context.Background(), usecases.Smartduka{})
+			if err != nil {
+				t.Fatalf("NewResolver() error = %v", err)
+			}
+			server := handler.New(generated.NewExecutableSchema(generated.Config{
+				Resolvers:  resolver,
+				Complexity: graph.Complexity(),
+			}))
+			server.AddTransport(transport.POST{})
+			server.Use(operation.Metrics{})
+			if tt.allowList != nil {
+				allowList, err := operation.NewAllowList(tt.allowList)
+				if err != nil {
+					t.Fatalf("NewAllowList() error = %v", err)
+				}
+				server.Use(allowList)
+			}
+			server.Use(gqlextension.AutomaticPersistedQuery{Cache: lru.New(100)})
+			server.Use(gqlextension.FixedComplexityLimit(100))
+			server.Use(operation.DepthLimit{Max: 4})
+
+			// the flavour is put on the context the way the request metadata middleware does
+			c := client.New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := context.WithValue(r.Context(), common.FlavourContextKey, r.Header.Get(common.FlavourHeader))
+				server.ServeHTTP(w, r.WithContext(ctx))
+			}))
+
+			options := []client.Option{client.AddHeader(common.FlavourHeader, tt.flavour)}
+			query := tt.query
+			if tt.hashOnly {
+				query = ""
+				options = append(options, client.Extensions(map[string]interface{}{
+					"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": operation.Hash(tt.query)},
+				}))
+			}
+
+			var resp map[string]interface{}
+			err = c.Post(query, &resp, options...)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("Post() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantCode) {
+				t.Errorf("Post() error = %v, want %s", err, tt.wantCode)
+			}
+		})
+	}
+}
@@ -6,7 +6,6 @@ package graph
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/generated"
@@ -14,7 +13,14 @@ import (
 
 // SendOtp is the resolver for the sendOTP field.
 func (r *mutationResolver) SendOtp(ctx context.Context, phoneNumber string, flavour enums.Flavour) (string, error) {
-	panic(fmt.Errorf("not implemented: SendOtp - sendOTP"))
+	r.checkPreconditions()
+
+	// the code itself is only ever sent to the phone
+	if _, err := r.smartduka.OTP.GenerateAndSendOTP(ctx, phoneNumber, flavour); err != nil {
+		return "", err
+	}
+
+	return "a verification code has been sent to your phone", nil
 }
 
 // Mutation returns generated.MutationResolver implementation.
@@ -0,0 +1,83 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.33
+
+import (
+	"context"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// ReceiveStock is the resolver for the receiveStock field.
+func (r *mutationResolver) ReceiveStock(ctx context.Context, input dto.StockReceiptInput) (*domain.StockReceipt, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.ReceiveStock(ctx, &input)
+}
+
+// RecordSale is the resolver for the recordSale field.
+func (r *mutationResolver) RecordSale(ctx context.Context, input dto.SaleInput) (*domain.Sale, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.RecordSale(ctx, &input)
+}
+
+// RecordReturn is the resolver for the recordReturn field.
+func (r *mutationResolver) RecordReturn(ctx context.Context, input dto.SaleInput) (*domain.Sale, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.RecordReturn(ctx, &input)
+}
+
+// DeleteProduct is the resolver for the deleteProduct field.
+func (r *mutationResolver) DeleteProduct(ctx context.Context, id string) (bool, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.DeleteProduct(ctx, id)
+}
+
+// RestoreProduct is the resolver for the restoreProduct field.
+func (r *mutationResolver) RestoreProduct(ctx context.Context, id string) (*domain.Product, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.RestoreProduct(ctx, id)
+}
+
+// DeleteSale is the resolver for the deleteSale field.
+func (r *mutationResolver) DeleteSale(ctx context.Context, id string) (bool, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.DeleteSale(ctx, id)
+}
+
+// RestoreSale is the resolver for the restoreSale field.
+func (r *mutationResolver) RestoreSale(ctx context.Context, id string) (*domain.Sale, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.RestoreSale(ctx, id)
+}
+
+// Products is the resolver for the products field.
+func (r *queryResolver) Products(ctx context.Context, filter *dto.ProductFilter, sort *dto.ProductSort, first *int, after *string) (*domain.ProductConnection, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.ListProducts(ctx, filter, sort, first, after)
+}
+
+// Sales is the resolver for the sales field.
+func (r *queryResolver) Sales(ctx context.Context, filter *dto.SaleFilter, direction *enums.SortDirection, first *int, after *string) (*domain.SaleConnection, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.ListSales(ctx, filter, direction, first, after)
+}
+
+// SearchProduct is the resolver for the searchProduct field.
+func (r *queryResolver) SearchProduct(ctx context.Context, searchTerm string, first *int) ([]*domain.Product, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Product.SearchProduct(ctx, searchTerm, first)
+}
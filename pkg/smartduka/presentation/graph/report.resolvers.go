@@ -0,0 +1,55 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.33
+
+import (
+	"context"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// DailySale is the resolver for the dailySale field.
+func (r *queryResolver) DailySale(ctx context.Context) ([]*domain.Sale, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Report.GetDailySale(ctx)
+}
+
+// SalesSummary is the resolver for the salesSummary field.
+func (r *queryResolver) SalesSummary(ctx context.Context, from time.Time, to time.Time, interval enums.ReportInterval) ([]*domain.SalesSummary, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Report.GetSalesSummary(ctx, from, to, interval)
+}
+
+// SalesBreakdown is the resolver for the salesBreakdown field.
+func (r *queryResolver) SalesBreakdown(ctx context.Context, from time.Time, to time.Time, groupBy enums.SalesGrouping) ([]*domain.SalesBreakdown, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Report.GetSalesBreakdown(ctx, from, to, groupBy)
+}
+
+// TopProducts is the resolver for the topProducts field.
+func (r *queryResolver) TopProducts(ctx context.Context, from time.Time, to time.Time, rankBy enums.ProductRanking, limit *int) ([]*domain.ProductSales, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Report.GetTopProducts(ctx, from, to, rankBy, limit)
+}
+
+// HourlySales is the resolver for the hourlySales field.
+func (r *queryResolver) HourlySales(ctx context.Context, from time.Time, to time.Time) ([]*domain.HourlySales, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Report.GetHourlySales(ctx, from, to)
+}
+
+// ProfitReport is the resolver for the profitReport field.
+func (r *queryResolver) ProfitReport(ctx context.Context, from time.Time, to time.Time, groupBy enums.ProfitGrouping) ([]*domain.ProfitSummary, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Report.GetProfitReport(ctx, from, to, groupBy)
+}
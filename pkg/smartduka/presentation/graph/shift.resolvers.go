@@ -0,0 +1,48 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.33
+
+import (
+	"context"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// OpenShift is the resolver for the openShift field.
+func (r *mutationResolver) OpenShift(ctx context.Context, openingFloat money.Money) (*domain.Shift, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Shift.OpenShift(ctx, openingFloat)
+}
+
+// RecordCashMovement is the resolver for the recordCashMovement field.
+func (r *mutationResolver) RecordCashMovement(ctx context.Context, input dto.CashMovementInput) (*domain.CashMovement, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Shift.RecordCashMovement(ctx, &input)
+}
+
+// CloseShift is the resolver for the closeShift field.
+func (r *mutationResolver) CloseShift(ctx context.Context, countedCash money.Money) (*domain.ZReport, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Shift.CloseShift(ctx, countedCash)
+}
+
+// CurrentShift is the resolver for the currentShift field.
+func (r *queryResolver) CurrentShift(ctx context.Context) (*domain.Shift, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Shift.GetCurrentShift(ctx)
+}
+
+// ZReport is the resolver for the zReport field.
+func (r *queryResolver) ZReport(ctx context.Context, shiftID string) (*domain.ZReport, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.Shift.GetZReport(ctx, shiftID)
+}
@@ -6,24 +6,106 @@ package graph
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/generated"
 )
 
-// MiddleName is the resolver for the middleName field.
-func (r *userResolver) MiddleName(ctx context.Context, obj *domain.User) (string, error) {
-	panic(fmt.Errorf("not implemented: MiddleName - middleName"))
+// Actor is the resolver for the actor field.
+func (r *auditLogResolver) Actor(ctx context.Context, obj *domain.AuditLog) (*domain.User, error) {
+	if obj.ActorID == "" {
+		return nil, nil
+	}
+
+	return load(ctx, users, "user", obj.ActorID)
+}
+
+// Product is the resolver for the product field.
+func (r *saleResolver) Product(ctx context.Context, obj *domain.Sale) (*domain.Product, error) {
+	return load(ctx, products, "product", obj.ProductID)
+}
+
+// Cashier is the resolver for the cashier field.
+func (r *saleResolver) Cashier(ctx context.Context, obj *domain.Sale) (*domain.User, error) {
+	return load(ctx, users, "user", obj.SoldBy)
+}
+
+// Cashier is the resolver for the cashier field.
+func (r *shiftResolver) Cashier(ctx context.Context, obj *domain.Shift) (*domain.User, error) {
+	return load(ctx, users, "user", obj.CashierID)
+}
+
+// Sales is the resolver for the sales field.
+func (r *shiftResolver) Sales(ctx context.Context, obj *domain.Shift) ([]*domain.Sale, error) {
+	loaders, err := loadersFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sales, _, err := loaders.ShiftSales.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return sales, nil
+}
+
+// Product is the resolver for the product field.
+func (r *stockReceiptResolver) Product(ctx context.Context, obj *domain.StockReceipt) (*domain.Product, error) {
+	return load(ctx, products, "product", obj.ProductID)
 }
 
 // Flavour is the resolver for the flavour field.
 func (r *userResolver) Flavour(ctx context.Context, obj *domain.User) (enums.Flavour, error) {
-	panic(fmt.Errorf("not implemented: Flavour - flavour"))
+	contact, err := r.UserContact(ctx, obj)
+	if err != nil {
+		return "", err
+	}
+
+	return contact.Flavour, nil
 }
 
+// UserContact is the resolver for the userContact field.
+func (r *userResolver) UserContact(ctx context.Context, obj *domain.User) (*domain.Contact, error) {
+	// users read with their contact need no lookup
+	if obj.UserContact.ID != "" {
+		return &obj.UserContact, nil
+	}
+
+	loaders, err := loadersFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contact, ok, err := loaders.Contacts.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &domain.Contact{}, nil
+	}
+
+	return contact, nil
+}
+
+// AuditLog returns generated.AuditLogResolver implementation.
+func (r *Resolver) AuditLog() generated.AuditLogResolver { return &auditLogResolver{r} }
+
+// Sale returns generated.SaleResolver implementation.
+func (r *Resolver) Sale() generated.SaleResolver { return &saleResolver{r} }
+
+// Shift returns generated.ShiftResolver implementation.
+func (r *Resolver) Shift() generated.ShiftResolver { return &shiftResolver{r} }
+
+// StockReceipt returns generated.StockReceiptResolver implementation.
+func (r *Resolver) StockReceipt() generated.StockReceiptResolver { return &stockReceiptResolver{r} }
+
 // User returns generated.UserResolver implementation.
 func (r *Resolver) User() generated.UserResolver { return &userResolver{r} }
 
+type auditLogResolver struct{ *Resolver }
+type saleResolver struct{ *Resolver }
+type shiftResolver struct{ *Resolver }
+type stockReceiptResolver struct{ *Resolver }
 type userResolver struct{ *Resolver }
@@ -6,31 +6,49 @@ package graph
 
 import (
 	"context"
-	"fmt"
 
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
-	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/graph/generated"
 )
 
-// SearchUser is the resolver for the searchUser field.
-func (r *queryResolver) SearchUser(ctx context.Context, searchTerm string) ([]*domain.User, error) {
-	panic(fmt.Errorf("not implemented: SearchUser - searchUser"))
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (bool, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.User.DeleteUser(ctx, id)
+}
+
+// RestoreUser is the resolver for the restoreUser field.
+func (r *mutationResolver) RestoreUser(ctx context.Context, id string) (*domain.User, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.User.RestoreUser(ctx, id)
 }
 
-// Query returns generated.QueryResolver implementation.
-func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
+// FreezeUser is the resolver for the freezeUser field.
+func (r *mutationResolver) FreezeUser(ctx context.Context, id string, reason string) (*domain.User, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.User.FreezeUser(ctx, id, reason)
+}
 
-type queryResolver struct{ *Resolver }
+// UnfreezeUser is the resolver for the unfreezeUser field.
+func (r *mutationResolver) UnfreezeUser(ctx context.Context, id string) (*domain.User, error) {
+	r.checkPreconditions()
 
-// !!! WARNING !!!
-// The code below was going to be deleted when updating resolvers. It has been copied here so you have
-// one last chance to move it out of harms way if you want. There are two reasons this happens:
-//   - When renaming or deleting a resolver the old code will be put in here. You can safely delete
-//     it when you're done.
-//   - You have helper methods in this file. Move them out to keep these resolver files clean.
-func (r *mutationResolver) FreezeUser(ctx context.Context, userID string) (bool, error) {
-	panic(fmt.Errorf("not implemented: FreezeUser - freezeUser"))
+	return r.smartduka.User.UnfreezeUser(ctx, id)
 }
-func (r *mutationResolver) UnfreezeUser(ctx context.Context, userID string) (bool, error) {
-	panic(fmt.Errorf("not implemented: UnfreezeUser - unfreezeUser"))
+
+// SearchUser is the resolver for the searchUser field.
+func (r *queryResolver) SearchUser(ctx context.Context, searchTerm string) ([]*domain.User, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.User.SearchUser(ctx, searchTerm)
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, filter *dto.UserFilter, sort *dto.UserSort, first *int, after *string) (*domain.UserConnection, error) {
+	r.checkPreconditions()
+
+	return r.smartduka.User.ListUsers(ctx, filter, sort, first, after)
 }
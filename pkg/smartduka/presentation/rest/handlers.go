@@ -1,12 +1,22 @@
 package rest
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/tabular"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases"
 )
 
@@ -17,8 +27,23 @@ var AcceptedContentTypes = []string{"application/json", "application/x-www-form-
 type PresentationHandlers interface {
 	HandleLoginByPhone() gin.HandlerFunc
 	HandleRegistration() gin.HandlerFunc
+	SendRegistrationOTP() gin.HandlerFunc
 	SetUserPIN() gin.HandlerFunc
 	GetUserProfileByPhoneNumber() gin.HandlerFunc
+
+	ImportProducts() gin.HandlerFunc
+	ExportProducts() gin.HandlerFunc
+	ExportStock() gin.HandlerFunc
+	ExportSales() gin.HandlerFunc
+
+	GetReceipt() gin.HandlerFunc
+
+	ListProducts() gin.HandlerFunc
+	ListSales() gin.HandlerFunc
+	SearchProduct() gin.HandlerFunc
+
+	SyncPush() gin.HandlerFunc
+	SyncPull() gin.HandlerFunc
 }
 
 // PresentationHandlersImpl represents the usecase implementation object
@@ -38,11 +63,13 @@ func (p PresentationHandlersImpl) HandleRegistration() gin.HandlerFunc {
 		c.Accepted = append(c.Accepted, AcceptedContentTypes...)
 
 		payload := &dto.RegisterUserInput{}
-		utils.DecodeJSONToTargetStruct(c.Writer, c.Request, payload)
+		if err := utils.DecodeJSONToTargetStruct(c.Writer, c.Request, payload); err != nil {
+			return
+		}
 
 		_, err := p.usecases.User.RegisterUser(ctx, payload)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			utils.ReportErr(ctx, c.Writer, err)
 			return
 		}
 
@@ -52,6 +79,28 @@ func (p PresentationHandlersImpl) HandleRegistration() gin.HandlerFunc {
 	}
 }
 
+// SendRegistrationOTP sends the OTP that verifies the phone number of a user who is registering
+func (p PresentationHandlersImpl) SendRegistrationOTP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		c.Accepted = append(c.Accepted, AcceptedContentTypes...)
+
+		payload := &dto.RegistrationOTPInput{}
+		if err := utils.DecodeJSONToTargetStruct(c.Writer, c.Request, payload); err != nil {
+			return
+		}
+
+		if err := p.usecases.OTP.SendRegistrationOTP(ctx, payload); err != nil {
+			utils.ReportErr(ctx, c.Writer, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "Successfully sent the verification code",
+		})
+	}
+}
+
 // HandleRegistration handles the user registration
 func (p PresentationHandlersImpl) HandleLoginByPhone() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -59,19 +108,18 @@ func (p PresentationHandlersImpl) HandleLoginByPhone() gin.HandlerFunc {
 		c.Accepted = append(c.Accepted, AcceptedContentTypes...)
 
 		payload := &dto.LoginInput{}
-		utils.DecodeJSONToTargetStruct(c.Writer, c.Request, payload)
-		if payload.PhoneNumber == "" {
-			err := fmt.Errorf("phone number is required")
-			utils.ReportErr(c.Writer, err, http.StatusBadRequest)
+		if err := utils.DecodeJSONToTargetStruct(c.Writer, c.Request, payload); err != nil {
 			return
 		}
 
 		response, err := p.usecases.User.Login(ctx, payload)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			utils.ReportErr(ctx, c.Writer, err)
 			return
 		}
 
+		// the response carries the user's tokens
+		c.Header("Cache-Control", "no-store")
 		c.JSON(http.StatusOK, gin.H{
 			"status":   "Successfully logged in user",
 			"response": response,
@@ -86,16 +134,13 @@ func (p PresentationHandlersImpl) SetUserPIN() gin.HandlerFunc {
 		c.Accepted = append(c.Accepted, AcceptedContentTypes...)
 
 		payload := &dto.UserPINInput{}
-		utils.DecodeJSONToTargetStruct(c.Writer, c.Request, payload)
-		if payload.UserID == "" {
-			err := fmt.Errorf("user ID is required")
-			utils.ReportErr(c.Writer, err, http.StatusBadRequest)
+		if err := utils.DecodeJSONToTargetStruct(c.Writer, c.Request, payload); err != nil {
 			return
 		}
 
 		ok, err := p.usecases.User.SetUserPIN(ctx, payload)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			utils.ReportErr(ctx, c.Writer, err)
 			return
 		}
 
@@ -115,14 +160,13 @@ func (p PresentationHandlersImpl) GetUserProfileByPhoneNumber() gin.HandlerFunc
 		// get phone from query params and validate
 		phone := c.Query("phoneNumber")
 		if phone == "" {
-			err := fmt.Errorf("phone number is required")
-			utils.ReportErr(c.Writer, err, http.StatusBadRequest)
+			utils.ReportErr(ctx, c.Writer, domain.Errorf(domain.Validation, "phone number is required"))
 			return
 		}
 
 		users, err := p.usecases.User.SearchUserByPhoneNumber(ctx, phone)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			utils.ReportErr(ctx, c.Writer, err)
 			return
 		}
 
@@ -132,3 +176,399 @@ func (p PresentationHandlersImpl) GetUserProfileByPhoneNumber() gin.HandlerFunc
 		})
 	}
 }
+
+// ImportProducts handles the upload of a CSV or XLSX file of products.
+// Passing `dry_run=true` validates the file and previews the changes without saving them
+func (p PresentationHandlersImpl) ImportProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		maxSize, err := helpers.GetImportMaxSize()
+		if err != nil {
+			utils.ReportErr(ctx, c.Writer, err)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+		header, err := c.FormFile("file")
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.ReportErr(ctx, c.Writer, domain.WrapError(domain.Validation, err, fmt.Sprintf("the file cannot be larger than %d MB", maxSize>>20)))
+			return
+		}
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, domain.WrapError(domain.Validation, err, "a file is required"))
+			return
+		}
+
+		format, err := tabular.FormatFromFilename(header.Filename)
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		dryRun := false
+		if value := c.Query("dry_run"); value != "" {
+			dryRun, err = strconv.ParseBool(value)
+			if err != nil {
+				utils.ReportErr(c.Request.Context(), c.Writer, domain.Errorf(domain.Validation, "dry_run must be true or false"))
+				return
+			}
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+		defer file.Close()
+
+		result, err := p.usecases.Product.ImportProducts(ctx, format, file, dryRun)
+		if err != nil {
+			utils.ReportErr(ctx, c.Writer, err)
+			return
+		}
+
+		if len(result.Errors) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"status": "The file has errors and no products were imported",
+				"import": result,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "Successfully imported products",
+			"import": result,
+		})
+	}
+}
+
+// ExportProducts handles the download of all the products as a CSV or XLSX file
+func (p PresentationHandlersImpl) ExportProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := exportFormat(c)
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		setAttachmentHeaders(c, "products", format)
+		if err := p.usecases.Product.ExportProducts(c.Request.Context(), format, c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// ExportStock handles the download of the stock levels as a CSV or XLSX file
+func (p PresentationHandlersImpl) ExportStock() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := exportFormat(c)
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		setAttachmentHeaders(c, "stock", format)
+		if err := p.usecases.Product.ExportStock(c.Request.Context(), format, c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// ExportSales handles the download of the sales made between the `from` and `to` dates as a CSV or XLSX file.
+// Dates are either RFC 3339 timestamps or calendar dates in the shop's timezone. The `to` date is exclusive
+func (p PresentationHandlersImpl) ExportSales() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := exportFormat(c)
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		from, err := parseExportDate(c.Query("from"))
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, domain.WrapError(domain.Validation, err, "invalid from date"))
+			return
+		}
+
+		to, err := parseExportDate(c.Query("to"))
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, domain.WrapError(domain.Validation, err, "invalid to date"))
+			return
+		}
+
+		if !from.Before(to) {
+			utils.ReportErr(c.Request.Context(), c.Writer, domain.Errorf(domain.Validation, "the from date must be before the to date"))
+			return
+		}
+
+		setAttachmentHeaders(c, "sales", format)
+		if err := p.usecases.Product.ExportSales(c.Request.Context(), format, from, to, c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// GetReceipt handles the download of a sale's receipt. The `format` query parameter selects
+// ESC_POS_58MM or ESC_POS_80MM printer output, PDF or TEXT and defaults to PDF
+func (p PresentationHandlersImpl) GetReceipt() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := enums.ReceiptFormat(strings.ToUpper(c.DefaultQuery("format", enums.ReceiptFormatPDF.String())))
+		if !format.IsValid() {
+			utils.ReportErr(c.Request.Context(), c.Writer, domain.Errorf(domain.Validation, "invalid receipt format %q", c.Query("format")))
+			return
+		}
+
+		receipt, contentType, err := p.usecases.Product.RenderReceipt(c.Request.Context(), c.Param("id"), format)
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		c.Data(http.StatusOK, contentType, receipt)
+	}
+}
+
+// SyncPush handles a batch of sales, returns and deliveries recorded by a till while it was offline
+func (p PresentationHandlersImpl) SyncPush() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload := &dto.SyncPushInput{}
+		if err := json.NewDecoder(c.Request.Body).Decode(payload); err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, domain.WrapError(domain.Validation, err, "invalid sync payload"))
+			return
+		}
+
+		result, err := p.usecases.Sync.Push(c.Request.Context(), payload)
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// SyncPull handles a till's request for the changes made since the `cursor` returned by its last pull
+func (p PresentationHandlersImpl) SyncPull() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := 0
+		if value := c.Query("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil {
+				utils.ReportErr(c.Request.Context(), c.Writer, domain.WrapError(domain.Validation, err, "invalid limit"))
+				return
+			}
+		}
+
+		result, err := p.usecases.Sync.Pull(c.Request.Context(), c.Query("deviceID"), c.Query("cursor"), limit)
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// ListProducts handles a request for a page of products. The `search`, `active`, `category`, `min_price` and
+// `max_price` query parameters filter the products, `sort` and `order` pick the sort order and
+// `limit` and `cursor` pick the page
+func (p PresentationHandlersImpl) ListProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := queryInt(c, "limit")
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		active, err := queryBool(c, "active")
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		filter := &dto.ProductFilter{Active: active}
+		if search := c.Query("search"); search != "" {
+			filter.SearchTerm = &search
+		}
+		if value := c.Query("category"); value != "" {
+			category := enums.Category(strings.ToUpper(value))
+			filter.Category = &category
+		}
+		if filter.MinPrice, err = queryMoney(c, "min_price"); err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+		if filter.MaxPrice, err = queryMoney(c, "max_price"); err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		sortBy := &dto.ProductSort{
+			Field:     enums.ProductSortField(strings.ToUpper(c.DefaultQuery("sort", enums.ProductSortFieldName.String()))),
+			Direction: enums.SortDirection(strings.ToUpper(c.Query("order"))),
+		}
+
+		products, err := p.usecases.Product.ListProducts(c.Request.Context(), filter, sortBy, limit, queryString(c, "cursor"))
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, products)
+	}
+}
+
+// ListSales handles a request for a page of sales. The `from`, `to`, `product_id` and `payment_method` query
+// parameters filter the sales, `order` picks whether the newest or oldest come first and
+// `limit` and `cursor` pick the page
+func (p PresentationHandlersImpl) ListSales() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := queryInt(c, "limit")
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		filter := &dto.SaleFilter{ProductID: queryString(c, "product_id")}
+		if value := c.Query("from"); value != "" {
+			from, err := parseExportDate(value)
+			if err != nil {
+				utils.ReportErr(c.Request.Context(), c.Writer, domain.WrapError(domain.Validation, err, "invalid from date"))
+				return
+			}
+			filter.From = &from
+		}
+		if value := c.Query("to"); value != "" {
+			to, err := parseExportDate(value)
+			if err != nil {
+				utils.ReportErr(c.Request.Context(), c.Writer, domain.WrapError(domain.Validation, err, "invalid to date"))
+				return
+			}
+			filter.To = &to
+		}
+		if value := c.Query("payment_method"); value != "" {
+			method := enums.PaymentMethod(strings.ToUpper(value))
+			filter.PaymentMethod = &method
+		}
+
+		var direction *enums.SortDirection
+		if value := c.Query("order"); value != "" {
+			order := enums.SortDirection(strings.ToUpper(value))
+			direction = &order
+		}
+
+		sales, err := p.usecases.Product.ListSales(c.Request.Context(), filter, direction, limit, queryString(c, "cursor"))
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, sales)
+	}
+}
+
+// SearchProduct handles a till's lookup of the products matching the `q` query parameter as the cashier types it.
+// `limit` caps the number of products returned
+func (p PresentationHandlersImpl) SearchProduct() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := queryInt(c, "limit")
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		products, err := p.usecases.Product.SearchProduct(c.Request.Context(), c.Query("q"), limit)
+		if err != nil {
+			utils.ReportErr(c.Request.Context(), c.Writer, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, products)
+	}
+}
+
+// queryString reads an optional query parameter
+func queryString(c *gin.Context, name string) *string {
+	value := c.Query(name)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// queryInt reads an optional whole number query parameter
+func queryInt(c *gin.Context, name string) (*int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, domain.WrapError(domain.Validation, err, fmt.Sprintf("invalid %s", name))
+	}
+	return &parsed, nil
+}
+
+// queryBool reads an optional true or false query parameter
+func queryBool(c *gin.Context, name string) (*bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, domain.WrapError(domain.Validation, err, fmt.Sprintf("invalid %s", name))
+	}
+	return &parsed, nil
+}
+
+// queryMoney reads an optional amount in the default currency from a query parameter
+func queryMoney(c *gin.Context, name string) (*money.Money, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := money.Parse(value, money.DefaultCurrency)
+	if err != nil {
+		return nil, domain.WrapError(domain.Validation, err, fmt.Sprintf("invalid %s", name))
+	}
+	return &amount, nil
+}
+
+// exportFormat reads the `format` query parameter, defaulting to CSV
+func exportFormat(c *gin.Context) (enums.FileFormat, error) {
+	format := enums.FileFormat(strings.ToUpper(c.DefaultQuery("format", enums.FileFormatCSV.String())))
+	if !format.IsValid() {
+		return "", domain.Errorf(domain.Validation, "invalid format %q, expected csv or xlsx", c.Query("format"))
+	}
+	return format, nil
+}
+
+// parseExportDate reads an RFC 3339 timestamp or a calendar date in the shop's timezone
+func parseExportDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, domain.Errorf(domain.Validation, "a date is required")
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	location, err := helpers.GetShopLocation()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation("2006-01-02", value, location)
+}
+
+// setAttachmentHeaders marks the response as a file download
+func setAttachmentHeaders(c *gin.Context, name string, format enums.FileFormat) {
+	c.Header("Content-Type", tabular.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", tabular.Filename(name, format)))
+	c.Status(http.StatusOK)
+}
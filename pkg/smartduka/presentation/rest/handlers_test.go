@@ -1 +1,74 @@
 package rest_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/rest"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases"
+)
+
+func TestPresentationHandlersImpl_ImportProducts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv(common.ImportMaxSizeEnvVarName, "1")
+
+	tests := []struct {
+		name       string
+		filename   string
+		size       int
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "Sad case: a file larger than the limit is refused before it is read",
+			filename:   "products.csv",
+			size:       2 << 20,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "the file cannot be larger than 1 MB",
+		},
+		{
+			name:       "Sad case: a file of an unsupported type is refused",
+			filename:   "products.txt",
+			size:       10,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "unsupported file type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			form := multipart.NewWriter(body)
+			part, err := form.CreateFormFile("file", tt.filename)
+			if err != nil {
+				t.Fatalf("CreateFormFile() error = %v", err)
+			}
+			if _, err := part.Write([]byte(strings.Repeat("a", tt.size))); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := form.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			router := gin.New()
+			router.POST("/products/import", rest.NewPresentationHandlers(usecases.Smartduka{}).ImportProducts())
+
+			request := httptest.NewRequest(http.MethodPost, "/products/import", body)
+			request.Header.Set("Content-Type", form.FormDataContentType())
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("ImportProducts() status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantError) {
+				t.Errorf("ImportProducts() body = %s, want an error containing %q", recorder.Body.String(), tt.wantError)
+			}
+		})
+	}
+}
@@ -1,13 +1,34 @@
 package rest
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
 	"strings"
 
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/gin-gonic/gin"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/idempotency"
+)
+
+const (
+	// IdempotencyKeyHeader carries the key a client sends to make retrying a request safe
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on a response that was stored for an earlier request with the same key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// MaxIdempotentRequestSize is the largest request body read to tell retries apart
+	MaxIdempotentRequestSize = 10 << 20
 )
 
 // authCheckFn is a function type for authorization and authentication checks
@@ -17,13 +38,18 @@ type authCheckFn = func(
 	c *gin.Context,
 ) (bool, map[string]string, string)
 
+// SessionValidator checks that a valid token has not been revoked
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, token string) error
+}
+
 // AuthMiddleware is a gin middleware that checks if the request is authorized
 // and authenticated
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(sessions SessionValidator) gin.HandlerFunc {
 	// multiple checks will be run in sequence (order matters)
 	// the first check to succeed will call `c.Next()` and `return`
 	// this means that more permissive checks (e.g exceptions) should come first
-	checkFuncs := []authCheckFn{HasValidFirebaseBearerToken}
+	checkFuncs := []authCheckFn{HasValidSession(sessions)}
 
 	return func(c *gin.Context) {
 		errs := []map[string]string{}
@@ -66,6 +92,73 @@ func HasValidFirebaseBearerToken(c *gin.Context) (bool, map[string]string, strin
 	return true, nil, validatedToken.Token
 }
 
+// HasValidSession returns a check that passes a request whose bearer token is valid and has not been revoked.
+// The session is checked before the token is renewed since a renewed token is issued anew
+func HasValidSession(sessions SessionValidator) authCheckFn {
+	return func(c *gin.Context) (bool, map[string]string, string) {
+		bearerToken, err := ExtractBearerToken(c)
+		if err != nil {
+			return false, utils.ErrorMap(err), ""
+		}
+
+		if err := sessions.ValidateSession(c.Request.Context(), bearerToken); err != nil {
+			return false, utils.ErrorMap(err), ""
+		}
+
+		return HasValidFirebaseBearerToken(c)
+	}
+}
+
+// IsWebsocketHandshake reports whether a request is a handshake opening a websocket
+func IsWebsocketHandshake(r *http.Request) bool {
+	upgrade := false
+	for _, value := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			upgrade = upgrade || strings.EqualFold(strings.TrimSpace(token), "upgrade")
+		}
+	}
+
+	return upgrade &&
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		r.Header.Get("Sec-WebSocket-Key") != ""
+}
+
+// WebsocketHandshakeOr runs the middleware on every request except a websocket handshake. Browsers cannot set
+// headers on websocket requests, so GraphQL websockets are authenticated with their init payload by WebsocketAuth.
+// It must only be used on the GraphQL route since nothing else checks the init payload
+func WebsocketHandshakeOr(middleware gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsWebsocketHandshake(c.Request) {
+			c.Next()
+			return
+		}
+
+		middleware(c)
+	}
+}
+
+// WebsocketAuth authenticates a GraphQL websocket with the bearer token in the `Authorization` field of its
+// init payload. Every websocket has to send one since its handshake is let through without a token
+func WebsocketAuth(sessions SessionValidator) transport.WebsocketInitFunc {
+	return func(ctx context.Context, initPayload transport.InitPayload) (context.Context, error) {
+		bearerToken := strings.TrimSpace(strings.TrimPrefix(initPayload.Authorization(), "Bearer"))
+		if bearerToken == "" {
+			return nil, fmt.Errorf("expected an `Authorization` field in the connection init payload")
+		}
+
+		if err := sessions.ValidateSession(ctx, bearerToken); err != nil {
+			return nil, err
+		}
+
+		validatedToken, err := utils.ValidateJWTToken(bearerToken)
+		if err != nil {
+			return nil, err
+		}
+
+		return context.WithValue(ctx, common.AuthTokenContextKey, validatedToken.Token), nil
+	}
+}
+
 // ExtractBearerToken gets a bearer token from an Authorization header.
 //
 // This is expected to contain a Firebase idToken prefixed with "Bearer "
@@ -95,3 +188,126 @@ func ExtractToken(c *gin.Context, header string, prefix string) (string, error)
 	token := strings.TrimSpace(strings.TrimPrefix(authHeader, prefix))
 	return token, nil
 }
+
+// IdempotencyMiddleware is a gin middleware that makes retrying a mutating request with an Idempotency-Key header safe.
+// A retry gets the response of the first request instead of being processed again,
+// and reusing a key for a different request is refused with a 409.
+// It covers GraphQL mutations since they are posted to the GraphQL endpoint.
+// Responses marked `Cache-Control: no-store`, such as those carrying credentials, are never stored
+func IdempotencyMiddleware(usecase idempotency.UseCasesIdempotency) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxIdempotentRequestSize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.ReportErr(ctx, c.Writer, domain.WrapError(domain.Validation, err, "the request body is too large"))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			utils.ReportErr(ctx, c.Writer, domain.WrapError(domain.Validation, err, "failed to read request body"))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// a reused key or one still being processed is a conflict
+		stored, err := usecase.Begin(ctx, key, requestHash(c.Request, body))
+		switch {
+		case err != nil:
+			utils.ReportErr(ctx, c.Writer, err)
+			c.Abort()
+			return
+		case stored != nil:
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.ResponseStatus, stored.ResponseContentType, stored.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		completed := false
+		defer func() {
+			// a request that failed or panicked had no lasting effect so the key is freed for the retry
+			if !completed {
+				if err := usecase.Release(ctx, key); err != nil {
+					log.Printf("failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError || strings.Contains(recorder.Header().Get("Cache-Control"), "no-store") {
+			return
+		}
+
+		if err := usecase.Complete(ctx, key, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// isMutating reports whether a request made with the method can change data
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestHash identifies a request so that a key cannot be reused for a different one
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body as it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(data string) (int, error) {
+	r.body.WriteString(data)
+	return r.ResponseWriter.WriteString(data)
+}
+
+// RequestMetadataMiddleware is a gin middleware that records where a request came from on its context
+// so that the changes it makes can be traced in the audit log, together with the app that sent it
+// and the language errors are reported in
+func RequestMetadataMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		device := c.GetHeader(common.DeviceIDHeader)
+		if device == "" {
+			device = c.Request.UserAgent()
+		}
+
+		ctx := context.WithValue(c.Request.Context(), common.ClientIPContextKey, c.ClientIP())
+		ctx = context.WithValue(ctx, common.DeviceContextKey, device)
+		ctx = context.WithValue(ctx, common.FlavourContextKey, c.GetHeader(common.FlavourHeader))
+		ctx = context.WithValue(ctx, common.LanguageContextKey, domain.Language(c.GetHeader("Accept-Language")))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,175 @@
+package rest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gin-gonic/gin"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/presentation/rest"
+)
+
+// fakeIdempotency remembers what the middleware did with a key
+type fakeIdempotency struct {
+	completed bool
+	released  bool
+}
+
+func (f *fakeIdempotency) Begin(ctx context.Context, key string, requestHash string) (*domain.IdempotencyKey, error) {
+	return nil, nil
+}
+
+func (f *fakeIdempotency) Complete(ctx context.Context, key string, status int, contentType string, body []byte) error {
+	f.completed = true
+	return nil
+}
+
+func (f *fakeIdempotency) Release(ctx context.Context, key string) error {
+	f.released = true
+	return nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		body          string
+		handler       gin.HandlerFunc
+		wantStatus    int
+		wantHandled   bool
+		wantCompleted bool
+	}{
+		{
+			name:          "Happy case: the response is stored for retries",
+			body:          `{"name":"Unga"}`,
+			handler:       func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) },
+			wantStatus:    http.StatusOK,
+			wantHandled:   true,
+			wantCompleted: true,
+		},
+		{
+			name: "Happy case: a response carrying credentials is not stored",
+			body: `{"phoneNumber":"+254711223344"}`,
+			handler: func(c *gin.Context) {
+				c.Header("Cache-Control", "no-store")
+				c.JSON(http.StatusOK, gin.H{"token": "secret"})
+			},
+			wantStatus:  http.StatusOK,
+			wantHandled: true,
+		},
+		{
+			name:       "Sad case: a body too large to tell retries apart is refused",
+			body:       strings.Repeat("a", rest.MaxIdempotentRequestSize+1),
+			handler:    func(c *gin.Context) { c.Status(http.StatusOK) },
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := &fakeIdempotency{}
+			handled := false
+
+			router := gin.New()
+			router.POST("/", rest.IdempotencyMiddleware(usecase), func(c *gin.Context) {
+				handled = true
+				tt.handler(c)
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			request.Header.Set(rest.IdempotencyKeyHeader, "key")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("IdempotencyMiddleware() status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if handled != tt.wantHandled {
+				t.Errorf("IdempotencyMiddleware() handled = %v, want %v", handled, tt.wantHandled)
+			}
+			if usecase.completed != tt.wantCompleted {
+				t.Errorf("IdempotencyMiddleware() stored the response = %v, want %v", usecase.completed, tt.wantCompleted)
+			}
+			if tt.wantHandled && usecase.released == tt.wantCompleted {
+				t.Errorf("IdempotencyMiddleware() released the key = %v, want %v", usecase.released, !tt.wantCompleted)
+			}
+		})
+	}
+}
+
+// fakeSessions refuses the tokens of sessions that have ended
+type fakeSessions struct {
+	revoked string
+}
+
+func (f *fakeSessions) ValidateSession(ctx context.Context, token string) error {
+	if token == f.revoked {
+		return fmt.Errorf("this session has ended")
+	}
+	return nil
+}
+
+func TestWebsocketAuth(t *testing.T) {
+	valid, err := utils.GenerateJWTToken("cashier")
+	if err != nil {
+		t.Fatalf("GenerateJWTToken() error = %v", err)
+	}
+	revoked, err := utils.GenerateJWTToken("frozen")
+	if err != nil {
+		t.Fatalf("GenerateJWTToken() error = %v", err)
+	}
+	// a token the handshake might have carried is not trusted on its own
+	handshake := context.WithValue(context.Background(), common.AuthTokenContextKey, valid.Token)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		payload transport.InitPayload
+		wantErr bool
+	}{
+		{
+			name:    "Happy case: the init payload carries a valid token",
+			ctx:     context.Background(),
+			payload: transport.InitPayload{"Authorization": "Bearer " + valid.Token},
+		},
+		{
+			name:    "Sad case: a token on the handshake does not stand in for the init payload",
+			ctx:     handshake,
+			payload: transport.InitPayload{},
+			wantErr: true,
+		},
+		{
+			name:    "Sad case: the session has ended",
+			ctx:     context.Background(),
+			payload: transport.InitPayload{"Authorization": "Bearer " + revoked.Token},
+			wantErr: true,
+		},
+		{
+			name:    "Sad case: the token is not valid",
+			ctx:     context.Background(),
+			payload: transport.InitPayload{"Authorization": "Bearer invalid"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := rest.WebsocketAuth(&fakeSessions{revoked: revoked.Token})(tt.ctx, tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WebsocketAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if token, _ := ctx.Value(common.AuthTokenContextKey).(string); token == "" {
+				t.Errorf("WebsocketAuth() did not put the token on the context")
+			}
+		})
+	}
+}
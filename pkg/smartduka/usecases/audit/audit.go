@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/authorization"
+)
+
+const (
+	// DefaultLimit is the number of changes returned when the filter does not ask for a number
+	DefaultLimit = 100
+
+	// MaxLimit is the most changes returned at once
+	MaxLimit = 500
+)
+
+// UseCasesAudit represents the audit log of who changed what
+type UseCasesAudit interface {
+	ListAuditLogs(ctx context.Context, filter *dto.AuditLogFilter) ([]*domain.AuditLog, error)
+}
+
+// UseCasesAuditImpl represents the audit usecase implementation
+type UseCasesAuditImpl struct {
+	Query     datastore.Query
+	Extension extension.Extension
+}
+
+// NewUseCasesAudit initializes the new audit implementation
+func NewUseCasesAudit(
+	query datastore.Query,
+	extension extension.Extension,
+) UseCasesAudit {
+	return &UseCasesAuditImpl{
+		Query:     query,
+		Extension: extension,
+	}
+}
+
+// ListAuditLogs returns the changes matching the filter, most recent first. Only the shop owner can see the audit log
+func (a *UseCasesAuditImpl) ListAuditLogs(ctx context.Context, filter *dto.AuditLogFilter) ([]*domain.AuditLog, error) {
+	if _, err := authorization.CheckOwner(ctx, a.Query, a.Extension, "view the audit log"); err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		filter = &dto.AuditLogFilter{}
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, domain.Errorf(domain.Validation, "from must be before to")
+	}
+
+	limit := DefaultLimit
+	if filter.Limit != nil && *filter.Limit > 0 {
+		limit = *filter.Limit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	return a.Query.GetAuditLogs(ctx, filter, limit)
+}
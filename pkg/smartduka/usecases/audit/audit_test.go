@@ -0,0 +1,71 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/audit"
+)
+
+type fakeQuery struct {
+	datastore.Query
+
+	users    map[string]*domain.User
+	gotLimit int
+}
+
+func (f *fakeQuery) GetUserProfileByUserID(ctx context.Context, userID string) (*domain.User, error) {
+	return f.users[userID], nil
+}
+
+func (f *fakeQuery) GetAuditLogs(ctx context.Context, filter *dto.AuditLogFilter, limit int) ([]*domain.AuditLog, error) {
+	f.gotLimit = limit
+	return []*domain.AuditLog{{Entity: "smartduka_product"}}, nil
+}
+
+type fakeExtension struct {
+	extension.Extension
+	userID string
+}
+
+func (f *fakeExtension) GetLoggedInUserUID(ctx context.Context) (string, error) {
+	return f.userID, nil
+}
+
+func TestUseCasesAuditImpl_ListAuditLogs(t *testing.T) {
+	limit := 10000
+
+	tests := []struct {
+		name      string
+		userID    string
+		filter    *dto.AuditLogFilter
+		wantLimit int
+		wantErr   bool
+	}{
+		{name: "Happy case: the owner sees the audit log", userID: "owner", wantLimit: audit.DefaultLimit},
+		{name: "Happy case: the limit is capped", userID: "owner", filter: &dto.AuditLogFilter{Limit: &limit}, wantLimit: audit.MaxLimit},
+		{name: "Sad case: a cashier cannot see the audit log", userID: "cashier", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &fakeQuery{users: map[string]*domain.User{
+				"owner":   {ID: "owner", UserType: "ADMIN"},
+				"cashier": {ID: "cashier", UserType: "STAFF"},
+			}}
+			usecase := audit.NewUseCasesAudit(query, &fakeExtension{userID: tt.userID})
+
+			got, err := usecase.ListAuditLogs(context.Background(), tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UseCasesAuditImpl.ListAuditLogs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (len(got) != 1 || query.gotLimit != tt.wantLimit) {
+				t.Errorf("UseCasesAuditImpl.ListAuditLogs() got %d entries with limit %d, want limit %d", len(got), query.gotLimit, tt.wantLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package authorization
+
+import (
+	"context"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+)
+
+// CheckOwner returns the logged in user if they are the shop owner.
+// The action describes what is being attempted e.g `view the audit log` and is used in the error
+func CheckOwner(ctx context.Context, query datastore.Query, ext extension.Extension, action string) (*domain.User, error) {
+	loggedInUserID, err := ext.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := query.GetUserProfileByUserID(ctx, loggedInUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.UserType != common.AdminUserType {
+		return nil, domain.Errorf(domain.Forbidden, "only the shop owner can %s", action)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,73 @@
+package authorization_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/authorization"
+)
+
+type fakeQuery struct {
+	datastore.Query
+	users map[string]*domain.User
+}
+
+func (f *fakeQuery) GetUserProfileByUserID(ctx context.Context, userID string) (*domain.User, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return nil, domain.Errorf(domain.NotFound, "no user has this ID")
+	}
+	return user, nil
+}
+
+type fakeExtension struct {
+	extension.Extension
+	userID string
+}
+
+func (f *fakeExtension) GetLoggedInUserUID(ctx context.Context) (string, error) {
+	if f.userID == "" {
+		return "", domain.Errorf(domain.Unauthenticated, "the request is not authenticated")
+	}
+	return f.userID, nil
+}
+
+func TestCheckOwner(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   string
+		wantCode domain.ErrorCode
+	}{
+		{name: "Happy case: the shop owner", userID: "owner"},
+		{name: "Sad case: a cashier is not the owner", userID: "cashier", wantCode: domain.Forbidden},
+		{name: "Sad case: nobody is logged in", wantCode: domain.Unauthenticated},
+		{name: "Sad case: the logged in user does not exist", userID: "deleted user", wantCode: domain.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &fakeQuery{users: map[string]*domain.User{
+				"owner":   {ID: "owner", UserType: "ADMIN"},
+				"cashier": {ID: "cashier", UserType: "STAFF"},
+			}}
+
+			got, err := authorization.CheckOwner(context.Background(), query, &fakeExtension{userID: tt.userID}, "delete users")
+			if tt.wantCode != "" {
+				if got != nil || domain.ErrorCodeOf(err) != tt.wantCode {
+					t.Errorf("CheckOwner() = %v, %v, want a %s error", got, err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil || got.ID != tt.userID {
+				t.Errorf("CheckOwner() = %v, %v, want the owner", got, err)
+			}
+		})
+	}
+
+	_, err := authorization.CheckOwner(context.Background(), &fakeQuery{users: map[string]*domain.User{"cashier": {UserType: "STAFF"}}}, &fakeExtension{userID: "cashier"}, "view the audit log")
+	if err == nil || err.Error() != "only the shop owner can view the audit log" {
+		t.Errorf("CheckOwner() error = %v, want it to name the action", err)
+	}
+}
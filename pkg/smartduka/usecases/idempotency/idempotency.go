@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+)
+
+const (
+	// MaxKeyLength is the longest idempotency key accepted
+	MaxKeyLength = 255
+
+	// Lease is how long a key is held by a request before another request may take it over.
+	// It matches the server's timeouts so that a request cannot still be running once its lease has passed
+	Lease = 2 * time.Minute
+
+	// Retention is how long a response is kept for replay
+	Retention = 24 * time.Hour
+)
+
+var (
+	// ErrKeyReused is returned when a key is sent again with a different request
+	ErrKeyReused = domain.Errorf(domain.Conflict, "the idempotency key has already been used for a different request")
+
+	// ErrRequestInProgress is returned when a key is sent again while the first request is still being processed
+	ErrRequestInProgress = domain.Errorf(domain.Conflict, "a request with this idempotency key is still being processed")
+)
+
+// UseCasesIdempotency makes retrying a request safe.
+// The first request made with a key is processed and its response stored, retries with the same key get the stored response
+type UseCasesIdempotency interface {
+	Begin(ctx context.Context, key string, requestHash string) (*domain.IdempotencyKey, error)
+	Complete(ctx context.Context, key string, status int, contentType string, body []byte) error
+	Release(ctx context.Context, key string) error
+}
+
+// UseCasesIdempotencyImpl represents the idempotency usecase implementation
+type UseCasesIdempotencyImpl struct {
+	Create    datastore.Create
+	Update    datastore.Update
+	Extension extension.Extension
+}
+
+// NewUseCasesIdempotency initializes the new idempotency implementation
+func NewUseCasesIdempotency(
+	create datastore.Create,
+	update datastore.Update,
+	extension extension.Extension,
+) UseCasesIdempotency {
+	return &UseCasesIdempotencyImpl{
+		Create:    create,
+		Update:    update,
+		Extension: extension,
+	}
+}
+
+// Begin reserves a key for a request. It returns nil when the request should be processed,
+// or the completed key whose response should be replayed instead
+func (i *UseCasesIdempotencyImpl) Begin(ctx context.Context, key string, requestHash string) (*domain.IdempotencyKey, error) {
+	if key == "" || len(key) > MaxKeyLength {
+		return nil, domain.Errorf(domain.Validation, "the idempotency key must have between 1 and %d characters", MaxKeyLength)
+	}
+
+	now := time.Now().UTC()
+	stored, reserved, err := i.Create.ReserveIdempotencyKey(ctx, &domain.IdempotencyKey{
+		Scope:       i.scope(ctx),
+		Key:         key,
+		RequestHash: requestHash,
+		LockedUntil: now.Add(Lease),
+		ExpiresAt:   now.Add(Retention),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reserved {
+		return nil, nil
+	}
+
+	if stored.RequestHash != requestHash {
+		return nil, ErrKeyReused
+	}
+
+	if stored.CompletedAt == nil {
+		return nil, ErrRequestInProgress
+	}
+
+	return stored, nil
+}
+
+// Complete stores the response to a request so that retries get the same response
+func (i *UseCasesIdempotencyImpl) Complete(ctx context.Context, key string, status int, contentType string, body []byte) error {
+	now := time.Now().UTC()
+
+	return i.Update.CompleteIdempotencyKey(ctx, &domain.IdempotencyKey{
+		Scope:               i.scope(ctx),
+		Key:                 key,
+		CompletedAt:         &now,
+		ExpiresAt:           now.Add(Retention),
+		ResponseStatus:      status,
+		ResponseContentType: contentType,
+		ResponseBody:        body,
+	})
+}
+
+// Release frees the key of a request that failed so that it can be retried
+func (i *UseCasesIdempotencyImpl) Release(ctx context.Context, key string) error {
+	return i.Update.ReleaseIdempotencyKey(ctx, i.scope(ctx), key)
+}
+
+// scope keeps the keys of different users apart. Keys are only used once the user has signed in
+func (i *UseCasesIdempotencyImpl) scope(ctx context.Context) string {
+	userID, err := i.Extension.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return ""
+	}
+
+	return userID
+}
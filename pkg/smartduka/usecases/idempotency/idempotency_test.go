@@ -0,0 +1,121 @@
+package idempotency_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/idempotency"
+)
+
+// fakeStore keeps idempotency keys in memory the way the database does
+type fakeStore struct {
+	datastore.Create
+	datastore.Update
+
+	keys map[string]*domain.IdempotencyKey
+}
+
+func (f *fakeStore) ReserveIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error) {
+	id := key.Scope + "/" + key.Key
+	stored, ok := f.keys[id]
+	if ok && (stored.CompletedAt != nil || stored.LockedUntil.After(time.Now())) {
+		return stored, false, nil
+	}
+
+	f.keys[id] = key
+	return key, true, nil
+}
+
+func (f *fakeStore) CompleteIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) error {
+	stored, ok := f.keys[key.Scope+"/"+key.Key]
+	if !ok {
+		return fmt.Errorf("idempotency key not found")
+	}
+	stored.CompletedAt = key.CompletedAt
+	stored.ResponseStatus = key.ResponseStatus
+	stored.ResponseContentType = key.ResponseContentType
+	stored.ResponseBody = key.ResponseBody
+	return nil
+}
+
+func (f *fakeStore) ReleaseIdempotencyKey(ctx context.Context, scope string, key string) error {
+	delete(f.keys, scope+"/"+key)
+	return nil
+}
+
+type fakeExtension struct {
+	extension.Extension
+	userID string
+}
+
+func (f *fakeExtension) GetLoggedInUserUID(ctx context.Context) (string, error) {
+	if f.userID == "" {
+		return "", fmt.Errorf("the request is not authenticated")
+	}
+	return f.userID, nil
+}
+
+func TestUseCasesIdempotencyImpl_Begin(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{keys: map[string]*domain.IdempotencyKey{}}
+	cashier := idempotency.NewUseCasesIdempotency(store, store, &fakeExtension{userID: "cashier"})
+	anonymous := idempotency.NewUseCasesIdempotency(store, store, &fakeExtension{})
+
+	if _, err := cashier.Begin(ctx, "completed", "sale"); err != nil {
+		t.Fatalf("UseCasesIdempotencyImpl.Begin() error = %v", err)
+	}
+	if err := cashier.Complete(ctx, "completed", 200, "application/json", []byte(`{"id":"1"}`)); err != nil {
+		t.Fatalf("UseCasesIdempotencyImpl.Complete() error = %v", err)
+	}
+	if _, err := cashier.Begin(ctx, "running", "sale"); err != nil {
+		t.Fatalf("UseCasesIdempotencyImpl.Begin() error = %v", err)
+	}
+	if _, err := cashier.Begin(ctx, "failed", "sale"); err != nil {
+		t.Fatalf("UseCasesIdempotencyImpl.Begin() error = %v", err)
+	}
+	if err := cashier.Release(ctx, "failed"); err != nil {
+		t.Fatalf("UseCasesIdempotencyImpl.Release() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		usecase    idempotency.UseCasesIdempotency
+		key        string
+		hash       string
+		wantReplay bool
+		wantErr    error
+		wantAnyErr bool
+	}{
+		{name: "Happy case: a new key is processed", usecase: cashier, key: "new", hash: "sale"},
+		{name: "Happy case: a completed request is replayed", usecase: cashier, key: "completed", hash: "sale", wantReplay: true},
+		{name: "Happy case: a failed request can be retried", usecase: cashier, key: "failed", hash: "sale"},
+		{name: "Happy case: keys are scoped to the user", usecase: anonymous, key: "completed", hash: "registration"},
+		{name: "Sad case: a key reused for a different request", usecase: cashier, key: "completed", hash: "another sale", wantErr: idempotency.ErrKeyReused},
+		{name: "Sad case: a key whose request is still running", usecase: cashier, key: "running", hash: "sale", wantErr: idempotency.ErrRequestInProgress},
+		{name: "Sad case: an empty key", usecase: cashier, key: "", hash: "sale", wantAnyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.usecase.Begin(ctx, tt.key, tt.hash)
+			if tt.wantErr != nil && err != tt.wantErr {
+				t.Errorf("UseCasesIdempotencyImpl.Begin() error = %v, want %v", err, tt.wantErr)
+				return
+			}
+			if (err != nil) != (tt.wantErr != nil || tt.wantAnyErr) {
+				t.Errorf("UseCasesIdempotencyImpl.Begin() unexpected error = %v", err)
+				return
+			}
+			if (got != nil) != tt.wantReplay {
+				t.Errorf("UseCasesIdempotencyImpl.Begin() replay = %v, want %v", got != nil, tt.wantReplay)
+			}
+			if tt.wantReplay && string(got.ResponseBody) != `{"id":"1"}` {
+				t.Errorf("UseCasesIdempotencyImpl.Begin() response = %s", got.ResponseBody)
+			}
+		})
+	}
+}
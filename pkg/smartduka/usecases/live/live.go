@@ -0,0 +1,167 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/pubsub"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/authorization"
+	"github.com/sirupsen/logrus"
+)
+
+// Topics the events are published to
+const (
+	SaleCompletedTopic     = "sale_completed"
+	StockLevelChangedTopic = "stock_level_changed"
+	LowStockAlertTopic     = "low_stock_alert"
+)
+
+// UseCasesLive represents the events clients watch as they happen at the counter
+type UseCasesLive interface {
+	PublishSale(ctx context.Context, sale *domain.Sale) error
+	PublishStockLevel(ctx context.Context, productID string, sold money.Decimal) error
+
+	SaleCompleted(ctx context.Context) (<-chan *domain.Sale, error)
+	StockLevelChanged(ctx context.Context, productID *string) (<-chan *domain.StockLevel, error)
+	LowStockAlert(ctx context.Context) (<-chan *domain.LowStockAlert, error)
+}
+
+// UseCasesLiveImpl represents the live usecase implementation
+type UseCasesLiveImpl struct {
+	Query     datastore.Query
+	PubSub    pubsub.PubSub
+	Extension extension.Extension
+
+	// Threshold is the quantity at or below which a product is running out of stock
+	Threshold money.Decimal
+}
+
+// NewUseCasesLive initializes the new live implementation
+func NewUseCasesLive(
+	query datastore.Query,
+	pubSub pubsub.PubSub,
+	extension extension.Extension,
+	threshold money.Decimal,
+) UseCasesLive {
+	return &UseCasesLiveImpl{
+		Query:     query,
+		PubSub:    pubSub,
+		Extension: extension,
+		Threshold: threshold,
+	}
+}
+
+// PublishSale tells the subscribers that a sale or return has been recorded
+func (l *UseCasesLiveImpl) PublishSale(ctx context.Context, sale *domain.Sale) error {
+	return l.publish(ctx, SaleCompletedTopic, sale)
+}
+
+// PublishStockLevel tells the subscribers how much of a product is left after sold units of it left the shop.
+// A negative quantity sold is stock coming in. The owner is alerted when a sale takes the stock down to the threshold
+func (l *UseCasesLiveImpl) PublishStockLevel(ctx context.Context, productID string, sold money.Decimal) error {
+	product, err := l.Query.GetProductByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	err = l.publish(ctx, StockLevelChangedTopic, &domain.StockLevel{
+		ProductID: product.ID,
+		Name:      product.Name,
+		Quantity:  product.Quantity,
+		Unit:      product.Unit,
+		InStock:   product.InStock,
+	})
+	if err != nil {
+		return err
+	}
+
+	// alert once, when the stock crosses the threshold
+	before := product.Quantity.Add(sold)
+	if product.Quantity.Cmp(l.Threshold) > 0 || before.Cmp(l.Threshold) <= 0 {
+		return nil
+	}
+
+	return l.publish(ctx, LowStockAlertTopic, &domain.LowStockAlert{
+		ProductID: product.ID,
+		Name:      product.Name,
+		Quantity:  product.Quantity,
+		Threshold: l.Threshold,
+	})
+}
+
+// SaleCompleted receives the sales and returns recorded until ctx is done. Only the shop owner can watch sales
+func (l *UseCasesLiveImpl) SaleCompleted(ctx context.Context) (<-chan *domain.Sale, error) {
+	if _, err := authorization.CheckOwner(ctx, l.Query, l.Extension, "watch sales"); err != nil {
+		return nil, err
+	}
+
+	return subscribe[domain.Sale](ctx, l.PubSub, SaleCompletedTopic, nil)
+}
+
+// StockLevelChanged receives the stock levels of products as they change until ctx is done.
+// Passing a product ID only receives that product's stock levels
+func (l *UseCasesLiveImpl) StockLevelChanged(ctx context.Context, productID *string) (<-chan *domain.StockLevel, error) {
+	if _, err := l.Extension.GetLoggedInUserUID(ctx); err != nil {
+		return nil, err
+	}
+
+	return subscribe(ctx, l.PubSub, StockLevelChangedTopic, func(level *domain.StockLevel) bool {
+		return productID == nil || level.ProductID == *productID
+	})
+}
+
+// LowStockAlert receives an alert whenever a product is running out of stock until ctx is done.
+// Only the shop owner gets alerts
+func (l *UseCasesLiveImpl) LowStockAlert(ctx context.Context) (<-chan *domain.LowStockAlert, error) {
+	if _, err := authorization.CheckOwner(ctx, l.Query, l.Extension, "get low stock alerts"); err != nil {
+		return nil, err
+	}
+
+	return subscribe[domain.LowStockAlert](ctx, l.PubSub, LowStockAlertTopic, nil)
+}
+
+func (l *UseCasesLiveImpl) publish(ctx context.Context, topic string, event interface{}) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", topic, err)
+	}
+
+	return l.PubSub.Publish(ctx, topic, payload)
+}
+
+// subscribe decodes the events published to a topic, passing on the ones keep accepts
+func subscribe[T any](ctx context.Context, pubSub pubsub.PubSub, topic string, keep func(*T) bool) (<-chan *T, error) {
+	payloads, err := pubSub.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan *T)
+	go func() {
+		defer close(events)
+
+		for payload := range payloads {
+			event := new(T)
+			if err := json.Unmarshal(payload, event); err != nil {
+				logrus.Printf("failed to decode %s event: %v", topic, err)
+				continue
+			}
+			if keep != nil && !keep(event) {
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,108 @@
+package live_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/pubsub"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/live"
+)
+
+type fakeQuery struct {
+	datastore.Query
+
+	users    map[string]*domain.User
+	products map[string]*domain.Product
+}
+
+func (f *fakeQuery) GetUserProfileByUserID(ctx context.Context, userID string) (*domain.User, error) {
+	return f.users[userID], nil
+}
+
+func (f *fakeQuery) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	return f.products[id], nil
+}
+
+type fakeExtension struct {
+	extension.Extension
+	userID string
+}
+
+func (f *fakeExtension) GetLoggedInUserUID(ctx context.Context) (string, error) {
+	return f.userID, nil
+}
+
+func TestUseCasesLiveImpl_PublishStockLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    string
+		quantity  int64
+		sold      int64
+		wantAlert bool
+		wantErr   bool
+	}{
+		{name: "Happy case: a sale takes the stock down to the threshold", userID: "owner", quantity: 10, sold: 2, wantAlert: true},
+		{name: "Happy case: no alert while the stock is above the threshold", userID: "owner", quantity: 11, sold: 2},
+		{name: "Happy case: no second alert once the stock is low", userID: "owner", quantity: 5, sold: 2},
+		{name: "Happy case: no alert when stock is received", userID: "owner", quantity: 8, sold: -3},
+		{name: "Sad case: a cashier does not get low stock alerts", userID: "cashier", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			query := &fakeQuery{
+				users: map[string]*domain.User{
+					"owner":   {ID: "owner", UserType: "ADMIN"},
+					"cashier": {ID: "cashier", UserType: "STAFF"},
+				},
+				products: map[string]*domain.Product{
+					"sugar": {ID: "sugar", Name: "Sugar", Quantity: money.DecimalFromInt(tt.quantity)},
+				},
+			}
+			usecase := live.NewUseCasesLive(query, pubsub.NewInProcess(), &fakeExtension{userID: tt.userID}, money.DecimalFromInt(10))
+
+			alerts, err := usecase.LowStockAlert(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseCasesLiveImpl.LowStockAlert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			levels, err := usecase.StockLevelChanged(ctx, nil)
+			if err != nil {
+				t.Fatalf("UseCasesLiveImpl.StockLevelChanged() error = %v", err)
+			}
+
+			if err := usecase.PublishStockLevel(ctx, "sugar", money.DecimalFromInt(tt.sold)); err != nil {
+				t.Fatalf("UseCasesLiveImpl.PublishStockLevel() error = %v", err)
+			}
+
+			select {
+			case level := <-levels:
+				if level.ProductID != "sugar" || level.Quantity != money.DecimalFromInt(tt.quantity) {
+					t.Errorf("UseCasesLiveImpl.StockLevelChanged() = %s at %s, want sugar at %d", level.ProductID, level.Quantity, tt.quantity)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("UseCasesLiveImpl.StockLevelChanged() received nothing")
+			}
+
+			select {
+			case <-alerts:
+				if !tt.wantAlert {
+					t.Errorf("UseCasesLiveImpl.LowStockAlert() alerted, want no alert")
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantAlert {
+					t.Errorf("UseCasesLiveImpl.LowStockAlert() received nothing, want an alert")
+				}
+			}
+		})
+	}
+}
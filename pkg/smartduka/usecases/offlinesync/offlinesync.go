@@ -0,0 +1,316 @@
+package offlinesync
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/product"
+)
+
+const (
+	// MaxPushOperations is the most operations accepted in a single push
+	MaxPushOperations = 500
+
+	// DefaultPullLimit is the number of changes returned by a pull when the client does not ask for a number
+	DefaultPullLimit = 200
+
+	// MaxPullLimit is the most changes returned by a single pull
+	MaxPullLimit = 1000
+)
+
+// UseCasesSync represents the sync protocol used by tills that keep working while offline.
+// Clients push the sales and deliveries they recorded locally and pull the changes made on the server
+type UseCasesSync interface {
+	Push(ctx context.Context, input *dto.SyncPushInput) (*domain.SyncPush, error)
+	Pull(ctx context.Context, deviceID string, cursor string, limit int) (*domain.SyncPull, error)
+}
+
+// UseCasesSyncImpl represents the sync usecase implementation
+type UseCasesSyncImpl struct {
+	Create    datastore.Create
+	Query     datastore.Query
+	Extension extension.Extension
+	Product   product.UseCasesProduct
+}
+
+// NewUseCasesSync initializes the new sync implementation
+func NewUseCasesSync(
+	create datastore.Create,
+	query datastore.Query,
+	extension extension.Extension,
+	product product.UseCasesProduct,
+) UseCasesSync {
+	return &UseCasesSyncImpl{
+		Create:    create,
+		Query:     query,
+		Extension: extension,
+		Product:   product,
+	}
+}
+
+// Push applies a batch of operations recorded by an offline client in the order they were made.
+// Each operation is applied once: pushing it again returns the outcome recorded the first time.
+// An operation that cannot be applied is rejected without affecting the rest of the batch.
+// Sales that take stock below zero or are made at a stale price are applied but flagged for review
+func (s *UseCasesSyncImpl) Push(ctx context.Context, input *dto.SyncPushInput) (*domain.SyncPush, error) {
+	if input.DeviceID == "" {
+		return nil, domain.Errorf(domain.Validation, "a device ID is required")
+	}
+
+	if len(input.Operations) > MaxPushOperations {
+		return nil, domain.Errorf(domain.Validation, "a push cannot have more than %d operations", MaxPushOperations)
+	}
+
+	loggedInUserID, err := s.Extension.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := s.Create.SaveSyncDevice(ctx, &domain.SyncDevice{
+		ID:           input.DeviceID,
+		UserID:       loggedInUserID,
+		LastPushedAt: &now,
+	}); err != nil {
+		return nil, err
+	}
+
+	result := &domain.SyncPush{Operations: []*domain.SyncOperation{}}
+	for _, operation := range input.Operations {
+		applied, err := s.apply(ctx, input.DeviceID, operation)
+		if err != nil {
+			return nil, err
+		}
+		result.Operations = append(result.Operations, applied)
+	}
+
+	return result, nil
+}
+
+// Pull returns the products changed since the cursor, oldest change first.
+// An empty cursor starts from the beginning so that a new till can download the whole catalogue
+func (s *UseCasesSyncImpl) Pull(ctx context.Context, deviceID string, cursor string, limit int) (*domain.SyncPull, error) {
+	if deviceID == "" {
+		return nil, domain.Errorf(domain.Validation, "a device ID is required")
+	}
+
+	if limit <= 0 {
+		limit = DefaultPullLimit
+	}
+	if limit > MaxPullLimit {
+		limit = MaxPullLimit
+	}
+
+	since, afterID, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	loggedInUserID, err := s.Extension.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// one change more than asked for is fetched to tell whether there are more to pull
+	products, err := s.Query.GetProductChanges(ctx, since, afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.SyncPull{Products: products, Cursor: cursor}
+	if len(products) > limit {
+		result.Products = products[:limit]
+		result.HasMore = true
+	}
+	if len(result.Products) > 0 {
+		last := result.Products[len(result.Products)-1]
+		result.Cursor = encodeCursor(last.UpdatedAt, last.ID)
+	}
+
+	now := time.Now().UTC()
+	if err := s.Create.SaveSyncDevice(ctx, &domain.SyncDevice{
+		ID:           deviceID,
+		UserID:       loggedInUserID,
+		Cursor:       result.Cursor,
+		LastPulledAt: &now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// apply applies a single pushed operation unless it has been applied before
+func (s *UseCasesSyncImpl) apply(ctx context.Context, deviceID string, input *dto.SyncOperationInput) (*domain.SyncOperation, error) {
+	operation := &domain.SyncOperation{
+		ID:         input.ID,
+		DeviceID:   deviceID,
+		Type:       input.Type,
+		OccurredAt: occurredAt(input.OccurredAt),
+	}
+
+	if _, err := uuid.Parse(input.ID); err != nil {
+		operation.Status = enums.SyncOperationStatusRejected
+		operation.Message = "the operation ID must be a UUID"
+		return operation, nil
+	}
+
+	if recorded, err := s.Query.GetSyncOperation(ctx, input.ID); err == nil {
+		recorded.Replayed = true
+		return recorded, nil
+	}
+
+	if s.recordExists(ctx, input) {
+		// the operation was applied but its outcome was not recorded before the previous push ended
+		operation.Status = enums.SyncOperationStatusApplied
+		operation.RecordID = input.ID
+		return s.Create.SaveSyncOperation(ctx, operation)
+	}
+
+	recordID, conflict, err := s.applyOperation(ctx, input, operation.OccurredAt)
+	if err != nil {
+		if domain.ErrorCodeOf(err) == domain.Internal {
+			// the operation was not at fault, the push fails so that the till retries it
+			return nil, err
+		}
+
+		// rejected operations had no effect so they are not recorded and can be pushed again
+		operation.Status = enums.SyncOperationStatusRejected
+		operation.Message = domain.UserMessage(err, utils.GetLanguage(ctx))
+		return operation, nil
+	}
+
+	operation.Status = enums.SyncOperationStatusApplied
+	operation.RecordID = recordID
+	if conflict != "" {
+		operation.Status = enums.SyncOperationStatusFlagged
+		operation.Conflict = conflict
+	}
+
+	return s.Create.SaveSyncOperation(ctx, operation)
+}
+
+// applyOperation records the sale, return or delivery of an operation and checks it for conflicts
+func (s *UseCasesSyncImpl) applyOperation(ctx context.Context, input *dto.SyncOperationInput, at time.Time) (string, enums.SyncConflict, error) {
+	switch input.Type {
+	case enums.SyncOperationTypeSale, enums.SyncOperationTypeReturn:
+		if input.Sale == nil {
+			return "", "", domain.Errorf(domain.Validation, "a %s operation must include the sale", input.Type)
+		}
+
+		saleInput := *input.Sale
+		saleInput.ID = input.ID
+		saleInput.SoldAt = at
+
+		var sale *domain.Sale
+		var err error
+		if input.Type == enums.SyncOperationTypeSale {
+			sale, err = s.Product.RecordSale(ctx, &saleInput)
+		} else {
+			sale, err = s.Product.RecordReturn(ctx, &saleInput)
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		return sale.ID, s.saleConflict(ctx, sale, input.Type), nil
+
+	case enums.SyncOperationTypeStockReceipt:
+		if input.StockReceipt == nil {
+			return "", "", domain.Errorf(domain.Validation, "a %s operation must include the stock receipt", input.Type)
+		}
+
+		receiptInput := *input.StockReceipt
+		receiptInput.ID = input.ID
+		receiptInput.ReceivedAt = at
+
+		receipt, err := s.Product.ReceiveStock(ctx, &receiptInput)
+		if err != nil {
+			return "", "", err
+		}
+
+		return receipt.ID, "", nil
+	}
+
+	return "", "", domain.Errorf(domain.Validation, "invalid operation type: %s", input.Type)
+}
+
+// saleConflict checks a sale made offline against the product as it is on the server
+func (s *UseCasesSyncImpl) saleConflict(ctx context.Context, sale *domain.Sale, operationType enums.SyncOperationType) enums.SyncConflict {
+	product, err := s.Query.GetProductByID(ctx, sale.ProductID)
+	if err != nil {
+		return ""
+	}
+
+	if product.Quantity.IsNegative() {
+		return enums.SyncConflictNegativeStock
+	}
+
+	if operationType == enums.SyncOperationTypeSale {
+		if cmp, err := sale.Price.Cmp(product.Price); err != nil || cmp != 0 {
+			return enums.SyncConflictPriceMismatch
+		}
+	}
+
+	return ""
+}
+
+// recordExists checks whether the sale or delivery created by an operation is already on the server
+func (s *UseCasesSyncImpl) recordExists(ctx context.Context, input *dto.SyncOperationInput) bool {
+	switch input.Type {
+	case enums.SyncOperationTypeSale, enums.SyncOperationTypeReturn:
+		_, err := s.Query.GetSaleByID(ctx, input.ID)
+		return err == nil
+	case enums.SyncOperationTypeStockReceipt:
+		_, err := s.Query.GetStockReceiptByID(ctx, input.ID)
+		return err == nil
+	}
+	return false
+}
+
+// occurredAt is when an operation was made on the till. Times in the future are taken to be a wrong clock
+func occurredAt(at time.Time) time.Time {
+	now := time.Now().UTC()
+	if at.IsZero() || at.After(now) {
+		return now
+	}
+	return at.UTC()
+}
+
+// encodeCursor records the last change a client has pulled
+func encodeCursor(updatedAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(updatedAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+// decodeCursor reads a cursor returned by an earlier pull. An empty cursor starts from the beginning
+func decodeCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Time{}, "", nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", domain.Errorf(domain.Validation, "invalid cursor")
+	}
+
+	updatedAt, id, found := strings.Cut(string(decoded), "|")
+	if !found {
+		return time.Time{}, "", domain.Errorf(domain.Validation, "invalid cursor")
+	}
+
+	since, err := time.Parse(time.RFC3339Nano, updatedAt)
+	if err != nil {
+		return time.Time{}, "", domain.Errorf(domain.Validation, "invalid cursor")
+	}
+
+	return since, id, nil
+}
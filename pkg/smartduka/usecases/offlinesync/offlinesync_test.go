@@ -0,0 +1,243 @@
+package offlinesync_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/offlinesync"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/product"
+)
+
+// fakeStore keeps just enough state in memory to exercise the sync rules
+type fakeStore struct {
+	datastore.Create
+	datastore.Query
+
+	products   map[string]*domain.Product
+	sales      map[string]*domain.Sale
+	operations map[string]*domain.SyncOperation
+	devices    map[string]*domain.SyncDevice
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		products: map[string]*domain.Product{
+			"panadol": {ID: "panadol", Name: "Panadol", Quantity: money.DecimalFromInt(1), Price: money.MustParse("50.00", money.CurrencyKES), UpdatedAt: time.Date(2023, 7, 1, 8, 0, 0, 0, time.UTC)},
+			"unga":    {ID: "unga", Name: "Unga", Quantity: money.DecimalFromInt(10), Price: money.MustParse("210.00", money.CurrencyKES), UpdatedAt: time.Date(2023, 7, 2, 8, 0, 0, 0, time.UTC)},
+		},
+		sales:      map[string]*domain.Sale{},
+		operations: map[string]*domain.SyncOperation{},
+		devices:    map[string]*domain.SyncDevice{},
+	}
+}
+
+type fakeExtension struct {
+	extension.Extension
+}
+
+func (f *fakeExtension) GetLoggedInUserUID(ctx context.Context) (string, error) {
+	return "cashier", nil
+}
+
+// fakeProduct records sales in the fake store without checking the stock, as the product usecase does
+type fakeProduct struct {
+	product.UseCasesProduct
+	store *fakeStore
+}
+
+func (p *fakeProduct) RecordSale(ctx context.Context, input *dto.SaleInput) (*domain.Sale, error) {
+	if input.ProductID == "unreachable" {
+		return nil, fmt.Errorf("failed to get product %s: connection refused", input.ProductID)
+	}
+	product, ok := p.store.products[input.ProductID]
+	if !ok {
+		return nil, domain.Errorf(domain.NotFound, "the product does not exist")
+	}
+	product.Quantity = product.Quantity.Sub(input.Quantity)
+
+	sale := &domain.Sale{ID: input.ID, ProductID: input.ProductID, Quantity: input.Quantity, Price: input.Price, SoldAt: input.SoldAt}
+	p.store.sales[sale.ID] = sale
+	return sale, nil
+}
+
+func (f *fakeStore) GetSaleByID(ctx context.Context, id string) (*domain.Sale, error) {
+	if sale, ok := f.sales[id]; ok {
+		return sale, nil
+	}
+	return nil, fmt.Errorf("sale %s not found", id)
+}
+
+func (f *fakeStore) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	if product, ok := f.products[id]; ok {
+		return product, nil
+	}
+	return nil, fmt.Errorf("product %s not found", id)
+}
+
+func (f *fakeStore) GetSyncOperation(ctx context.Context, id string) (*domain.SyncOperation, error) {
+	if operation, ok := f.operations[id]; ok {
+		stored := *operation
+		return &stored, nil
+	}
+	return nil, fmt.Errorf("sync operation %s not found", id)
+}
+
+func (f *fakeStore) SaveSyncOperation(ctx context.Context, operation *domain.SyncOperation) (*domain.SyncOperation, error) {
+	if _, ok := f.operations[operation.ID]; !ok {
+		f.operations[operation.ID] = operation
+	}
+	return f.GetSyncOperation(ctx, operation.ID)
+}
+
+func (f *fakeStore) SaveSyncDevice(ctx context.Context, device *domain.SyncDevice) error {
+	f.devices[device.ID] = device
+	return nil
+}
+
+func (f *fakeStore) GetProductChanges(ctx context.Context, since time.Time, afterID string, limit int) ([]*domain.Product, error) {
+	changes := []*domain.Product{}
+	for _, id := range []string{"panadol", "unga"} {
+		product := f.products[id]
+		if product.UpdatedAt.After(since) || (product.UpdatedAt.Equal(since) && product.ID > afterID) {
+			changes = append(changes, product)
+		}
+	}
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, nil
+}
+
+func newSync(store *fakeStore) offlinesync.UseCasesSync {
+	return offlinesync.NewUseCasesSync(store, store, &fakeExtension{}, &fakeProduct{store: store})
+}
+
+func saleOperation(id string, productID string, quantity int64, price string) *dto.SyncOperationInput {
+	return &dto.SyncOperationInput{
+		ID:         id,
+		Type:       enums.SyncOperationTypeSale,
+		OccurredAt: time.Date(2023, 7, 14, 15, 45, 0, 0, time.UTC),
+		Sale: &dto.SaleInput{
+			ProductID: productID,
+			Quantity:  money.DecimalFromInt(quantity),
+			Price:     money.MustParse(price, money.CurrencyKES),
+		},
+	}
+}
+
+func TestUseCasesSyncImpl_Push(t *testing.T) {
+	store := newFakeStore()
+	sync := newSync(store)
+	ctx := context.Background()
+
+	applied, negative, stale, missing := uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()
+	operations := []*dto.SyncOperationInput{
+		saleOperation(applied, "unga", 1, "210.00"),
+		saleOperation(negative, "panadol", 2, "50.00"),
+		saleOperation(stale, "unga", 1, "200.00"),
+		saleOperation(missing, "unknown", 1, "10.00"),
+		saleOperation("not-a-uuid", "unga", 1, "210.00"),
+	}
+
+	got, err := sync.Push(ctx, &dto.SyncPushInput{DeviceID: "till-1", Operations: operations})
+	if err != nil {
+		t.Fatalf("UseCasesSyncImpl.Push() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		operation    *domain.SyncOperation
+		wantStatus   enums.SyncOperationStatus
+		wantConflict enums.SyncConflict
+		wantMessage  string
+	}{
+		{name: "sale is applied", operation: got.Operations[0], wantStatus: enums.SyncOperationStatusApplied},
+		{name: "sale taking stock below zero is flagged", operation: got.Operations[1], wantStatus: enums.SyncOperationStatusFlagged, wantConflict: enums.SyncConflictNegativeStock},
+		{name: "sale at a stale price is flagged", operation: got.Operations[2], wantStatus: enums.SyncOperationStatusFlagged, wantConflict: enums.SyncConflictPriceMismatch},
+		{name: "sale of an unknown product is rejected", operation: got.Operations[3], wantStatus: enums.SyncOperationStatusRejected, wantMessage: "the product does not exist"},
+		{name: "operation without a UUID is rejected", operation: got.Operations[4], wantStatus: enums.SyncOperationStatusRejected, wantMessage: "the operation ID must be a UUID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.operation.Status != tt.wantStatus {
+				t.Errorf("UseCasesSyncImpl.Push() status = %v, want %v", tt.operation.Status, tt.wantStatus)
+			}
+			if tt.operation.Conflict != tt.wantConflict {
+				t.Errorf("UseCasesSyncImpl.Push() conflict = %v, want %v", tt.operation.Conflict, tt.wantConflict)
+			}
+			if tt.operation.Message != tt.wantMessage {
+				t.Errorf("UseCasesSyncImpl.Push() message = %q, want %q", tt.operation.Message, tt.wantMessage)
+			}
+		})
+	}
+
+	if _, ok := store.operations[missing]; ok {
+		t.Errorf("UseCasesSyncImpl.Push() recorded a rejected operation")
+	}
+	if store.devices["till-1"] == nil {
+		t.Errorf("UseCasesSyncImpl.Push() did not record the device")
+	}
+
+	// pushing the same batch again must not record the sales twice
+	stock := store.products["unga"].Quantity
+	replayed, err := sync.Push(ctx, &dto.SyncPushInput{DeviceID: "till-1", Operations: operations[:3]})
+	if err != nil {
+		t.Fatalf("UseCasesSyncImpl.Push() error = %v", err)
+	}
+	for i, operation := range replayed.Operations {
+		if !operation.Replayed || operation.Status != got.Operations[i].Status {
+			t.Errorf("UseCasesSyncImpl.Push() replay = %+v, want the recorded outcome %+v", operation, got.Operations[i])
+		}
+	}
+	if store.products["unga"].Quantity.Cmp(stock) != 0 {
+		t.Errorf("UseCasesSyncImpl.Push() replay changed the stock from %v to %v", stock, store.products["unga"].Quantity)
+	}
+
+	// an operation that fails through no fault of its own is pushed again rather than rejected
+	failed := uuid.NewString()
+	if _, err := sync.Push(ctx, &dto.SyncPushInput{DeviceID: "till-1", Operations: []*dto.SyncOperationInput{saleOperation(failed, "unreachable", 1, "10.00")}}); err == nil {
+		t.Errorf("UseCasesSyncImpl.Push() expected an error when the sale could not be recorded")
+	}
+}
+
+func TestUseCasesSyncImpl_Pull(t *testing.T) {
+	sync := newSync(newFakeStore())
+	ctx := context.Background()
+
+	first, err := sync.Pull(ctx, "till-1", "", 1)
+	if err != nil {
+		t.Fatalf("UseCasesSyncImpl.Pull() error = %v", err)
+	}
+	if len(first.Products) != 1 || first.Products[0].ID != "panadol" || !first.HasMore {
+		t.Errorf("UseCasesSyncImpl.Pull() first page = %+v", first)
+	}
+
+	second, err := sync.Pull(ctx, "till-1", first.Cursor, 1)
+	if err != nil {
+		t.Fatalf("UseCasesSyncImpl.Pull() error = %v", err)
+	}
+	if len(second.Products) != 1 || second.Products[0].ID != "unga" || second.HasMore {
+		t.Errorf("UseCasesSyncImpl.Pull() second page = %+v", second)
+	}
+
+	last, err := sync.Pull(ctx, "till-1", second.Cursor, 1)
+	if err != nil {
+		t.Fatalf("UseCasesSyncImpl.Pull() error = %v", err)
+	}
+	if len(last.Products) != 0 || last.Cursor != second.Cursor {
+		t.Errorf("UseCasesSyncImpl.Pull() expected no more changes, got %+v", last)
+	}
+
+	if _, err := sync.Pull(ctx, "till-1", "not a cursor", 1); err == nil {
+		t.Errorf("UseCasesSyncImpl.Pull() expected an error for an invalid cursor")
+	}
+}
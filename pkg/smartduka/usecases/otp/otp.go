@@ -6,12 +6,13 @@ import (
 	"time"
 
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/validation"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
-	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -21,6 +22,7 @@ const (
 // UseCasesOTP contain all the method required for OTP delivery
 type UseCasesOTP interface {
 	GenerateAndSendOTP(ctx context.Context, phoneNumber string, flavour enums.Flavour) (string, error)
+	SendRegistrationOTP(ctx context.Context, input *dto.RegistrationOTPInput) error
 }
 
 // UseCasesOTPImpl represents the user otp usecase implementation
@@ -47,7 +49,7 @@ func NewUseCaseOTP(
 func (o *UseCasesOTPImpl) GenerateAndSendOTP(ctx context.Context, phoneNumber string, flavour enums.Flavour) (string, error) {
 	validatePhoneNumber, err := helpers.NormalizeMSISDN(phoneNumber)
 	if err != nil {
-		return "", err
+		return "", domain.WrapError(domain.Validation, err, "invalid phone number")
 	}
 
 	userProfile, err := o.Query.GetUserProfileByPhoneNumber(ctx, *validatePhoneNumber, flavour)
@@ -55,34 +57,60 @@ func (o *UseCasesOTPImpl) GenerateAndSendOTP(ctx context.Context, phoneNumber st
 		return "", err
 	}
 
+	if !userProfile.Active {
+		return "", domain.Errorf(domain.Forbidden, "your account has been frozen, please contact the shop owner")
+	}
+
 	if !flavour.IsValid() {
-		return "", fmt.Errorf("invalid flavour")
+		return "", domain.Errorf(domain.Validation, "invalid flavour")
 	}
 
-	otp, err := utils.GenerateOTP()
+	return o.sendOTP(ctx, *validatePhoneNumber, flavour, userProfile.ID)
+}
+
+// SendRegistrationOTP sends an OTP to a phone number that is about to be registered. Users prove that they own
+// the phone number by registering with the OTP. The OTP is only sent by SMS so it is not returned
+func (o *UseCasesOTPImpl) SendRegistrationOTP(ctx context.Context, input *dto.RegistrationOTPInput) error {
+	if err := validation.Validate(input); err != nil {
+		return err
+	}
+
+	phoneNumber, err := helpers.NormalizeMSISDN(input.PhoneNumber)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate an OTP")
+		return domain.WrapError(domain.Validation, err, "invalid phone number")
 	}
 
-	var message string
-	switch flavour {
-	case enums.FlavourConsumer:
-		message = fmt.Sprintf("Your %v verification code is %s", appName, otp)
-	case enums.FlavourPro:
-		message = fmt.Sprintf("Your %v verification code is %s", appName, otp)
+	_, err = o.Query.GetUserProfileByPhoneNumber(ctx, *phoneNumber, input.Flavour)
+	switch {
+	case err == nil:
+		return &domain.Error{
+			Code:    domain.Conflict,
+			Message: "this user is already registered",
+			Fields:  []domain.FieldError{{Field: "phone_number", Message: "has already been registered"}},
+		}
+	case domain.ErrorCodeOf(err) != domain.NotFound:
+		return err
 	}
 
-	// TODO: 1. Implement send sms logic here
-	logrus.Print("OTP MESSAGE: ", message)
+	_, err = o.sendOTP(ctx, *phoneNumber, input.Flavour, "")
+	return err
+}
+
+// sendOTP generates an OTP, saves it and sends it to the phone number by SMS
+func (o *UseCasesOTPImpl) sendOTP(ctx context.Context, phoneNumber string, flavour enums.Flavour, userID string) (string, error) {
+	otp, err := utils.GenerateOTP()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate an OTP")
+	}
 
 	otpData := &domain.OTP{
 		IsValid:     true,
 		ValidUntil:  time.Now().Add(time.Minute * 5),
-		PhoneNumber: *validatePhoneNumber,
+		PhoneNumber: phoneNumber,
 		OTP:         otp,
 		Flavour:     flavour,
 		Medium:      "SMS",
-		UserID:      userProfile.ID,
+		UserID:      userID,
 	}
 
 	// Save the OTP to the database
@@ -91,5 +119,10 @@ func (o *UseCasesOTPImpl) GenerateAndSendOTP(ctx context.Context, phoneNumber st
 		return "", err
 	}
 
+	message := fmt.Sprintf("Your %v verification code is %s", appName, otp)
+	if err := o.Ext.SendSMS(ctx, phoneNumber, message); err != nil {
+		return "", err
+	}
+
 	return otp, nil
 }
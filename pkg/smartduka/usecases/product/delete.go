@@ -0,0 +1,53 @@
+package product
+
+import (
+	"context"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/authorization"
+)
+
+// DeleteProduct removes a product from the catalogue. Its past sales are kept
+func (p *UseCasesProductImpl) DeleteProduct(ctx context.Context, productID string) (bool, error) {
+	if _, err := authorization.CheckOwner(ctx, p.Query, p.Extension, "delete products"); err != nil {
+		return false, err
+	}
+
+	if err := p.Delete.DeleteProduct(ctx, productID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RestoreProduct brings back a deleted product
+func (p *UseCasesProductImpl) RestoreProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	if _, err := authorization.CheckOwner(ctx, p.Query, p.Extension, "restore products"); err != nil {
+		return nil, err
+	}
+
+	return p.Update.RestoreProduct(ctx, productID)
+}
+
+// DeleteSale removes a sale that was recorded in error and puts the goods back in stock.
+// Sales that have been reported to KRA or closed in a Z-report need a return instead
+func (p *UseCasesProductImpl) DeleteSale(ctx context.Context, saleID string) (bool, error) {
+	if _, err := authorization.CheckOwner(ctx, p.Query, p.Extension, "delete sales"); err != nil {
+		return false, err
+	}
+
+	if err := p.Delete.DeleteSale(ctx, saleID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RestoreSale brings back a deleted sale and takes the goods out of stock again
+func (p *UseCasesProductImpl) RestoreSale(ctx context.Context, saleID string) (*domain.Sale, error) {
+	if _, err := authorization.CheckOwner(ctx, p.Query, p.Extension, "restore sales"); err != nil {
+		return nil, err
+	}
+
+	return p.Update.RestoreSale(ctx, saleID)
+}
@@ -0,0 +1,331 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/tabular"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+const (
+	// maxImportRows is the largest number of products that can be imported from a single file
+	maxImportRows = 5000
+
+	// maxExportDays is the longest period sales can be exported for
+	maxExportDays = 366 * 2
+)
+
+// productColumns are the columns of a product file. Products are exported with the same columns they are imported with
+var productColumns = []string{"sku", "name", "category", "quantity", "unit", "price", "cost_price", "vat", "description", "manufacturer"}
+
+// requiredProductColumns must be present in an imported file
+var requiredProductColumns = []string{"name", "category", "unit", "price"}
+
+// ImportProducts creates or updates the products in a CSV or XLSX file. A product is matched by its SKU,
+// or by its name when it has no SKU. Every row is validated first and nothing is saved when any row has an error.
+// A dry run previews what would be created or updated without saving anything
+func (p *UseCasesProductImpl) ImportProducts(ctx context.Context, format enums.FileFormat, file io.Reader, dryRun bool) (*domain.ProductImport, error) {
+	reader, err := tabular.NewReader(format, file)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, domain.WrapError(domain.Validation, err, "failed to read the header row")
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")] = i
+	}
+	for _, name := range requiredProductColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, domain.Errorf(domain.Validation, "the file is missing the %q column", name)
+		}
+	}
+
+	result := &domain.ProductImport{DryRun: dryRun, Errors: []*domain.ImportRowError{}}
+	products := []*domain.Product{}
+	seen := map[string]int{}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, domain.WrapError(domain.Validation, err, "failed to read the file")
+		}
+		rowNumber := reader.Line()
+
+		if isBlankRow(row) {
+			continue
+		}
+
+		result.Rows++
+		if result.Rows > maxImportRows {
+			return nil, domain.Errorf(domain.Validation, "a file cannot have more than %d products", maxImportRows)
+		}
+
+		product, rowErrors := parseProductRow(rowNumber, row, columns)
+		if product.Name != "" {
+			column, key := "name", "name:"+strings.ToLower(product.Name)
+			if product.SKU != "" {
+				column, key = "sku", "sku:"+product.SKU
+			}
+			if first, ok := seen[key]; ok {
+				rowErrors = append(rowErrors, &domain.ImportRowError{
+					Row:     rowNumber,
+					Column:  column,
+					Message: fmt.Sprintf("duplicates the product on row %d", first),
+				})
+			}
+			seen[key] = rowNumber
+		}
+
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		products = append(products, product)
+	}
+
+	if len(result.Errors) > 0 || len(products) == 0 {
+		return result, nil
+	}
+
+	loggedInUserID, err := p.Extension.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Created, result.Updated, err = p.Create.ImportProducts(ctx, products, loggedInUserID, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ExportProducts writes every product to a CSV or XLSX file that can be edited and imported again
+func (p *UseCasesProductImpl) ExportProducts(ctx context.Context, format enums.FileFormat, w io.Writer) error {
+	writer, err := tabular.NewWriter(format, w, "Products")
+	if err != nil {
+		return err
+	}
+
+	if err := writer.Write(productColumns); err != nil {
+		return err
+	}
+
+	err = p.Query.StreamProducts(ctx, func(product *domain.Product) error {
+		return writer.Write([]string{
+			product.SKU,
+			product.Name,
+			product.Category,
+			product.Quantity.String(),
+			product.Unit,
+			product.Price.String(),
+			product.CostPrice.String(),
+			product.VAT.String(),
+			product.Description,
+			product.Manufacturer,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// ExportStock writes the stock level and value of every product to a CSV or XLSX file
+func (p *UseCasesProductImpl) ExportStock(ctx context.Context, format enums.FileFormat, w io.Writer) error {
+	writer, err := tabular.NewWriter(format, w, "Stock")
+	if err != nil {
+		return err
+	}
+
+	if err := writer.Write([]string{"sku", "name", "category", "quantity", "unit", "cost_price", "stock_value", "in_stock"}); err != nil {
+		return err
+	}
+
+	err = p.Query.StreamProducts(ctx, func(product *domain.Product) error {
+		return writer.Write([]string{
+			product.SKU,
+			product.Name,
+			product.Category,
+			product.Quantity.String(),
+			product.Unit,
+			product.CostPrice.String(),
+			product.CostPrice.Mul(product.Quantity).String(),
+			fmt.Sprint(product.InStock),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// ExportSales writes the sales made in the period [from, to) to a CSV or XLSX file.
+// Sale times are written in the shop's timezone
+func (p *UseCasesProductImpl) ExportSales(ctx context.Context, format enums.FileFormat, from, to time.Time, w io.Writer) error {
+	if !from.Before(to) {
+		return domain.Errorf(domain.Validation, "the start of the export period must be before its end")
+	}
+
+	if to.Sub(from) > maxExportDays*24*time.Hour {
+		return domain.Errorf(domain.Validation, "sales cannot be exported for more than %d days at a time", maxExportDays)
+	}
+
+	location, err := helpers.GetShopLocation()
+	if err != nil {
+		return err
+	}
+
+	writer, err := tabular.NewWriter(format, w, "Sales")
+	if err != nil {
+		return err
+	}
+
+	header := []string{"sold_at", "sale_id", "product", "category", "quantity", "unit", "price", "discount", "total", "cost_of_goods", "payment_method", "cashier", "shift_id"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err = p.Query.StreamSales(ctx, from, to, func(sale *domain.SaleExport) error {
+		total, err := sale.Price.Mul(sale.Quantity).Sub(sale.Discount)
+		if err != nil {
+			return err
+		}
+
+		return writer.Write([]string{
+			sale.SoldAt.In(location).Format(time.RFC3339),
+			sale.ID,
+			sale.ProductName,
+			sale.ProductCategory,
+			sale.Quantity.String(),
+			sale.Unit,
+			sale.Price.String(),
+			sale.Discount.String(),
+			total.String(),
+			sale.CostOfGoods.String(),
+			sale.PaymentMethod.String(),
+			sale.CashierName,
+			sale.ShiftID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// parseProductRow converts a row of an imported file into a product and reports any invalid values
+func parseProductRow(rowNumber int, row []string, columns map[string]int) (*domain.Product, []*domain.ImportRowError) {
+	rowErrors := []*domain.ImportRowError{}
+	fail := func(column, message string) {
+		rowErrors = append(rowErrors, &domain.ImportRowError{Row: rowNumber, Column: column, Message: message})
+	}
+	value := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	enumValue := func(column string) string {
+		return strings.ReplaceAll(strings.ToUpper(value(column)), " ", "_")
+	}
+
+	product := &domain.Product{
+		SKU:          value("sku"),
+		Name:         value("name"),
+		Description:  value("description"),
+		Manufacturer: value("manufacturer"),
+	}
+
+	if product.Name == "" {
+		fail("name", "name is required")
+	}
+
+	category := enums.Category(enumValue("category"))
+	if !category.IsValid() {
+		fail("category", fmt.Sprintf("%q is not a valid category", value("category")))
+	}
+	product.Category = category.String()
+
+	unit := enums.Unit(enumValue("unit"))
+	if !unit.IsValid() {
+		fail("unit", fmt.Sprintf("%q is not a valid unit", value("unit")))
+	}
+	product.Unit = unit.String()
+
+	if quantity := value("quantity"); quantity != "" {
+		parsed, err := money.ParseDecimal(quantity)
+		switch {
+		case err != nil:
+			fail("quantity", err.Error())
+		case parsed.IsNegative():
+			fail("quantity", "quantity cannot be negative")
+		}
+		product.Quantity = parsed
+	}
+	product.InStock = !product.Quantity.IsZero() && !product.Quantity.IsNegative()
+
+	price, err := money.Parse(value("price"), money.DefaultCurrency)
+	switch {
+	case err != nil:
+		fail("price", err.Error())
+	case price.IsNegative():
+		fail("price", "price cannot be negative")
+	}
+	product.Price = price
+
+	product.CostPrice = money.Zero(money.DefaultCurrency)
+	if costPrice := value("cost_price"); costPrice != "" {
+		parsed, err := money.Parse(costPrice, money.DefaultCurrency)
+		switch {
+		case err != nil:
+			fail("cost_price", err.Error())
+		case parsed.IsNegative():
+			fail("cost_price", "cost price cannot be negative")
+		}
+		product.CostPrice = parsed
+	}
+
+	if vat := value("vat"); vat != "" {
+		parsed, err := money.ParseDecimal(strings.TrimSuffix(vat, "%"))
+		switch {
+		case err != nil:
+			fail("vat", err.Error())
+		case parsed.IsNegative() || parsed.Cmp(money.DecimalFromInt(100)) > 0:
+			fail("vat", "vat must be a percentage between 0 and 100")
+		}
+		product.VAT = parsed
+	}
+
+	return product, rowErrors
+}
+
+// isBlankRow returns true if every cell of a row is empty
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package product_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/product"
+)
+
+func TestUseCasesProductImpl_ImportProducts_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		wantErr    bool
+		wantErrors map[int]string
+	}{
+		{
+			name:    "Sad case: missing required column",
+			file:    "name,category,unit\nPanadol,MEDICINE,ONE\n",
+			wantErr: true,
+		},
+		{
+			name: "Sad case: invalid rows are reported",
+			file: "sku,name,category,unit,price,vat\n" +
+				"P1,Panadol,MEDICINE,ONE,760.00,16\n" +
+				"P2,,DRINKS,ONE,abc,\n" +
+				"\n" +
+				"P1,Panadol Extra,medicine,dozen,10.005,120\n",
+			wantErrors: map[int]string{
+				3: "name,category,price",
+				5: "price,vat,sku",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// rows with errors are rejected before anything is saved so no datastore is needed
+			p := product.NewUseCasesProduct(nil, nil, nil, nil, nil, nil, nil)
+
+			got, err := p.ImportProducts(context.Background(), enums.FileFormatCSV, strings.NewReader(tt.file), true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UseCasesProductImpl.ImportProducts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			columns := map[int][]string{}
+			for _, rowError := range got.Errors {
+				columns[rowError.Row] = append(columns[rowError.Row], rowError.Column)
+			}
+			for row, want := range tt.wantErrors {
+				if strings.Join(columns[row], ",") != want {
+					t.Errorf("row %d errors = %v, want %v", row, columns[row], want)
+				}
+			}
+			if len(columns) != len(tt.wantErrors) {
+				t.Errorf("got errors on rows %v, want %v", columns, tt.wantErrors)
+			}
+			if got.Rows != 3 {
+				t.Errorf("UseCasesProductImpl.ImportProducts() rows = %v, want 3", got.Rows)
+			}
+		})
+	}
+}
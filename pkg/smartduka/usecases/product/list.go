@@ -0,0 +1,98 @@
+package product
+
+import (
+	"context"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// ListProducts returns a page of the products matching the filter. Products are sorted by name unless asked otherwise
+func (p *UseCasesProductImpl) ListProducts(ctx context.Context, filter *dto.ProductFilter, sortBy *dto.ProductSort, first *int, after *string) (*domain.ProductConnection, error) {
+	if filter == nil {
+		filter = &dto.ProductFilter{}
+	}
+
+	if filter.Category != nil && !filter.Category.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid category: %s", *filter.Category)
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MinPrice.Amount > filter.MaxPrice.Amount {
+		return nil, domain.Errorf(domain.Validation, "the minimum price cannot be more than the maximum price")
+	}
+
+	order := dto.ProductSort{Field: enums.ProductSortFieldName, Direction: enums.SortDirectionAsc}
+	if sortBy != nil {
+		order = *sortBy
+	}
+	if !order.Field.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid sort field: %s", order.Field)
+	}
+	if order.Direction == "" {
+		order.Direction = enums.SortDirectionAsc
+	}
+	if !order.Direction.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid sort direction: %s", order.Direction)
+	}
+
+	limit, cursor, err := pagination.Args(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Query.ListProducts(ctx, filter, order, cursor, limit)
+}
+
+// ListSales returns a page of the sales matching the filter, the most recent first unless asked otherwise
+func (p *UseCasesProductImpl) ListSales(ctx context.Context, filter *dto.SaleFilter, direction *enums.SortDirection, first *int, after *string) (*domain.SaleConnection, error) {
+	if filter == nil {
+		filter = &dto.SaleFilter{}
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, domain.Errorf(domain.Validation, "from must be before to")
+	}
+
+	if filter.PaymentMethod != nil && !filter.PaymentMethod.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid payment method: %s", *filter.PaymentMethod)
+	}
+
+	order := enums.SortDirectionDesc
+	if direction != nil {
+		order = *direction
+	}
+	if !order.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid sort direction: %s", order)
+	}
+
+	limit, cursor, err := pagination.Args(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Query.ListSales(ctx, filter, order, cursor, limit)
+}
+
+// SearchProduct finds the active products matching what a cashier typed, best matches first.
+// Each word typed matches the start of a word in a product's name, manufacturer or description and
+// names spelt close to the term match too
+func (p *UseCasesProductImpl) SearchProduct(ctx context.Context, searchTerm string, first *int) ([]*domain.Product, error) {
+	limit, err := pagination.Limit(first)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Query.SearchProduct(ctx, searchTerm, limit)
+}
+
+// GetProductsByIDs returns the products with the IDs, including deleted ones so that past sales can name them
+func (p *UseCasesProductImpl) GetProductsByIDs(ctx context.Context, ids []string) ([]*domain.Product, error) {
+	return p.Query.GetProductsByIDs(ctx, ids)
+}
+
+// GetSalesByShiftIDs returns the sales made during the shifts with the IDs in the order they were made
+func (p *UseCasesProductImpl) GetSalesByShiftIDs(ctx context.Context, shiftIDs []string) ([]*domain.Sale, error) {
+	return p.Query.GetSalesByShiftIDs(ctx, shiftIDs)
+}
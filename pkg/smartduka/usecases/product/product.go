@@ -1 +1,237 @@
 package product
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/validation"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/live"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/taxinvoice"
+	"github.com/sirupsen/logrus"
+)
+
+// UseCasesProduct represents the stock and sales business logic
+type UseCasesProduct interface {
+	ReceiveStock(ctx context.Context, input *dto.StockReceiptInput) (*domain.StockReceipt, error)
+	RecordSale(ctx context.Context, input *dto.SaleInput) (*domain.Sale, error)
+	RecordReturn(ctx context.Context, input *dto.SaleInput) (*domain.Sale, error)
+
+	GetReceipt(ctx context.Context, saleID string) (*domain.Receipt, error)
+	RenderReceipt(ctx context.Context, saleID string, format enums.ReceiptFormat) ([]byte, string, error)
+
+	ImportProducts(ctx context.Context, format enums.FileFormat, file io.Reader, dryRun bool) (*domain.ProductImport, error)
+	ExportProducts(ctx context.Context, format enums.FileFormat, w io.Writer) error
+	ExportStock(ctx context.Context, format enums.FileFormat, w io.Writer) error
+	ExportSales(ctx context.Context, format enums.FileFormat, from, to time.Time, w io.Writer) error
+
+	DeleteProduct(ctx context.Context, productID string) (bool, error)
+	RestoreProduct(ctx context.Context, productID string) (*domain.Product, error)
+	DeleteSale(ctx context.Context, saleID string) (bool, error)
+	RestoreSale(ctx context.Context, saleID string) (*domain.Sale, error)
+
+	ListProducts(ctx context.Context, filter *dto.ProductFilter, sortBy *dto.ProductSort, first *int, after *string) (*domain.ProductConnection, error)
+	ListSales(ctx context.Context, filter *dto.SaleFilter, direction *enums.SortDirection, first *int, after *string) (*domain.SaleConnection, error)
+	SearchProduct(ctx context.Context, searchTerm string, first *int) ([]*domain.Product, error)
+	GetProductsByIDs(ctx context.Context, ids []string) ([]*domain.Product, error)
+	GetSalesByShiftIDs(ctx context.Context, shiftIDs []string) ([]*domain.Sale, error)
+}
+
+// UseCasesProductImpl represents the product usecase implementation
+type UseCasesProductImpl struct {
+	Create     datastore.Create
+	Query      datastore.Query
+	Update     datastore.Update
+	Delete     datastore.Delete
+	Extension  extension.Extension
+	TaxInvoice taxinvoice.UseCasesTaxInvoice
+	Live       live.UseCasesLive
+}
+
+// NewUseCasesProduct initializes the new product implementation
+func NewUseCasesProduct(
+	create datastore.Create,
+	query datastore.Query,
+	update datastore.Update,
+	delete datastore.Delete,
+	extension extension.Extension,
+	taxInvoice taxinvoice.UseCasesTaxInvoice,
+	live live.UseCasesLive,
+) UseCasesProduct {
+	return &UseCasesProductImpl{
+		Create:     create,
+		Query:      query,
+		Update:     update,
+		Delete:     delete,
+		Extension:  extension,
+		TaxInvoice: taxInvoice,
+		Live:       live,
+	}
+}
+
+// ReceiveStock records a delivery of stock. The product's quantity and cost price are updated with it
+func (p *UseCasesProductImpl) ReceiveStock(ctx context.Context, input *dto.StockReceiptInput) (*domain.StockReceipt, error) {
+	if err := validation.Validate(input); err != nil {
+		return nil, err
+	}
+
+	if input.Quantity.IsZero() || input.Quantity.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "stock received must have a quantity greater than zero")
+	}
+
+	if input.UnitCost.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "unit cost cannot be negative")
+	}
+
+	loggedInUserID, err := p.Extension.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := p.Create.AddStockReceipt(ctx, &domain.StockReceipt{
+		ID:         input.ID,
+		ReceivedAt: input.ReceivedAt,
+		ProductID:  input.ProductID,
+		Quantity:   input.Quantity,
+		UnitCost:   input.UnitCost,
+		Supplier:   input.Supplier,
+		ReceivedBy: loggedInUserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Live.PublishStockLevel(ctx, receipt.ProductID, money.DecimalFromInt(0).Sub(receipt.Quantity)); err != nil {
+		logrus.Printf("failed to publish the stock level of product %s: %v", receipt.ProductID, err)
+	}
+
+	return receipt, nil
+}
+
+// RecordSale records the sale of a product during the cashier's open shift.
+// The cost of the goods sold is worked out using the shop's costing method
+func (p *UseCasesProductImpl) RecordSale(ctx context.Context, input *dto.SaleInput) (*domain.Sale, error) {
+	if err := validation.Validate(input); err != nil {
+		return nil, err
+	}
+
+	if input.Quantity.IsZero() || input.Quantity.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "sale must have a quantity greater than zero")
+	}
+
+	if input.Price.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "sale price cannot be negative")
+	}
+
+	if input.Discount.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "discount cannot be negative")
+	}
+
+	exceeds, err := input.Discount.Cmp(input.Price.Mul(input.Quantity))
+	if err != nil {
+		return nil, err
+	}
+	if exceeds > 0 {
+		return nil, domain.Errorf(domain.Validation, "discount cannot be more than the value of the sale")
+	}
+
+	return p.recordSaleLine(ctx, input, input.Quantity)
+}
+
+// RecordReturn records goods returned by a customer during the cashier's open shift.
+// The return is kept as a sale line with a negative quantity so that the goods go back into stock
+func (p *UseCasesProductImpl) RecordReturn(ctx context.Context, input *dto.SaleInput) (*domain.Sale, error) {
+	if err := validation.Validate(input); err != nil {
+		return nil, err
+	}
+
+	if input.Quantity.IsZero() || input.Quantity.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "return must have a quantity greater than zero")
+	}
+
+	if input.Price.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "return price cannot be negative")
+	}
+
+	if !input.Discount.IsZero() {
+		return nil, domain.Errorf(domain.Validation, "a return cannot be discounted")
+	}
+
+	return p.recordSaleLine(ctx, input, money.DecimalFromInt(0).Sub(input.Quantity))
+}
+
+// recordSaleLine saves a sale line against the logged in cashier's open shift.
+// When the shop submits its sales to eTIMS the sale is submitted straight away so that its receipt
+// carries the control unit invoice number. A sale that cannot be submitted is retried from the outbox
+func (p *UseCasesProductImpl) recordSaleLine(ctx context.Context, input *dto.SaleInput, quantity money.Decimal) (*domain.Sale, error) {
+	paymentMethod := input.PaymentMethod
+	if paymentMethod == "" {
+		paymentMethod = enums.PaymentMethodCash
+	}
+	if !paymentMethod.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid payment method: %s", paymentMethod)
+	}
+
+	costing, err := helpers.GetCostingMethod()
+	if err != nil {
+		return nil, err
+	}
+
+	loggedInUserID, err := p.Extension.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shift, err := p.Query.GetOpenShift(ctx, loggedInUserID)
+	if domain.ErrorCodeOf(err) == domain.NotFound {
+		return nil, domain.WrapError(domain.Conflict, err, "open a shift before recording sales")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sale := &domain.Sale{
+		ID:            input.ID,
+		SoldAt:        input.SoldAt,
+		ProductID:     input.ProductID,
+		Quantity:      quantity,
+		Unit:          input.Unit.String(),
+		Price:         input.Price,
+		Discount:      input.Discount,
+		PaymentMethod: paymentMethod,
+		ShiftID:       shift.ID,
+		SoldBy:        loggedInUserID,
+	}
+	if p.TaxInvoice.Enabled() {
+		// the outbox leaves the invoice alone while it is submitted below
+		sale.TaxInvoice = &domain.TaxInvoice{NextAttemptAt: time.Now().UTC().Add(taxinvoice.ClaimLease)}
+	}
+
+	recorded, err := p.Create.AddSaleRecord(ctx, sale, costing)
+	if err != nil {
+		return nil, err
+	}
+
+	if recorded.TaxInvoice != nil {
+		if _, err := p.TaxInvoice.SubmitInvoice(ctx, recorded.TaxInvoice); err != nil {
+			logrus.Printf("sale %s was not submitted to eTIMS and will be retried: %v", recorded.ID, err)
+		}
+	}
+
+	// the sale stands even when watchers cannot be told about it
+	if err := p.Live.PublishSale(ctx, recorded); err != nil {
+		logrus.Printf("failed to publish sale %s: %v", recorded.ID, err)
+	}
+	if err := p.Live.PublishStockLevel(ctx, recorded.ProductID, recorded.Quantity); err != nil {
+		logrus.Printf("failed to publish the stock level of product %s: %v", recorded.ProductID, err)
+	}
+
+	return recorded, nil
+}
@@ -0,0 +1,85 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/receipt"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+)
+
+// GetReceipt builds the receipt of a completed sale
+func (p *UseCasesProductImpl) GetReceipt(ctx context.Context, saleID string) (*domain.Receipt, error) {
+	shop, err := helpers.GetShopDetails()
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := helpers.GetShopLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	sale, err := p.Query.GetSaleByID(ctx, saleID)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := p.Query.GetProductByID(ctx, sale.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product sold: %w", err)
+	}
+
+	cashier := ""
+	if sale.SoldBy != "" {
+		user, err := p.Query.GetUserProfileByUserID(ctx, sale.SoldBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cashier: %w", err)
+		}
+		cashier = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+
+	verificationURL := ""
+	if baseURL := os.Getenv(common.ReceiptVerificationURLEnvVarName); baseURL != "" {
+		verificationURL = strings.TrimSuffix(baseURL, "/") + "/" + sale.ID
+	}
+
+	return receipt.Build(&domain.Receipt{
+		Number:   sale.ID,
+		IssuedAt: sale.SoldAt.In(location),
+		Shop:     *shop,
+		Cashier:  cashier,
+		Lines: []*domain.ReceiptLine{
+			{
+				Description: product.Name,
+				Quantity:    sale.Quantity,
+				Unit:        sale.Unit,
+				UnitPrice:   sale.Price,
+				Discount:    sale.Discount,
+				VATRate:     product.VAT,
+			},
+		},
+		PaymentMethod:   sale.PaymentMethod,
+		VerificationURL: verificationURL,
+		TaxInvoice:      sale.TaxInvoice,
+	})
+}
+
+// RenderReceipt produces the receipt of a completed sale in the requested format together with its content type
+func (p *UseCasesProductImpl) RenderReceipt(ctx context.Context, saleID string, format enums.ReceiptFormat) ([]byte, string, error) {
+	if !format.IsValid() {
+		return nil, "", domain.Errorf(domain.Validation, "invalid receipt format: %s", format)
+	}
+
+	built, err := p.GetReceipt(ctx, saleID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return receipt.Render(built, format)
+}
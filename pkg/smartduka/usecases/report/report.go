@@ -0,0 +1,198 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+)
+
+const (
+	// defaultTopProductsLimit is the number of products returned when no limit is given
+	defaultTopProductsLimit = 10
+
+	// maxTopProductsLimit is the largest number of products that can be requested
+	maxTopProductsLimit = 100
+
+	// maxReportDays is the longest period a report can cover
+	maxReportDays = 366 * 2
+)
+
+// UseCasesReport contains the sales reporting methods
+type UseCasesReport interface {
+	GetDailySale(ctx context.Context) ([]*domain.Sale, error)
+	GetSalesSummary(ctx context.Context, from, to time.Time, interval enums.ReportInterval) ([]*domain.SalesSummary, error)
+	GetSalesBreakdown(ctx context.Context, from, to time.Time, grouping enums.SalesGrouping) ([]*domain.SalesBreakdown, error)
+	GetTopProducts(ctx context.Context, from, to time.Time, ranking enums.ProductRanking, limit *int) ([]*domain.ProductSales, error)
+	GetHourlySales(ctx context.Context, from, to time.Time) ([]*domain.HourlySales, error)
+	GetProfitReport(ctx context.Context, from, to time.Time, grouping enums.ProfitGrouping) ([]*domain.ProfitSummary, error)
+}
+
+// UseCasesReportImpl represents the report usecase implementation
+type UseCasesReportImpl struct {
+	Query datastore.Query
+}
+
+// NewUseCasesReport initializes the new report implementation
+func NewUseCasesReport(query datastore.Query) UseCasesReport {
+	return &UseCasesReportImpl{
+		Query: query,
+	}
+}
+
+// GetDailySale returns the sales made today in the shop's timezone
+func (r *UseCasesReportImpl) GetDailySale(ctx context.Context) ([]*domain.Sale, error) {
+	location, err := helpers.GetShopLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Query.GetDailySale(ctx, location)
+}
+
+// GetSalesSummary returns the sales totals for each calendar day, week or month in the period [from, to)
+func (r *UseCasesReportImpl) GetSalesSummary(ctx context.Context, from, to time.Time, interval enums.ReportInterval) ([]*domain.SalesSummary, error) {
+	if !interval.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid report interval: %s", interval)
+	}
+
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+
+	location, err := helpers.GetShopLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Query.GetSalesSummary(ctx, from, to, interval, location)
+}
+
+// GetSalesBreakdown returns the sales totals in the period [from, to) for each cashier, product category or payment method
+func (r *UseCasesReportImpl) GetSalesBreakdown(ctx context.Context, from, to time.Time, grouping enums.SalesGrouping) ([]*domain.SalesBreakdown, error) {
+	if !grouping.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid sales grouping: %s", grouping)
+	}
+
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+
+	return r.Query.GetSalesBreakdown(ctx, from, to, grouping)
+}
+
+// GetTopProducts returns the best selling products in the period [from, to)
+func (r *UseCasesReportImpl) GetTopProducts(ctx context.Context, from, to time.Time, ranking enums.ProductRanking, limit *int) ([]*domain.ProductSales, error) {
+	if !ranking.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid product ranking: %s", ranking)
+	}
+
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+
+	count := defaultTopProductsLimit
+	if limit != nil {
+		count = *limit
+	}
+	if count < 1 || count > maxTopProductsLimit {
+		return nil, domain.Errorf(domain.Validation, "limit must be between 1 and %d", maxTopProductsLimit)
+	}
+
+	return r.Query.GetTopProducts(ctx, from, to, ranking, count)
+}
+
+// GetHourlySales returns a heatmap of the sales made in the period [from, to).
+// Every hour of every day of the week is present, including those with no sales
+func (r *UseCasesReportImpl) GetHourlySales(ctx context.Context, from, to time.Time) ([]*domain.HourlySales, error) {
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+
+	location, err := helpers.GetShopLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := r.Query.GetHourlySales(ctx, from, to, location)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := map[[2]int]*domain.HourlySales{}
+	for _, record := range records {
+		recorded[[2]int{record.DayOfWeek, record.Hour}] = record
+	}
+
+	heatmap := []*domain.HourlySales{}
+	for day := 1; day <= 7; day++ {
+		for hour := 0; hour < 24; hour++ {
+			if record, ok := recorded[[2]int{day, hour}]; ok {
+				heatmap = append(heatmap, record)
+				continue
+			}
+
+			heatmap = append(heatmap, &domain.HourlySales{
+				DayOfWeek: day,
+				Hour:      hour,
+				Revenue:   money.Zero(money.DefaultCurrency),
+			})
+		}
+	}
+
+	return heatmap, nil
+}
+
+// GetProfitReport returns the gross profit and margin made in the period [from, to) on each product,
+// category or calendar period. Sales made below cost are counted so that they can be investigated
+func (r *UseCasesReportImpl) GetProfitReport(ctx context.Context, from, to time.Time, grouping enums.ProfitGrouping) ([]*domain.ProfitSummary, error) {
+	if !grouping.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid profit grouping: %s", grouping)
+	}
+
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+
+	location, err := helpers.GetShopLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := r.Query.GetProfitReport(ctx, from, to, grouping, location)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, summary := range report {
+		summary.GrossProfit, err = summary.Revenue.Sub(summary.CostOfGoods)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate gross profit for %s: %w", summary.Label, err)
+		}
+
+		summary.Margin, err = money.Percentage(summary.GrossProfit, summary.Revenue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate margin for %s: %w", summary.Label, err)
+		}
+	}
+
+	return report, nil
+}
+
+// validatePeriod ensures that a report period is not empty or unreasonably long
+func validatePeriod(from, to time.Time) error {
+	if !from.Before(to) {
+		return domain.Errorf(domain.Validation, "the start of the report period must be before its end")
+	}
+
+	if to.Sub(from) > maxReportDays*24*time.Hour {
+		return domain.Errorf(domain.Validation, "a report cannot cover more than %d days", maxReportDays)
+	}
+
+	return nil
+}
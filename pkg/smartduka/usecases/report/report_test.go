@@ -0,0 +1,141 @@
+package report_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/report"
+)
+
+// fakeQuery records what the report usecase asked the datastore for
+type fakeQuery struct {
+	datastore.Query
+	location *time.Location
+	limit    int
+}
+
+func (f *fakeQuery) GetSalesSummary(ctx context.Context, from, to time.Time, interval enums.ReportInterval, location *time.Location) ([]*domain.SalesSummary, error) {
+	f.location = location
+	return []*domain.SalesSummary{}, nil
+}
+
+func (f *fakeQuery) GetTopProducts(ctx context.Context, from, to time.Time, ranking enums.ProductRanking, limit int) ([]*domain.ProductSales, error) {
+	f.limit = limit
+	return []*domain.ProductSales{}, nil
+}
+
+func (f *fakeQuery) GetHourlySales(ctx context.Context, from, to time.Time, location *time.Location) ([]*domain.HourlySales, error) {
+	f.location = location
+	return []*domain.HourlySales{
+		{DayOfWeek: 5, Hour: 18, Revenue: money.MustParse("450.00", money.CurrencyKES), Transactions: 3},
+	}, nil
+}
+
+func TestUseCasesReportImpl_GetSalesSummary(t *testing.T) {
+	from := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		timezone     string
+		from, to     time.Time
+		interval     enums.ReportInterval
+		wantLocation string
+		wantErr      bool
+	}{
+		{name: "Happy case: days in the default timezone", from: from, to: from.AddDate(0, 1, 0), interval: enums.ReportIntervalDay, wantLocation: common.DefaultShopTimezone},
+		{name: "Happy case: weeks in the shop's timezone", timezone: "Africa/Kampala", from: from, to: from.AddDate(0, 1, 0), interval: enums.ReportIntervalWeek, wantLocation: "Africa/Kampala"},
+		{name: "Sad case: the period starts after it ends", from: from, to: from.AddDate(0, 0, -1), interval: enums.ReportIntervalDay, wantErr: true},
+		{name: "Sad case: an empty period", from: from, to: from, interval: enums.ReportIntervalDay, wantErr: true},
+		{name: "Sad case: a period longer than two years", from: from, to: from.AddDate(3, 0, 0), interval: enums.ReportIntervalMonth, wantErr: true},
+		{name: "Sad case: an invalid interval", from: from, to: from.AddDate(0, 1, 0), interval: "YEAR", wantErr: true},
+		{name: "Sad case: an unknown timezone", timezone: "Africa/Atlantis", from: from, to: from.AddDate(0, 1, 0), interval: enums.ReportIntervalDay, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.ShopTimezoneEnvVarName, tt.timezone)
+			query := &fakeQuery{}
+
+			_, err := report.NewUseCasesReport(query).GetSalesSummary(context.Background(), tt.from, tt.to, tt.interval)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseCasesReportImpl.GetSalesSummary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if query.location != nil {
+					t.Errorf("UseCasesReportImpl.GetSalesSummary() queried sales for an invalid report")
+				}
+				return
+			}
+			if query.location.String() != tt.wantLocation {
+				t.Errorf("UseCasesReportImpl.GetSalesSummary() location = %v, want %v", query.location, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestUseCasesReportImpl_GetTopProducts(t *testing.T) {
+	from := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	limit := func(n int) *int { return &n }
+
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		limit     *int
+		wantLimit int
+		wantErr   bool
+	}{
+		{name: "Happy case: the default limit", from: from, to: from.AddDate(0, 0, 7), wantLimit: 10},
+		{name: "Happy case: a single product", from: from, to: from.AddDate(0, 0, 7), limit: limit(1), wantLimit: 1},
+		{name: "Happy case: the largest limit", from: from, to: from.AddDate(0, 0, 7), limit: limit(100), wantLimit: 100},
+		{name: "Sad case: no products", from: from, to: from.AddDate(0, 0, 7), limit: limit(0), wantErr: true},
+		{name: "Sad case: a limit above the largest", from: from, to: from.AddDate(0, 0, 7), limit: limit(101), wantErr: true},
+		{name: "Sad case: the period starts after it ends", from: from.AddDate(0, 0, 7), to: from, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &fakeQuery{}
+
+			_, err := report.NewUseCasesReport(query).GetTopProducts(context.Background(), tt.from, tt.to, enums.ProductRankingRevenue, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseCasesReportImpl.GetTopProducts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && domain.ErrorCodeOf(err) != domain.Validation {
+				t.Errorf("UseCasesReportImpl.GetTopProducts() code = %s, want %s", domain.ErrorCodeOf(err), domain.Validation)
+			}
+			if query.limit != tt.wantLimit {
+				t.Errorf("UseCasesReportImpl.GetTopProducts() limit = %d, want %d", query.limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestUseCasesReportImpl_GetHourlySales(t *testing.T) {
+	t.Setenv(common.ShopTimezoneEnvVarName, "Africa/Kampala")
+	from := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	query := &fakeQuery{}
+
+	got, err := report.NewUseCasesReport(query).GetHourlySales(context.Background(), from, from.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("UseCasesReportImpl.GetHourlySales() error = %v", err)
+	}
+
+	if query.location.String() != "Africa/Kampala" {
+		t.Errorf("UseCasesReportImpl.GetHourlySales() location = %v, want the shop's timezone", query.location)
+	}
+	if len(got) != 7*24 {
+		t.Fatalf("UseCasesReportImpl.GetHourlySales() returned %d hours, want every hour of the week", len(got))
+	}
+	// Friday 18:00 is the 5th day's 19th hour
+	friday := got[4*24+18]
+	if friday.DayOfWeek != 5 || friday.Hour != 18 || friday.Transactions != 3 || friday.Revenue.String() != "450.00" {
+		t.Errorf("UseCasesReportImpl.GetHourlySales() Friday 18:00 = %+v", friday)
+	}
+	if empty := got[0]; empty.Transactions != 0 || !empty.Revenue.IsZero() {
+		t.Errorf("UseCasesReportImpl.GetHourlySales() Monday 00:00 = %+v, want no sales", empty)
+	}
+}
@@ -0,0 +1,35 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+)
+
+// UseCasesRetention represents the purging of deleted records once their retention period is over
+type UseCasesRetention interface {
+	PurgeDeleted(ctx context.Context, limit int) (int, error)
+}
+
+// UseCasesRetentionImpl represents the retention usecase implementation
+type UseCasesRetentionImpl struct {
+	Delete    datastore.Delete
+	Retention time.Duration
+}
+
+// NewUseCasesRetention initializes the new retention implementation
+func NewUseCasesRetention(
+	delete datastore.Delete,
+	retention time.Duration,
+) UseCasesRetention {
+	return &UseCasesRetentionImpl{
+		Delete:    delete,
+		Retention: retention,
+	}
+}
+
+// PurgeDeleted permanently removes up to limit records of each kind that were deleted longer than the retention period ago
+func (r *UseCasesRetentionImpl) PurgeDeleted(ctx context.Context, limit int) (int, error) {
+	return r.Delete.PurgeDeleted(ctx, time.Now().Add(-r.Retention), limit)
+}
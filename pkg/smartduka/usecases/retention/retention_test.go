@@ -0,0 +1,59 @@
+package retention_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/retention"
+)
+
+// fakeDelete holds records by the time they were deleted
+type fakeDelete struct {
+	datastore.Delete
+	deleted map[string]time.Time
+	before  time.Time
+}
+
+func (f *fakeDelete) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	f.before = before
+	purged := 0
+	for id, deletedAt := range f.deleted {
+		if deletedAt.Before(before) && purged < limit {
+			delete(f.deleted, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func TestUseCasesRetentionImpl_PurgeDeleted(t *testing.T) {
+	const period = 30 * 24 * time.Hour
+	now := time.Now()
+	store := &fakeDelete{deleted: map[string]time.Time{
+		"deleted yesterday":          now.Add(-24 * time.Hour),
+		"deleted just inside period": now.Add(-period + time.Hour),
+		"deleted just after period":  now.Add(-period - time.Hour),
+		"deleted long ago":           now.Add(-3 * period),
+	}}
+
+	before := time.Now()
+	purged, err := retention.NewUseCasesRetention(store, period).PurgeDeleted(context.Background(), 100)
+	after := time.Now()
+	if err != nil {
+		t.Fatalf("UseCasesRetentionImpl.PurgeDeleted() error = %v", err)
+	}
+
+	if store.before.Before(before.Add(-period)) || store.before.After(after.Add(-period)) {
+		t.Errorf("UseCasesRetentionImpl.PurgeDeleted() cutoff = %v, want now less %v", store.before, period)
+	}
+	if purged != 2 {
+		t.Errorf("UseCasesRetentionImpl.PurgeDeleted() purged %d records, want 2", purged)
+	}
+	for _, id := range []string{"deleted yesterday", "deleted just inside period"} {
+		if _, ok := store.deleted[id]; !ok {
+			t.Errorf("UseCasesRetentionImpl.PurgeDeleted() purged the record %s within the retention period", id)
+		}
+	}
+}
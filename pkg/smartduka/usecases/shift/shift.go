@@ -0,0 +1,204 @@
+package shift
+
+import (
+	"context"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/validation"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/authorization"
+)
+
+// UseCasesShift represents the cashier shift and cash drawer business logic
+type UseCasesShift interface {
+	OpenShift(ctx context.Context, openingFloat money.Money) (*domain.Shift, error)
+	GetCurrentShift(ctx context.Context) (*domain.Shift, error)
+	RecordCashMovement(ctx context.Context, input *dto.CashMovementInput) (*domain.CashMovement, error)
+	CloseShift(ctx context.Context, countedCash money.Money) (*domain.ZReport, error)
+	GetZReport(ctx context.Context, shiftID string) (*domain.ZReport, error)
+}
+
+// UseCasesShiftImpl represents the shift usecase implementation
+type UseCasesShiftImpl struct {
+	Create    datastore.Create
+	Query     datastore.Query
+	Update    datastore.Update
+	Extension extension.Extension
+}
+
+// NewUseCasesShift initializes the new shift implementation
+func NewUseCasesShift(
+	create datastore.Create,
+	query datastore.Query,
+	update datastore.Update,
+	extension extension.Extension,
+) UseCasesShift {
+	return &UseCasesShiftImpl{
+		Create:    create,
+		Query:     query,
+		Update:    update,
+		Extension: extension,
+	}
+}
+
+// OpenShift starts a shift for the logged in cashier with the float placed in the cash drawer
+func (s *UseCasesShiftImpl) OpenShift(ctx context.Context, openingFloat money.Money) (*domain.Shift, error) {
+	if openingFloat.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "opening float cannot be negative")
+	}
+
+	loggedInUserID, err := s.Extension.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.Query.GetOpenShift(ctx, loggedInUserID)
+	switch {
+	case err == nil:
+		return nil, domain.Errorf(domain.Conflict, "close your open shift before opening another")
+	case domain.ErrorCodeOf(err) != domain.NotFound:
+		return nil, err
+	}
+
+	return s.Create.OpenShift(ctx, &domain.Shift{
+		CashierID:    loggedInUserID,
+		OpeningFloat: openingFloat,
+	})
+}
+
+// GetCurrentShift returns the logged in cashier's open shift
+func (s *UseCasesShiftImpl) GetCurrentShift(ctx context.Context) (*domain.Shift, error) {
+	loggedInUserID, err := s.Extension.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Query.GetOpenShift(ctx, loggedInUserID)
+}
+
+// RecordCashMovement records cash put into or taken out of the logged in cashier's drawer e.g petty cash or a cash drop
+func (s *UseCasesShiftImpl) RecordCashMovement(ctx context.Context, input *dto.CashMovementInput) (*domain.CashMovement, error) {
+	if err := validation.Validate(input); err != nil {
+		return nil, err
+	}
+
+	if input.Amount.IsZero() || input.Amount.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "cash movement amount must be greater than zero")
+	}
+
+	if input.Type == enums.CashMovementTypeCashOut && input.Reason == "" {
+		return nil, domain.Errorf(domain.Validation, "a reason is required when paying cash out of the drawer")
+	}
+
+	shift, err := s.GetCurrentShift(ctx)
+	if domain.ErrorCodeOf(err) == domain.NotFound {
+		return nil, domain.WrapError(domain.Conflict, err, "open a shift before moving cash")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Create.AddCashMovement(ctx, &domain.CashMovement{
+		ShiftID:    shift.ID,
+		Type:       input.Type,
+		Amount:     input.Amount,
+		Reason:     input.Reason,
+		RecordedBy: shift.CashierID,
+	})
+}
+
+// CloseShift closes the logged in cashier's shift using the cash counted in the drawer and returns its Z-report.
+// A closed shift is locked against further sales and cash movements
+func (s *UseCasesShiftImpl) CloseShift(ctx context.Context, countedCash money.Money) (*domain.ZReport, error) {
+	if countedCash.IsNegative() {
+		return nil, domain.Errorf(domain.Validation, "counted cash cannot be negative")
+	}
+
+	shift, err := s.GetCurrentShift(ctx)
+	if domain.ErrorCodeOf(err) == domain.NotFound {
+		return nil, domain.WrapError(domain.Conflict, err, "there is no open shift to close")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	closed, err := s.Update.CloseShift(ctx, shift.ID, countedCash)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildZReport(ctx, closed)
+}
+
+// GetZReport returns the report of a shift to its cashier or the shop owner.
+// The expected cash of a shift that is still open is worked out as at now
+func (s *UseCasesShiftImpl) GetZReport(ctx context.Context, shiftID string) (*domain.ZReport, error) {
+	loggedInUserID, err := s.Extension.GetLoggedInUserUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shift, err := s.Query.GetShiftByID(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	if shift.CashierID != loggedInUserID {
+		if _, err := authorization.CheckOwner(ctx, s.Query, s.Extension, "view another cashier's Z-report"); err != nil {
+			return nil, err
+		}
+	}
+
+	if shift.Status == enums.ShiftStatusOpen {
+		shift.ExpectedCash, err = s.Query.GetShiftExpectedCash(ctx, shift.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.buildZReport(ctx, shift)
+}
+
+// buildZReport gathers the sales by tender, returns, discounts, VAT and cash movements of a shift
+func (s *UseCasesShiftImpl) buildZReport(ctx context.Context, shift *domain.Shift) (*domain.ZReport, error) {
+	tenders, err := s.Query.GetShiftTenders(ctx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	movements, err := s.Query.GetShiftCashMovements(ctx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.Query.GetShiftTotals(ctx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	netSales, err := totals.GrossSales.Sub(totals.Returns)
+	if err != nil {
+		return nil, err
+	}
+
+	netSales, err = netSales.Sub(totals.Discounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ZReport{
+		Shift:         shift,
+		Tenders:       tenders,
+		CashMovements: movements,
+		GrossSales:    totals.GrossSales,
+		Returns:       totals.Returns,
+		Discounts:     totals.Discounts,
+		NetSales:      netSales,
+		VAT:           totals.VAT,
+		Transactions:  totals.Transactions,
+	}, nil
+}
@@ -0,0 +1,208 @@
+package shift_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/shift"
+)
+
+func kes(amount string) money.Money {
+	return money.MustParse(amount, money.CurrencyKES)
+}
+
+type fakeQuery struct {
+	datastore.Query
+	shifts []*domain.Shift
+}
+
+func (f *fakeQuery) GetUserProfileByUserID(ctx context.Context, userID string) (*domain.User, error) {
+	if userID == "owner" {
+		return &domain.User{ID: userID, UserType: "ADMIN"}, nil
+	}
+	return &domain.User{ID: userID, UserType: "STAFF"}, nil
+}
+
+func (f *fakeQuery) GetOpenShift(ctx context.Context, cashierID string) (*domain.Shift, error) {
+	for _, s := range f.shifts {
+		if s.CashierID == cashierID && s.Status == enums.ShiftStatusOpen {
+			return s, nil
+		}
+	}
+	return nil, domain.Errorf(domain.NotFound, "the cashier has no open shift")
+}
+
+func (f *fakeQuery) GetShiftByID(ctx context.Context, id string) (*domain.Shift, error) {
+	for _, s := range f.shifts {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return nil, domain.Errorf(domain.NotFound, "the shift does not exist")
+}
+
+func (f *fakeQuery) GetShiftTenders(ctx context.Context, shiftID string) ([]*domain.TenderTotal, error) {
+	return []*domain.TenderTotal{}, nil
+}
+
+func (f *fakeQuery) GetShiftCashMovements(ctx context.Context, shiftID string) ([]*domain.CashMovementTotal, error) {
+	return []*domain.CashMovementTotal{}, nil
+}
+
+func (f *fakeQuery) GetShiftTotals(ctx context.Context, shiftID string) (*domain.ShiftTotals, error) {
+	return &domain.ShiftTotals{
+		GrossSales:   kes("1500.00"),
+		Returns:      kes("200.00"),
+		Discounts:    kes("50.50"),
+		VAT:          kes("172.34"),
+		Transactions: 12,
+	}, nil
+}
+
+func (f *fakeQuery) GetShiftExpectedCash(ctx context.Context, shiftID string) (money.Money, error) {
+	return kes("1249.50"), nil
+}
+
+type fakeCreate struct {
+	datastore.Create
+	opened []*domain.Shift
+}
+
+func (f *fakeCreate) OpenShift(ctx context.Context, s *domain.Shift) (*domain.Shift, error) {
+	s.ID, s.Status = "new shift", enums.ShiftStatusOpen
+	f.opened = append(f.opened, s)
+	return s, nil
+}
+
+type fakeUpdate struct {
+	datastore.Update
+	closed []string
+}
+
+func (f *fakeUpdate) CloseShift(ctx context.Context, shiftID string, countedCash money.Money) (*domain.Shift, error) {
+	f.closed = append(f.closed, shiftID)
+	return &domain.Shift{ID: shiftID, Status: enums.ShiftStatusClosed, CountedCash: countedCash}, nil
+}
+
+type fakeExtension struct {
+	extension.Extension
+	userID string
+}
+
+func (f *fakeExtension) GetLoggedInUserUID(ctx context.Context) (string, error) {
+	return f.userID, nil
+}
+
+func TestUseCasesShiftImpl_OpenShift(t *testing.T) {
+	tests := []struct {
+		name         string
+		openingFloat money.Money
+		shifts       []*domain.Shift
+		wantCode     domain.ErrorCode
+	}{
+		{name: "Happy case: open a shift", openingFloat: kes("500.00")},
+		{name: "Happy case: the cashier's earlier shift was closed", openingFloat: kes("500.00"), shifts: []*domain.Shift{{ID: "earlier", CashierID: "cashier", Status: enums.ShiftStatusClosed}}},
+		{name: "Sad case: the cashier already has an open shift", openingFloat: kes("500.00"), shifts: []*domain.Shift{{ID: "open", CashierID: "cashier", Status: enums.ShiftStatusOpen}}, wantCode: domain.Conflict},
+		{name: "Sad case: a negative opening float", openingFloat: kes("-1.00"), wantCode: domain.Validation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			create := &fakeCreate{}
+			u := shift.NewUseCasesShift(create, &fakeQuery{shifts: tt.shifts}, nil, &fakeExtension{userID: "cashier"})
+
+			got, err := u.OpenShift(context.Background(), tt.openingFloat)
+			if tt.wantCode != "" {
+				if domain.ErrorCodeOf(err) != tt.wantCode || len(create.opened) != 0 {
+					t.Errorf("UseCasesShiftImpl.OpenShift() error = %v, opened %d shifts, want a %s error", err, len(create.opened), tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UseCasesShiftImpl.OpenShift() error = %v", err)
+			}
+			if got.CashierID != "cashier" || got.OpeningFloat.String() != tt.openingFloat.String() {
+				t.Errorf("UseCasesShiftImpl.OpenShift() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestUseCasesShiftImpl_CloseShift(t *testing.T) {
+	open := &domain.Shift{ID: "open", CashierID: "cashier", Status: enums.ShiftStatusOpen}
+
+	tests := []struct {
+		name        string
+		countedCash money.Money
+		shifts      []*domain.Shift
+		wantCode    domain.ErrorCode
+	}{
+		{name: "Happy case: close the open shift", countedCash: kes("1249.50"), shifts: []*domain.Shift{open}},
+		{name: "Sad case: there is no open shift", countedCash: kes("1249.50"), wantCode: domain.Conflict},
+		{name: "Sad case: negative counted cash", countedCash: kes("-0.01"), shifts: []*domain.Shift{open}, wantCode: domain.Validation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := &fakeUpdate{}
+			u := shift.NewUseCasesShift(nil, &fakeQuery{shifts: tt.shifts}, update, &fakeExtension{userID: "cashier"})
+
+			got, err := u.CloseShift(context.Background(), tt.countedCash)
+			if tt.wantCode != "" {
+				if domain.ErrorCodeOf(err) != tt.wantCode || len(update.closed) != 0 {
+					t.Errorf("UseCasesShiftImpl.CloseShift() error = %v, closed %v, want a %s error", err, update.closed, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UseCasesShiftImpl.CloseShift() error = %v", err)
+			}
+
+			// net sales are the gross sales less returns and discounts
+			if got.NetSales.String() != "1249.50" || got.GrossSales.String() != "1500.00" || got.Transactions != 12 {
+				t.Errorf("UseCasesShiftImpl.CloseShift() net sales = %v of gross sales %v", got.NetSales, got.GrossSales)
+			}
+			if got.Shift.Status != enums.ShiftStatusClosed || got.Shift.CountedCash.String() != "1249.50" {
+				t.Errorf("UseCasesShiftImpl.CloseShift() shift = %+v", got.Shift)
+			}
+		})
+	}
+}
+
+func TestUseCasesShiftImpl_GetZReport(t *testing.T) {
+	shifts := []*domain.Shift{{ID: "shift", CashierID: "cashier", Status: enums.ShiftStatusOpen}}
+
+	tests := []struct {
+		name     string
+		userID   string
+		shiftID  string
+		wantCode domain.ErrorCode
+	}{
+		{name: "Happy case: a cashier sees their own Z-report", userID: "cashier", shiftID: "shift"},
+		{name: "Happy case: the owner sees a cashier's Z-report", userID: "owner", shiftID: "shift"},
+		{name: "Sad case: a cashier cannot see another cashier's Z-report", userID: "another cashier", shiftID: "shift", wantCode: domain.Forbidden},
+		{name: "Sad case: the shift does not exist", userID: "owner", shiftID: "missing", wantCode: domain.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := shift.NewUseCasesShift(nil, &fakeQuery{shifts: shifts}, nil, &fakeExtension{userID: tt.userID})
+
+			got, err := u.GetZReport(context.Background(), tt.shiftID)
+			if tt.wantCode != "" {
+				if domain.ErrorCodeOf(err) != tt.wantCode {
+					t.Errorf("UseCasesShiftImpl.GetZReport() error = %v, want a %s error", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UseCasesShiftImpl.GetZReport() error = %v", err)
+			}
+			if got.Shift.ExpectedCash.String() != "1249.50" || got.NetSales.String() != "1249.50" {
+				t.Errorf("UseCasesShiftImpl.GetZReport() expected cash = %v, net sales = %v", got.Shift.ExpectedCash, got.NetSales)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package taxinvoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/etims"
+)
+
+const (
+	// submitTimeout keeps a sale from waiting on eTIMS for long when it cannot be reached
+	submitTimeout = 5 * time.Second
+
+	// ClaimLease is how long an invoice being submitted is hidden from other submitters
+	ClaimLease = time.Minute
+
+	// minRetryDelay and maxRetryDelay bound the wait between attempts to submit an invoice while eTIMS cannot be reached
+	minRetryDelay = 30 * time.Second
+	maxRetryDelay = time.Hour
+)
+
+// UseCasesTaxInvoice represents the submission of sales to KRA eTIMS.
+// Every sale queues a tax invoice in an outbox and the outbox is retried until eTIMS accepts or rejects each invoice
+type UseCasesTaxInvoice interface {
+	Enabled() bool
+	SubmitInvoice(ctx context.Context, invoice *domain.TaxInvoice) (*domain.TaxInvoice, error)
+	SubmitPendingInvoices(ctx context.Context, limit int) (int, error)
+}
+
+// UseCasesTaxInvoiceImpl represents the tax invoice usecase implementation
+type UseCasesTaxInvoiceImpl struct {
+	Query  datastore.Query
+	Update datastore.Update
+	ETIMS  etims.ServiceETIMS
+}
+
+// NewUseCasesTaxInvoice initializes the new tax invoice implementation.
+// Sales are not submitted when the eTIMS service is nil
+func NewUseCasesTaxInvoice(
+	query datastore.Query,
+	update datastore.Update,
+	service etims.ServiceETIMS,
+) UseCasesTaxInvoice {
+	return &UseCasesTaxInvoiceImpl{
+		Query:  query,
+		Update: update,
+		ETIMS:  service,
+	}
+}
+
+// Enabled reports whether the shop submits its sales to eTIMS
+func (t *UseCasesTaxInvoiceImpl) Enabled() bool {
+	return t.ETIMS != nil
+}
+
+// SubmitInvoice submits a queued tax invoice to eTIMS and records the outcome.
+// An invoice that could not be submitted is left in the outbox to be retried
+func (t *UseCasesTaxInvoiceImpl) SubmitInvoice(ctx context.Context, invoice *domain.TaxInvoice) (*domain.TaxInvoice, error) {
+	if !t.Enabled() {
+		return nil, fmt.Errorf("eTIMS is not configured")
+	}
+
+	result, err := t.submit(ctx, invoice)
+	now := time.Now().UTC()
+	invoice.Attempts++
+	updateData := map[string]interface{}{
+		"attempts":   invoice.Attempts,
+		"updated_at": now,
+	}
+
+	var rejected *etims.RejectedError
+	switch {
+	case err == nil:
+		invoice.Status = enums.TaxInvoiceStatusSubmitted
+		invoice.InvoiceNumber = result.InvoiceNumber
+		invoice.ReceiptSignature = result.ReceiptSignature
+		invoice.QRData = result.QRData
+		invoice.SubmittedAt = &now
+		invoice.LastError = ""
+		updateData["status"] = invoice.Status
+		updateData["invoice_number"] = invoice.InvoiceNumber
+		updateData["receipt_signature"] = invoice.ReceiptSignature
+		updateData["qr_data"] = invoice.QRData
+		updateData["submitted_at"] = now
+		updateData["last_error"] = nil
+	case errors.As(err, &rejected):
+		invoice.Status = enums.TaxInvoiceStatusRejected
+		invoice.LastError = err.Error()
+		updateData["status"] = invoice.Status
+		updateData["last_error"] = invoice.LastError
+	default:
+		invoice.NextAttemptAt = now.Add(retryDelay(invoice.Attempts))
+		invoice.LastError = err.Error()
+		updateData["next_attempt_at"] = invoice.NextAttemptAt
+		updateData["last_error"] = invoice.LastError
+	}
+
+	if updateErr := t.Update.UpdateTaxInvoice(ctx, invoice, updateData); updateErr != nil {
+		return nil, updateErr
+	}
+
+	return invoice, err
+}
+
+// SubmitPendingInvoices submits the invoices in the outbox that are due. It returns how many eTIMS accepted
+func (t *UseCasesTaxInvoiceImpl) SubmitPendingInvoices(ctx context.Context, limit int) (int, error) {
+	if !t.Enabled() {
+		return 0, nil
+	}
+
+	invoices, err := t.Update.ClaimPendingTaxInvoices(ctx, limit, ClaimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	submitted := 0
+	for _, invoice := range invoices {
+		result, err := t.SubmitInvoice(ctx, invoice)
+		if result == nil && err != nil {
+			return submitted, err
+		}
+		if result.Status == enums.TaxInvoiceStatusSubmitted {
+			submitted++
+		}
+	}
+
+	return submitted, nil
+}
+
+// submit builds a sale's invoice from its product's VAT rate and sends it to eTIMS
+func (t *UseCasesTaxInvoiceImpl) submit(ctx context.Context, invoice *domain.TaxInvoice) (*etims.Result, error) {
+	location, err := helpers.GetShopLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	sale, err := t.Query.GetSaleByID(ctx, invoice.SaleID)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := t.Query.GetProductByID(ctx, sale.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product sold: %w", err)
+	}
+
+	etimsInvoice, err := etims.NewInvoice(sale, product, location)
+	if err != nil {
+		// the sale can never be submitted as it is so it is not retried
+		return nil, &etims.RejectedError{Message: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, submitTimeout)
+	defer cancel()
+
+	return t.ETIMS.SubmitInvoice(ctx, etimsInvoice)
+}
+
+// retryDelay doubles the wait after each failed attempt
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
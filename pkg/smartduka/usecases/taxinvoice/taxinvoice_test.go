@@ -0,0 +1,196 @@
+package taxinvoice_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/money"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/etims"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/etims/mock"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/taxinvoice"
+)
+
+const communicationKey = "test-communication-key"
+
+type outboxQuery struct {
+	datastore.Query
+}
+
+func (o *outboxQuery) GetSaleByID(ctx context.Context, id string) (*domain.Sale, error) {
+	return &domain.Sale{
+		ID:            id,
+		ProductID:     "8c2ff1b6-4a45-4bc5-9e6a-3cfa5bda7d3b",
+		Quantity:      money.MustParseDecimal("1"),
+		Unit:          "PIECE",
+		Price:         money.MustParse("116.00", money.CurrencyKES),
+		Discount:      money.Zero(money.CurrencyKES),
+		PaymentMethod: enums.PaymentMethodCash,
+		SoldAt:        time.Date(2023, 7, 14, 15, 45, 0, 0, time.UTC),
+	}, nil
+}
+
+func (o *outboxQuery) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	return &domain.Product{ID: id, SKU: "PAN-24", Name: "Panadol Extra", VAT: money.DecimalFromInt(16)}, nil
+}
+
+// outboxUpdate is an outbox holding the invoices queued by sales
+type outboxUpdate struct {
+	datastore.Update
+	invoices []*domain.TaxInvoice
+	updates  []map[string]interface{}
+}
+
+func (o *outboxUpdate) ClaimPendingTaxInvoices(ctx context.Context, limit int, lease time.Duration) ([]*domain.TaxInvoice, error) {
+	claimed := []*domain.TaxInvoice{}
+	for _, invoice := range o.invoices {
+		if invoice.Status == enums.TaxInvoiceStatusPending && !invoice.NextAttemptAt.After(time.Now()) && len(claimed) < limit {
+			claimed = append(claimed, invoice)
+		}
+	}
+	return claimed, nil
+}
+
+func (o *outboxUpdate) UpdateTaxInvoice(ctx context.Context, invoice *domain.TaxInvoice, updateData map[string]interface{}) error {
+	o.updates = append(o.updates, updateData)
+	return nil
+}
+
+func newTaxInvoiceUsecase(t *testing.T, server *mock.ETIMSServerMock, outbox *outboxUpdate) taxinvoice.UseCasesTaxInvoice {
+	t.Setenv(common.ETIMSBaseURLEnvVarName, server.URL)
+	t.Setenv(common.ShopKRAPINEnvVarName, "P051234567X")
+	t.Setenv(common.ETIMSCommunicationKeyEnvVarName, communicationKey)
+
+	service, err := etims.NewServiceETIMS()
+	if err != nil {
+		t.Fatalf("NewServiceETIMS() error = %v", err)
+	}
+
+	return taxinvoice.NewUseCasesTaxInvoice(&outboxQuery{}, outbox, service)
+}
+
+func TestUseCasesTaxInvoiceImpl_SubmitInvoice(t *testing.T) {
+	tests := []struct {
+		name         string
+		offline      bool
+		rejectCode   string
+		attempts     int
+		wantStatus   enums.TaxInvoiceStatus
+		wantRetryIn  time.Duration
+		wantRejected bool
+	}{
+		{name: "Happy case: eTIMS accepts the invoice", wantStatus: enums.TaxInvoiceStatusSubmitted},
+		{name: "Sad case: eTIMS rejects the invoice for good", rejectCode: "910", wantStatus: enums.TaxInvoiceStatusRejected, wantRejected: true},
+		{name: "Sad case: eTIMS cannot be reached on the first attempt", offline: true, wantStatus: enums.TaxInvoiceStatusPending, wantRetryIn: 30 * time.Second},
+		{name: "Sad case: the wait doubles after each attempt", offline: true, attempts: 3, wantStatus: enums.TaxInvoiceStatusPending, wantRetryIn: 4 * time.Minute},
+		{name: "Sad case: the wait stops growing at an hour", offline: true, attempts: 20, wantStatus: enums.TaxInvoiceStatusPending, wantRetryIn: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := mock.NewETIMSServerMock(communicationKey)
+			defer server.Close()
+			server.SetOffline(tt.offline)
+			server.Reject(tt.rejectCode, "invalid invoice")
+
+			outbox := &outboxUpdate{}
+			u := newTaxInvoiceUsecase(t, server, outbox)
+
+			invoice := &domain.TaxInvoice{ID: "invoice", SaleID: "sale", Status: enums.TaxInvoiceStatusPending, Attempts: tt.attempts}
+			before := time.Now()
+			got, err := u.SubmitInvoice(context.Background(), invoice)
+			if (err != nil) != (tt.wantStatus != enums.TaxInvoiceStatusSubmitted) {
+				t.Fatalf("UseCasesTaxInvoiceImpl.SubmitInvoice() error = %v", err)
+			}
+			var rejected *etims.RejectedError
+			if errors.As(err, &rejected) != tt.wantRejected {
+				t.Errorf("UseCasesTaxInvoiceImpl.SubmitInvoice() error = %v, wantRejected %v", err, tt.wantRejected)
+			}
+
+			if got.Status != tt.wantStatus || got.Attempts != tt.attempts+1 {
+				t.Errorf("UseCasesTaxInvoiceImpl.SubmitInvoice() status = %v after %d attempts, want %v", got.Status, got.Attempts, tt.wantStatus)
+			}
+			if len(outbox.updates) != 1 {
+				t.Fatalf("UseCasesTaxInvoiceImpl.SubmitInvoice() updated the outbox %d times", len(outbox.updates))
+			}
+
+			update := outbox.updates[0]
+			nextAttemptAt, retried := update["next_attempt_at"].(time.Time)
+			if retried != (tt.wantRetryIn != 0) {
+				t.Fatalf("UseCasesTaxInvoiceImpl.SubmitInvoice() next attempt = %v, want a retry %v", update["next_attempt_at"], tt.wantRetryIn != 0)
+			}
+			if retried {
+				if wait := nextAttemptAt.Sub(before); wait < tt.wantRetryIn || wait > tt.wantRetryIn+5*time.Second {
+					t.Errorf("UseCasesTaxInvoiceImpl.SubmitInvoice() retries in %v, want %v", wait, tt.wantRetryIn)
+				}
+				if update["status"] != nil {
+					t.Errorf("UseCasesTaxInvoiceImpl.SubmitInvoice() changed the status of an invoice to retry to %v", update["status"])
+				}
+			}
+
+			if tt.wantStatus == enums.TaxInvoiceStatusSubmitted {
+				if update["invoice_number"] != mock.ControlUnitID+"/1" || !strings.HasPrefix(got.QRData, common.ETIMSReceiptQRBaseURL) {
+					t.Errorf("UseCasesTaxInvoiceImpl.SubmitInvoice() stored %v", update)
+				}
+			}
+		})
+	}
+}
+
+func TestUseCasesTaxInvoiceImpl_SubmitPendingInvoices(t *testing.T) {
+	ctx := context.Background()
+	server := mock.NewETIMSServerMock(communicationKey)
+	defer server.Close()
+
+	queued := &domain.TaxInvoice{ID: "queued", SaleID: "queued sale", Status: enums.TaxInvoiceStatusPending}
+	rejected := &domain.TaxInvoice{ID: "rejected", SaleID: "rejected sale", Status: enums.TaxInvoiceStatusPending}
+	outbox := &outboxUpdate{invoices: []*domain.TaxInvoice{rejected}}
+	u := newTaxInvoiceUsecase(t, server, outbox)
+
+	// a rejection is final so the invoice is never submitted again
+	server.Reject("910", "invalid invoice")
+	if submitted, err := u.SubmitPendingInvoices(ctx, 10); err != nil || submitted != 0 {
+		t.Fatalf("UseCasesTaxInvoiceImpl.SubmitPendingInvoices() = %d, %v, want the invoice rejected", submitted, err)
+	}
+	server.Reject("", "")
+
+	// a sale made while eTIMS cannot be reached stays in the outbox
+	outbox.invoices = append(outbox.invoices, queued)
+	server.SetOffline(true)
+	if submitted, err := u.SubmitPendingInvoices(ctx, 10); err != nil || submitted != 0 {
+		t.Fatalf("UseCasesTaxInvoiceImpl.SubmitPendingInvoices() = %d, %v while offline", submitted, err)
+	}
+	if queued.Status != enums.TaxInvoiceStatusPending || queued.LastError == "" {
+		t.Fatalf("UseCasesTaxInvoiceImpl.SubmitPendingInvoices() left the invoice %+v", queued)
+	}
+
+	// it is not retried before it is due
+	server.SetOffline(false)
+	if submitted, err := u.SubmitPendingInvoices(ctx, 10); err != nil || submitted != 0 {
+		t.Fatalf("UseCasesTaxInvoiceImpl.SubmitPendingInvoices() = %d, %v before the invoice was due", submitted, err)
+	}
+
+	// a later run submits it once it is due
+	queued.NextAttemptAt = time.Now().Add(-time.Second)
+	if submitted, err := u.SubmitPendingInvoices(ctx, 10); err != nil || submitted != 1 {
+		t.Fatalf("UseCasesTaxInvoiceImpl.SubmitPendingInvoices() = %d, %v, want the queued invoice submitted", submitted, err)
+	}
+
+	if queued.Status != enums.TaxInvoiceStatusSubmitted || queued.Attempts != 2 || queued.LastError != "" {
+		t.Errorf("UseCasesTaxInvoiceImpl.SubmitPendingInvoices() left the invoice %+v", queued)
+	}
+	if queued.InvoiceNumber != mock.ControlUnitID+"/1" || !strings.HasPrefix(queued.QRData, common.ETIMSReceiptQRBaseURL+"P051234567X00") {
+		t.Errorf("UseCasesTaxInvoiceImpl.SubmitPendingInvoices() stored invoice number %q and QR data %q", queued.InvoiceNumber, queued.QRData)
+	}
+	if rejected.Status != enums.TaxInvoiceStatusRejected || rejected.Attempts != 1 {
+		t.Errorf("UseCasesTaxInvoiceImpl.SubmitPendingInvoices() retried a rejected invoice %+v", rejected)
+	}
+	if len(server.Invoices()) != 1 {
+		t.Errorf("eTIMS received %d invoices, want the queued invoice once", len(server.Invoices()))
+	}
+}
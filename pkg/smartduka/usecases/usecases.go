@@ -1,24 +1,48 @@
 package usecases
 
 import (
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/audit"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/live"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/offlinesync"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/otp"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/product"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/report"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/shift"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/user"
 )
 
 // Smartduka manages the usecases intrefaces
 type Smartduka struct {
-	User user.UseCasesUser
-	OTP  otp.UseCasesOTP
+	User    user.UseCasesUser
+	OTP     otp.UseCasesOTP
+	Report  report.UseCasesReport
+	Product product.UseCasesProduct
+	Shift   shift.UseCasesShift
+	Sync    offlinesync.UseCasesSync
+	Audit   audit.UseCasesAudit
+	Live    live.UseCasesLive
 }
 
 // NewUseCasesInteractor initializes a new usecases interactor
 func NewSmartdukaUsecase(
 	user user.UseCasesUser,
 	otp otp.UseCasesOTP,
+	report report.UseCasesReport,
+	product product.UseCasesProduct,
+	shift shift.UseCasesShift,
+	sync offlinesync.UseCasesSync,
+	audit audit.UseCasesAudit,
+	live live.UseCasesLive,
 ) *Smartduka {
 	m := &Smartduka{
-		User: user,
-		OTP:  otp,
+		User:    user,
+		OTP:     otp,
+		Report:  report,
+		Product: product,
+		Shift:   shift,
+		Sync:    sync,
+		Audit:   audit,
+		Live:    live,
 	}
 
 	return m
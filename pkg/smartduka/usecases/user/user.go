@@ -3,15 +3,28 @@ package user
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common/helpers"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/pagination"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/validation"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
 	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/authorization"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	appName = "Smartduka"
+
+	// maxFreezeReasonLength is the longest reason the frozen_reason column holds
+	maxFreezeReasonLength = 200
 )
 
 // UseCasesUser represents all the user business logic
@@ -21,6 +34,16 @@ type UseCasesUser interface {
 	SetUserPIN(ctx context.Context, input *dto.UserPINInput) (bool, error)
 	SearchUserByPhoneNumber(ctx context.Context, phoneNumber string) (*domain.User, error)
 	SearchUser(ctx context.Context, searchTerm string) ([]*domain.User, error)
+	ListUsers(ctx context.Context, filter *dto.UserFilter, sortBy *dto.UserSort, first *int, after *string) (*domain.UserConnection, error)
+	GetUsersByIDs(ctx context.Context, ids []string) ([]*domain.User, error)
+	GetContactsByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Contact, error)
+
+	DeleteUser(ctx context.Context, userID string) (bool, error)
+	RestoreUser(ctx context.Context, userID string) (*domain.User, error)
+
+	FreezeUser(ctx context.Context, userID string, reason string) (*domain.User, error)
+	UnfreezeUser(ctx context.Context, userID string) (*domain.User, error)
+	ValidateSession(ctx context.Context, token string) error
 }
 
 // UseCasesUserImpl represents the user usecase implementation
@@ -28,6 +51,7 @@ type UseCasesUserImpl struct {
 	Create    datastore.Create
 	Query     datastore.Query
 	Update    datastore.Update
+	Delete    datastore.Delete
 	Extension extension.Extension
 }
 
@@ -36,110 +60,206 @@ func NewUseCasesUser(
 	create datastore.Create,
 	query datastore.Query,
 	update datastore.Update,
+	delete datastore.Delete,
 	extension extension.Extension,
 ) UseCasesUser {
 	return &UseCasesUserImpl{
 		Create:    create,
 		Query:     query,
 		Update:    update,
+		Delete:    delete,
 		Extension: extension,
 	}
 }
 
-// HandleIncomingMessages receives ang processes the incoming SMS data
+// Login checks a user's PIN and issues them a token. Frozen users cannot log in
 func (u UseCasesUserImpl) Login(ctx context.Context, loginInput *dto.LoginInput) (*dto.LoginResponse, error) {
-	// TODO: Restore later
-	// user, err := u.Query.GetUserProfileByPhoneNumber(ctx, loginInput.PhoneNumber, loginInput.Flavour)
-	// if err != nil {
-	// 	return nil, err
-	// }
-
-	// userPIN, err := u.Query.GetUserPINByUserID(ctx, user.ID, user.Flavour)
-	// if err != nil {
-	// 	return nil, err
-	// }
-
-	// If pin `ValidTo` field is in the past (expired). This means the user has to change their pin
-	// currentTime := time.Now()
-	// expired := currentTime.After(userPIN.ValidTo)
-	// if expired {
-	// 	return nil, fmt.Errorf("pin expired. Please change your pin")
-	// }
-
-	// matched := utils.ComparePIN(
-	// 	loginInput.PIN,
-	// 	userPIN.Salt,
-	// 	userPIN.HashedPIN,
-	// 	nil,
-	// )
-
-	// if !matched {
-	// 	return nil, fmt.Errorf("invalid pin")
-	// }
-
-	// tokenResponse, err := utils.GenerateJWTToken(user.ID)
-	// if err != nil {
-	// 	return nil, err
-	// }
-
-	// userToken, err := utils.ValidateJWTToken(tokenResponse.Token)
-	// if err != nil {
-	// 	return nil, err
-	// }
-
-	// user.AuthCredentials.IDToken = userToken.Token
-	// user.AuthCredentials.ExpiresIn = userToken.ExpiresIn
-
-	// return &dto.LoginResponse{
-	// 	UserProfile: user,
-	// }, nil
-
-	return nil, nil
-}
-
-// HandleRegistration handles the user registration
+	if err := validation.Validate(loginInput); err != nil {
+		return nil, err
+	}
+
+	phoneNumber, err := helpers.NormalizeMSISDN(loginInput.PhoneNumber)
+	if err != nil {
+		return nil, domain.WrapError(domain.Validation, err, "invalid phone number")
+	}
+
+	user, err := u.Query.GetUserProfileByPhoneNumber(ctx, *phoneNumber, loginInput.Flavour)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Active {
+		return nil, domain.Errorf(domain.Forbidden, "your account has been frozen, please contact the shop owner")
+	}
+
+	userPIN, err := u.Query.GetUserPINByUserID(ctx, user.ID, loginInput.Flavour)
+	if err != nil {
+		return nil, err
+	}
+
+	// a PIN past its `ValidTo` date has expired and has to be changed
+	if time.Now().After(userPIN.ValidTo) {
+		return nil, domain.Errorf(domain.Unauthenticated, "pin expired. Please change your pin")
+	}
+
+	if !utils.ComparePIN(loginInput.PIN, userPIN.Salt, userPIN.HashedPIN, nil) {
+		return nil, domain.Errorf(domain.Unauthenticated, "invalid pin")
+	}
+
+	token, err := utils.GenerateJWTToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.AuthCredentials.IDToken = token.Token
+	user.AuthCredentials.ExpiresIn = token.ExpiresIn
+
+	return &dto.LoginResponse{
+		UserProfile: user,
+	}, nil
+}
+
+// RegisterUser registers a user together with their phone number, identification document and initial PIN.
+// A phone number can only be registered once in each flavour and a username or document only once.
+// When REGISTRATION_OTP_REQUIRED is set users verify their phone number with an OTP before they register
 func (u UseCasesUserImpl) RegisterUser(ctx context.Context, registerInput *dto.RegisterUserInput) (*domain.User, error) {
+	if err := validation.Validate(registerInput); err != nil {
+		return nil, err
+	}
+
+	phoneNumber, err := helpers.NormalizeMSISDN(registerInput.PhoneNumber)
+	if err != nil {
+		return nil, domain.WrapError(domain.Validation, err, "invalid phone number")
+	}
+
+	if err := u.checkNotRegistered(ctx, registerInput, *phoneNumber); err != nil {
+		return nil, err
+	}
+
+	if utils.BoolEnv(common.RegistrationOTPRequiredEnvVarName) {
+		if err := u.verifyPhoneNumber(ctx, *phoneNumber, registerInput.Flavour, registerInput.OTP); err != nil {
+			return nil, err
+		}
+	}
+
+	expiryDate, err := helpers.GetPinExpiryDate()
+	if err != nil {
+		return nil, err
+	}
+	salt, encryptedPIN := utils.EncryptPIN(registerInput.PIN, nil)
+
 	user := &domain.User{
-		FirstName: registerInput.FirstName,
-		LastName:  registerInput.LastName,
-		Active:    true,
-		UserName:  registerInput.UserName,
+		FirstName:   registerInput.FirstName,
+		MiddleName:  registerInput.MiddleName,
+		LastName:    registerInput.LastName,
+		Active:      true,
+		UserName:    registerInput.UserName,
+		DeviceToken: registerInput.DeviceToken,
+		Residence:   registerInput.Residence,
 	}
 
 	contact := &domain.Contact{
 		Active:       true,
 		ContactType:  "PHONE",
-		ContactValue: registerInput.PhoneNumber,
+		ContactValue: *phoneNumber,
 		Flavour:      registerInput.Flavour,
 	}
 
-	result, err := u.Create.RegisterUser(ctx, user, contact)
-	if err != nil {
-		return nil, err
+	var identifier *domain.Identifier
+	if registerInput.IdentificationDocumentNumber != "" {
+		identifier = &domain.Identifier{
+			Active:          true,
+			IdentifierType:  registerInput.IdentificationDocumentType,
+			IdentifierValue: registerInput.IdentificationDocumentNumber,
+		}
+	}
+
+	pin := &domain.UserPIN{
+		Active:    true,
+		Flavour:   registerInput.Flavour,
+		ValidFrom: time.Now(),
+		ValidTo:   *expiryDate,
+		HashedPIN: encryptedPIN,
+		Salt:      salt,
+	}
+
+	return u.Create.RegisterUser(ctx, user, contact, identifier, pin)
+}
+
+// checkNotRegistered makes sure that no other user has registered the phone number, username or identification
+// document. The error lists every one of them that has been taken
+func (u UseCasesUserImpl) checkNotRegistered(ctx context.Context, registerInput *dto.RegisterUserInput, phoneNumber string) error {
+	lookups := map[string]func() (*domain.User, error){
+		"phone_number": func() (*domain.User, error) {
+			return u.Query.GetUserProfileByPhoneNumber(ctx, phoneNumber, registerInput.Flavour)
+		},
+		"username": func() (*domain.User, error) {
+			return u.Query.GetUserByUsername(ctx, registerInput.UserName)
+		},
+	}
+	if registerInput.IdentificationDocumentNumber != "" {
+		lookups["identification_number"] = func() (*domain.User, error) {
+			return u.Query.GetUserByIdentifier(ctx, registerInput.IdentificationDocumentType, registerInput.IdentificationDocumentNumber)
+		}
+	}
+
+	taken := []domain.FieldError{}
+	for _, field := range []string{"identification_number", "phone_number", "username"} {
+		lookup, ok := lookups[field]
+		if !ok {
+			continue
+		}
+
+		_, err := lookup()
+		if domain.ErrorCodeOf(err) == domain.NotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		taken = append(taken, domain.FieldError{Field: field, Message: "has already been registered"})
+	}
+
+	if len(taken) > 0 {
+		return &domain.Error{Code: domain.Conflict, Message: "this user is already registered", Fields: taken}
+	}
+	return nil
+}
+
+// verifyPhoneNumber uses up the OTP sent to a phone number, proving that the user registering owns it
+func (u UseCasesUserImpl) verifyPhoneNumber(ctx context.Context, phoneNumber string, flavour enums.Flavour, otp string) error {
+	if otp == "" {
+		return &domain.Error{
+			Code:    domain.Validation,
+			Message: validation.InvalidInputMessage,
+			Fields:  []domain.FieldError{{Field: "otp", Message: "is required"}},
+		}
 	}
 
-	return result, nil
+	sent, err := u.Query.GetValidOTP(ctx, phoneNumber, flavour, otp)
+	if err == nil {
+		err = u.Update.InvalidateOTP(ctx, sent.ID)
+	}
+	if domain.ErrorCodeOf(err) == domain.NotFound {
+		return domain.WrapError(domain.Validation, err, "the verification code is wrong or has expired")
+	}
+
+	return err
 }
 
 // SetUserPIN sets the user pin
 func (u UseCasesUserImpl) SetUserPIN(ctx context.Context, input *dto.UserPINInput) (bool, error) {
-	userProfile, err := u.Query.GetUserProfileByUserID(ctx, input.UserID)
-	if err != nil {
-		return false, fmt.Errorf("failed to get a user profile by user ID: %v", err)
+	if err := validation.Validate(input); err != nil {
+		return false, err
 	}
 
-	err = utils.ValidatePIN(input.PIN)
+	userProfile, err := u.Query.GetUserProfileByUserID(ctx, input.UserID)
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("failed to get a user profile by user ID: %w", err)
 	}
 
 	salt, encryptedPIN := utils.EncryptPIN(input.PIN, nil)
 
-	isMatch := utils.ComparePIN(input.ConfirmPIN, salt, encryptedPIN, nil)
-	if !isMatch {
-		return false, err
-	}
-
 	expiryDate, err := helpers.GetPinExpiryDate()
 	if err != nil {
 		return false, err
@@ -167,7 +287,7 @@ func (u UseCasesUserImpl) SetUserPIN(ctx context.Context, input *dto.UserPINInpu
 func (u UseCasesUserImpl) SearchUserByPhoneNumber(ctx context.Context, phoneNumber string) (*domain.User, error) {
 	normalizedPhone, err := helpers.NormalizeMSISDN(phoneNumber)
 	if err != nil {
-		return nil, err
+		return nil, domain.WrapError(domain.Validation, err, "invalid phone number")
 	}
 
 	return u.Query.GetUserProfileByPhoneNumber(ctx, *normalizedPhone, enums.FlavourConsumer)
@@ -177,3 +297,204 @@ func (u UseCasesUserImpl) SearchUserByPhoneNumber(ctx context.Context, phoneNumb
 func (u UseCasesUserImpl) SearchUser(ctx context.Context, searchTerm string) ([]*domain.User, error) {
 	return u.Query.SearchUser(ctx, searchTerm)
 }
+
+// ListUsers returns a page of the users matching the filter. Users are sorted by username unless asked otherwise
+func (u UseCasesUserImpl) ListUsers(ctx context.Context, filter *dto.UserFilter, sortBy *dto.UserSort, first *int, after *string) (*domain.UserConnection, error) {
+	if filter == nil {
+		filter = &dto.UserFilter{}
+	}
+
+	order := dto.UserSort{Field: enums.UserSortFieldUsername, Direction: enums.SortDirectionAsc}
+	if sortBy != nil {
+		order = *sortBy
+	}
+	if !order.Field.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid sort field: %s", order.Field)
+	}
+	if order.Direction == "" {
+		order.Direction = enums.SortDirectionAsc
+	}
+	if !order.Direction.IsValid() {
+		return nil, domain.Errorf(domain.Validation, "invalid sort direction: %s", order.Direction)
+	}
+
+	limit, cursor, err := pagination.Args(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.Query.ListUsers(ctx, filter, order, cursor, limit)
+}
+
+// GetUsersByIDs returns the users with the IDs, including deleted ones
+func (u UseCasesUserImpl) GetUsersByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	return u.Query.GetUsersByIDs(ctx, ids)
+}
+
+// GetContactsByUserIDs returns the contacts of the users with the IDs
+func (u UseCasesUserImpl) GetContactsByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Contact, error) {
+	return u.Query.GetContactsByUserIDs(ctx, userIDs)
+}
+
+// DeleteUser removes a user's account. Only the shop owner can delete users and they cannot delete themselves
+func (u UseCasesUserImpl) DeleteUser(ctx context.Context, userID string) (bool, error) {
+	owner, err := authorization.CheckOwner(ctx, u.Query, u.Extension, "delete users")
+	if err != nil {
+		return false, err
+	}
+
+	if owner.ID == userID {
+		return false, domain.Errorf(domain.Forbidden, "you cannot delete your own account")
+	}
+
+	if err := u.Delete.DeleteUser(ctx, userID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RestoreUser brings back a deleted user's account
+func (u UseCasesUserImpl) RestoreUser(ctx context.Context, userID string) (*domain.User, error) {
+	if _, err := authorization.CheckOwner(ctx, u.Query, u.Extension, "restore users"); err != nil {
+		return nil, err
+	}
+
+	return u.Update.RestoreUser(ctx, userID)
+}
+
+// FreezeUser stops a user from using their account until it is unfrozen. The tokens they hold stop working
+// straight away and they are told why by SMS. Only the shop owner can freeze users and they cannot freeze themselves
+func (u UseCasesUserImpl) FreezeUser(ctx context.Context, userID string, reason string) (*domain.User, error) {
+	owner, err := authorization.CheckOwner(ctx, u.Query, u.Extension, "freeze users")
+	if err != nil {
+		return nil, err
+	}
+
+	if owner.ID == userID {
+		return nil, domain.Errorf(domain.Forbidden, "you cannot freeze your own account")
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.Errorf(domain.Validation, "a reason is required to freeze a user")
+	}
+	if len(reason) > maxFreezeReasonLength {
+		return nil, domain.Errorf(domain.Validation, "the reason cannot be longer than %d characters", maxFreezeReasonLength)
+	}
+
+	user, err := u.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.FrozenAt != nil {
+		return nil, domain.Errorf(domain.Conflict, "the user is already frozen")
+	}
+
+	// tokens only record the second they were issued in, so the revocation is kept to the second too
+	now := time.Now().UTC().Truncate(time.Second)
+	err = u.Update.UpdateUser(ctx, user, map[string]interface{}{
+		"active":            false,
+		"frozen_reason":     reason,
+		"frozen_at":         now,
+		"tokens_revoked_at": now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.Active = false
+	user.FrozenReason = reason
+	user.FrozenAt = &now
+	user.TokensRevokedAt = &now
+
+	u.notify(ctx, user, fmt.Sprintf("Your %s account has been frozen: %s. Please contact the shop owner", appName, reason))
+
+	return user, nil
+}
+
+// UnfreezeUser lets a frozen user log in again. The tokens revoked when they were frozen stay revoked
+func (u UseCasesUserImpl) UnfreezeUser(ctx context.Context, userID string) (*domain.User, error) {
+	if _, err := authorization.CheckOwner(ctx, u.Query, u.Extension, "unfreeze users"); err != nil {
+		return nil, err
+	}
+
+	user, err := u.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.FrozenAt == nil {
+		return nil, domain.Errorf(domain.Conflict, "the user is not frozen")
+	}
+
+	err = u.Update.UpdateUser(ctx, user, map[string]interface{}{
+		"active":        true,
+		"frozen_reason": "",
+		"frozen_at":     nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.Active = true
+	user.FrozenReason = ""
+	user.FrozenAt = nil
+
+	u.notify(ctx, user, fmt.Sprintf("Your %s account has been restored. You can log in again", appName))
+
+	return user, nil
+}
+
+// ValidateSession checks that a token may still be used. Tokens of frozen users
+// and tokens issued before the user's tokens were revoked are refused
+func (u UseCasesUserImpl) ValidateSession(ctx context.Context, token string) error {
+	claims, err := utils.ParseJWTToken(token)
+	if err != nil {
+		return err
+	}
+
+	// frozen users are not returned since they are not active
+	user, err := u.Query.GetUserProfileByUserID(ctx, claims.UserID)
+	if err != nil {
+		return domain.WrapError(domain.Unauthenticated, err, "this account cannot be used, please contact the shop owner")
+	}
+
+	// tokens only record the second they were issued in so a token issued in the same second as the revocation is refused too.
+	// Revocations stored before they were kept to the second are compared by their second as well
+	if user.TokensRevokedAt != nil && claims.IssuedAt != nil && !claims.IssuedAt.After(user.TokensRevokedAt.Truncate(time.Second)) {
+		return domain.Errorf(domain.Unauthenticated, "this session has ended, please log in again")
+	}
+
+	return nil
+}
+
+// getUser returns a user whether or not they are active
+func (u UseCasesUserImpl) getUser(ctx context.Context, userID string) (*domain.User, error) {
+	users, err := u.Query.GetUsersByIDs(ctx, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, domain.Errorf(domain.NotFound, "no user has this ID")
+	}
+
+	return users[0], nil
+}
+
+// notify sends a user an SMS. The change they are told about has already been made so failing to send it is only logged
+func (u UseCasesUserImpl) notify(ctx context.Context, user *domain.User, message string) {
+	contacts, err := u.Query.GetContactsByUserIDs(ctx, []string{user.ID})
+	if err != nil {
+		logrus.Errorf("failed to get the phone number of user %s: %v", user.ID, err)
+		return
+	}
+
+	for _, contact := range contacts {
+		if contact.ContactType != "PHONE" {
+			continue
+		}
+		if err := u.Extension.SendSMS(ctx, contact.ContactValue, message); err != nil {
+			logrus.Errorf("failed to send SMS to user %s: %v", user.ID, err)
+		}
+	}
+}
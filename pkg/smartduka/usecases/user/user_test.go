@@ -1 +1,364 @@
 package user_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/common"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/dto"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/enums"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/extension"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/application/utils"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/domain"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/infrastructure/datastore"
+	"github.com/oryx-systems/smartduka/pkg/smartduka/usecases/user"
+)
+
+type fakeQuery struct {
+	datastore.Query
+}
+
+func (f *fakeQuery) GetUserProfileByUserID(ctx context.Context, userID string) (*domain.User, error) {
+	if userID == "owner" {
+		return &domain.User{ID: userID, UserType: "ADMIN"}, nil
+	}
+	return &domain.User{ID: userID, UserType: "CASHIER"}, nil
+}
+
+func (f *fakeQuery) GetUsersByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	users := []*domain.User{}
+	for _, id := range ids {
+		user, _ := f.GetUserProfileByUserID(ctx, id)
+		if id == "frozen cashier" {
+			frozenAt := time.Now()
+			user.FrozenAt = &frozenAt
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (f *fakeQuery) GetContactsByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Contact, error) {
+	return []*domain.Contact{{ContactType: "PHONE", ContactValue: "+254711223344", UserID: userIDs[0]}}, nil
+}
+
+type fakeUpdate struct {
+	datastore.Update
+	updates map[string]map[string]interface{}
+}
+
+func (f *fakeUpdate) UpdateUser(ctx context.Context, user *domain.User, updateData map[string]interface{}) error {
+	f.updates[user.ID] = updateData
+	return nil
+}
+
+type fakeDelete struct {
+	datastore.Delete
+	deleted []string
+}
+
+func (f *fakeDelete) DeleteUser(ctx context.Context, userID string) error {
+	f.deleted = append(f.deleted, userID)
+	return nil
+}
+
+type fakeExtension struct {
+	extension.Extension
+	userID string
+	sent   []string
+}
+
+func (f *fakeExtension) GetLoggedInUserUID(ctx context.Context) (string, error) {
+	return f.userID, nil
+}
+
+func (f *fakeExtension) SendSMS(ctx context.Context, phoneNumber string, message string) error {
+	f.sent = append(f.sent, phoneNumber)
+	return nil
+}
+
+func TestUseCasesUserImpl_DeleteUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		loggedIn string
+		userID   string
+		wantErr  bool
+	}{
+		{name: "Happy case: the owner deletes a cashier", loggedIn: "owner", userID: "cashier"},
+		{name: "Sad case: a cashier cannot delete users", loggedIn: "cashier", userID: "another cashier", wantErr: true},
+		{name: "Sad case: the owner cannot delete themselves", loggedIn: "owner", userID: "owner", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeDelete{}
+			u := user.NewUseCasesUser(nil, &fakeQuery{}, nil, store, &fakeExtension{userID: tt.loggedIn})
+
+			got, err := u.DeleteUser(context.Background(), tt.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UseCasesUserImpl.DeleteUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got == tt.wantErr || (len(store.deleted) == 0) != tt.wantErr {
+				t.Errorf("UseCasesUserImpl.DeleteUser() = %v, deleted %v", got, store.deleted)
+			}
+		})
+	}
+}
+
+func TestUseCasesUserImpl_FreezeUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		loggedIn string
+		userID   string
+		reason   string
+		wantErr  bool
+	}{
+		{name: "Happy case: the owner freezes a cashier", loggedIn: "owner", userID: "cashier", reason: "cash missing from the till"},
+		{name: "Sad case: a cashier cannot freeze users", loggedIn: "cashier", userID: "another cashier", reason: "cash missing from the till", wantErr: true},
+		{name: "Sad case: the owner cannot freeze themselves", loggedIn: "owner", userID: "owner", reason: "cash missing from the till", wantErr: true},
+		{name: "Sad case: a reason is required", loggedIn: "owner", userID: "cashier", reason: " ", wantErr: true},
+		{name: "Sad case: a user who is already frozen", loggedIn: "owner", userID: "frozen cashier", reason: "cash missing from the till", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeUpdate{updates: map[string]map[string]interface{}{}}
+			ext := &fakeExtension{userID: tt.loggedIn}
+			u := user.NewUseCasesUser(nil, &fakeQuery{}, store, nil, ext)
+
+			got, err := u.FreezeUser(context.Background(), tt.userID, tt.reason)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UseCasesUserImpl.FreezeUser() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if len(store.updates) != 0 || len(ext.sent) != 0 {
+					t.Errorf("UseCasesUserImpl.FreezeUser() updated %v and sent %v", store.updates, ext.sent)
+				}
+				return
+			}
+
+			update := store.updates[tt.userID]
+			if got.Active || update["active"] != false || update["frozen_reason"] != tt.reason || update["tokens_revoked_at"] == nil {
+				t.Errorf("UseCasesUserImpl.FreezeUser() update = %v", update)
+			}
+			if revokedAt, _ := update["tokens_revoked_at"].(time.Time); revokedAt.Nanosecond() != 0 {
+				t.Errorf("UseCasesUserImpl.FreezeUser() revoked tokens at %v, want a whole second", revokedAt)
+			}
+			if len(ext.sent) != 1 {
+				t.Errorf("UseCasesUserImpl.FreezeUser() sent %d SMS, want 1", len(ext.sent))
+			}
+		})
+	}
+}
+
+type sessionQuery struct {
+	datastore.Query
+	user *domain.User
+}
+
+func (s *sessionQuery) GetUserProfileByUserID(ctx context.Context, userID string) (*domain.User, error) {
+	if s.user == nil {
+		return nil, domain.Errorf(domain.NotFound, "no user has this ID")
+	}
+	return s.user, nil
+}
+
+func TestUseCasesUserImpl_ValidateSession(t *testing.T) {
+	token, err := utils.GenerateJWTToken("cashier")
+	if err != nil {
+		t.Fatalf("GenerateJWTToken() error = %v", err)
+	}
+	before := time.Now().Add(-time.Minute)
+	after := time.Now().Add(time.Minute)
+
+	claims, err := utils.ParseJWTToken(token.Token)
+	if err != nil {
+		t.Fatalf("ParseJWTToken() error = %v", err)
+	}
+	// revocations late in the second the token was issued in and late in the second before it
+	sameSecond := claims.IssuedAt.Add(900 * time.Millisecond)
+	secondBefore := claims.IssuedAt.Add(-100 * time.Millisecond)
+
+	tests := []struct {
+		name    string
+		token   string
+		user    *domain.User
+		wantErr bool
+	}{
+		{name: "Happy case: an active user", token: token.Token, user: &domain.User{ID: "cashier", Active: true}},
+		{name: "Happy case: a token issued after the user's tokens were revoked", token: token.Token, user: &domain.User{ID: "cashier", Active: true, TokensRevokedAt: &before}},
+		{name: "Happy case: a token issued the second after the user's tokens were revoked", token: token.Token, user: &domain.User{ID: "cashier", Active: true, TokensRevokedAt: &secondBefore}},
+		{name: "Sad case: a token issued before the user's tokens were revoked", token: token.Token, user: &domain.User{ID: "cashier", Active: true, TokensRevokedAt: &after}, wantErr: true},
+		{name: "Sad case: a token issued in the second the user's tokens were revoked", token: token.Token, user: &domain.User{ID: "cashier", Active: true, TokensRevokedAt: &sameSecond}, wantErr: true},
+		{name: "Sad case: a frozen user", token: token.Token, wantErr: true},
+		{name: "Sad case: an invalid token", token: "invalid", user: &domain.User{ID: "cashier", Active: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := user.NewUseCasesUser(nil, &sessionQuery{user: tt.user}, nil, nil, &fakeExtension{})
+
+			err := u.ValidateSession(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UseCasesUserImpl.ValidateSession() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && domain.ErrorCodeOf(err) != domain.Unauthenticated {
+				t.Errorf("UseCasesUserImpl.ValidateSession() code = %s, want %s", domain.ErrorCodeOf(err), domain.Unauthenticated)
+			}
+		})
+	}
+}
+
+type registrationQuery struct {
+	datastore.Query
+	phones    []string
+	usernames []string
+	otp       string
+}
+
+func (r *registrationQuery) GetUserProfileByPhoneNumber(ctx context.Context, phoneNumber string, flavour enums.Flavour) (*domain.User, error) {
+	for _, phone := range r.phones {
+		if phone == phoneNumber {
+			return &domain.User{ID: "registered"}, nil
+		}
+	}
+	return nil, domain.Errorf(domain.NotFound, "no user has this phone number")
+}
+
+func (r *registrationQuery) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	for _, name := range r.usernames {
+		if name == username {
+			return &domain.User{ID: "registered"}, nil
+		}
+	}
+	return nil, domain.Errorf(domain.NotFound, "no user has this username")
+}
+
+func (r *registrationQuery) GetUserByIdentifier(ctx context.Context, identifierType enums.IdentifierType, value string) (*domain.User, error) {
+	return nil, domain.Errorf(domain.NotFound, "no user has this identification document")
+}
+
+func (r *registrationQuery) GetValidOTP(ctx context.Context, phoneNumber string, flavour enums.Flavour, otp string) (*domain.OTP, error) {
+	if otp != r.otp {
+		return nil, domain.Errorf(domain.NotFound, "the verification code is wrong or has expired")
+	}
+	return &domain.OTP{ID: "otp", PhoneNumber: phoneNumber, OTP: otp}, nil
+}
+
+type registrationCreate struct {
+	datastore.Create
+	contact *domain.Contact
+	pin     *domain.UserPIN
+}
+
+func (r *registrationCreate) RegisterUser(ctx context.Context, user *domain.User, contact *domain.Contact, identifier *domain.Identifier, pin *domain.UserPIN) (*domain.User, error) {
+	r.contact, r.pin = contact, pin
+	user.ID = "new user"
+	return user, nil
+}
+
+type registrationUpdate struct {
+	datastore.Update
+	invalidated []string
+}
+
+func (r *registrationUpdate) InvalidateOTP(ctx context.Context, id string) error {
+	r.invalidated = append(r.invalidated, id)
+	return nil
+}
+
+func TestUseCasesUserImpl_RegisterUser(t *testing.T) {
+	t.Setenv("PIN_EXPIRY_DAYS", "30")
+
+	input := func(otp string) *dto.RegisterUserInput {
+		return &dto.RegisterUserInput{
+			FirstName:   "Wanjiku",
+			LastName:    "Kamau",
+			PhoneNumber: "0722000000",
+			Flavour:     enums.FlavourPro,
+			UserName:    "wanjiku_k",
+			PIN:         "1234",
+			ConfirmPIN:  "1234",
+			OTP:         otp,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		otpRequired bool
+		query       *registrationQuery
+		input       *dto.RegisterUserInput
+		wantCode    domain.ErrorCode
+		wantFields  []domain.FieldError
+	}{
+		{
+			name:  "Happy case: register a user",
+			query: &registrationQuery{},
+			input: input(""),
+		},
+		{
+			name:        "Happy case: register a user who verified their phone number",
+			otpRequired: true,
+			query:       &registrationQuery{otp: "123456"},
+			input:       input("123456"),
+		},
+		{
+			name:     "Sad case: the phone number and username have been registered",
+			query:    &registrationQuery{phones: []string{"+254722000000"}, usernames: []string{"wanjiku_k"}},
+			input:    input(""),
+			wantCode: domain.Conflict,
+			wantFields: []domain.FieldError{
+				{Field: "phone_number", Message: "has already been registered"},
+				{Field: "username", Message: "has already been registered"},
+			},
+		},
+		{
+			name:        "Sad case: a verification code is required",
+			otpRequired: true,
+			query:       &registrationQuery{otp: "123456"},
+			input:       input(""),
+			wantCode:    domain.Validation,
+			wantFields:  []domain.FieldError{{Field: "otp", Message: "is required"}},
+		},
+		{
+			name:        "Sad case: a wrong verification code",
+			otpRequired: true,
+			query:       &registrationQuery{otp: "123456"},
+			input:       input("654321"),
+			wantCode:    domain.Validation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(common.RegistrationOTPRequiredEnvVarName, strconv.FormatBool(tt.otpRequired))
+			create := &registrationCreate{}
+			update := &registrationUpdate{}
+			u := user.NewUseCasesUser(create, tt.query, update, nil, &fakeExtension{})
+
+			got, err := u.RegisterUser(context.Background(), tt.input)
+			if (err != nil) != (tt.wantCode != "") || (err != nil && domain.ErrorCodeOf(err) != tt.wantCode) {
+				t.Fatalf("UseCasesUserImpl.RegisterUser() error = %v, want code %q", err, tt.wantCode)
+			}
+			if err != nil {
+				var coded *domain.Error
+				if errors.As(err, &coded) && tt.wantFields != nil && !reflect.DeepEqual(coded.Fields, tt.wantFields) {
+					t.Errorf("UseCasesUserImpl.RegisterUser() fields = %v, want %v", coded.Fields, tt.wantFields)
+				}
+				if create.contact != nil || len(update.invalidated) != 0 {
+					t.Errorf("UseCasesUserImpl.RegisterUser() registered %v and used OTPs %v", create.contact, update.invalidated)
+				}
+				return
+			}
+
+			if got.ID == "" || create.contact.ContactValue != "+254722000000" || create.pin == nil || create.pin.HashedPIN == "" {
+				t.Errorf("UseCasesUserImpl.RegisterUser() registered %v with contact %v and PIN %v", got, create.contact, create.pin)
+			}
+			if tt.otpRequired && len(update.invalidated) != 1 {
+				t.Errorf("UseCasesUserImpl.RegisterUser() used OTPs %v, want the verification code used", update.invalidated)
+			}
+		})
+	}
+}
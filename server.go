@@ -19,6 +19,13 @@ const waitSeconds = 30
 func main() {
 	ctx := context.Background()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	port, err := strconv.Atoi(helpers.MustGetEnvVar(common.PortEnvVarName))
 	if err != nil {
 		helpers.LogStartupError(ctx, err)